@@ -9,8 +9,8 @@ objects at runtime. Instead, Granitic component definition files are used to gen
 compiled along with your application. The grnc-bind tool performs this code generation.
 
 In most cases, the grnc-bind command will be run, without arguments, in your application's root directory (the same folder
-that contains your resources directory. The tool will merge together any .json files found in resources/components and
-create a file bindings/bindings.go. This file includes a single function:
+that contains your resources directory. The tool will merge together any .json, .yaml, .yml or .toml files found in resources/components
+and create a file bindings/bindings.go. This file includes a single function:
 
 	Components() *ioc.ProtoComponents
 
@@ -68,7 +68,7 @@ func main() {
 
 }
 
-// Loads JSON files from local files and remote URLs and provides a mechanism for writing the resulting merged
+// Loads JSON (or YAML/TOML) files from local files and remote URLs and provides a mechanism for writing the resulting merged
 // file to disk
 type jsonDefinitionLoader struct {
 }
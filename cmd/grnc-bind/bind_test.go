@@ -7,8 +7,10 @@ import (
 	"github.com/graniticio/granitic/v2/cmd/grnc-bind/binder"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/test"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -76,3 +78,41 @@ func TestOutputMerged(t *testing.T) {
 	}
 
 }
+
+func TestBindYAML(t *testing.T) {
+
+	tmp := os.TempDir()
+
+	bindOut := filepath.Join(tmp, "bindings-yaml.go")
+
+	compDir := test.FilePath("yaml")
+	merged := ""
+
+	b := new(binder.Binder)
+	b.ToolName = "bind-test"
+	b.Loader = new(jsonDefinitionLoader)
+
+	s := binder.Settings{
+		CompDefLocation: &compDir,
+		BindingsFile:    &bindOut,
+		MergedDebugFile: &merged,
+	}
+
+	b.Log = new(logging.ConsoleErrorLogger)
+
+	b.Bind(s)
+
+	if b.Failed() {
+		t.FailNow()
+	}
+
+	src, err := ioutil.ReadFile(bindOut)
+
+	test.ExpectNil(t, err)
+
+	for _, name := range []string{"artistHandler", "submitArtistHandler", "SubmitArtistLogic"} {
+		if !strings.Contains(string(src), name) {
+			t.Errorf("Expected the generated bindings to contain %s", name)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+The grnc-config tool - used to check an application's configuration without starting the application.
+
+Granitic's facilities declare the configuration they accept. When an application starts, its merged configuration is
+checked against those declarations and any unknown settings (usually typos like "Prot" instead of "Port"), values of
+the wrong type and missing required values are reported together (see System.ConfigValidation in the facility package
+documentation). This tool performs the same check offline, so it can be run while editing configuration or as part of a
+build.
+
+grnc-config is normally run, without arguments, in your application's root directory. Granitic's built-in configuration
+is found in the same way as grnc-bind (via your go.mod file, the GRANITIC_HOME environment variable or a standard
+checkout under GOPATH), your application's configuration files are merged on top of it and any configuration layers
+enabled in ConfigLayers are applied. The settings of every facility enabled in the Facilities section are then checked.
+
+Settings declared by your own components (those implementing config.SchemaDeclarer) are only checked when the
+application starts, as the tool does not have access to your components.
+
+If problems are found, they are printed and the tool exits with status 1.
+
+Usage of grnc-config:
+
+	grnc-config [-c config-files] [-l log-level]
+
+	-c string
+		A comma separated list of configuration files, directories containing configuration files or URLs (default "config", or "resource/config" if "config" does not exist)
+	-l string
+		The level at which the tool will output messages: TRACE, DEBUG, INFO, ERROR, FATAL (default ERROR)
+
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/graniticio/granitic/v2/cmd/grnc-bind/binder"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/logging"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	toolName            = "grnc-config"
+	defaultConfLocation = "config"
+)
+
+func main() {
+
+	v1ConfLocation := filepath.Join("resource", "config")
+
+	configFiles := flag.String("c", defaultConfLocation, "A comma separated list of configuration files, directories containing configuration files or URLs")
+	logLevel := flag.String("l", "ERROR", "The level at which the tool will output messages: TRACE, DEBUG, INFO, ERROR, FATAL")
+	flag.Parse()
+
+	ll, err := logging.LogLevelFromLabel(*logLevel)
+
+	if err != nil {
+		exitError(err)
+	}
+
+	if *configFiles == defaultConfLocation && !folderExists(defaultConfLocation) && folderExists(v1ConfLocation) {
+		configFiles = &v1ConfLocation
+	}
+
+	log := logging.NewStdoutLogger(ll, toolName+": ")
+
+	builtInPath, err := binder.LocateFacilityConfig(log)
+
+	if err != nil {
+		exitError(err)
+	}
+
+	sources, err := config.ExpandToFilesAndURLs(strings.Split(*configFiles, ","))
+
+	if err != nil {
+		exitError(err)
+	}
+
+	ca, err := loadConfig(builtInPath, sources, ll)
+
+	if err != nil {
+		exitError(err)
+	}
+
+	if err := facility.CheckConfig(ca, facility.Builders(), nil); err != nil {
+		exitError(err)
+	}
+
+	fmt.Printf("%s: configuration matches the settings declared by enabled facilities\n", toolName)
+}
+
+// loadConfig merges the application's configuration files into Granitic's built-in configuration and applies layers, as
+// happens when an application starts
+func loadConfig(builtInPath string, sources []string, ll logging.LogLevel) (*config.Accessor, error) {
+
+	flm := logging.CreateComponentLoggerManager(ll, map[string]interface{}{}, []logging.LogWriter{new(logging.ConsoleWriter)}, logging.NewFrameworkLogMessageFormatter())
+
+	builtInFiles, err := config.FindJSONFilesInDir(builtInPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	jm := config.NewJSONMergerWithManagedLogging(flm, new(config.JSONContentParser))
+	jm.MergeArrays = true
+
+	builtIn, err := jm.LoadAndMergeConfig(builtInFiles)
+
+	if err != nil {
+		return nil, fmt.Errorf("problem loading Granitic's built-in configuration: %s", err.Error())
+	}
+
+	l := new(config.Loader)
+	l.BuiltIn = builtIn
+	l.Sources = sources
+	l.FrameworkLoggingManager = flm
+
+	return l.Load()
+}
+
+func folderExists(path string) bool {
+
+	fi, err := os.Stat(path)
+
+	return err == nil && fi.IsDir()
+}
+
+func exitError(err error) {
+	fmt.Printf("%s: %s\n", toolName, err.Error())
+	instance.ExitError()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility"
+	"github.com/graniticio/granitic/v2/logging"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var builtInPath = filepath.Join("..", "..", "facility", "config")
+
+func TestValidConfig(t *testing.T) {
+
+	os.Setenv("GRNC_CONFIG_TEST_HTTPServer_Port", "9000")
+	defer os.Unsetenv("GRNC_CONFIG_TEST_HTTPServer_Port")
+
+	ca, err := loadConfig(builtInPath, []string{filepath.Join("testdata", "valid", "config.json")}, logging.Fatal)
+
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	if port, _ := ca.IntVal("HTTPServer.Port"); port != 9000 {
+		t.Errorf("Expected layers to be applied, port was %d", port)
+	}
+
+	if err := facility.CheckConfig(ca, facility.Builders(), nil); err != nil {
+		t.Errorf("%s", err.Error())
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+
+	ca, err := loadConfig(builtInPath, []string{filepath.Join("testdata", "invalid", "config.json")}, logging.Fatal)
+
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	err = facility.CheckConfig(ca, facility.Builders(), nil)
+
+	if err == nil {
+		t.Fatalf("Expected problems to be found")
+	}
+
+	if p := err.(*config.SchemaError).Problems; len(p) != 3 {
+		t.Errorf("Expected 3 problems, found %d: %s", len(p), err.Error())
+	}
+}
@@ -173,13 +173,25 @@ func renderOutput(res *ctlResponse) {
 		return
 	}
 
-	if co.RenderHint == "COLUMNS" {
+	switch co.RenderHint {
+	case "COLUMNS":
 		columnOutput(co)
-	} else {
+	case "RAW":
+		rawOutput(co)
+	default:
 		paragraphOutput(co)
 	}
 }
 
+func rawOutput(co *commandOutcome) {
+
+	for _, p := range co.OutputBody {
+		for _, s := range p {
+			fmt.Println(s)
+		}
+	}
+}
+
 func columnOutput(co *commandOutcome) {
 
 	tWidth := termWidth
@@ -1,5 +1,6 @@
 #! /bin/sh
 
 (cd cmd/grnc-bind && go install)
+(cd cmd/grnc-config && go install)
 (cd cmd/grnc-ctl && go install)
 (cd cmd/grnc-project && go install)
\ No newline at end of file
@@ -79,8 +79,21 @@ would result in:
 
 	{ "methods": ["GET", "POST"] }
 
+Files do not have to be written in JSON. Files with a .yaml or .yml extension are parsed as YAML (see YAMLContentParser)
+and files with a .toml extension as TOML (see TOMLContentParser). Files in these formats are converted to the same structure
+as the equivalent JSON file before they are merged, so the rules above apply regardless of the format of each file. URLs
+are parsed according to the content type returned by the server or, if the content type is not recognised, the URL's extension.
+
+	database:
+	  host: remotehost
+	  flags: [d]
+
 Another core concept used by the types in this package is a config path. This is the absolute path to field in the
 eventual merged configuration file with a dot-delimited notation. E.g "database.host".
+
+The settings expected at a config path can be described with a Schema (usually created from the struct that the
+configuration is populated into with SchemaFromType) and checked with CheckSchemas, which reports unknown keys, values of
+the wrong type and missing required values in a single error.
 */
 package config
 
@@ -125,6 +138,9 @@ type Accessor struct {
 
 	// Logger used by Granitic framework components. Automatically injected.
 	FrameworkLogger logging.Logger
+
+	// The paths whose values were set by a Layer and should not be displayed. May be nil.
+	Redactions *Redactions
 }
 
 // Flush removes internal references to the (potentially very large) merged JSON data so the associated
@@ -181,7 +197,7 @@ func (ac *Accessor) StringVal(path string) (string, error) {
 		return s, nil
 	}
 
-	return "", fmt.Errorf("Value at %s is %q and cannot be converted to a string", path, v)
+	return "", fmt.Errorf("Value at %s is %q and cannot be converted to a string", path, ac.displayValue(path, v))
 
 }
 
@@ -199,7 +215,7 @@ func (ac *Accessor) IntVal(path string) (int, error) {
 		return int(f), nil
 	}
 
-	return 0, fmt.Errorf("alue at %s is %q and cannot be converted to an int", path, v)
+	return 0, fmt.Errorf("alue at %s is %q and cannot be converted to an int", path, ac.displayValue(path, v))
 
 }
 
@@ -214,7 +230,7 @@ func (ac *Accessor) Float64Val(path string) (float64, error) {
 		return f, nil
 	}
 
-	return 0, fmt.Errorf("value at %s is %q and cannot be converted to a float64", path, v)
+	return 0, fmt.Errorf("value at %s is %q and cannot be converted to a float64", path, ac.displayValue(path, v))
 }
 
 // Array returns the value of an array of JSON obects at the supplied path. Caution should be used when calling this method
@@ -247,7 +263,7 @@ func (ac *Accessor) BoolVal(path string) (bool, error) {
 		return b, nil
 	}
 
-	return false, fmt.Errorf("Value at %s is %q and cannot be converted to a bool", path, v)
+	return false, fmt.Errorf("Value at %s is %q and cannot be converted to a bool", path, ac.displayValue(path, v))
 
 }
 
@@ -39,6 +39,9 @@ type InitialSettings struct {
 	// Additional parsers to support config files in a format other than JSON
 	ConfigParsers []ContentParser
 
+	// Additional layers to apply to the merged configuration, after any layers enabled in the ConfigLayers section of configuration.
+	ConfigLayers []Layer
+
 	// Exit immediately after container has successfully started
 	DryRun bool
 }
@@ -62,7 +62,9 @@ func NewJSONMergerWithManagedLogging(flm *logging.ComponentLoggerManager, cp Con
 
 }
 
-// NewJSONMergerWithDirectLogging creates a JSONMerger that uses the supplied logger
+// NewJSONMergerWithDirectLogging creates a JSONMerger that uses the supplied logger. The supplied ContentParser is used
+// for URLs whose content type is not recognised. YAMLContentParser and TOMLContentParser are also registered, so files
+// and URLs in those formats can be merged with JSON files.
 func NewJSONMergerWithDirectLogging(l logging.Logger, cp ContentParser) *JSONMerger {
 
 	jm := new(JSONMerger)
@@ -72,6 +74,8 @@ func NewJSONMergerWithDirectLogging(l logging.Logger, cp ContentParser) *JSONMer
 	jm.parserByContent = make(map[string]ContentParser)
 	jm.parserByFile = make(map[string]ContentParser)
 
+	jm.RegisterContentParser(new(YAMLContentParser))
+	jm.RegisterContentParser(new(TOMLContentParser))
 	jm.RegisterContentParser(cp)
 
 	return jm
@@ -166,11 +170,15 @@ func (jm *JSONMerger) LoadAndMergeConfigWithBase(config map[string]interface{},
 			if _, found := err.(EmptyFileError); found {
 				jm.Logger.LogWarnf("Config file/URL %s is empty", fileName)
 			} else {
-				return nil, fmt.Errorf("Problem parsing data from a file or URL (%s) as JSON : %s", fileName, err)
+				return nil, fmt.Errorf("Problem parsing data from a file or URL (%s): %s", fileName, err)
 			}
 		}
 
-		additionalConfig := loadedConfig.(map[string]interface{})
+		additionalConfig, found := loadedConfig.(map[string]interface{})
+
+		if !found {
+			return nil, fmt.Errorf("The file or URL %s does not contain an object at the top level", fileName)
+		}
 
 		config = jm.merge(config, additionalConfig)
 
@@ -199,17 +207,19 @@ func (jm *JSONMerger) loadFromURL(url string) ([]byte, ContentParser, error) {
 	}
 
 	cp := jm.DefaultParser
-
-	if ct := r.Header.Get("content-type"); ct != "" {
-		ct = strings.Split(ct, ";")[0]
-		ct = strings.TrimSpace(ct)
-		ct = strings.ToLower(ct)
-
-		if jm.parserByContent[ct] != nil {
-			jm.Logger.LogDebugf("Found content parser for %s", ct)
-			cp = jm.parserByContent[ct]
-		}
-
+	ct := r.Header.Get("content-type")
+
+	ct = strings.Split(ct, ";")[0]
+	ct = strings.TrimSpace(ct)
+	ct = strings.ToLower(ct)
+
+	if jm.parserByContent[ct] != nil {
+		jm.Logger.LogDebugf("Found content parser for %s", ct)
+		cp = jm.parserByContent[ct]
+	} else if ext := jm.extractExtension(r.Request.URL.Path); jm.parserByFile[ext] != nil {
+		// Servers often use a generic content type (e.g. text/plain) for YAML and TOML files
+		jm.Logger.LogDebugf("Found content parser for extension %s", ext)
+		cp = jm.parserByFile[ext]
 	}
 
 	if r.StatusCode >= 400 {
@@ -0,0 +1,491 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RedactedValue replaces the values of redacted config paths when configuration is printed or logged.
+const RedactedValue = "[REDACTED]"
+
+// DefaultEnvironmentPrefix is the prefix of the names of environment variables that are mapped onto config paths by
+// an EnvironmentLayer, unless another prefix is configured.
+const DefaultEnvironmentPrefix = "GRNC_"
+
+// The path of the settings that control which layers are applied to merged configuration
+const layersPath = "ConfigLayers"
+
+// layerSettings is the structure of the ConfigLayers section of configuration
+type layerSettings struct {
+	Environment struct {
+		Enabled bool
+		Prefix  string
+	}
+	References struct {
+		Enabled bool
+	}
+}
+
+// LayersSchema returns a Schema describing the ConfigLayers section of configuration.
+func LayersSchema() map[string]*Schema {
+	return map[string]*Schema{layersPath: SchemaFromType(new(layerSettings))}
+}
+
+// A Layer modifies the merged view of configuration files before it is used to configure facilities and components.
+type Layer interface {
+	// Apply modifies the merged configuration in place, adding the paths of any values that should not be displayed to
+	// the supplied Redactions.
+	Apply(config map[string]interface{}, r *Redactions) error
+}
+
+// ApplyLayers applies each of the supplied layers, in order, to the merged configuration and returns the paths whose
+// values have come from a layer.
+func ApplyLayers(config map[string]interface{}, layers []Layer) (*Redactions, error) {
+
+	r := NewRedactions()
+
+	for _, l := range layers {
+		if err := l.Apply(config, r); err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+// LayersFromConfig creates the layers enabled by the ConfigLayers section of the supplied merged configuration:
+//
+//	{
+//	  "ConfigLayers": {
+//	    "Environment": {
+//	      "Enabled": true,
+//	      "Prefix": "GRNC_"
+//	    },
+//	    "References": {
+//	      "Enabled": true
+//	    }
+//	  }
+//	}
+//
+// Both layers are disabled in Granitic's built-in configuration. The EnvironmentLayer is applied before the
+// ReferenceLayer, so environment variables can contain references. Returns no layers if the section is missing.
+func LayersFromConfig(config map[string]interface{}) ([]Layer, error) {
+
+	ca := &Accessor{JSONData: config}
+
+	if !ca.PathExists(layersPath) {
+		return nil, nil
+	}
+
+	settings := layerSettings{}
+
+	if err := ca.Populate(layersPath, &settings); err != nil {
+		return nil, err
+	}
+
+	var layers []Layer
+
+	if settings.Environment.Enabled {
+		layers = append(layers, &EnvironmentLayer{Prefix: settings.Environment.Prefix})
+	}
+
+	if settings.References.Enabled {
+		layers = append(layers, new(ReferenceLayer))
+	}
+
+	return layers, nil
+}
+
+// EnvironmentLayer sets config values from environment variables whose names start with Prefix. The rest of the
+// variable's name is a config path with underscores instead of dots, so with the default prefix GRNC_, the variable
+//
+//	GRNC_RdbmsAccess_Default_Password
+//
+// sets the value at RdbmsAccess.Default.Password. Names are matched to existing config keys without regard to case
+// (GRNC_RDBMSACCESS_DEFAULT_PASSWORD has the same effect) and a key that itself contains underscores is matched if it
+// already exists in the configuration.
+//
+// If the path already has a bool, number, array or object value, the variable's value is converted to the same type (arrays
+// and objects must be JSON). Otherwise the value is set as a string. Every path set by this layer is redacted.
+type EnvironmentLayer struct {
+	// The prefix of variables to map onto config paths. Defaults to DefaultEnvironmentPrefix.
+	Prefix string
+
+	// Returns the environment as a list of NAME=value strings. Defaults to os.Environ
+	environ func() []string
+}
+
+// Apply implements Layer.Apply
+func (el *EnvironmentLayer) Apply(config map[string]interface{}, r *Redactions) error {
+
+	prefix := el.Prefix
+
+	if prefix == "" {
+		prefix = DefaultEnvironmentPrefix
+	}
+
+	environ := el.environ
+
+	if environ == nil {
+		environ = os.Environ
+	}
+
+	vars := environ()
+
+	// Apply in a predictable order so that the outcome of conflicting variables does not vary between runs
+	sort.Strings(vars)
+
+	for _, kv := range vars {
+
+		i := strings.Index(kv, "=")
+
+		if i < 0 {
+			continue
+		}
+
+		name, value := kv[:i], kv[i+1:]
+
+		if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+
+		path, err := el.set(config, strings.Split(name[len(prefix):], "_"), value)
+
+		if err != nil {
+			return fmt.Errorf("unable to use environment variable %s: %s", name, err.Error())
+		}
+
+		r.Add(path)
+	}
+
+	return nil
+}
+
+// set finds (or creates) the path described by segments and sets the value there, returning the dot-delimited path.
+func (el *EnvironmentLayer) set(config map[string]interface{}, segments []string, value string) (string, error) {
+
+	var path []string
+	current := config
+
+	for len(segments) > 0 {
+
+		key, used := matchKey(current, segments)
+		segments = segments[used:]
+		path = append(path, key)
+
+		if len(segments) == 0 {
+
+			v, err := convertToExistingType(current[key], value)
+
+			if err != nil {
+				return "", err
+			}
+
+			current[key] = v
+			break
+		}
+
+		next, found := current[key].(map[string]interface{})
+
+		if !found {
+
+			if current[key] != nil {
+				return "", fmt.Errorf("%s is not a JSON object", strings.Join(path, JSONPathSeparator))
+			}
+
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+
+		current = next
+	}
+
+	return strings.Join(path, JSONPathSeparator), nil
+}
+
+// matchKey finds the existing key in m that matches the longest run of segments (joined with underscores), ignoring
+// case. If no key matches, the first segment is used as a new key. Returns the key and the number of segments used.
+func matchKey(m map[string]interface{}, segments []string) (string, int) {
+
+	for n := len(segments); n > 0; n-- {
+
+		candidate := strings.Join(segments[:n], "_")
+
+		if _, found := m[candidate]; found {
+			return candidate, n
+		}
+
+		for k := range m {
+			if strings.EqualFold(k, candidate) {
+				return k, n
+			}
+		}
+	}
+
+	return segments[0], 1
+}
+
+func convertToExistingType(existing interface{}, value string) (interface{}, error) {
+
+	switch JSONType(existing) {
+	case JSONBool:
+		return strconv.ParseBool(value)
+
+	case JSONArray, JSONMap:
+		var v interface{}
+
+		if err := json.Unmarshal([]byte(value), &v); err != nil || JSONType(v) != JSONType(existing) {
+			return nil, fmt.Errorf("%q is not valid JSON of the same type as the existing value", value)
+		}
+
+		return v, nil
+	}
+
+	if _, found := existing.(float64); found {
+		return strconv.ParseFloat(value, 64)
+	}
+
+	return value, nil
+}
+
+var referencePattern = regexp.MustCompile(`\$\{(env|file):([^}]+)\}`)
+
+// ReferenceLayer replaces references in string values with the value of an environment variable or the contents of a
+// file (for example a secret mounted by a container orchestrator):
+//
+//	{
+//	  "RdbmsAccess": {
+//	    "Default": {
+//	      "User": "${env:DB_USER}",
+//	      "Password": "${file:/run/secrets/db-password}",
+//	      "DSN": "postgres://${env:DB_HOST}:5432/orders"
+//	    }
+//	  }
+//	}
+//
+// A reference can make up the whole value or part of it. Trailing line breaks are removed from the contents of files.
+// Referring to an environment variable that is not set or a file that cannot be read is an error. Every path whose
+// value contained a reference is redacted.
+type ReferenceLayer struct {
+	// Returns the value of an environment variable. Defaults to os.LookupEnv
+	lookupEnv func(string) (string, bool)
+}
+
+// Apply implements Layer.Apply
+func (rl *ReferenceLayer) Apply(config map[string]interface{}, r *Redactions) error {
+
+	if rl.lookupEnv == nil {
+		rl.lookupEnv = os.LookupEnv
+	}
+
+	_, _, err := rl.resolve("", config, r)
+
+	return err
+}
+
+// resolve replaces references in v (recursively, for objects and arrays), returning the new value and whether any
+// references were found.
+func (rl *ReferenceLayer) resolve(path string, v interface{}, r *Redactions) (interface{}, bool, error) {
+
+	switch t := v.(type) {
+	case string:
+		return rl.resolveString(path, t)
+
+	case map[string]interface{}:
+		found := false
+
+		for k, mv := range t {
+
+			p := k
+
+			if path != "" {
+				p = path + JSONPathSeparator + k
+			}
+
+			resolved, changed, err := rl.resolve(p, mv, r)
+
+			if err != nil {
+				return nil, false, err
+			}
+
+			if changed {
+				t[k] = resolved
+				found = true
+
+				if JSONType(resolved) == JSONString {
+					r.Add(p)
+				}
+			}
+		}
+
+		return t, found, nil
+
+	case []interface{}:
+		found := false
+
+		for i, av := range t {
+
+			resolved, changed, err := rl.resolve(fmt.Sprintf("%s[%d]", path, i), av, r)
+
+			if err != nil {
+				return nil, false, err
+			}
+
+			if changed {
+				t[i] = resolved
+				found = true
+			}
+		}
+
+		if found {
+			// Individual array elements cannot be redacted, so the whole array is
+			r.Add(path)
+		}
+
+		return t, found, nil
+	}
+
+	return v, false, nil
+}
+
+func (rl *ReferenceLayer) resolveString(path, s string) (interface{}, bool, error) {
+
+	if !strings.Contains(s, "${") {
+		return s, false, nil
+	}
+
+	var err error
+
+	resolved := referencePattern.ReplaceAllStringFunc(s, func(ref string) string {
+
+		if err != nil {
+			return ref
+		}
+
+		m := referencePattern.FindStringSubmatch(ref)
+		source, name := m[1], m[2]
+
+		var v string
+
+		if source == "env" {
+
+			var found bool
+
+			if v, found = rl.lookupEnv(name); !found {
+				err = fmt.Errorf("the environment variable %s referred to at %s is not set", name, path)
+			}
+
+		} else {
+
+			var b []byte
+
+			if b, err = ioutil.ReadFile(name); err != nil {
+				err = fmt.Errorf("unable to read the file %s referred to at %s: %s", name, path, err.Error())
+			}
+
+			v = strings.TrimRight(string(b), "\r\n")
+		}
+
+		return v
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return resolved, resolved != s, nil
+}
+
+// Redactions records the config paths whose values should not be displayed, because they were set by a Layer (and so
+// might be secrets).
+type Redactions struct {
+	paths map[string]bool
+}
+
+// NewRedactions creates an empty set of Redactions
+func NewRedactions() *Redactions {
+	return &Redactions{paths: make(map[string]bool)}
+}
+
+// Add records that the value at the supplied dot-delimited path should be redacted.
+func (r *Redactions) Add(path string) {
+	r.paths[path] = true
+}
+
+// Contains returns true if the value at the supplied path should be redacted.
+func (r *Redactions) Contains(path string) bool {
+	return r != nil && r.paths[path]
+}
+
+// Paths returns the redacted paths in alphabetical order.
+func (r *Redactions) Paths() []string {
+
+	var paths []string
+
+	if r == nil {
+		return paths
+	}
+
+	for p := range r.paths {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// Apply returns a copy of the supplied configuration with the value at each redacted path replaced with RedactedValue.
+// The supplied configuration is not modified.
+func (r *Redactions) Apply(config map[string]interface{}) map[string]interface{} {
+	return r.copyMap("", config)
+}
+
+func (r *Redactions) copyMap(path string, m map[string]interface{}) map[string]interface{} {
+
+	c := make(map[string]interface{}, len(m))
+
+	for k, v := range m {
+
+		p := k
+
+		if path != "" {
+			p = path + JSONPathSeparator + k
+		}
+
+		if r.Contains(p) {
+			c[k] = RedactedValue
+		} else if child, found := v.(map[string]interface{}); found {
+			c[k] = r.copyMap(p, child)
+		} else {
+			c[k] = v
+		}
+	}
+
+	return c
+}
+
+// RedactedJSONData returns a copy of the merged configuration with the values of redacted paths replaced with
+// RedactedValue. Use this rather than JSONData when configuration needs to be logged or written out.
+func (ac *Accessor) RedactedJSONData() map[string]interface{} {
+	return ac.Redactions.Apply(ac.JSONData)
+}
+
+// displayValue returns a value that is safe to include in an error message
+func (ac *Accessor) displayValue(path string, v interface{}) interface{} {
+
+	if ac.Redactions.Contains(path) {
+		return RedactedValue
+	}
+
+	return v
+}
@@ -0,0 +1,193 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func layerTestConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"RdbmsAccess": map[string]interface{}{
+			"Default": map[string]interface{}{
+				"Password": "changeme",
+				"Port":     float64(5432),
+				"Pooled":   false,
+				"Hosts":    []interface{}{"a"},
+			},
+		},
+		"Access_Log": map[string]interface{}{
+			"Path": "./access.log",
+		},
+	}
+}
+
+func TestEnvironmentLayer(t *testing.T) {
+
+	c := layerTestConfig()
+
+	el := new(EnvironmentLayer)
+	el.environ = func() []string {
+		return []string{
+			"GRNC_RdbmsAccess_Default_Password=s3cret",
+			"GRNC_RDBMSACCESS_DEFAULT_PORT=6543",
+			"GRNC_rdbmsaccess_default_pooled=true",
+			"GRNC_RdbmsAccess_Default_Hosts=[\"b\",\"c\"]",
+			"GRNC_Access_Log_Path=/var/log/access.log",
+			"GRNC_New_Setting=value=with=equals",
+			"OTHER_RdbmsAccess_Default_Password=ignored",
+		}
+	}
+
+	r, err := ApplyLayers(c, []Layer{el})
+	test.ExpectNil(t, err)
+
+	ca := &Accessor{JSONData: c, Redactions: r}
+
+	s, _ := ca.StringVal("RdbmsAccess.Default.Password")
+	test.ExpectString(t, s, "s3cret")
+
+	i, _ := ca.IntVal("RdbmsAccess.Default.Port")
+	test.ExpectInt(t, i, 6543)
+
+	b, _ := ca.BoolVal("RdbmsAccess.Default.Pooled")
+	test.ExpectBool(t, b, true)
+
+	a, _ := ca.Array("RdbmsAccess.Default.Hosts")
+	test.ExpectInt(t, len(a), 2)
+
+	s, _ = ca.StringVal("Access_Log.Path")
+	test.ExpectString(t, s, "/var/log/access.log")
+
+	s, _ = ca.StringVal("New.Setting")
+	test.ExpectString(t, s, "value=with=equals")
+
+	test.ExpectBool(t, r.Contains("RdbmsAccess.Default.Password"), true)
+	test.ExpectBool(t, r.Contains("RdbmsAccess.Default.Port"), true)
+	test.ExpectInt(t, len(r.Paths()), 6)
+}
+
+func TestEnvironmentLayerErrors(t *testing.T) {
+
+	el := new(EnvironmentLayer)
+	el.environ = func() []string { return []string{"GRNC_RdbmsAccess_Default_Port=high"} }
+
+	_, err := ApplyLayers(layerTestConfig(), []Layer{el})
+	test.ExpectNotNil(t, err)
+
+	el.environ = func() []string { return []string{"GRNC_RdbmsAccess_Default_Password_Extra=x"} }
+
+	_, err = ApplyLayers(layerTestConfig(), []Layer{el})
+	test.ExpectNotNil(t, err)
+
+	el.Prefix = "MYAPP_"
+
+	_, err = ApplyLayers(layerTestConfig(), []Layer{el})
+	test.ExpectNil(t, err)
+}
+
+func TestReferenceLayer(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "granitic-layers")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	secret := filepath.Join(dir, "password")
+	ioutil.WriteFile(secret, []byte("fromfile\n"), 0600)
+
+	c := map[string]interface{}{
+		"DB": map[string]interface{}{
+			"Password": "${file:" + secret + "}",
+			"DSN":      "postgres://${env:DB_USER}@${env:DB_HOST}/orders",
+			"Plain":    "no references",
+			"Hosts":    []interface{}{"${env:DB_HOST}", "other"},
+		},
+	}
+
+	rl := new(ReferenceLayer)
+	rl.lookupEnv = func(n string) (string, bool) {
+		v, found := map[string]string{"DB_USER": "app", "DB_HOST": "db1"}[n]
+		return v, found
+	}
+
+	r, err := ApplyLayers(c, []Layer{rl})
+	test.ExpectNil(t, err)
+
+	ca := &Accessor{JSONData: c, Redactions: r}
+
+	s, _ := ca.StringVal("DB.Password")
+	test.ExpectString(t, s, "fromfile")
+
+	s, _ = ca.StringVal("DB.DSN")
+	test.ExpectString(t, s, "postgres://app@db1/orders")
+
+	a, _ := ca.Array("DB.Hosts")
+	test.ExpectString(t, a[0].(string), "db1")
+
+	test.ExpectBool(t, r.Contains("DB.Password"), true)
+	test.ExpectBool(t, r.Contains("DB.DSN"), true)
+	test.ExpectBool(t, r.Contains("DB.Hosts"), true)
+	test.ExpectBool(t, r.Contains("DB.Plain"), false)
+
+	redacted := ca.RedactedJSONData()["DB"].(map[string]interface{})
+
+	test.ExpectString(t, redacted["Password"].(string), RedactedValue)
+	test.ExpectString(t, redacted["Hosts"].(string), RedactedValue)
+	test.ExpectString(t, redacted["Plain"].(string), "no references")
+
+	// The original is unchanged
+	s, _ = ca.StringVal("DB.Password")
+	test.ExpectString(t, s, "fromfile")
+}
+
+func TestReferenceLayerErrors(t *testing.T) {
+
+	rl := new(ReferenceLayer)
+	rl.lookupEnv = func(n string) (string, bool) { return "", false }
+
+	_, err := ApplyLayers(map[string]interface{}{"A": "${env:MISSING}"}, []Layer{rl})
+	test.ExpectNotNil(t, err)
+
+	_, err = ApplyLayers(map[string]interface{}{"A": "${file:/no/such/file}"}, []Layer{rl})
+	test.ExpectNotNil(t, err)
+}
+
+func TestLayersFromConfig(t *testing.T) {
+
+	layers, err := LayersFromConfig(map[string]interface{}{})
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(layers), 0)
+
+	c := map[string]interface{}{
+		"ConfigLayers": map[string]interface{}{
+			"Environment": map[string]interface{}{"Enabled": true, "Prefix": "MYAPP_"},
+			"References":  map[string]interface{}{"Enabled": false},
+		},
+	}
+
+	layers, err = LayersFromConfig(c)
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(layers), 1)
+	test.ExpectString(t, layers[0].(*EnvironmentLayer).Prefix, "MYAPP_")
+}
+
+func TestRedactedErrorMessages(t *testing.T) {
+
+	r := NewRedactions()
+	r.Add("Secret")
+
+	ca := &Accessor{JSONData: map[string]interface{}{"Secret": "hunter2"}, Redactions: r}
+
+	_, err := ca.IntVal("Secret")
+	test.ExpectNotNil(t, err)
+
+	if err != nil && (!strings.Contains(err.Error(), RedactedValue) || strings.Contains(err.Error(), "hunter2")) {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+}
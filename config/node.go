@@ -0,0 +1,75 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import "fmt"
+
+// copyNode deep copies objects and arrays so that a copy can be merged into (or otherwise modified) without affecting the original.
+func copyNode(v interface{}) interface{} {
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(t))
+
+		for k, mv := range t {
+			c[k] = copyNode(mv)
+		}
+
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(t))
+
+		for i, av := range t {
+			c[i] = copyNode(av)
+		}
+
+		return c
+	}
+
+	return v
+}
+
+// maxAliasNodes is the number of objects, arrays and values that aliases in a single YAML document may expand to, so that
+// a small document with nested aliases (a 'billion laughs' document) cannot exhaust memory.
+const maxAliasNodes = 100000
+
+// copyAlias copies the value of an alias, returning an error if the total number of nodes expanded from aliases,
+// recorded in expanded, would exceed maxAliasNodes.
+func copyAlias(v interface{}, expanded *int) (interface{}, error) {
+
+	*expanded += countNodes(v, maxAliasNodes-*expanded+1)
+
+	if *expanded > maxAliasNodes {
+		return nil, fmt.Errorf("aliases expand to more than %d values", maxAliasNodes)
+	}
+
+	return copyNode(v), nil
+}
+
+// countNodes returns the number of objects, arrays and values in v, stopping once limit has been reached.
+func countNodes(v interface{}, limit int) int {
+
+	n := 1
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for _, mv := range t {
+			if n >= limit {
+				break
+			}
+
+			n += countNodes(mv, limit-n)
+		}
+	case []interface{}:
+		for _, av := range t {
+			if n >= limit {
+				break
+			}
+
+			n += countNodes(av, limit-n)
+		}
+	}
+
+	return n
+}
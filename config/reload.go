@@ -0,0 +1,159 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/logging"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// LoaderComponentName is the name of the component that stores the Loader used to create the application's merged configuration.
+const LoaderComponentName = instance.FrameworkPrefix + "ConfigLoader"
+
+const accessorComponentName = instance.FrameworkPrefix + "Accessor"
+
+// ConfigChangeListener is implemented by components that are able to apply changes to configuration while the application
+// is running. When the ConfigReload facility is enabled and configuration is reloaded, ConfigChanged is called on every
+// component implementing this interface if any configuration has changed.
+type ConfigChangeListener interface {
+	// ConfigChanged is passed the dot-delimited paths of every value that has been added, removed or modified (in alphabetical
+	// order) and an Accessor for the reloaded configuration. Use PathChanged to check whether a path the component depends
+	// on is affected. If an error is returned, the component is assumed to be using its previous configuration.
+	ConfigChanged(changed []string, ca *Accessor) error
+}
+
+// ChangedPaths compares two merged views of configuration and returns the dot-delimited paths of values that are different,
+// in alphabetical order. Objects are compared field by field; any other value (including an array) is treated as a single value,
+// so a change to one element of an array is reported as a change to the path of the array. If an object has been added
+// or removed, only the path of that object is returned.
+func ChangedPaths(previous, current map[string]interface{}) []string {
+
+	changed := make([]string, 0)
+
+	changed = appendChangedPaths(changed, "", previous, current)
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+func appendChangedPaths(changed []string, path string, previous, current map[string]interface{}) []string {
+
+	join := func(k string) string {
+		if path == "" {
+			return k
+		}
+
+		return path + JSONPathSeparator + k
+	}
+
+	for k, pv := range previous {
+
+		cv, found := current[k]
+
+		if !found {
+			changed = append(changed, join(k))
+			continue
+		}
+
+		pm, pIsMap := pv.(map[string]interface{})
+		cm, cIsMap := cv.(map[string]interface{})
+
+		if pIsMap && cIsMap {
+			changed = appendChangedPaths(changed, join(k), pm, cm)
+		} else if !reflect.DeepEqual(pv, cv) {
+			changed = append(changed, join(k))
+		}
+	}
+
+	for k := range current {
+		if _, found := previous[k]; !found {
+			changed = append(changed, join(k))
+		}
+	}
+
+	return changed
+}
+
+// PathChanged returns true if the value at the supplied path was affected by a change. This is the case if the path,
+// one of its parents or one of its children appears in the list of changed paths.
+func PathChanged(changed []string, path string) bool {
+
+	for _, c := range changed {
+
+		if c == path || strings.HasPrefix(c, path+JSONPathSeparator) || strings.HasPrefix(path, c+JSONPathSeparator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Loader creates a merged view of configuration from Granitic's built-in configuration and the files and URLs supplied
+// when the application was started, then applies any layers (see LayersFromConfig). The same Loader is used to reload
+// configuration while the application is running, so files are re-read each time Load is called. Note that directories
+// are expanded into a list of files when the application starts, so files added to a directory later are not loaded.
+type Loader struct {
+	// Granitic's built-in configuration, which other files are merged into. Load does not modify this map.
+	BuiltIn map[string]interface{}
+
+	// Paths and URLs of configuration files, in the order in which they are merged.
+	Sources []string
+
+	// Parsers for file formats that are not supported by default.
+	Parsers []ContentParser
+
+	// Layers to apply after any layers enabled in the ConfigLayers section of the merged configuration.
+	Layers []Layer
+
+	// Used to create Loggers for the components that merge and access configuration.
+	FrameworkLoggingManager *logging.ComponentLoggerManager
+}
+
+// Load merges the configuration files and applies layers, returning an Accessor for the result.
+func (l *Loader) Load() (*Accessor, error) {
+
+	jm := NewJSONMergerWithManagedLogging(l.FrameworkLoggingManager, new(JSONContentParser))
+
+	for _, cp := range l.Parsers {
+		jm.RegisterContentParser(cp)
+	}
+
+	base, _ := copyNode(l.BuiltIn).(map[string]interface{})
+
+	if base == nil {
+		base = make(map[string]interface{})
+	}
+
+	merged, err := jm.LoadAndMergeConfigWithBase(base, l.Sources)
+
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := LayersFromConfig(merged)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply configuration layers: %s", err.Error())
+	}
+
+	layers = append(layers, l.Layers...)
+
+	redactions, err := ApplyLayers(merged, layers)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply configuration layers: %s", err.Error())
+	}
+
+	ca := new(Accessor)
+	ca.JSONData = merged
+	ca.FrameworkLogger = l.FrameworkLoggingManager.CreateLogger(accessorComponentName)
+	ca.Redactions = redactions
+
+	return ca, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChangedPaths(t *testing.T) {
+
+	previous := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": 1.0,
+			"c": "same",
+			"d": []interface{}{1.0, 2.0},
+		},
+		"removed": map[string]interface{}{"x": true},
+		"same":    []interface{}{"y"},
+	}
+
+	current := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": 2.0,
+			"c": "same",
+			"d": []interface{}{1.0, 3.0},
+			"e": "new",
+		},
+		"added": "z",
+		"same":  []interface{}{"y"},
+	}
+
+	changed := ChangedPaths(previous, current)
+
+	test.ExpectString(t, strings.Join(changed, ","), "a.b,a.d,a.e,added,removed")
+
+	test.ExpectInt(t, len(ChangedPaths(previous, previous)), 0)
+
+	// A value replaced by an object (or vice versa) is reported at its own path
+	changed = ChangedPaths(map[string]interface{}{"a": "flat"}, map[string]interface{}{"a": map[string]interface{}{"b": 1.0}})
+	test.ExpectString(t, strings.Join(changed, ","), "a")
+}
+
+func TestPathChanged(t *testing.T) {
+
+	changed := []string{"ApplicationLogger.GlobalLogLevel", "RateLimiting"}
+
+	test.ExpectBool(t, PathChanged(changed, "ApplicationLogger.GlobalLogLevel"), true)
+	test.ExpectBool(t, PathChanged(changed, "ApplicationLogger"), true)
+	test.ExpectBool(t, PathChanged(changed, "RateLimiting.Handlers.h"), true)
+	test.ExpectBool(t, PathChanged(changed, "ApplicationLogger.ComponentLogLevels"), false)
+	test.ExpectBool(t, PathChanged(changed, "RateLimit"), false)
+	test.ExpectBool(t, PathChanged(changed, "ApplicationLog"), false)
+}
+
+func TestLoaderReloadsFiles(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "granitic-reload")
+	test.ExpectNil(t, err)
+
+	defer os.RemoveAll(dir)
+
+	f := filepath.Join(dir, "app.yaml")
+
+	test.ExpectNil(t, ioutil.WriteFile(f, []byte("Limits:\n  Max: 10\nSecret: ${env:GRNC_RELOAD_TEST}\n"), 0644))
+
+	os.Setenv("GRNC_RELOAD_TEST", "first")
+	defer os.Unsetenv("GRNC_RELOAD_TEST")
+
+	l := new(Loader)
+	l.BuiltIn = map[string]interface{}{
+		"Limits":       map[string]interface{}{"Max": 1.0, "Min": 0.0},
+		"ConfigLayers": map[string]interface{}{"References": map[string]interface{}{"Enabled": true}},
+	}
+	l.Sources = []string{f}
+	l.Parsers = []ContentParser{new(YAMLContentParser)}
+	l.FrameworkLoggingManager = logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, nil, nil)
+
+	ca, err := l.Load()
+	test.ExpectNil(t, err)
+
+	i, _ := ca.IntVal("Limits.Max")
+	test.ExpectInt(t, i, 10)
+
+	s, _ := ca.StringVal("Secret")
+	test.ExpectString(t, s, "first")
+
+	test.ExpectNil(t, ioutil.WriteFile(f, []byte("Limits:\n  Max: 20\nSecret: ${env:GRNC_RELOAD_TEST}\n"), 0644))
+	os.Setenv("GRNC_RELOAD_TEST", "second")
+
+	reloaded, err := l.Load()
+	test.ExpectNil(t, err)
+
+	changed := ChangedPaths(ca.JSONData, reloaded.JSONData)
+	test.ExpectString(t, strings.Join(changed, ","), "Limits.Max,Secret")
+
+	// Built-in configuration is not modified by merging
+	test.ExpectFloat(t, l.BuiltIn["Limits"].(map[string]interface{})["Max"].(float64), 1)
+
+	test.ExpectNil(t, ioutil.WriteFile(f, []byte("Limits: [unclosed"), 0644))
+
+	_, err = l.Load()
+	test.ExpectNotNil(t, err)
+}
@@ -0,0 +1,556 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ValueType identifies the type of value a Schema expects to find at a configuration path.
+type ValueType string
+
+// Types of value that can be declared in a Schema.
+const (
+	// AnyValue accepts any value, including objects and arrays, without checking their contents
+	AnyValue ValueType = "ANY"
+	// StringValue accepts strings
+	StringValue ValueType = "STRING"
+	// IntValue accepts numbers without a fractional part
+	IntValue ValueType = "INT"
+	// NumberValue accepts any number
+	NumberValue ValueType = "NUMBER"
+	// BoolValue accepts true or false
+	BoolValue ValueType = "BOOL"
+	// ObjectValue accepts objects. The keys the object may contain are declared in the Schema's Fields
+	ObjectValue ValueType = "OBJECT"
+	// ArrayValue accepts arrays. The type of each element can be declared in the Schema's Elements
+	ArrayValue ValueType = "ARRAY"
+)
+
+// SchemaDeclarer is implemented by facility builders and components that declare the configuration they accept, so
+// that misspelled settings, values of the wrong type and missing values can be reported when the application starts.
+type SchemaDeclarer interface {
+	// ConfigSchema returns a Schema for each configuration path read by the facility or component, keyed by path
+	// (e.g. "HTTPServer" or "myApp.database").
+	ConfigSchema() map[string]*Schema
+}
+
+// Schema describes the value expected at a configuration path and, for objects and arrays, the values they may contain.
+// Schemas are normally created with SchemaFromType (which accepts the same values as Accessor.Populate) or NewSchema.
+type Schema struct {
+	// The type of value expected.
+	Type ValueType
+
+	// Whether or not a value (other than null) must be present.
+	Required bool
+
+	// For objects, the keys the object may contain. Keys not in Fields are reported as unknown, unless Fields is nil,
+	// in which case any key is accepted.
+	Fields map[string]*Schema
+
+	// Whether keys are matched to Fields regardless of case, as json.Unmarshal (and so Accessor.Populate) does. Set by
+	// SchemaFromType.
+	IgnoreCase bool
+
+	// For objects where any key is accepted, the schema each value must match. For arrays, the schema each element
+	// must match. Values are not checked if Elements is nil.
+	Elements *Schema
+}
+
+// NewSchema creates a Schema expecting a value of the supplied type. An object Schema is created with an empty set of
+// Fields, so every key is reported as unknown until it is declared with WithField.
+func NewSchema(t ValueType) *Schema {
+
+	s := new(Schema)
+	s.Type = t
+
+	if t == ObjectValue {
+		s.Fields = make(map[string]*Schema)
+	}
+
+	return s
+}
+
+// NewMapSchema creates a Schema for an object that may contain any key. Each value must match the supplied Schema (values
+// are not checked if it is nil).
+func NewMapSchema(values *Schema) *Schema {
+	return &Schema{Type: ObjectValue, Elements: values}
+}
+
+// Require marks the value as required and returns the Schema.
+func (s *Schema) Require() *Schema {
+	s.Required = true
+
+	return s
+}
+
+// WithField declares that an object may contain the named key, replacing any existing declaration. Returns the Schema.
+func (s *Schema) WithField(name string, fs *Schema) *Schema {
+
+	if s.Fields == nil {
+		s.Fields = make(map[string]*Schema)
+	}
+
+	s.Fields[name] = fs
+
+	return s
+}
+
+// WithElements declares the Schema that each value of an object (with any key) or array must match. Returns the Schema.
+func (s *Schema) WithElements(es *Schema) *Schema {
+	s.Elements = es
+
+	return s
+}
+
+// Field returns the Schema for a key declared with WithField (or derived from a struct field by SchemaFromType), or nil
+// if the key has not been declared.
+func (s *Schema) Field(name string) *Schema {
+	return s.Fields[name]
+}
+
+// SchemaFromType creates a Schema accepting the configuration that Accessor.Populate is able to set on the supplied struct
+// (or pointer to a struct). Exported fields of basic types, slices, maps and structs are declared under their own name (or
+// the name set with a json tag); fields that cannot be set from configuration (such as interfaces and functions) are ignored.
+// Types that unmarshal themselves from JSON accept any value.
+func SchemaFromType(target interface{}) *Schema {
+	return schemaForType(reflect.TypeOf(target), make(map[reflect.Type]bool))
+}
+
+var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func schemaForType(t reflect.Type, inProgress map[reflect.Type]bool) *Schema {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if reflect.PtrTo(t).Implements(jsonUnmarshaler) || reflect.PtrTo(t).Implements(textUnmarshaler) {
+		return NewSchema(AnyValue)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return NewSchema(StringValue)
+	case reflect.Bool:
+		return NewSchema(BoolValue)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return NewSchema(IntValue)
+	case reflect.Float32, reflect.Float64:
+		return NewSchema(NumberValue)
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: ArrayValue, Elements: schemaForType(t.Elem(), inProgress)}
+	case reflect.Map:
+		return &Schema{Type: ObjectValue, Elements: schemaForType(t.Elem(), inProgress)}
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return NewSchema(AnyValue)
+		}
+
+		return nil
+	case reflect.Struct:
+
+		if inProgress[t] {
+			// Recursive type - accept anything rather than declaring an infinitely deep schema
+			return &Schema{Type: ObjectValue}
+		}
+
+		inProgress[t] = true
+		defer delete(inProgress, t)
+
+		s := NewSchema(ObjectValue)
+		s.IgnoreCase = true
+		addStructFields(s, t, inProgress)
+
+		return s
+	}
+
+	return nil
+}
+
+func addStructFields(s *Schema, t reflect.Type, inProgress map[reflect.Type]bool) {
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+		name := f.Name
+
+		if tag, found := f.Tag.Lookup("json"); found {
+
+			tagName := strings.Split(tag, ",")[0]
+
+			if tagName == "-" {
+				continue
+			}
+
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		ft := f.Type
+
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if f.Anonymous && ft.Kind() == reflect.Struct && name == f.Name {
+			// Fields of embedded structs are promoted
+			addStructFields(s, ft, inProgress)
+			continue
+		}
+
+		if f.PkgPath != "" {
+			// Unexported
+			continue
+		}
+
+		if fs := schemaForType(f.Type, inProgress); fs != nil {
+			s.Fields[name] = fs
+		}
+	}
+}
+
+// SchemaError is returned when configuration does not match the declared schemas. It lists every problem found.
+type SchemaError struct {
+	// A description of each problem, in path order.
+	Problems []string
+}
+
+// Error implements error.Error
+func (se *SchemaError) Error() string {
+	return fmt.Sprintf("configuration does not match the settings declared by the framework and components (%d problems):\n  %s", len(se.Problems), strings.Join(se.Problems, "\n  "))
+}
+
+// CheckSchemas compares configuration with the Schemas declared for each path and returns a *SchemaError describing
+// every unknown key, value of the wrong type and missing required value, or nil if no problems were found. If more
+// than one Schema is declared for a path (e.g. by different SchemaDeclarers), use MergeSchemas to combine them first.
+func CheckSchemas(data map[string]interface{}, schemas map[string]*Schema) error {
+
+	ca := &Accessor{JSONData: data}
+	var problems []string
+
+	for path, s := range schemas {
+
+		if s == nil {
+			continue
+		}
+
+		problems = checkValue(path, ca.Value(path), s, problems)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	sort.Strings(problems)
+
+	return &SchemaError{Problems: problems}
+}
+
+func checkValue(path string, v interface{}, s *Schema, problems []string) []string {
+
+	if v == nil {
+
+		if s.Required {
+			problems = append(problems, fmt.Sprintf("%s is required", path))
+		}
+
+		return problems
+	}
+
+	if !matchesType(v, s.Type) {
+		return append(problems, fmt.Sprintf("%s should be %s but is %s", path, describeType(s.Type), describeValue(v)))
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+
+		if s.Type != ObjectValue {
+			return problems
+		}
+
+		for k, fv := range t {
+
+			fp := path + JSONPathSeparator + k
+
+			if s.Fields == nil {
+
+				if s.Elements != nil {
+					problems = checkValue(fp, fv, s.Elements, problems)
+				}
+
+				continue
+			}
+
+			if fs := s.fieldFor(k); fs != nil {
+				problems = checkValue(fp, fv, fs, problems)
+			} else {
+				problems = append(problems, unknownKey(fp, k, s.Fields))
+			}
+		}
+
+		for k, fs := range s.Fields {
+
+			if fs != nil && fs.Required && !s.hasKey(t, k) {
+				problems = append(problems, fmt.Sprintf("%s%s%s is required", path, JSONPathSeparator, k))
+			}
+		}
+
+	case []interface{}:
+
+		if s.Type != ArrayValue || s.Elements == nil {
+			return problems
+		}
+
+		for i, ev := range t {
+			problems = checkValue(fmt.Sprintf("%s[%d]", path, i), ev, s.Elements, problems)
+		}
+	}
+
+	return problems
+}
+
+// fieldFor returns the Schema declared for a key, or nil if the key has not been declared
+func (s *Schema) fieldFor(key string) *Schema {
+
+	if fs := s.Fields[key]; fs != nil || !s.IgnoreCase {
+		return fs
+	}
+
+	for k, fs := range s.Fields {
+		if strings.EqualFold(k, key) {
+			return fs
+		}
+	}
+
+	return nil
+}
+
+// hasKey checks whether an object contains a value for a declared field
+func (s *Schema) hasKey(o map[string]interface{}, field string) bool {
+
+	if _, found := o[field]; found || !s.IgnoreCase {
+		return found
+	}
+
+	for k := range o {
+		if strings.EqualFold(k, field) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesType(v interface{}, t ValueType) bool {
+
+	switch t {
+	case StringValue:
+		_, found := v.(string)
+		return found
+	case BoolValue:
+		_, found := v.(bool)
+		return found
+	case IntValue:
+		f, found := number(v)
+		return found && f == math.Trunc(f)
+	case NumberValue:
+		_, found := number(v)
+		return found
+	case ObjectValue:
+		_, found := v.(map[string]interface{})
+		return found
+	case ArrayValue:
+		_, found := v.([]interface{})
+		return found
+	}
+
+	return true
+}
+
+func number(v interface{}) (float64, bool) {
+
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+
+	return 0, false
+}
+
+func describeType(t ValueType) string {
+
+	switch t {
+	case StringValue:
+		return "a string"
+	case BoolValue:
+		return "true or false"
+	case IntValue:
+		return "a whole number"
+	case NumberValue:
+		return "a number"
+	case ObjectValue:
+		return "an object"
+	case ArrayValue:
+		return "an array"
+	}
+
+	return "any value"
+}
+
+func describeValue(v interface{}) string {
+
+	switch t := v.(type) {
+	case string:
+		return fmt.Sprintf("the string %q", t)
+	case bool:
+		return fmt.Sprintf("%t", t)
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	}
+
+	if f, found := number(v); found {
+		return fmt.Sprintf("%v", f)
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+// unknownKey describes a key that has not been declared, suggesting a declared key with a similar name if there is one
+func unknownKey(path, key string, fields map[string]*Schema) string {
+
+	m := fmt.Sprintf("%s is not a recognised setting", path)
+
+	best := ""
+	bestDistance := len(key)/3 + 1
+
+	for f := range fields {
+
+		var d int
+
+		if strings.EqualFold(f, key) {
+			d = 0
+		} else {
+			d = editDistance(strings.ToLower(f), strings.ToLower(key))
+		}
+
+		if d < bestDistance || (d == bestDistance && best != "" && f < best) {
+			best = f
+			bestDistance = d
+		}
+	}
+
+	if best != "" {
+		m += fmt.Sprintf(" (did you mean %s?)", best)
+	}
+
+	return m
+}
+
+// editDistance is the number of single character insertions, deletions, substitutions or transpositions needed to change a into b
+func editDistance(a, b string) int {
+
+	ar, br := []rune(a), []rune(b)
+	d := make([][]int, len(ar)+1)
+
+	for i := range d {
+		d[i] = make([]int, len(br)+1)
+		d[i][0] = i
+	}
+
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		for j := 1; j <= len(br); j++ {
+
+			cost := 1
+
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = minOf(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				d[i][j] = minOf(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ar)][len(br)]
+}
+
+func minOf(values ...int) int {
+
+	m := values[0]
+
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
+
+// MergeSchemas combines two Schemas declared for the same path into one that accepts anything accepted by either. If
+// either Schema is nil, the other is returned.
+func MergeSchemas(a, b *Schema) *Schema {
+
+	if a == nil {
+		return b
+	}
+
+	if b == nil {
+		return a
+	}
+
+	m := new(Schema)
+	m.Required = a.Required || b.Required
+	m.IgnoreCase = a.IgnoreCase || b.IgnoreCase
+
+	if a.Type != b.Type {
+		m.Type = AnyValue
+		return m
+	}
+
+	m.Type = a.Type
+	m.Elements = MergeSchemas(a.Elements, b.Elements)
+
+	if a.Fields == nil || b.Fields == nil {
+		// One of the Schemas accepts any key
+		return m
+	}
+
+	m.Fields = make(map[string]*Schema)
+
+	for k, fs := range a.Fields {
+		m.Fields[k] = MergeSchemas(fs, b.Fields[k])
+	}
+
+	for k, fs := range b.Fields {
+		if _, found := a.Fields[k]; !found {
+			m.Fields[k] = fs
+		}
+	}
+
+	return m
+}
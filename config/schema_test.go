@@ -0,0 +1,145 @@
+package config
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"strings"
+	"testing"
+)
+
+type schemaInner struct {
+	Enabled bool
+}
+
+type schemaEmbedded struct {
+	Timeout int
+}
+
+type schemaTarget struct {
+	schemaEmbedded
+	Name      string
+	Ratio     float64
+	Tags      []string
+	Limits    map[string]int
+	Inner     *schemaInner
+	Renamed   string `json:"other"`
+	Ignored   string `json:"-"`
+	Anything  interface{}
+	Logger    Layer
+	unexposed string
+}
+
+func TestSchemaFromType(t *testing.T) {
+
+	s := SchemaFromType(new(schemaTarget))
+
+	test.ExpectString(t, string(s.Type), string(ObjectValue))
+	test.ExpectString(t, string(s.Field("Timeout").Type), string(IntValue))
+	test.ExpectString(t, string(s.Field("Name").Type), string(StringValue))
+	test.ExpectString(t, string(s.Field("Ratio").Type), string(NumberValue))
+	test.ExpectString(t, string(s.Field("Tags").Elements.Type), string(StringValue))
+	test.ExpectString(t, string(s.Field("Limits").Elements.Type), string(IntValue))
+	test.ExpectString(t, string(s.Field("Inner").Field("Enabled").Type), string(BoolValue))
+	test.ExpectString(t, string(s.Field("Anything").Type), string(AnyValue))
+	test.ExpectNotNil(t, s.Field("other"))
+
+	test.ExpectBool(t, s.Field("Renamed") == nil, true)
+	test.ExpectBool(t, s.Field("Ignored") == nil, true)
+	test.ExpectBool(t, s.Field("Logger") == nil, true)
+	test.ExpectBool(t, s.Field("unexposed") == nil, true)
+}
+
+func TestCheckSchemasAcceptsValidConfig(t *testing.T) {
+
+	data := map[string]interface{}{
+		"app": map[string]interface{}{
+			"name":    "lower case keys are accepted, as they are by Populate",
+			"Timeout": float64(10),
+			"Ratio":   float64(1),
+			"Tags":    []interface{}{"a", "b"},
+			"Limits":  map[string]interface{}{"x": float64(1)},
+			"Inner":   map[string]interface{}{"Enabled": true},
+			"other":   "o",
+		},
+		"unchecked": map[string]interface{}{"Anything": 1},
+	}
+
+	s := SchemaFromType(new(schemaTarget))
+	s.Field("Name").Require()
+
+	test.ExpectNil(t, CheckSchemas(data, map[string]*Schema{"app": s}))
+}
+
+func TestCheckSchemasReportsAllProblems(t *testing.T) {
+
+	data := map[string]interface{}{
+		"app": map[string]interface{}{
+			"Timeout": "10",
+			"Ratio":   float64(1.5),
+			"Tags":    []interface{}{"a", float64(2)},
+			"Limits":  map[string]interface{}{"x": 1.5},
+			"Inner":   map[string]interface{}{"Enabeld": true},
+			"Nmae":    "n",
+		},
+	}
+
+	s := SchemaFromType(new(schemaTarget))
+	s.Field("Name").Require()
+
+	err := CheckSchemas(data, map[string]*Schema{"app": s, "missing": NewSchema(StringValue).Require()})
+
+	if !test.ExpectNotNil(t, err) {
+		return
+	}
+
+	p := err.(*SchemaError).Problems
+
+	if !test.ExpectInt(t, len(p), 7) {
+		t.Fatalf("%s", err.Error())
+	}
+
+	test.ExpectString(t, p[0], "app.Inner.Enabeld is not a recognised setting (did you mean Enabled?)")
+	test.ExpectString(t, p[1], "app.Limits.x should be a whole number but is 1.5")
+	test.ExpectString(t, p[2], "app.Name is required")
+	test.ExpectString(t, p[3], "app.Nmae is not a recognised setting (did you mean Name?)")
+	test.ExpectString(t, p[4], "app.Tags[1] should be a string but is 2")
+	test.ExpectString(t, p[5], "app.Timeout should be a whole number but is the string \"10\"")
+	test.ExpectString(t, p[6], "missing is required")
+
+	test.ExpectBool(t, strings.HasPrefix(err.Error(), "configuration does not match"), true)
+}
+
+func TestUnknownKeyWithoutSuggestion(t *testing.T) {
+
+	s := NewSchema(ObjectValue).WithField("Port", NewSchema(IntValue))
+
+	err := CheckSchemas(map[string]interface{}{"server": map[string]interface{}{"Completely": 1}}, map[string]*Schema{"server": s})
+
+	test.ExpectString(t, err.(*SchemaError).Problems[0], "server.Completely is not a recognised setting")
+}
+
+func TestExplicitSchemasAreCaseSensitive(t *testing.T) {
+
+	s := NewSchema(ObjectValue).WithField("Port", NewSchema(IntValue))
+
+	err := CheckSchemas(map[string]interface{}{"server": map[string]interface{}{"port": 1}}, map[string]*Schema{"server": s})
+
+	test.ExpectString(t, err.(*SchemaError).Problems[0], "server.port is not a recognised setting (did you mean Port?)")
+}
+
+func TestMergeSchemas(t *testing.T) {
+
+	a := NewSchema(ObjectValue).WithField("A", NewSchema(StringValue)).WithField("Both", NewSchema(IntValue))
+	b := NewSchema(ObjectValue).WithField("B", NewSchema(BoolValue).Require()).WithField("Both", NewSchema(StringValue))
+
+	m := MergeSchemas(a, b)
+
+	test.ExpectString(t, string(m.Field("A").Type), string(StringValue))
+	test.ExpectString(t, string(m.Field("B").Type), string(BoolValue))
+	test.ExpectBool(t, m.Field("B").Required, true)
+	test.ExpectString(t, string(m.Field("Both").Type), string(AnyValue))
+
+	open := MergeSchemas(a, NewMapSchema(nil))
+
+	test.ExpectBool(t, open.Fields == nil, true)
+	test.ExpectBool(t, MergeSchemas(nil, a) == a, true)
+}
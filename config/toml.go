@@ -0,0 +1,663 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TOMLContentParser supports the loading and parsing of TOML (v1.0) configuration and component definition files. The
+// parsed file has the same structure as the equivalent JSON file so files in either format can be merged together. Tables
+// and inline tables become objects, integers and floats become float64 numbers and dates and times become strings in the
+// format they were written. Infinity and NaN are not supported as they cannot be represented in JSON.
+type TOMLContentParser struct {
+}
+
+// ParseInto takes a byte array that is assumed to be a serialised TOML document and attempts to parse that into the supplied target object
+func (tcp *TOMLContentParser) ParseInto(data []byte, target interface{}) error {
+
+	tp := &tomlParser{s: strings.TrimPrefix(string(data), "\ufeff")}
+
+	v, err := tp.parseDocument()
+
+	if err != nil {
+		return err
+	}
+
+	return setParsedContent(v, target)
+}
+
+// Extensions returns the list of filename extensions (lowercase, without leading dot) that will be considered to be TOML files.
+func (tcp *TOMLContentParser) Extensions() []string {
+	return []string{"toml"}
+}
+
+// ContentTypes returns the MIME media types/HTTP content-types that will be considered to represent TOML
+func (tcp *TOMLContentParser) ContentTypes() []string {
+	return []string{"application/toml", "text/toml", "text/x-toml"}
+}
+
+var (
+	tomlIntegerPattern  = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)$`)
+	tomlFloatPattern    = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)((\.[0-9](_?[0-9])*)([eE][-+]?[0-9](_?[0-9])*)?|[eE][-+]?[0-9](_?[0-9])*)$`)
+	tomlPrefixedPattern = regexp.MustCompile(`^0(x[0-9a-fA-F](_?[0-9a-fA-F])*|o[0-7](_?[0-7])*|b[01](_?[01])*)$`)
+	tomlDatePattern     = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+	tomlDateTimePattern = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2}([Tt ][0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?([Zz]|[-+][0-9]{2}:[0-9]{2})?)?|[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?)$`)
+	tomlBareKeyPattern  = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// The separator used when recording the paths of tables that have been defined
+const tomlPathSeparator = "\x00"
+
+// tomlInlineTable is an inline table while a document is being parsed. Inline tables are self-contained, so keys cannot
+// be added to them by table headers or dotted keys later in the document.
+type tomlInlineTable map[string]interface{}
+
+type tomlParser struct {
+	s       string
+	i       int
+	root    map[string]interface{}
+	current map[string]interface{}
+	defined map[string]bool
+	content bool
+}
+
+func (p *tomlParser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("line %d: %s", strings.Count(p.s[:p.i], "\n")+1, fmt.Sprintf(format, a...))
+}
+
+func (p *tomlParser) parseDocument() (interface{}, error) {
+
+	p.root = make(map[string]interface{})
+	p.current = p.root
+	p.defined = make(map[string]bool)
+
+	for {
+		p.skipBlankLines()
+
+		if p.i >= len(p.s) {
+			break
+		}
+
+		p.content = true
+
+		var err error
+
+		if p.s[p.i] == '[' {
+			err = p.parseTableHeader()
+		} else {
+			err = p.parseKeyValue(p.current)
+		}
+
+		if err == nil {
+			err = p.expectLineEnd()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !p.content {
+		return nil, nil
+	}
+
+	return unwrapInlineTables(p.root), nil
+}
+
+// unwrapInlineTables replaces each tomlInlineTable in v with a plain object, so the parsed document has the same
+// structure as a JSON document.
+func unwrapInlineTables(v interface{}) interface{} {
+
+	switch t := v.(type) {
+	case tomlInlineTable:
+		return unwrapInlineTables(map[string]interface{}(t))
+	case map[string]interface{}:
+		for k, mv := range t {
+			t[k] = unwrapInlineTables(mv)
+		}
+	case []interface{}:
+		for i, av := range t {
+			t[i] = unwrapInlineTables(av)
+		}
+	}
+
+	return v
+}
+
+func (p *tomlParser) skipSpace() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if p.i < len(p.s) && p.s[p.i] == '#' {
+		for p.i < len(p.s) && p.s[p.i] != '\n' {
+			p.i++
+		}
+	}
+}
+
+// skipBlankLines skips whitespace, line breaks and comments
+func (p *tomlParser) skipBlankLines() {
+
+	for p.i < len(p.s) {
+
+		switch p.s[p.i] {
+		case ' ', '\t', '\r', '\n':
+			p.i++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) expectLineEnd() error {
+
+	p.skipSpace()
+	p.skipComment()
+
+	if strings.HasPrefix(p.s[p.i:], "\r\n") {
+		p.i += 2
+	} else if p.i < len(p.s) && p.s[p.i] == '\n' {
+		p.i++
+	} else if p.i < len(p.s) {
+		return p.errorf("expected a new line but found %q", p.s[p.i])
+	}
+
+	return nil
+}
+
+func (p *tomlParser) parseTableHeader() error {
+
+	array := strings.HasPrefix(p.s[p.i:], "[[")
+
+	if array {
+		p.i += 2
+	} else {
+		p.i++
+	}
+
+	keys, err := p.parseKey()
+
+	if err != nil {
+		return err
+	}
+
+	p.skipSpace()
+
+	closing := "]"
+
+	if array {
+		closing = "]]"
+	}
+
+	if !strings.HasPrefix(p.s[p.i:], closing) {
+		return p.errorf("expected %s at the end of a table header", closing)
+	}
+
+	p.i += len(closing)
+
+	path := strings.Join(keys, tomlPathSeparator)
+
+	if !array {
+
+		if p.defined[path] {
+			return p.errorf("the table %s is defined more than once", strings.Join(keys, "."))
+		}
+
+		t, err := p.descend(p.root, keys)
+
+		if err != nil {
+			return err
+		}
+
+		p.defined[path] = true
+		p.current = t
+
+		return nil
+	}
+
+	parent, err := p.descend(p.root, keys[:len(keys)-1])
+
+	if err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+
+	tables, found := parent[last].([]interface{})
+
+	if parent[last] != nil && !found {
+		return p.errorf("%s already has a value that is not an array of tables", strings.Join(keys, "."))
+	}
+
+	t := make(map[string]interface{})
+	parent[last] = append(tables, t)
+	p.current = t
+
+	// Sub-tables of the previous element of the array may be defined again in the new element
+	for d := range p.defined {
+		if strings.HasPrefix(d, path+tomlPathSeparator) {
+			delete(p.defined, d)
+		}
+	}
+
+	return nil
+}
+
+// descend follows (creating where necessary) the tables named by keys, starting at t. If a key refers to an array of
+// tables, the last table in the array is used.
+func (p *tomlParser) descend(t map[string]interface{}, keys []string) (map[string]interface{}, error) {
+
+	for i, k := range keys {
+
+		switch v := t[k].(type) {
+		case nil:
+			n := make(map[string]interface{})
+			t[k] = n
+			t = n
+			continue
+		case map[string]interface{}:
+			t = v
+			continue
+		case tomlInlineTable:
+			return nil, p.errorf("%s is an inline table and cannot be extended", strings.Join(keys[:i+1], "."))
+		case []interface{}:
+			if len(v) > 0 {
+				if last, found := v[len(v)-1].(map[string]interface{}); found {
+					t = last
+					continue
+				}
+			}
+		}
+
+		return nil, p.errorf("%s already has a value that is not a table", strings.Join(keys[:i+1], "."))
+	}
+
+	return t, nil
+}
+
+// parseKey parses a bare, quoted or dotted key, returning each part of the key
+func (p *tomlParser) parseKey() ([]string, error) {
+
+	var keys []string
+
+	for {
+		p.skipSpace()
+
+		if p.i >= len(p.s) {
+			return nil, p.errorf("expected a key")
+		}
+
+		var k string
+		var err error
+
+		switch p.s[p.i] {
+		case '"':
+			k, err = p.parseBasicString()
+		case '\'':
+			k, err = p.parseLiteralString()
+		default:
+			start := p.i
+
+			for p.i < len(p.s) && tomlBareKeyPattern.MatchString(p.s[p.i:p.i+1]) {
+				p.i++
+			}
+
+			if start == p.i {
+				return nil, p.errorf("expected a key")
+			}
+
+			k = p.s[start:p.i]
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, k)
+
+		p.skipSpace()
+
+		if p.i >= len(p.s) || p.s[p.i] != '.' {
+			return keys, nil
+		}
+
+		p.i++
+	}
+}
+
+func (p *tomlParser) parseKeyValue(t map[string]interface{}) error {
+
+	keys, err := p.parseKey()
+
+	if err != nil {
+		return err
+	}
+
+	if p.i >= len(p.s) || p.s[p.i] != '=' {
+		return p.errorf("expected = after the key %s", strings.Join(keys, "."))
+	}
+
+	p.i++
+	p.skipSpace()
+
+	v, err := p.parseValue()
+
+	if err != nil {
+		return err
+	}
+
+	if t, err = p.descend(t, keys[:len(keys)-1]); err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+
+	if _, found := t[last]; found {
+		return p.errorf("the key %s is defined more than once", strings.Join(keys, "."))
+	}
+
+	t[last] = v
+
+	return nil
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+
+	if p.i >= len(p.s) {
+		return nil, p.errorf("expected a value")
+	}
+
+	rest := p.s[p.i:]
+
+	switch {
+	case strings.HasPrefix(rest, `"""`):
+		return p.parseMultiLineString(`"""`)
+	case strings.HasPrefix(rest, "'''"):
+		return p.parseMultiLineString("'''")
+	case rest[0] == '"':
+		return p.parseBasicString()
+	case rest[0] == '\'':
+		return p.parseLiteralString()
+	case rest[0] == '[':
+		return p.parseArray()
+	case rest[0] == '{':
+		return p.parseInlineTable()
+	}
+
+	start := p.i
+
+	for p.i < len(p.s) && strings.IndexByte(" \t\r\n,]}#", p.s[p.i]) < 0 {
+		p.i++
+	}
+
+	token := p.s[start:p.i]
+
+	// A space may separate the date and time of a date-time
+	if tomlDatePattern.MatchString(token) && p.i+1 < len(p.s) && p.s[p.i] == ' ' && p.s[p.i+1] >= '0' && p.s[p.i+1] <= '9' {
+
+		p.i++
+
+		for p.i < len(p.s) && strings.IndexByte(" \t\r\n,]}#", p.s[p.i]) < 0 {
+			p.i++
+		}
+
+		token = p.s[start:p.i]
+	}
+
+	switch {
+	case token == "true":
+		return true, nil
+	case token == "false":
+		return false, nil
+	case tomlIntegerPattern.MatchString(token) || tomlFloatPattern.MatchString(token):
+		return strconv.ParseFloat(strings.Replace(token, "_", "", -1), 64)
+	case tomlPrefixedPattern.MatchString(token):
+		i, err := strconv.ParseInt(token, 0, 64)
+
+		if err != nil {
+			return nil, p.errorf("invalid integer %s", token)
+		}
+
+		return float64(i), nil
+	case tomlDateTimePattern.MatchString(token):
+		return token, nil
+	case strings.HasSuffix(token, "inf") || strings.HasSuffix(token, "nan"):
+		return nil, p.errorf("%s cannot be used as it cannot be represented in JSON", token)
+	}
+
+	p.i = start
+
+	return nil, p.errorf("invalid value %s", token)
+}
+
+func (p *tomlParser) parseArray() (interface{}, error) {
+
+	p.i++
+
+	a := make([]interface{}, 0)
+
+	for {
+		p.skipBlankLines()
+
+		if p.i < len(p.s) && p.s[p.i] == ']' {
+			p.i++
+			return a, nil
+		}
+
+		v, err := p.parseValue()
+
+		if err != nil {
+			return nil, err
+		}
+
+		a = append(a, v)
+
+		p.skipBlankLines()
+
+		if p.i >= len(p.s) {
+			return nil, p.errorf("unterminated array")
+		}
+
+		switch p.s[p.i] {
+		case ',':
+			p.i++
+		case ']':
+			p.i++
+			return a, nil
+		default:
+			return nil, p.errorf("expected , or ] in an array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+
+	p.i++
+
+	t := make(map[string]interface{})
+
+	p.skipSpace()
+
+	if p.i < len(p.s) && p.s[p.i] == '}' {
+		p.i++
+		return tomlInlineTable(t), nil
+	}
+
+	for {
+		if err := p.parseKeyValue(t); err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+
+		if p.i >= len(p.s) {
+			return nil, p.errorf("unterminated inline table")
+		}
+
+		switch p.s[p.i] {
+		case ',':
+			p.i++
+		case '}':
+			p.i++
+			return tomlInlineTable(t), nil
+		default:
+			return nil, p.errorf("expected , or } in an inline table")
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+
+	end := strings.IndexAny(p.s[p.i+1:], "'\n")
+
+	if end < 0 || p.s[p.i+1+end] != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+
+	v := p.s[p.i+1 : p.i+1+end]
+	p.i += end + 2
+
+	return v, nil
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+
+	p.i++
+
+	var b strings.Builder
+
+	for p.i < len(p.s) {
+
+		c := p.s[p.i]
+
+		switch c {
+		case '"':
+			p.i++
+			return b.String(), nil
+		case '\n':
+			return "", p.errorf("unterminated string")
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.i++
+		}
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+// parseMultiLineString parses a multi-line basic or literal string, depending on the supplied delimiter
+func (p *tomlParser) parseMultiLineString(delim string) (string, error) {
+
+	start := p.i
+	p.i += 3
+
+	// A line break immediately after the opening delimiter is not part of the string
+	if strings.HasPrefix(p.s[p.i:], "\r\n") {
+		p.i += 2
+	} else if strings.HasPrefix(p.s[p.i:], "\n") {
+		p.i++
+	}
+
+	var b strings.Builder
+
+	for p.i < len(p.s) {
+
+		if strings.HasPrefix(p.s[p.i:], delim) {
+
+			// Up to two quotes may appear immediately before the closing delimiter
+			extra := 0
+
+			for extra < 2 && strings.HasPrefix(p.s[p.i+1+extra:], delim) {
+				extra++
+			}
+
+			b.WriteString(p.s[p.i : p.i+extra])
+			p.i += extra + 3
+
+			return b.String(), nil
+		}
+
+		c := p.s[p.i]
+
+		if c == '\\' && delim == `"""` {
+
+			// A backslash at the end of a line removes the line break and any whitespace that follows
+			j := p.i + 1
+
+			for j < len(p.s) && (p.s[j] == ' ' || p.s[j] == '\t') {
+				j++
+			}
+
+			if j < len(p.s) && (p.s[j] == '\n' || strings.HasPrefix(p.s[j:], "\r\n")) {
+
+				for p.i = j; p.i < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.i]) >= 0; p.i++ {
+				}
+
+				continue
+			}
+
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+
+			continue
+		}
+
+		b.WriteByte(c)
+		p.i++
+	}
+
+	p.i = start
+
+	return "", p.errorf("unterminated multi-line string")
+}
+
+var tomlEscapes = map[byte]byte{'b': '\b', 't': '\t', 'n': '\n', 'f': '\f', 'r': '\r', '"': '"', '\\': '\\'}
+
+// parseEscape parses the escape sequence starting with the backslash at the current position
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+
+	if p.i+1 >= len(p.s) {
+		return p.errorf("incomplete escape sequence")
+	}
+
+	c := p.s[p.i+1]
+
+	if e, found := tomlEscapes[c]; found {
+		b.WriteByte(e)
+		p.i += 2
+		return nil
+	}
+
+	digits := map[byte]int{'u': 4, 'U': 8}[c]
+
+	if digits == 0 || p.i+2+digits > len(p.s) {
+		return p.errorf("invalid escape sequence \\%c", c)
+	}
+
+	r, err := strconv.ParseUint(p.s[p.i+2:p.i+2+digits], 16, 32)
+
+	if err != nil || !utf8.ValidRune(rune(r)) {
+		return p.errorf("invalid escape sequence \\%s", p.s[p.i+1:p.i+2+digits])
+	}
+
+	b.WriteRune(rune(r))
+	p.i += 2 + digits
+
+	return nil
+}
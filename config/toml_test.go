@@ -0,0 +1,142 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func parseTOML(t *testing.T, doc string) map[string]interface{} {
+
+	var v interface{}
+
+	if err := new(TOMLContentParser).ParseInto([]byte(doc), &v); err != nil {
+		t.Fatalf("Unexpected error parsing TOML: %s", err.Error())
+	}
+
+	return v.(map[string]interface{})
+}
+
+func TestTOMLValues(t *testing.T) {
+
+	doc := `
+# A comment
+title = "TOML \"example\" \u00e9"
+literal = 'C:\Users\app'
+int = 1_000
+hex = 0xff
+float = -2.5e-1
+enabled = true
+date = 1979-05-27
+datetime = 1979-05-27 07:32:00Z
+"quoted key" = 1
+site."google.com" = true
+multi = """
+Roses are red \
+  Violets are blue"""
+raw = '''
+first line
+second line'''
+array = [
+  1,
+  2, # comment
+]
+nested = [[1, 2], ["a"]]
+inline = { x = 1, y.z = "deep" }
+`
+	m := parseTOML(t, doc)
+
+	test.ExpectString(t, m["title"].(string), `TOML "example" é`)
+	test.ExpectString(t, m["literal"].(string), `C:\Users\app`)
+	test.ExpectFloat(t, m["int"].(float64), 1000)
+	test.ExpectFloat(t, m["hex"].(float64), 255)
+	test.ExpectFloat(t, m["float"].(float64), -0.25)
+	test.ExpectBool(t, m["enabled"].(bool), true)
+	test.ExpectString(t, m["date"].(string), "1979-05-27")
+	test.ExpectString(t, m["datetime"].(string), "1979-05-27 07:32:00Z")
+	test.ExpectFloat(t, m["quoted key"].(float64), 1)
+	test.ExpectBool(t, m["site"].(map[string]interface{})["google.com"].(bool), true)
+	test.ExpectString(t, m["multi"].(string), "Roses are red Violets are blue")
+	test.ExpectString(t, m["raw"].(string), "first line\nsecond line")
+	test.ExpectInt(t, len(m["array"].([]interface{})), 2)
+	test.ExpectInt(t, len(m["nested"].([]interface{})), 2)
+
+	inline := m["inline"].(map[string]interface{})
+	test.ExpectString(t, inline["y"].(map[string]interface{})["z"].(string), "deep")
+}
+
+func TestTOMLTables(t *testing.T) {
+
+	doc := `
+[database]
+host = "localhost"
+
+[database.pool]
+size = 10
+
+[[servers]]
+name = "alpha"
+
+[servers.limits]
+cpu = 2
+
+[[servers]]
+name = "beta"
+
+[servers.limits]
+cpu = 4
+`
+	m := parseTOML(t, doc)
+
+	db := m["database"].(map[string]interface{})
+
+	test.ExpectString(t, db["host"].(string), "localhost")
+	test.ExpectFloat(t, db["pool"].(map[string]interface{})["size"].(float64), 10)
+
+	servers := m["servers"].([]interface{})
+
+	test.ExpectInt(t, len(servers), 2)
+
+	beta := servers[1].(map[string]interface{})
+
+	test.ExpectString(t, beta["name"].(string), "beta")
+	test.ExpectFloat(t, beta["limits"].(map[string]interface{})["cpu"].(float64), 4)
+}
+
+func TestTOMLErrors(t *testing.T) {
+
+	docs := []string{
+		"a = 1\na = 2",
+		"[a]\n[a]",
+		"a = 1\n[a]",
+		"a = \"unterminated",
+		"a = [1, 2",
+		"a = inf",
+		"a = 1 b = 2",
+		"a",
+		"a = value",
+		"x = {a = 1}\n[x]",
+		"x = {a = 1}\n[x.b]",
+		"x = {a = 1}\nx.b = 2",
+		"x = {a = {b = 1}, a.c = 2}",
+	}
+
+	for _, doc := range docs {
+
+		var v interface{}
+
+		if err := new(TOMLContentParser).ParseInto([]byte(doc), &v); err == nil {
+			t.Errorf("Expected an error parsing %q", doc)
+		}
+	}
+
+	var v interface{}
+
+	err := new(TOMLContentParser).ParseInto([]byte("# Only a comment\n"), &v)
+
+	if _, found := err.(EmptyFileError); !found {
+		t.Errorf("Expected an EmptyFileError")
+	}
+}
@@ -0,0 +1,1143 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// YAMLContentParser supports the loading and parsing of YAML configuration and component definition files. The parsed
+// file has the same structure as the equivalent JSON file (objects, arrays, strings, float64 numbers, bools and nulls) so
+// files in either format can be merged together.
+//
+// The parser supports the parts of YAML 1.2 commonly used in configuration files: block and flow mappings and sequences,
+// plain, quoted and block (| and >) scalars, comments, anchors, aliases and merge keys (<<) and the standard !!str, !!int,
+// !!float, !!bool, !!null, !!map and !!seq tags. Keys are always treated as strings. A file may contain only one document.
+// Each alias is expanded to a copy of its anchored value. A file whose aliases expand to more than 100,000 values in
+// total is rejected.
+type YAMLContentParser struct {
+}
+
+// ParseInto takes a byte array that is assumed to be a serialised YAML document and attempts to parse that into the supplied target object
+func (ycp *YAMLContentParser) ParseInto(data []byte, target interface{}) error {
+
+	yp := newYAMLParser(string(data))
+
+	v, err := yp.parseDocument()
+
+	if err != nil {
+		return err
+	}
+
+	return setParsedContent(v, target)
+}
+
+// Extensions returns the list of filename extensions (lowercase, without leading dot) that will be considered to be YAML files.
+func (ycp *YAMLContentParser) Extensions() []string {
+	return []string{"yaml", "yml"}
+}
+
+// ContentTypes returns the MIME media types/HTTP content-types that will be considered to represent YAML
+func (ycp *YAMLContentParser) ContentTypes() []string {
+	return []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"}
+}
+
+// setParsedContent copies a document parsed into generic maps and slices into the target a ContentParser was asked to
+// populate, using the same rules as if the document had been JSON. An empty document results in an empty object and an EmptyFileError.
+func setParsedContent(v interface{}, target interface{}) error {
+
+	if v == nil {
+
+		json.Unmarshal([]byte("{}"), target)
+
+		return EmptyFileError{Message: "document is empty"}
+	}
+
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, target)
+}
+
+var (
+	yamlNumberPattern = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	yamlHexPattern    = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+	yamlOctalPattern  = regexp.MustCompile(`^0o[0-7]+$`)
+)
+
+type yamlParser struct {
+	lines    []string
+	pos      int
+	anchors  map[string]interface{}
+	expanded int
+}
+
+func newYAMLParser(doc string) *yamlParser {
+
+	doc = strings.TrimPrefix(doc, "\ufeff")
+
+	// The line break at the end of the last line does not start another (empty) line
+	lines := strings.Split(strings.TrimSuffix(doc, "\n"), "\n")
+
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+
+	return &yamlParser{lines: lines, anchors: make(map[string]interface{})}
+}
+
+func (p *yamlParser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.pos+1, fmt.Sprintf(format, a...))
+}
+
+// parseDocument removes directives and document markers then parses the single document in the file.
+func (p *yamlParser) parseDocument() (interface{}, error) {
+
+	content := false
+
+	for i, l := range p.lines {
+
+		if l == "..." || strings.HasPrefix(l, "... ") {
+			p.lines = p.lines[:i]
+			break
+		}
+
+		if l == "---" || strings.HasPrefix(l, "--- ") {
+
+			if content {
+				p.pos = i
+				return nil, p.errorf("files containing more than one YAML document are not supported")
+			}
+
+			p.lines[i] = strings.TrimSpace(l[3:])
+			content = !isBlankYAML(p.lines[i])
+
+		} else if !content && strings.HasPrefix(l, "%") {
+			p.lines[i] = ""
+		} else if !isBlankYAML(l) {
+			content = true
+		}
+	}
+
+	v, err := p.parseBlock(0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipBlank()
+
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected content (check the indentation of this line)")
+	}
+
+	return v, nil
+}
+
+func isBlankYAML(l string) bool {
+	t := strings.TrimSpace(l)
+
+	return t == "" || t[0] == '#'
+}
+
+func isSequenceEntry(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ") || strings.HasPrefix(content, "-\t")
+}
+
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) && isBlankYAML(p.lines[p.pos]) {
+		p.pos++
+	}
+}
+
+// current returns the indentation and content of the current line
+func (p *yamlParser) current() (int, string, error) {
+
+	l := p.lines[p.pos]
+	content := strings.TrimLeft(l, " ")
+
+	if strings.HasPrefix(content, "\t") {
+		return 0, "", p.errorf("tabs cannot be used for indentation")
+	}
+
+	return len(l) - len(content), content, nil
+}
+
+// parseBlock parses the node starting on the next non-blank line, provided that line is indented by at least minIndent spaces.
+func (p *yamlParser) parseBlock(minIndent int) (interface{}, error) {
+
+	p.skipBlank()
+
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+
+	indent, content, err := p.current()
+
+	if err != nil || indent < minIndent {
+		return nil, err
+	}
+
+	if isSequenceEntry(content) {
+		return p.parseSequence(indent)
+	}
+
+	if mappingColon(stripYAMLComment(content)) >= 0 {
+		return p.parseMapping(indent)
+	}
+
+	return p.parseValue(content, indent-1, false)
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+
+	seq := make([]interface{}, 0)
+
+	for {
+		p.skipBlank()
+
+		if p.pos >= len(p.lines) {
+			break
+		}
+
+		ind, content, err := p.current()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ind < indent {
+			break
+		}
+
+		if ind > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+
+		if !isSequenceEntry(content) {
+			break
+		}
+
+		rest := strings.TrimLeft(content[1:], " \t")
+
+		if stripYAMLComment(rest) == "" {
+			p.pos++
+		} else {
+			// Replace the dash with spaces so the rest of the line is parsed as a node nested in the entry
+			p.lines[p.pos] = strings.Repeat(" ", ind+len(content)-len(rest)) + rest
+		}
+
+		v, err := p.parseBlock(indent + 1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		seq = append(seq, v)
+	}
+
+	return seq, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+
+	m := make(map[string]interface{})
+	var merges []interface{}
+
+	for {
+		p.skipBlank()
+
+		if p.pos >= len(p.lines) {
+			break
+		}
+
+		ind, content, err := p.current()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ind < indent {
+			break
+		}
+
+		if ind > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+
+		content = stripYAMLComment(content)
+		c := mappingColon(content)
+
+		if c < 0 {
+			return nil, p.errorf("expected a key followed by a colon")
+		}
+
+		keyText := strings.TrimSpace(content[:c])
+		key := keyText
+
+		if keyText != "" && (keyText[0] == '"' || keyText[0] == '\'') {
+
+			var rest string
+
+			if key, rest, err = scanQuoted(keyText); err != nil || strings.TrimSpace(rest) != "" {
+				return nil, p.errorf("invalid quoted key %s", keyText)
+			}
+
+		} else if keyText == "<<" {
+
+			v, err := p.parseValue(content[c+1:], indent, true)
+
+			if err != nil {
+				return nil, err
+			}
+
+			merges = append(merges, v)
+			continue
+		}
+
+		if _, found := m[key]; found {
+			return nil, p.errorf("the key %s appears more than once", key)
+		}
+
+		v, err := p.parseValue(content[c+1:], indent, true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		m[key] = v
+	}
+
+	// Keys from merged mappings never replace keys set explicitly or from an earlier merged mapping
+	for _, merge := range merges {
+
+		sources, found := merge.([]interface{})
+
+		if !found {
+			sources = []interface{}{merge}
+		}
+
+		for _, source := range sources {
+
+			sm, found := source.(map[string]interface{})
+
+			if !found {
+				return nil, p.errorf("the value of a merge key (<<) must be a mapping or a sequence of mappings")
+			}
+
+			for k, v := range sm {
+				if _, found := m[k]; !found {
+					m[k] = copyNode(v)
+				}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// parseValue parses the value starting with the supplied text, which is the remainder of the current line. The value
+// may continue onto following lines if they are indented more than parentIndent. If compact is true, a sequence
+// indented by the same amount as its parent mapping's keys is allowed.
+func (p *yamlParser) parseValue(rest string, parentIndent int, compact bool) (interface{}, error) {
+
+	rest = strings.TrimSpace(stripYAMLComment(rest))
+
+	var anchor, tag string
+
+	for rest != "" && (rest[0] == '&' || rest[0] == '!') {
+
+		property := rest
+		rest = ""
+
+		if i := strings.IndexAny(property, " \t"); i > 0 {
+			property, rest = property[:i], strings.TrimSpace(property[i:])
+		}
+
+		if property[0] == '&' {
+			anchor = property[1:]
+		} else {
+			tag = property
+		}
+	}
+
+	var v interface{}
+	var err error
+
+	switch {
+	case rest == "":
+		p.pos++
+		p.skipBlank()
+
+		if p.pos < len(p.lines) {
+
+			ind, content, err := p.current()
+
+			if err != nil {
+				return nil, err
+			}
+
+			if ind > parentIndent {
+				v, err = p.parseBlock(ind)
+			} else if compact && ind == parentIndent && isSequenceEntry(content) {
+				v, err = p.parseSequence(ind)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	case rest[0] == '*':
+		var found bool
+
+		if v, found = p.anchors[rest[1:]]; !found {
+			return nil, p.errorf("unknown alias %s", rest)
+		}
+
+		if v, err = copyAlias(v, &p.expanded); err != nil {
+			return nil, p.errorf("%s", err.Error())
+		}
+
+		p.pos++
+
+	case rest[0] == '|' || rest[0] == '>':
+		v, err = p.parseBlockScalar(rest, parentIndent)
+
+	case rest[0] == '[' || rest[0] == '{':
+		v, err = p.parseFlow(rest)
+
+	case rest[0] == '"' || rest[0] == '\'':
+		v, err = p.parseQuoted(rest)
+
+	default:
+		v, err = p.parsePlain(rest, parentIndent, tag == "!!str")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if v, err = p.applyTag(tag, v); err != nil {
+		return nil, err
+	}
+
+	if anchor != "" {
+		p.anchors[anchor] = v
+	}
+
+	return v, nil
+}
+
+func (p *yamlParser) applyTag(tag string, v interface{}) (interface{}, error) {
+
+	var valid bool
+
+	switch tag {
+	case "":
+		return v, nil
+	case "!!str":
+		if v == nil {
+			return "", nil
+		}
+
+		_, valid = v.(string)
+	case "!!int", "!!float":
+		if s, found := v.(string); found {
+			v = resolvePlainScalar(s)
+		}
+
+		_, valid = v.(float64)
+	case "!!bool":
+		if s, found := v.(string); found {
+			v = resolvePlainScalar(s)
+		}
+
+		_, valid = v.(bool)
+	case "!!null":
+		valid = v == nil || v == ""
+		v = nil
+	case "!!map":
+		_, valid = v.(map[string]interface{})
+	case "!!seq":
+		_, valid = v.([]interface{})
+	default:
+		return nil, p.errorf("unsupported tag %s", tag)
+	}
+
+	if !valid {
+		return nil, p.errorf("value cannot be used with the tag %s", tag)
+	}
+
+	return v, nil
+}
+
+func (p *yamlParser) parsePlain(text string, parentIndent int, raw bool) (interface{}, error) {
+
+	p.pos++
+
+	// Continuation lines are folded into a single line
+	for p.pos < len(p.lines) && !isBlankYAML(p.lines[p.pos]) {
+
+		ind, content, err := p.current()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ind <= parentIndent {
+			break
+		}
+
+		content = stripYAMLComment(content)
+
+		if mappingColon(content) >= 0 {
+			return nil, p.errorf("a mapping cannot be nested in a scalar value (check the indentation of this line)")
+		}
+
+		text += " " + content
+		p.pos++
+	}
+
+	if raw {
+		return text, nil
+	}
+
+	return resolvePlainScalar(text), nil
+}
+
+func (p *yamlParser) parseQuoted(text string) (interface{}, error) {
+
+	start := p.pos
+
+	for quotedEnd(text) < 0 {
+
+		p.pos++
+
+		if p.pos >= len(p.lines) {
+			p.pos = start
+			return nil, p.errorf("unterminated quoted string")
+		}
+
+		text += "\n" + p.lines[p.pos]
+	}
+
+	v, rest, err := scanQuoted(text)
+
+	if err != nil {
+		return nil, p.errorf("%s", err.Error())
+	}
+
+	if rest = strings.TrimSpace(rest); rest != "" && rest[0] != '#' {
+		return nil, p.errorf("unexpected content after a quoted string")
+	}
+
+	p.pos++
+
+	return v, nil
+}
+
+func (p *yamlParser) parseFlow(text string) (interface{}, error) {
+
+	start := p.pos
+
+	for flowDepth(text) > 0 {
+
+		p.pos++
+
+		if p.pos >= len(p.lines) {
+			p.pos = start
+			return nil, p.errorf("unterminated flow collection")
+		}
+
+		text += " " + strings.TrimSpace(stripYAMLComment(p.lines[p.pos]))
+	}
+
+	fp := &yamlFlowParser{s: text, anchors: p.anchors, expanded: &p.expanded}
+
+	v, err := fp.value()
+
+	if err == nil {
+		fp.skipSpace()
+
+		if fp.i < len(fp.s) {
+			err = errors.New("unexpected content after a flow collection")
+		}
+	}
+
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("%s", err.Error())
+	}
+
+	p.pos++
+
+	return v, nil
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar with the supplied header
+func (p *yamlParser) parseBlockScalar(header string, parentIndent int) (interface{}, error) {
+
+	var chomp byte
+	contentIndent := -1
+
+	for _, c := range header[1:] {
+
+		switch {
+		case c == '-' || c == '+':
+			chomp = byte(c)
+		case c >= '1' && c <= '9':
+			contentIndent = int(c - '0')
+
+			if parentIndent > 0 {
+				contentIndent += parentIndent
+			}
+		default:
+			return nil, p.errorf("invalid block scalar header %s", header)
+		}
+	}
+
+	p.pos++
+
+	var lines []string
+
+	for ; p.pos < len(p.lines); p.pos++ {
+
+		l := p.lines[p.pos]
+
+		if strings.TrimSpace(l) == "" {
+			lines = append(lines, "")
+			continue
+		}
+
+		ind := len(l) - len(strings.TrimLeft(l, " "))
+
+		if contentIndent < 0 {
+
+			if ind <= parentIndent {
+				break
+			}
+
+			contentIndent = ind
+		}
+
+		if ind < contentIndent {
+			break
+		}
+
+		lines = append(lines, l[contentIndent:])
+	}
+
+	trailing := 0
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var body string
+
+	if header[0] == '|' {
+		body = strings.Join(lines, "\n")
+	} else {
+		body = foldBlockLines(lines)
+	}
+
+	switch {
+	case chomp == '-':
+		return body, nil
+	case len(lines) == 0 && chomp == '+':
+		return strings.Repeat("\n", trailing), nil
+	case len(lines) == 0:
+		return "", nil
+	case chomp == '+':
+		return body + "\n" + strings.Repeat("\n", trailing), nil
+	default:
+		return body + "\n", nil
+	}
+}
+
+// foldBlockLines joins the lines of a folded block scalar. Line breaks between lines of text become spaces, empty lines
+// become line breaks and line breaks around more-indented lines are preserved.
+func foldBlockLines(lines []string) string {
+
+	var b strings.Builder
+
+	moreIndented := func(l string) bool {
+		return l != "" && (l[0] == ' ' || l[0] == '\t')
+	}
+
+	for i, l := range lines {
+
+		if i > 0 {
+
+			prev := lines[i-1]
+			normalPrev := prev != "" && !moreIndented(prev)
+
+			switch {
+			case normalPrev && l != "" && !moreIndented(l):
+				b.WriteString(" ")
+			case normalPrev && l == "":
+			default:
+				b.WriteString("\n")
+			}
+		}
+
+		b.WriteString(l)
+	}
+
+	return b.String()
+}
+
+// resolvePlainScalar converts an unquoted scalar to a nil, bool or float64 if it matches YAML's core schema for those types.
+func resolvePlainScalar(s string) interface{} {
+
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if yamlNumberPattern.MatchString(s) {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+
+	base := 0
+
+	if yamlHexPattern.MatchString(s) {
+		base = 16
+	} else if yamlOctalPattern.MatchString(s) {
+		base = 8
+	}
+
+	if base > 0 {
+		if i, err := strconv.ParseInt(s[2:], base, 64); err == nil {
+			return float64(i)
+		}
+	}
+
+	return s
+}
+
+// outsideQuotes calls f with the index of each byte of s that is not part of a quoted scalar, stopping if f returns false.
+// Quotes only start a quoted scalar at the start of a token, so apostrophes inside plain text are ignored.
+func outsideQuotes(s string, f func(i int) bool) {
+
+	for i := 0; i < len(s); i++ {
+
+		c := s[i]
+
+		if (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,:", s[i-1]) >= 0) {
+
+			end := quotedEnd(s[i:])
+
+			if end < 0 {
+				return
+			}
+
+			i += end
+			continue
+		}
+
+		if !f(i) {
+			return
+		}
+	}
+}
+
+// stripYAMLComment removes a trailing comment and any trailing whitespace from a line
+func stripYAMLComment(s string) string {
+
+	end := len(s)
+
+	outsideQuotes(s, func(i int) bool {
+
+		if s[i] == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t') {
+			end = i
+			return false
+		}
+
+		return true
+	})
+
+	return strings.TrimRight(s[:end], " \t")
+}
+
+// mappingColon returns the index of the colon separating a key from its value or -1 if the line is not a mapping entry.
+func mappingColon(s string) int {
+
+	if s == "" || s[0] == '[' || s[0] == '{' || isSequenceEntry(s) {
+		return -1
+	}
+
+	idx := -1
+
+	outsideQuotes(s, func(i int) bool {
+
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ' || s[i+1] == '\t') {
+			idx = i
+			return false
+		}
+
+		return true
+	})
+
+	return idx
+}
+
+// flowDepth returns the number of flow collections that are still open at the end of s
+func flowDepth(s string) int {
+
+	depth := 0
+
+	outsideQuotes(s, func(i int) bool {
+
+		switch s[i] {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+
+		return true
+	})
+
+	return depth
+}
+
+// quotedEnd returns the index of the quote closing the quoted scalar at the start of s, or -1 if it is not closed.
+func quotedEnd(s string) int {
+
+	q := s[0]
+
+	for i := 1; i < len(s); i++ {
+
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// scanQuoted returns the value of the quoted scalar at the start of s and the text that follows it. Line breaks inside
+// the scalar are folded.
+func scanQuoted(s string) (string, string, error) {
+
+	end := quotedEnd(s)
+
+	if end < 0 {
+		return "", "", errors.New("unterminated quoted string")
+	}
+
+	double := s[0] == '"'
+	lines := strings.Split(s[1:end], "\n")
+
+	var b strings.Builder
+
+	b.WriteString(strings.TrimRight(lines[0], " \t"))
+
+	escapedBreak := double && endsWithEscape(lines[0])
+	empty := 0
+
+	for i := 1; i < len(lines); i++ {
+
+		l := strings.TrimLeft(lines[i], " \t")
+		last := i == len(lines)-1
+
+		if !last {
+			l = strings.TrimRight(l, " \t")
+		}
+
+		if l == "" && !last {
+			empty++
+			continue
+		}
+
+		switch {
+		case escapedBreak:
+			// Remove the backslash that escaped the line break
+			text := b.String()
+			b.Reset()
+			b.WriteString(text[:len(text)-1])
+		case l == "" && empty == 0:
+			b.WriteString(" ")
+		case l == "":
+			b.WriteString(strings.Repeat("\n", empty))
+		case empty == 0:
+			b.WriteString(" ")
+		default:
+			b.WriteString(strings.Repeat("\n", empty))
+		}
+
+		b.WriteString(l)
+		escapedBreak = double && endsWithEscape(l)
+		empty = 0
+	}
+
+	if !double {
+		return strings.Replace(b.String(), "''", "'", -1), s[end+1:], nil
+	}
+
+	v, err := unescapeYAML(b.String())
+
+	return v, s[end+1:], err
+}
+
+func endsWithEscape(s string) bool {
+
+	n := 0
+
+	for i := len(s) - 1; i >= 0 && s[i] == '\\'; i-- {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f", 'r': "\r", 'e': "\x1b",
+	' ': " ", '"': "\"", '/': "/", '\\': "\\", 'N': "\u0085", '_': " ", 'L': " ", 'P': " ",
+}
+
+// unescapeYAML replaces the escape sequences allowed in double-quoted YAML scalars
+func unescapeYAML(s string) (string, error) {
+
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if i++; i >= len(s) {
+			return "", errors.New("incomplete escape sequence")
+		}
+
+		if e, found := yamlEscapes[s[i]]; found {
+			b.WriteString(e)
+			continue
+		}
+
+		digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[i]]
+
+		if digits == 0 || i+digits >= len(s) {
+			return "", fmt.Errorf("invalid escape sequence \\%c", s[i])
+		}
+
+		r, err := strconv.ParseUint(s[i+1:i+1+digits], 16, 32)
+
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return "", fmt.Errorf("invalid escape sequence \\%s", s[i:i+1+digits])
+		}
+
+		b.WriteRune(rune(r))
+		i += digits
+	}
+
+	return b.String(), nil
+}
+
+// yamlFlowParser parses flow collections ([a, b] and {a: b}) that have been joined onto a single line
+type yamlFlowParser struct {
+	s        string
+	i        int
+	anchors  map[string]interface{}
+	expanded *int
+}
+
+func (fp *yamlFlowParser) skipSpace() {
+	for fp.i < len(fp.s) && (fp.s[fp.i] == ' ' || fp.s[fp.i] == '\t') {
+		fp.i++
+	}
+}
+
+func (fp *yamlFlowParser) value() (interface{}, error) {
+
+	fp.skipSpace()
+
+	if fp.i >= len(fp.s) {
+		return nil, errors.New("unterminated flow collection")
+	}
+
+	switch fp.s[fp.i] {
+	case '[':
+		return fp.sequence()
+	case '{':
+		return fp.mapping()
+	case '"', '\'':
+		return fp.quoted()
+	}
+
+	text := fp.plain()
+
+	if strings.HasPrefix(text, "*") {
+
+		v, found := fp.anchors[text[1:]]
+
+		if !found {
+			return nil, fmt.Errorf("unknown alias %s", text)
+		}
+
+		return copyAlias(v, fp.expanded)
+	}
+
+	return resolvePlainScalar(text), nil
+}
+
+func (fp *yamlFlowParser) quoted() (string, error) {
+
+	v, rest, err := scanQuoted(fp.s[fp.i:])
+
+	fp.i = len(fp.s) - len(rest)
+
+	return v, err
+}
+
+func (fp *yamlFlowParser) plain() string {
+
+	start := fp.i
+
+	for ; fp.i < len(fp.s); fp.i++ {
+
+		c := fp.s[fp.i]
+
+		if c == ',' || c == ']' || c == '}' {
+			break
+		}
+
+		if c == ':' && (fp.i+1 == len(fp.s) || strings.IndexByte(" \t,]}", fp.s[fp.i+1]) >= 0) {
+			break
+		}
+	}
+
+	return strings.TrimSpace(fp.s[start:fp.i])
+}
+
+// next skips whitespace and returns the next character, or 0 if there are no more characters
+func (fp *yamlFlowParser) next() byte {
+
+	fp.skipSpace()
+
+	if fp.i >= len(fp.s) {
+		return 0
+	}
+
+	return fp.s[fp.i]
+}
+
+func (fp *yamlFlowParser) sequence() (interface{}, error) {
+
+	fp.i++
+
+	seq := make([]interface{}, 0)
+
+	for {
+
+		if fp.next() == ']' {
+			fp.i++
+			return seq, nil
+		}
+
+		v, err := fp.value()
+
+		if err != nil {
+			return nil, err
+		}
+
+		seq = append(seq, v)
+
+		switch fp.next() {
+		case ',':
+			fp.i++
+		case ']':
+			fp.i++
+			return seq, nil
+		default:
+			return nil, errors.New("expected , or ] in a flow sequence")
+		}
+	}
+}
+
+func (fp *yamlFlowParser) mapping() (interface{}, error) {
+
+	fp.i++
+
+	m := make(map[string]interface{})
+
+	for {
+
+		c := fp.next()
+
+		if c == '}' {
+			fp.i++
+			return m, nil
+		}
+
+		var key string
+		var err error
+
+		if c == '"' || c == '\'' {
+			if key, err = fp.quoted(); err != nil {
+				return nil, err
+			}
+		} else {
+			key = fp.plain()
+		}
+
+		var v interface{}
+
+		if fp.next() == ':' {
+
+			fp.i++
+
+			if c := fp.next(); c != ',' && c != '}' {
+				if v, err = fp.value(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if _, found := m[key]; found {
+			return nil, fmt.Errorf("the key %s appears more than once", key)
+		}
+
+		m[key] = v
+
+		switch fp.next() {
+		case ',':
+			fp.i++
+		case '}':
+			fp.i++
+			return m, nil
+		default:
+			return nil, errors.New("expected , or } in a flow mapping")
+		}
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package config
+
+import (
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func parseYAML(t *testing.T, doc string) map[string]interface{} {
+
+	var v interface{}
+
+	if err := new(YAMLContentParser).ParseInto([]byte(doc), &v); err != nil {
+		t.Fatalf("Unexpected error parsing YAML: %s", err.Error())
+	}
+
+	return v.(map[string]interface{})
+}
+
+func TestYAMLScalars(t *testing.T) {
+
+	doc := `
+%YAML 1.2
+---
+string: plain text # comment
+quoted: "a \"b\" \u00e9 # not a comment"
+single: 'it''s'
+apostrophe: don't
+int: 42
+negative: -7
+float: 3.5e2
+hex: 0x1F
+bool: true
+null1: ~
+null2:
+forced: !!str 123
+url: http://example.com:8080/path
+folded plain: this value
+  continues here
+multi quoted: "first
+  second"
+`
+	m := parseYAML(t, doc)
+
+	test.ExpectString(t, m["string"].(string), "plain text")
+	test.ExpectString(t, m["quoted"].(string), `a "b" é # not a comment`)
+	test.ExpectString(t, m["single"].(string), "it's")
+	test.ExpectString(t, m["apostrophe"].(string), "don't")
+	test.ExpectFloat(t, m["int"].(float64), 42)
+	test.ExpectFloat(t, m["negative"].(float64), -7)
+	test.ExpectFloat(t, m["float"].(float64), 350)
+	test.ExpectFloat(t, m["hex"].(float64), 31)
+	test.ExpectBool(t, m["bool"].(bool), true)
+	test.ExpectNil(t, m["null1"])
+	test.ExpectNil(t, m["null2"])
+	test.ExpectString(t, m["forced"].(string), "123")
+	test.ExpectString(t, m["url"].(string), "http://example.com:8080/path")
+	test.ExpectString(t, m["folded plain"].(string), "this value continues here")
+	test.ExpectString(t, m["multi quoted"].(string), "first second")
+}
+
+func TestYAMLCollections(t *testing.T) {
+
+	doc := `
+components:
+  artistHandler:
+    type: handler.WsHandler
+    Methods:
+    - GET
+    - POST
+    BindPathParams: [Id, "Name"]
+    Limits: {max: 10, min: 1}
+  nested:
+    - name: a
+      values:
+        - 1
+        - 2
+    - name: b
+    - - x
+      - y
+    -
+      name: c
+multiLineFlow: [
+  one,   # first
+  two
+]
+empty: []
+`
+	m := parseYAML(t, doc)
+
+	ah := m["components"].(map[string]interface{})["artistHandler"].(map[string]interface{})
+
+	test.ExpectString(t, ah["type"].(string), "handler.WsHandler")
+	test.ExpectInt(t, len(ah["Methods"].([]interface{})), 2)
+	test.ExpectString(t, ah["BindPathParams"].([]interface{})[1].(string), "Name")
+	test.ExpectFloat(t, ah["Limits"].(map[string]interface{})["max"].(float64), 10)
+
+	nested := m["components"].(map[string]interface{})["nested"].([]interface{})
+
+	test.ExpectInt(t, len(nested), 4)
+	test.ExpectString(t, nested[0].(map[string]interface{})["name"].(string), "a")
+	test.ExpectInt(t, len(nested[0].(map[string]interface{})["values"].([]interface{})), 2)
+	test.ExpectString(t, nested[1].(map[string]interface{})["name"].(string), "b")
+	test.ExpectString(t, nested[2].([]interface{})[1].(string), "y")
+	test.ExpectString(t, nested[3].(map[string]interface{})["name"].(string), "c")
+
+	test.ExpectInt(t, len(m["multiLineFlow"].([]interface{})), 2)
+	test.ExpectInt(t, len(m["empty"].([]interface{})), 0)
+}
+
+func TestYAMLBlockScalars(t *testing.T) {
+
+	doc := `
+literal: |
+  line one
+    indented
+
+  line three
+folded: >
+  folded
+  text
+
+  new paragraph
+stripped: |-
+  no newline
+kept: |+
+  trailing
+
+last: end
+`
+	m := parseYAML(t, doc)
+
+	test.ExpectString(t, m["literal"].(string), "line one\n  indented\n\nline three\n")
+	test.ExpectString(t, m["folded"].(string), "folded text\nnew paragraph\n")
+	test.ExpectString(t, m["stripped"].(string), "no newline")
+	test.ExpectString(t, m["kept"].(string), "trailing\n\n")
+	test.ExpectString(t, m["last"].(string), "end")
+}
+
+func TestYAMLKeptBlockScalarAtEndOfFile(t *testing.T) {
+
+	m := parseYAML(t, "kept: |+\n  trailing\n")
+	test.ExpectString(t, m["kept"].(string), "trailing\n")
+
+	m = parseYAML(t, "kept: |+\n  trailing\n\n")
+	test.ExpectString(t, m["kept"].(string), "trailing\n\n")
+
+	m = parseYAML(t, "kept: |+\r\n  trailing\r\n")
+	test.ExpectString(t, m["kept"].(string), "trailing\n")
+}
+
+func TestYAMLAnchorsAndMerges(t *testing.T) {
+
+	doc := `
+defaults: &defaults
+  timeout: 5
+  retries: 3
+primary:
+  <<: *defaults
+  retries: 1
+copy: *defaults
+`
+	m := parseYAML(t, doc)
+
+	p := m["primary"].(map[string]interface{})
+
+	test.ExpectFloat(t, p["timeout"].(float64), 5)
+	test.ExpectFloat(t, p["retries"].(float64), 1)
+
+	// Aliased values are copies, so changing one does not change the other
+	m["copy"].(map[string]interface{})["timeout"] = 10.0
+	test.ExpectFloat(t, m["defaults"].(map[string]interface{})["timeout"].(float64), 5)
+}
+
+func TestYAMLNestedAliasesLimited(t *testing.T) {
+
+	block := `
+a: &a [x, x, x, x, x, x, x, x, x, x]
+b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a, *a]
+c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b, *b]
+d: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c, *c]
+e: &e [*d, *d, *d, *d, *d, *d, *d, *d, *d, *d]
+f: &f [*e, *e, *e, *e, *e, *e, *e, *e, *e, *e]
+g: &g [*f, *f, *f, *f, *f, *f, *f, *f, *f, *f]
+h: &h [*g, *g, *g, *g, *g, *g, *g, *g, *g, *g]
+i: &i [*h, *h, *h, *h, *h, *h, *h, *h, *h, *h]
+`
+	nested := `
+a: &a [x, x, x, x, x, x, x, x, x, x]
+b: &b
+  - *a
+  - *a
+  - *a
+  - *a
+  - *a
+  - *a
+  - *a
+  - *a
+  - *a
+  - *a
+c: &c
+  one: *b
+  two: *b
+  three: *b
+  four: *b
+  five: *b
+  six: *b
+  seven: *b
+  eight: *b
+  nine: *b
+  ten: *b
+d: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c, *c]
+e: [*d, *d, *d, *d, *d, *d, *d, *d, *d, *d]
+`
+
+	for _, doc := range []string{block, nested} {
+
+		var v interface{}
+
+		err := new(YAMLContentParser).ParseInto([]byte(doc), &v)
+
+		if test.ExpectNotNil(t, err) {
+			test.ExpectBool(t, strings.Contains(err.Error(), "aliases expand to more than"), true)
+		}
+	}
+
+	// A reasonable number of aliases is still accepted
+	m := parseYAML(t, "a: &a [x, x]\nb: &b [*a, *a]\nc: [*b, *b]\n")
+	test.ExpectInt(t, len(m["c"].([]interface{})), 2)
+}
+
+func TestYAMLErrors(t *testing.T) {
+
+	docs := []string{
+		"a: 1\na: 2",
+		"a: 1\n  b: 2",
+		"a:\n\tb: 1",
+		"a: \"unterminated",
+		"a: [1, 2",
+		"a: *missing",
+		"a: 1\n---\nb: 2",
+		"a: !!int text",
+		"a: !custom value",
+	}
+
+	for _, doc := range docs {
+
+		var v interface{}
+
+		if err := new(YAMLContentParser).ParseInto([]byte(doc), &v); err == nil {
+			t.Errorf("Expected an error parsing %q", doc)
+		}
+	}
+
+	var v interface{}
+
+	err := new(YAMLContentParser).ParseInto([]byte("# Only a comment\n"), &v)
+
+	if _, found := err.(EmptyFileError); !found {
+		t.Errorf("Expected an EmptyFileError")
+	}
+}
+
+func TestMergeMixedFormats(t *testing.T) {
+
+	dir := test.FilePath("formats")
+
+	files := []string{
+		filepath.Join(dir, "a.json"),
+		filepath.Join(dir, "b.yaml"),
+		filepath.Join(dir, "c.toml"),
+	}
+
+	jm := NewJSONMergerWithDirectLogging(new(logging.ConsoleErrorLogger), new(JSONContentParser))
+
+	m, err := jm.LoadAndMergeConfig(files)
+
+	test.ExpectNil(t, err)
+
+	ca := &Accessor{JSONData: m}
+
+	s, _ := ca.StringVal("database.host")
+	test.ExpectString(t, s, "remotehost")
+
+	i, _ := ca.IntVal("database.port")
+	test.ExpectInt(t, i, 3306)
+
+	a, _ := ca.Array("database.flags")
+	test.ExpectInt(t, len(a), 1)
+
+	i, _ = ca.IntVal("database.pool.size")
+	test.ExpectInt(t, i, 10)
+
+	f, _ := ca.Float64Val("database.pool.timeout")
+	test.ExpectFloat(t, f, 2.5)
+
+	s, _ = ca.StringVal("name")
+	test.ExpectString(t, s, "toml")
+
+	// Arrays are joined in the same way as JSON when merging component definitions
+	jm.MergeArrays = true
+
+	m, err = jm.LoadAndMergeConfig(files)
+	test.ExpectNil(t, err)
+
+	a, _ = (&Accessor{JSONData: m}).Array("database.flags")
+	test.ExpectInt(t, len(a), 4)
+}
+
+func TestYAMLFromURL(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("fromURL: yes\nnumber: 1\n"))
+	}))
+
+	defer ts.Close()
+
+	jm := NewJSONMergerWithDirectLogging(new(logging.ConsoleErrorLogger), new(JSONContentParser))
+
+	m, err := jm.LoadAndMergeConfig([]string{ts.URL + "/config/app.yaml"})
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, m["fromURL"].(string), "yes")
+	test.ExpectFloat(t, m["number"].(float64), 1)
+}
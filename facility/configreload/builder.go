@@ -0,0 +1,122 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package configreload provides the ConfigReload facility which allows an application's configuration to be reloaded
+while it is running.
+
+Enabling the facility
+
+	{
+	  "Facilities": {
+		"ConfigReload": true
+	  }
+	}
+
+When configuration is reloaded, the configuration files and URLs that the application was started with are re-read and
+merged in the same way as at startup (including any configuration layers). The new merged configuration is compared with
+the previous version and, if anything has changed, every component that implements config.ConfigChangeListener is passed
+the paths that changed and an Accessor for the new configuration.
+
+The configuration that the first reload is compared with is loaded from the same files and URLs when the facility starts,
+rather than kept from startup, so the merged configuration used to build the application is still discarded if
+System.FlushMergedConfig is set.
+
+Note that values injected into components with the conf: and c: prefixes are not changed - only components that implement
+config.ConfigChangeListener can react to changes. Directories are expanded into a list of files when the application
+starts, so files added to a configuration directory later will not be loaded.
+
+Built-in components that support reloading are:
+
+	ApplicationLogger/FrameworkLogger GlobalLogLevel and ComponentLogLevels (ApplicationLogging facility)
+	RateLimiting limits (RateLimiting facility)
+	validate.RuleValidator rules (if RulesPath is set - see the validate package)
+
+Triggering a reload
+
+If the RuntimeCtl facility is enabled, configuration can be reloaded with
+
+	grnc-ctl reload-config
+
+Alternatively, the facility can watch the configuration files the application was started with and reload configuration
+when any of them are modified:
+
+	{
+	  "ConfigReload": {
+		"WatchFiles": true,
+		"WatchIntervalMS": 5000
+	  }
+	}
+
+Files are checked for changes (by comparing their size and modification time) every WatchIntervalMS milliseconds.
+Configuration loaded from URLs is not watched.
+
+If the reloaded configuration cannot be loaded or merged, the error is logged and components continue to use their existing
+configuration. If a component is unable to apply a change, the error is logged and the other components are still notified.
+*/
+package configreload
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/runtimectl"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+)
+
+const (
+	// ReloaderComponentName is the name of the component that reloads configuration and notifies listeners of changes
+	ReloaderComponentName = instance.FrameworkPrefix + "ConfigReloader"
+
+	// ReloadConfigComponentName is the name of the component able to reload configuration at runtime
+	ReloadConfigComponentName = instance.FrameworkPrefix + "CommandReloadConfig"
+)
+
+// FacilityBuilder creates the components that make up the ConfigReload facility.
+type FacilityBuilder struct {
+}
+
+// BuildAndRegister implements FacilityBuilder.BuildAndRegister
+func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) error {
+
+	r := newReloader()
+
+	if err := ca.Populate("ConfigReload", r); err != nil {
+		return err
+	}
+
+	p := ioc.CreateProtoComponent(r, ReloaderComponentName)
+	p.AddDependency("Loader", config.LoaderComponentName)
+
+	cn.AddProto(p)
+
+	if runtimectl.Enabled(ca) {
+
+		rc := new(reloadConfigCommand)
+		rc.Reloader = r
+
+		cn.WrapAndAddProto(ReloadConfigComponentName, rc)
+	}
+
+	return nil
+}
+
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (fb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := config.NewSchema(config.ObjectValue)
+	s.WithField("WatchFiles", config.NewSchema(config.BoolValue))
+	s.WithField("WatchIntervalMS", config.NewSchema(config.IntValue))
+
+	return map[string]*config.Schema{"ConfigReload": s}
+}
+
+// FacilityName implements FacilityBuilder.FacilityName
+func (fb *FacilityBuilder) FacilityName() string {
+	return "ConfigReload"
+}
+
+// DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
+func (fb *FacilityBuilder) DependsOnFacilities() []string {
+	return []string{}
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package configreload
+
+import (
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+)
+
+const (
+	rcCommandName = "reload-config"
+	rcSummary     = "Reloads the application's configuration files and notifies components of any changes."
+	rcUsage       = "reload-config"
+	rcHelp        = "Configuration files and URLs are re-read and merged in the same way as when the application started. The paths of any " +
+		"configuration values that were added, removed or modified are listed."
+	rcHelpTwo = "Only components that support reloading (such as the log level, rate limiting and validation components) apply the changes. " +
+		"Values injected into components when the application started are not changed."
+)
+
+type reloadConfigCommand struct {
+	FrameworkLogger logging.Logger
+	Reloader        *reloader
+}
+
+func (c *reloadConfigCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	changed, failures, err := c.Reloader.Reload()
+
+	if err != nil {
+		return nil, []*ws.CategorisedError{ctl.NewCommandUnexpectedError(err.Error())}
+	}
+
+	if len(failures) > 0 {
+
+		errs := make([]*ws.CategorisedError, len(failures))
+
+		for i, f := range failures {
+			errs[i] = ctl.NewCommandUnexpectedError(f)
+		}
+
+		return nil, errs
+	}
+
+	rows := make([][]string, len(changed))
+
+	for i, p := range changed {
+		rows[i] = []string{p}
+	}
+
+	co := new(ctl.CommandOutput)
+	co.OutputBody = rows
+	co.RenderHint = ctl.Columns
+
+	return co, nil
+}
+
+// Name returns the command's name
+func (c *reloadConfigCommand) Name() string {
+	return rcCommandName
+}
+
+// Summary returns an explanation of what the command does
+func (c *reloadConfigCommand) Summmary() string {
+	return rcSummary
+}
+
+// Usage defines how to invoke the command
+func (c *reloadConfigCommand) Usage() string {
+	return rcUsage
+}
+
+// Help give detailed information about the command
+func (c *reloadConfigCommand) Help() []string {
+	return []string{rcHelp, rcHelpTwo}
+}
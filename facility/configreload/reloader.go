@@ -0,0 +1,236 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package configreload
+
+import (
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// reloader finds every component that implements config.ConfigChangeListener and notifies them of the paths that changed
+// when configuration is reloaded, either on demand or, if WatchFiles is set, when a configuration file is modified.
+type reloader struct {
+	FrameworkLogger logging.Logger
+
+	// Used to re-create the merged view of configuration
+	Loader *config.Loader
+
+	// Whether or not configuration files should be checked for changes
+	WatchFiles bool
+
+	// How often (in milliseconds) configuration files are checked for changes
+	WatchIntervalMS int
+
+	current   map[string]interface{}
+	listeners map[string]config.ConfigChangeListener
+	files     map[string]fileState
+	stop      chan bool
+	mutex     sync.Mutex
+}
+
+// fileState is the information used to decide whether or not a file has been modified
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+func newReloader() *reloader {
+	r := new(reloader)
+	r.listeners = make(map[string]config.ConfigChangeListener)
+
+	return r
+}
+
+func (r *reloader) add(name string, l config.ConfigChangeListener) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.listeners[name] = l
+}
+
+// Reload re-creates the merged view of configuration and notifies every listener if any configuration has changed.
+// Returns the paths that changed and a message for each listener that was unable to apply the change. An error is
+// returned if configuration could not be loaded, in which case no listeners are notified.
+func (r *reloader) Reload() (changed []string, failures []string, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ca, err := r.Loader.Load()
+
+	if err != nil {
+		r.FrameworkLogger.LogErrorf("Unable to reload configuration: %s", err.Error())
+		return nil, nil, err
+	}
+
+	changed = config.ChangedPaths(r.current, ca.JSONData)
+
+	if len(changed) == 0 {
+		r.FrameworkLogger.LogInfof("Configuration reloaded (no changes)")
+		return changed, nil, nil
+	}
+
+	r.FrameworkLogger.LogInfof("Configuration reloaded (%d paths changed)", len(changed))
+	r.current = ca.JSONData
+
+	names := make([]string, 0, len(r.listeners))
+
+	for name := range r.listeners {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		if err := r.listeners[name].ConfigChanged(changed, ca); err != nil {
+			m := fmt.Sprintf("Unable to apply configuration changes to %s: %s", name, err.Error())
+
+			r.FrameworkLogger.LogErrorf("%s", m)
+			failures = append(failures, m)
+		}
+	}
+
+	return changed, failures, nil
+}
+
+// OfInterest returns true if the supplied component implements config.ConfigChangeListener
+func (r *reloader) OfInterest(subject *ioc.Component) bool {
+	_, found := subject.Instance.(config.ConfigChangeListener)
+
+	return found
+}
+
+// DecorateComponent records the component so it can be notified when configuration changes
+func (r *reloader) DecorateComponent(subject *ioc.Component, cc *ioc.ComponentContainer) {
+	r.add(subject.Name, subject.Instance.(config.ConfigChangeListener))
+}
+
+// StartComponent loads the configuration that later reloads are compared with and starts watching configuration files
+// for changes, if WatchFiles is set. The baseline is loaded again, rather than kept from startup, so that the merged
+// configuration used to build the application can be discarded (see System.FlushMergedConfig).
+func (r *reloader) StartComponent() error {
+
+	if err := r.loadBaseline(); err != nil {
+		return fmt.Errorf("unable to load the configuration that reloaded configuration will be compared with: %s", err.Error())
+	}
+
+	if !r.WatchFiles {
+		return nil
+	}
+
+	if r.WatchIntervalMS <= 0 {
+		return errors.New("ConfigReload.WatchIntervalMS must be greater than zero")
+	}
+
+	r.files = r.fileStates()
+
+	if len(r.files) == 0 {
+		r.FrameworkLogger.LogWarnf("WatchFiles is set, but the application was not started with any configuration files that can be watched")
+		return nil
+	}
+
+	r.stop = make(chan bool)
+
+	go r.watch(time.Duration(r.WatchIntervalMS)*time.Millisecond, r.stop)
+
+	return nil
+}
+
+// loadBaseline loads the configuration that the next reload will be compared with
+func (r *reloader) loadBaseline() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ca, err := r.Loader.Load()
+
+	if err != nil {
+		return err
+	}
+
+	r.current = ca.JSONData
+
+	return nil
+}
+
+func (r *reloader) watch(interval time.Duration, stop chan bool) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if r.filesModified() {
+				r.FrameworkLogger.LogInfof("Configuration files modified - reloading configuration")
+				r.Reload()
+			}
+		}
+	}
+}
+
+// filesModified returns true if the size or modification time of any configuration file has changed since the last check
+func (r *reloader) filesModified() bool {
+
+	latest := r.fileStates()
+	modified := len(latest) != len(r.files)
+
+	for f, s := range latest {
+
+		if p, found := r.files[f]; !found || p.size != s.size || !p.modTime.Equal(s.modTime) {
+			modified = true
+		}
+	}
+
+	r.files = latest
+
+	return modified
+}
+
+// fileStates returns the current size and modification time of each of the configuration sources that is a local file
+func (r *reloader) fileStates() map[string]fileState {
+
+	states := make(map[string]fileState)
+
+	for _, f := range r.Loader.Sources {
+
+		fi, err := os.Stat(f)
+
+		if err != nil || fi.IsDir() {
+			continue
+		}
+
+		states[f] = fileState{size: fi.Size(), modTime: fi.ModTime()}
+	}
+
+	return states
+}
+
+// PrepareToStop implements ioc.Stoppable
+func (r *reloader) PrepareToStop() {
+}
+
+// ReadyToStop always returns true. Implements ioc.Stoppable
+func (r *reloader) ReadyToStop() (bool, error) {
+	return true, nil
+}
+
+// Stop stops watching configuration files. Implements ioc.Stoppable
+func (r *reloader) Stop() error {
+
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+
+	return nil
+}
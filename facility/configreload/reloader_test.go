@@ -0,0 +1,141 @@
+package configreload
+
+import (
+	"errors"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type mockListener struct {
+	calls   int
+	changed []string
+	err     error
+}
+
+func (ml *mockListener) ConfigChanged(changed []string, ca *config.Accessor) error {
+	ml.calls++
+	ml.changed = changed
+
+	return ml.err
+}
+
+func testReloader(t *testing.T, dir string, content string) (*reloader, string) {
+
+	f := filepath.Join(dir, "config.json")
+	test.ExpectNil(t, ioutil.WriteFile(f, []byte(content), 0644))
+
+	l := new(config.Loader)
+	l.Sources = []string{f}
+	l.FrameworkLoggingManager = logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, nil, nil)
+
+	r := newReloader()
+	r.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	r.Loader = l
+
+	return r, f
+}
+
+func TestReloadNotifiesListeners(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "granitic-configreload")
+	test.ExpectNil(t, err)
+
+	defer os.RemoveAll(dir)
+
+	r, f := testReloader(t, dir, `{"a": {"b": 1}}`)
+
+	test.ExpectNil(t, r.StartComponent())
+
+	good := new(mockListener)
+	comp := ioc.NewComponent("good", good)
+
+	test.ExpectBool(t, r.OfInterest(comp), true)
+	test.ExpectBool(t, r.OfInterest(ioc.NewComponent("other", new(reloadConfigCommand))), false)
+
+	r.DecorateComponent(comp, nil)
+
+	bad := &mockListener{err: errors.New("cannot change")}
+	r.add("bad", bad)
+
+	rc := new(reloadConfigCommand)
+	rc.Reloader = r
+
+	// Nothing has changed, so listeners aren't notified
+	co, errs := rc.ExecuteCommand([]string{}, map[string]string{})
+
+	test.ExpectInt(t, len(errs), 0)
+	test.ExpectInt(t, len(co.OutputBody), 0)
+	test.ExpectInt(t, good.calls, 0)
+
+	test.ExpectNil(t, ioutil.WriteFile(f, []byte(`{"a": {"b": 2}}`), 0644))
+
+	_, errs = rc.ExecuteCommand([]string{}, map[string]string{})
+
+	test.ExpectInt(t, len(errs), 1)
+	test.ExpectInt(t, good.calls, 1)
+	test.ExpectInt(t, bad.calls, 1)
+	test.ExpectString(t, good.changed[0], "a.b")
+
+	// Configuration that can't be loaded isn't passed to listeners
+	test.ExpectNil(t, ioutil.WriteFile(f, []byte(`{"a": `), 0644))
+
+	_, errs = rc.ExecuteCommand([]string{}, map[string]string{})
+
+	test.ExpectInt(t, len(errs), 1)
+	test.ExpectInt(t, good.calls, 1)
+}
+
+func TestStartFailsIfBaselineCannotBeLoaded(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "granitic-configreload")
+	test.ExpectNil(t, err)
+
+	defer os.RemoveAll(dir)
+
+	r, f := testReloader(t, dir, `{"a": 1}`)
+
+	test.ExpectNil(t, ioutil.WriteFile(f, []byte(`{"a": `), 0644))
+	test.ExpectNotNil(t, r.StartComponent())
+}
+
+func TestWatchFiles(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "granitic-configreload")
+	test.ExpectNil(t, err)
+
+	defer os.RemoveAll(dir)
+
+	r, f := testReloader(t, dir, `{"a": 1}`)
+	r.WatchFiles = true
+	r.WatchIntervalMS = 10
+
+	ml := new(mockListener)
+	r.add("listener", ml)
+
+	test.ExpectNil(t, r.StartComponent())
+	defer r.Stop()
+
+	test.ExpectNil(t, ioutil.WriteFile(f, []byte(`{"a": 100}`), 0644))
+
+	for i := 0; i < 100; i++ {
+
+		r.mutex.Lock()
+		calls := ml.calls
+		r.mutex.Unlock()
+
+		if calls > 0 {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Errorf("Listener was not notified after configuration file was modified")
+}
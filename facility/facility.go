@@ -69,6 +69,47 @@ change the port on which your application's HTTP server listens on, you could ad
     }
   }
 
+Checking configuration
+
+Facilities declare the settings they accept (by implementing config.SchemaDeclarer). When your application starts, the
+settings of every enabled facility are checked and any unknown settings, values of the wrong type and missing required
+values are reported in a single error. A typo like:
+
+  {
+    "HTTPServer":{
+      "Prot": 9000
+    }
+  }
+
+is reported as
+
+	HTTPServer.Prot is not a recognised setting (did you mean Port?)
+
+Your own components can have their configuration checked in the same way by implementing config.SchemaDeclarer.
+Top-level sections of configuration that have not been declared (such as sections your application reads with
+Accessor.StringVal and similar methods, rather than Populate) are not checked. To have one of these sections checked,
+declare it from any of your components that implements config.SchemaDeclarer:
+
+	func (c *MyComponent) ConfigSchema() map[string]*config.Schema {
+		return map[string]*config.Schema{
+			"myApp": config.NewSchema(config.ObjectValue).WithField("Greeting", config.NewSchema(config.StringValue)),
+		}
+	}
+
+or use config.NewMapSchema(nil) for a section that may contain any keys.
+
+By default, problems are logged as warnings and the application continues to start. To make the application exit
+instead, change the System.ConfigValidation setting:
+
+  {
+    "System":{
+      "ConfigValidation": "FAIL"
+    }
+  }
+
+where FAIL exits, WARN (the default) logs the problems and continues and OFF disables the check. The grnc-config tool
+performs the same check without starting your application.
+
 */
 package facility
 
@@ -0,0 +1,130 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package health provides the Health facility which exposes liveness and readiness endpoints suitable for use by load
+balancers and container orchestrators.
+
+Enabling the facility
+
+	{
+	  "Facilities": {
+		"HTTPServer": true,
+		"Health": true
+	  }
+	}
+
+Endpoints
+
+Two endpoints are served by the HTTPServer facility:
+
+	GET /health/live   200 while the application is running.
+	GET /health/ready  200 if the application is ready to receive requests, 503 otherwise.
+
+Both endpoints respond with a small JSON document:
+
+	{
+	  "Status": "DOWN",
+	  "Problems": {
+		"grncHTTPServer": "server is suspended"
+	  }
+	}
+
+Readiness
+
+The application is reported as ready when all of the following are true:
+
+	1. The container has made components accessible (see ioc.Accessible).
+	2. The application is not stopping.
+	3. Every component implementing ioc.HealthChecker returns nil from CheckHealth.
+	4. Every component implementing ioc.AccessibilityBlocker (but not ioc.HealthChecker) is not blocking access.
+
+The HTTPServer facility implements ioc.HealthChecker and reports a problem while it is suspended, so suspending the server
+(for example with grnc-ctl suspend) causes load balancers to stop sending it traffic. The health endpoints continue to be
+served while the server is suspended. The RdbmsAccess facility's client managers also implement ioc.HealthChecker by
+checking that their database can be reached.
+
+Configuration
+
+	{
+	  "Health": {
+		"LivePath": "/health/live",
+		"ReadyPath": "/health/ready",
+		"CheckTimeoutMS": 2000,
+		"ExcludeComponents": []
+	  }
+	}
+
+Each component is checked on its own goroutine and CheckTimeoutMS is the maximum time allowed for each component's check.
+A check that takes longer is reported as a problem, without waiting for it to finish. Components listed in
+ExcludeComponents are not checked.
+
+Once a component implementing ioc.AccessibilityBlocker has stopped blocking access, it is not asked again.
+*/
+package health
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+)
+
+const (
+	// MonitorComponentName is the name of the component that assesses the health of the application
+	MonitorComponentName   = instance.FrameworkPrefix + "HealthMonitor"
+	liveEndpointComponent  = instance.FrameworkPrefix + "HealthLiveEndpoint"
+	readyEndpointComponent = instance.FrameworkPrefix + "HealthReadyEndpoint"
+)
+
+// FacilityBuilder creates the components that make up the Health facility.
+type FacilityBuilder struct {
+}
+
+// BuildAndRegister implements FacilityBuilder.BuildAndRegister
+func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) error {
+
+	m := new(Monitor)
+
+	if err := ca.Populate("Health", m); err != nil {
+		return err
+	}
+
+	cn.WrapAndAddProto(MonitorComponentName, m)
+
+	live := new(endpoint)
+	live.Monitor = m
+	live.Path, _ = ca.StringVal("Health.LivePath")
+	live.check = m.Liveness
+
+	cn.WrapAndAddProto(liveEndpointComponent, live)
+
+	ready := new(endpoint)
+	ready.Monitor = m
+	ready.Path, _ = ca.StringVal("Health.ReadyPath")
+	ready.check = m.Readiness
+
+	cn.WrapAndAddProto(readyEndpointComponent, ready)
+
+	return nil
+}
+
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (fb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := config.SchemaFromType(new(Monitor))
+	s.WithField("LivePath", config.NewSchema(config.StringValue))
+	s.WithField("ReadyPath", config.NewSchema(config.StringValue))
+
+	return map[string]*config.Schema{"Health": s}
+}
+
+// FacilityName implements FacilityBuilder.FacilityName
+func (fb *FacilityBuilder) FacilityName() string {
+	return "Health"
+}
+
+// DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
+func (fb *FacilityBuilder) DependsOnFacilities() []string {
+	return []string{"HTTPServer"}
+}
@@ -0,0 +1,324 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// StatusUp indicates that the application is healthy
+	StatusUp = "UP"
+
+	// StatusDown indicates that the application is not healthy
+	StatusDown = "DOWN"
+
+	applicationProblemKey = "application"
+)
+
+// Report describes the health of the application.
+type Report struct {
+	// StatusUp or StatusDown
+	Status string
+
+	// A description of each problem found, keyed by the name of the component with the problem.
+	Problems map[string]string `json:",omitempty"`
+}
+
+// Healthy returns true if no problems were found.
+func (r *Report) Healthy() bool {
+	return r.Status == StatusUp
+}
+
+func (r *Report) addProblem(name string, problem string) {
+
+	if r.Problems == nil {
+		r.Problems = make(map[string]string)
+	}
+
+	r.Problems[name] = problem
+	r.Status = StatusDown
+}
+
+// Monitor assesses the liveness and readiness of the application from the lifecycle state of the container and the
+// health of components implementing ioc.HealthChecker or ioc.AccessibilityBlocker.
+type Monitor struct {
+	// Logger used by Granitic framework components. Automatically injected.
+	FrameworkLogger logging.Logger
+
+	// The maximum number of milliseconds allowed for each component's check when assessing readiness. Zero or less
+	// means no limit.
+	CheckTimeoutMS int64
+
+	// The names of components that should not be checked when assessing readiness.
+	ExcludeComponents []string
+
+	container *ioc.ComponentContainer
+	state     ioc.ComponentState
+	unblocked map[string]bool
+	mutex     sync.RWMutex
+}
+
+// checkResult is the outcome of checking a single component
+type checkResult struct {
+	name    string
+	problem string
+}
+
+// Container implements ioc.ContainerAccessor.Container
+func (m *Monitor) Container(container *ioc.ComponentContainer) {
+	m.container = container
+}
+
+// AllowAccess records that the container has made components accessible. Implements ioc.Accessible
+func (m *Monitor) AllowAccess() error {
+	m.setState(ioc.RunningState)
+
+	return nil
+}
+
+// PrepareToStop records that the application is stopping, so that it is no longer reported as ready. Implements ioc.Stoppable
+func (m *Monitor) PrepareToStop() {
+	m.setState(ioc.StoppingState)
+}
+
+// setState records the lifecycle state of the container. The state is read by readiness checks running on HTTP request goroutines.
+func (m *Monitor) setState(state ioc.ComponentState) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.state = state
+}
+
+func (m *Monitor) currentState() ioc.ComponentState {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.state
+}
+
+// ReadyToStop always returns true. Implements ioc.Stoppable
+func (m *Monitor) ReadyToStop() (bool, error) {
+	return true, nil
+}
+
+// Stop implements ioc.Stoppable
+func (m *Monitor) Stop() error {
+	return nil
+}
+
+// Liveness reports the application as healthy if it is running.
+func (m *Monitor) Liveness(ctx context.Context) *Report {
+	return &Report{Status: StatusUp}
+}
+
+// Readiness reports the application as healthy if the container has made components accessible, the application is
+// not stopping and no component reports a problem.
+func (m *Monitor) Readiness(ctx context.Context) *Report {
+
+	r := &Report{Status: StatusUp}
+
+	switch m.currentState() {
+	case ioc.RunningState:
+	case ioc.StoppingState:
+		r.addProblem(applicationProblemKey, "application is stopping")
+		return r
+	default:
+		r.addProblem(applicationProblemKey, "application is not yet accessible")
+		return r
+	}
+
+	if m.container == nil {
+		return r
+	}
+
+	excluded := make(map[string]bool)
+
+	for _, n := range m.ExcludeComponents {
+		excluded[n] = true
+	}
+
+	checks := make(map[string]func(context.Context) error)
+
+	for _, c := range m.container.AllComponents() {
+
+		if excluded[c.Name] || c.Instance == m {
+			continue
+		}
+
+		if hc, found := c.Instance.(ioc.HealthChecker); found {
+			checks[c.Name] = hc.CheckHealth
+			continue
+		}
+
+		if ab, found := c.Instance.(ioc.AccessibilityBlocker); found && !m.hasUnblocked(c.Name) {
+			checks[c.Name] = m.blockerCheck(c.Name, ab)
+		}
+	}
+
+	results := make(chan checkResult, len(checks))
+
+	for name, check := range checks {
+		go m.runCheck(ctx, name, check, results)
+	}
+
+	for range checks {
+
+		if cr := <-results; cr.problem != "" {
+			r.addProblem(cr.name, cr.problem)
+		}
+	}
+
+	if !r.Healthy() {
+		m.FrameworkLogger.LogDebugfCtx(ctx, "Application not ready: %v", r.Problems)
+	}
+
+	return r
+}
+
+// runCheck runs a single component's check on its own goroutine, so that a slow check does not delay the others, and
+// sends the outcome to results. A check that does not complete within CheckTimeoutMS is reported as a problem.
+func (m *Monitor) runCheck(ctx context.Context, name string, check func(context.Context) error, results chan<- checkResult) {
+
+	if m.CheckTimeoutMS > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(m.CheckTimeoutMS)*time.Millisecond)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- check(ctx)
+	}()
+
+	cr := checkResult{name: name}
+
+	select {
+	case err := <-done:
+
+		if err != nil {
+			cr.problem = err.Error()
+		}
+
+	case <-ctx.Done():
+
+		if ctx.Err() == context.DeadlineExceeded {
+			cr.problem = fmt.Sprintf("check did not complete within %dms", m.CheckTimeoutMS)
+		} else {
+			cr.problem = "check did not complete: " + ctx.Err().Error()
+		}
+	}
+
+	results <- cr
+}
+
+// blockerCheck adapts an ioc.AccessibilityBlocker to a check. Once the component has stopped blocking access it is not
+// asked again, as blockers are only expected to delay the application becoming accessible.
+func (m *Monitor) blockerCheck(name string, ab ioc.AccessibilityBlocker) func(context.Context) error {
+
+	return func(ctx context.Context) error {
+
+		blocked, err := ab.BlockAccess()
+
+		if !blocked {
+			m.mutex.Lock()
+			defer m.mutex.Unlock()
+
+			if m.unblocked == nil {
+				m.unblocked = make(map[string]bool)
+			}
+
+			m.unblocked[name] = true
+
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return errors.New("blocking access")
+	}
+}
+
+func (m *Monitor) hasUnblocked(name string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.unblocked[name]
+}
+
+// Serves a health report as JSON. Implements httpendpoint.Provider
+type endpoint struct {
+	Monitor *Monitor
+	Path    string
+	check   func(ctx context.Context) *Report
+}
+
+// SupportedHTTPMethods implements httpendpoint.Provider.SupportedHTTPMethods
+func (e *endpoint) SupportedHTTPMethods() []string {
+	return []string{http.MethodGet, http.MethodHead}
+}
+
+// RegexPattern implements httpendpoint.Provider.RegexPattern
+func (e *endpoint) RegexPattern() string {
+	return "^" + regexp.QuoteMeta(e.Path) + "$"
+}
+
+// ServeHTTP implements httpendpoint.Provider.ServeHTTP
+func (e *endpoint) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+
+	r := e.check(ctx)
+
+	status := http.StatusOK
+
+	if !r.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if req.Method == http.MethodHead {
+		return ctx
+	}
+
+	if err := json.NewEncoder(w).Encode(r); err != nil {
+		e.Monitor.FrameworkLogger.LogErrorfCtx(ctx, "Unable to write health report: %s", err.Error())
+	}
+
+	return ctx
+}
+
+// ServeWhileSuspended returns true so that a suspended server can report that it is not ready. Implements httpendpoint.SuspensionExemptProvider
+func (e *endpoint) ServeWhileSuspended() bool {
+	return true
+}
+
+// VersionAware implements httpendpoint.Provider.VersionAware
+func (e *endpoint) VersionAware() bool {
+	return false
+}
+
+// SupportsVersion implements httpendpoint.Provider.SupportsVersion
+func (e *endpoint) SupportsVersion(version httpendpoint.RequiredVersion) bool {
+	return true
+}
+
+// AutoWireable implements httpendpoint.Provider.AutoWireable
+func (e *endpoint) AutoWireable() bool {
+	return true
+}
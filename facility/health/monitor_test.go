@@ -0,0 +1,195 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type checkedComponent struct {
+	problem error
+}
+
+func (cc *checkedComponent) CheckHealth(ctx context.Context) error {
+	return cc.problem
+}
+
+type blockingComponent struct {
+	blocked bool
+}
+
+func (bc *blockingComponent) BlockAccess() (bool, error) {
+	return bc.blocked, nil
+}
+
+func newTestMonitor(t *testing.T, components map[string]interface{}) *Monitor {
+
+	fm := logging.CreateComponentLoggerManager(logging.Fatal, nil, []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter())
+	cc := ioc.NewComponentContainer(fm, new(config.Accessor), new(instance.System))
+
+	m := new(Monitor)
+	m.FrameworkLogger = new(logging.ConsoleErrorLogger)
+
+	cc.WrapAndAddProto(MonitorComponentName, m)
+
+	for n, c := range components {
+		cc.WrapAndAddProto(n, c)
+	}
+
+	test.ExpectNil(t, cc.Populate())
+
+	return m
+}
+
+func TestReadiness(t *testing.T) {
+
+	checked := new(checkedComponent)
+	blocking := &blockingComponent{blocked: true}
+
+	m := newTestMonitor(t, map[string]interface{}{"checked": checked, "blocking": blocking})
+
+	r := m.Readiness(context.Background())
+	test.ExpectBool(t, r.Healthy(), false)
+	test.ExpectString(t, r.Problems[applicationProblemKey], "application is not yet accessible")
+
+	test.ExpectNil(t, m.AllowAccess())
+
+	checked.problem = errors.New("server is suspended")
+
+	r = m.Readiness(context.Background())
+	test.ExpectBool(t, r.Healthy(), false)
+	test.ExpectString(t, r.Problems["checked"], "server is suspended")
+	test.ExpectString(t, r.Problems["blocking"], "blocking access")
+
+	checked.problem = nil
+	blocking.blocked = false
+
+	test.ExpectBool(t, m.Readiness(context.Background()).Healthy(), true)
+
+	// Blockers are not asked again once they have stopped blocking access
+	blocking.blocked = true
+	test.ExpectBool(t, m.Readiness(context.Background()).Healthy(), true)
+
+	checked.problem = errors.New("server is suspended")
+
+	m.ExcludeComponents = []string{"checked", "blocking"}
+	test.ExpectBool(t, m.Readiness(context.Background()).Healthy(), true)
+
+	m.PrepareToStop()
+
+	r = m.Readiness(context.Background())
+	test.ExpectString(t, r.Problems[applicationProblemKey], "application is stopping")
+	test.ExpectBool(t, m.Liveness(context.Background()).Healthy(), true)
+}
+
+type slowComponent struct {
+	release chan bool
+}
+
+func (sc *slowComponent) CheckHealth(ctx context.Context) error {
+	<-sc.release
+	return nil
+}
+
+type slowBlocker struct {
+	release chan bool
+}
+
+func (sb *slowBlocker) BlockAccess() (bool, error) {
+	<-sb.release
+	return false, nil
+}
+
+func TestSlowChecksTimeOutIndividually(t *testing.T) {
+
+	slow := &slowComponent{release: make(chan bool)}
+	blocker := &slowBlocker{release: make(chan bool)}
+	checked := new(checkedComponent)
+
+	defer close(slow.release)
+	defer close(blocker.release)
+
+	m := newTestMonitor(t, map[string]interface{}{"slow": slow, "blocker": blocker, "checked": checked})
+	m.CheckTimeoutMS = 20
+
+	test.ExpectNil(t, m.AllowAccess())
+
+	start := time.Now()
+	r := m.Readiness(context.Background())
+
+	// Checks run at the same time, so the probe takes roughly one timeout rather than one per slow component
+	test.ExpectBool(t, time.Since(start) < time.Second, true)
+	test.ExpectInt(t, len(r.Problems), 2)
+	test.ExpectString(t, r.Problems["slow"], "check did not complete within 20ms")
+	test.ExpectString(t, r.Problems["blocker"], "check did not complete within 20ms")
+}
+
+func TestEndpoints(t *testing.T) {
+
+	checked := new(checkedComponent)
+
+	m := newTestMonitor(t, map[string]interface{}{"checked": checked})
+	test.ExpectNil(t, m.AllowAccess())
+
+	ready := &endpoint{Monitor: m, Path: "/health/ready", check: m.Readiness}
+	test.ExpectString(t, ready.RegexPattern(), "^/health/ready$")
+	test.ExpectBool(t, ready.ServeWhileSuspended(), true)
+
+	serve := func() (int, *Report) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+
+		ready.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+		r := new(Report)
+		test.ExpectNil(t, json.Unmarshal(rec.Body.Bytes(), r))
+
+		return rec.Code, r
+	}
+
+	status, r := serve()
+	test.ExpectInt(t, status, http.StatusOK)
+	test.ExpectString(t, r.Status, StatusUp)
+
+	checked.problem = errors.New("unreachable")
+
+	status, r = serve()
+	test.ExpectInt(t, status, http.StatusServiceUnavailable)
+	test.ExpectString(t, r.Status, StatusDown)
+	test.ExpectString(t, r.Problems["checked"], "unreachable")
+}
+
+func TestStateChangedWhileReadinessChecked(t *testing.T) {
+
+	m := newTestMonitor(t, map[string]interface{}{})
+
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			m.Readiness(context.Background())
+		}
+
+		done <- true
+	}()
+
+	test.ExpectNil(t, m.AllowAccess())
+	m.PrepareToStop()
+
+	<-done
+
+	test.ExpectString(t, m.Readiness(context.Background()).Problems[applicationProblemKey], "application is stopping")
+}
@@ -10,8 +10,8 @@ import (
 	"fmt"
 	"github.com/graniticio/granitic/v2/httpendpoint"
 	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
 	"net/http"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -58,6 +58,7 @@ const (
 	puery
 	processTimeMicro
 	processTime
+	timedOut
 )
 
 type logLineTokenType int
@@ -105,7 +106,7 @@ func newPlaceholderWithVarLineElement(phType logFormatPlaceHolder, variable stri
 
 // AccessLogWriter is a component able to asynchronously write an Apache HTTPD style access log. See the top of this GoDoc page for more information.
 type AccessLogWriter struct {
-	logFile *os.File
+	logFile *logging.RotatingFile
 	// The path of the log file to be written to (and created if required)
 	LogPath string
 
@@ -121,6 +122,9 @@ type AccessLogWriter struct {
 	//Whether or not timestamps should be converted to UTC before they are written to the access log.
 	UtcTimes bool
 
+	//Controls when the log file is automatically rotated. If nil, the file is only rotated when Rotate is called.
+	Rotation *logging.RotationPolicy
+
 	elements []*logLineToken
 	lines    chan string
 	state    ioc.ComponentState
@@ -211,7 +215,7 @@ func (alw *AccessLogWriter) openFile() error {
 		return errors.New("HTTP server access log is enabled, but no path to a log file specified")
 	}
 
-	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	f, err := logging.OpenRotatingFile(logPath, alw.Rotation)
 
 	if err != nil {
 		return err
@@ -221,6 +225,17 @@ func (alw *AccessLogWriter) openFile() error {
 	return nil
 }
 
+// Rotate renames the current access log file and starts writing to a new file at LogPath. Lines waiting to be written
+// when the rotation starts are written to the new file. Implements logging.Rotatable
+func (alw *AccessLogWriter) Rotate() error {
+
+	if alw.logFile == nil {
+		return errors.New("the access log file is not open")
+	}
+
+	return alw.logFile.Rotate()
+}
+
 func (alw *AccessLogWriter) configureLogFormat() error {
 
 	f := alw.LogLineFormat
@@ -400,6 +415,8 @@ func (alw *AccessLogWriter) mapPlaceholder(ph string) logFormatPlaceHolder {
 		return userID
 	case "U":
 		return path
+	case "x":
+		return timedOut
 	}
 
 }
@@ -409,6 +426,18 @@ func (alw *AccessLogWriter) findValueWithVar(ctx context.Context, element *logLi
 	case requestHeader:
 		return alw.requestHeader(element.variable, req)
 
+	case bytesReturned, bytesReturnedClf:
+
+		if element.variable != "uncompressed" {
+			return unsupportedPlaceholder
+		}
+
+		if element.placeholderType == bytesReturnedClf && res.UncompressedBytesServed == 0 {
+			return hyphen
+		}
+
+		return strconv.Itoa(res.UncompressedBytesServed)
+
 	case receivedTime:
 		return received.Format(element.variable)
 
@@ -482,6 +511,13 @@ func (alw *AccessLogWriter) findValue(ctx context.Context, element *logLineToken
 	case processTime:
 		return alw.processTime(received, finished, time.Second)
 
+	case timedOut:
+		if res.TimedOut {
+			return "timeout"
+		}
+
+		return hyphen
+
 	default:
 		return unsupportedPlaceholder
 
@@ -45,3 +45,50 @@ func TestAccessLogWriter(t *testing.T) {
 		alw.Stop()
 	}
 }
+
+func TestCompressedSizePlaceholders(t *testing.T) {
+
+	alw := new(AccessLogWriter)
+
+	if err := alw.parseFormat("%b %B %{uncompressed}b %{uncompressed}B"); err != nil {
+		t.Fatal(err)
+	}
+
+	rw := new(httpendpoint.HTTPResponseWriter)
+	rw.BytesServed = 120
+	rw.UncompressedBytesServed = 800
+
+	if l := alw.buildLine(context.Background(), new(http.Request), rw, nil, nil); l != "120 120 800 800\n" {
+		t.Fatalf("Unexpected access log line %q", l)
+	}
+
+	rw = new(httpendpoint.HTTPResponseWriter)
+
+	if l := alw.buildLine(context.Background(), new(http.Request), rw, nil, nil); l != "- 0 - 0\n" {
+		t.Fatalf("Unexpected access log line %q", l)
+	}
+}
+
+func TestTimedOutPlaceholder(t *testing.T) {
+
+	alw := new(AccessLogWriter)
+
+	if err := alw.parseFormat("%s %x"); err != nil {
+		t.Fatal(err)
+	}
+
+	rw := new(httpendpoint.HTTPResponseWriter)
+	rw.Status = 504
+	rw.TimedOut = true
+
+	if l := alw.buildLine(context.Background(), new(http.Request), rw, nil, nil); l != "504 timeout\n" {
+		t.Fatalf("Unexpected access log line %q", l)
+	}
+
+	rw = new(httpendpoint.HTTPResponseWriter)
+	rw.Status = 200
+
+	if l := alw.buildLine(context.Background(), new(http.Request), rw, nil, nil); l != "200 -\n" {
+		t.Fatalf("Unexpected access log line %q", l)
+	}
+}
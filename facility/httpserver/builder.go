@@ -15,6 +15,7 @@ import (
 const HTTPServerComponentName = instance.FrameworkPrefix + "HTTPServer"
 const contextIDDecoratorName = instance.FrameworkPrefix + "RequestIDContextDecorator"
 const instrumentationDecoratorName = instance.FrameworkPrefix + "RequestInstrumentationDecorator"
+const filterDecoratorName = instance.FrameworkPrefix + "RequestFilterDecorator"
 
 // HTTPServerAbnormalStatusFieldName is the field on the HTTPServer component into which a ws.AbnormalStatusWriter can be injected. Most applications will use either
 // the JSONWs or XMLWs facility, in which case a AbnormalStatusWriter that will respond to requests with an abnormal result
@@ -24,8 +25,10 @@ const instrumentationDecoratorName = instance.FrameworkPrefix + "RequestInstrume
 // (see http://granitic.io/ref/component-definition-files)
 const HTTPServerAbnormalStatusFieldName = "AbnormalStatusWriter"
 const accessLogWriterName = instance.FrameworkPrefix + "AccessLogWriter"
+const tlsManagerName = instance.FrameworkPrefix + "TLSManager"
 
-// FacilityBuilder creates the components that make up the HTTPServer facility (the server and an access log writer).
+// FacilityBuilder creates the components that make up the HTTPServer facility (the server, an access log writer and
+// a TLS certificate manager).
 type FacilityBuilder struct {
 }
 
@@ -48,10 +51,31 @@ func (hsfb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager
 		cn.WrapAndAddProto(accessLogWriterName, accessLogWriter)
 	}
 
+	if httpServer.EnableTLS {
+		tlsManager := new(TLSManager)
+		ca.Populate("HTTPServer.TLS", tlsManager)
+
+		httpServer.TLSManager = tlsManager
+
+		cn.WrapAndAddProto(tlsManagerName, tlsManager)
+	}
+
 	idbd := new(contextBuilderDecorator)
 	idbd.Server = httpServer
 	cn.WrapAndAddProto(contextIDDecoratorName, idbd)
 
+	if !httpServer.DisableFilterAutoWire {
+
+		fd := new(filterDecorator)
+		fd.Server = httpServer
+		fd.Log = lm.CreateLogger(filterDecoratorName)
+
+		cn.WrapAndAddProto(filterDecoratorName, fd)
+
+	} else {
+		log.LogDebugf("Auto wiring of request filters disabled")
+	}
+
 	if !httpServer.DisableInstrumentationAutoWire {
 
 		log.LogDebugf("Will attempt to auto-wire an implementation of instrument.RequestInstrumentationManager")
@@ -70,6 +94,17 @@ func (hsfb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager
 
 }
 
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (hsfb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := config.SchemaFromType(new(HTTPServer))
+	s.WithField("AccessLog", config.SchemaFromType(new(AccessLogWriter)))
+	s.WithField("TLS", config.SchemaFromType(new(TLSManager)))
+	s.Field("Port").Require()
+
+	return map[string]*config.Schema{"HTTPServer": s}
+}
+
 // FacilityName implements FacilityBuilder.FacilityName
 func (hsfb *FacilityBuilder) FacilityName() string {
 	return "HTTPServer"
@@ -98,17 +133,18 @@ func (id *instrumentationDecorator) OfInterest(subject *ioc.Component) bool {
 	return result
 }
 
-// DecorateComponent injects the instrument.RequestInstrumentationManager into the HTTP server
+// DecorateComponent injects the instrument.RequestInstrumentationManager into the HTTP server. If more than one
+// instrument.RequestInstrumentationManager is found, they are combined (see instrument.Combine).
 func (id *instrumentationDecorator) DecorateComponent(subject *ioc.Component, cc *ioc.ComponentContainer) {
 
 	im := subject.Instance.(instrument.RequestInstrumentationManager)
 
-	if id.Server.InstrumentationManager != nil {
-		id.Log.LogWarnf("Multiple components implementing instrument.RequestInstrumentationManager found. Using %s", subject.Name)
+	id.Log.LogDebugf("HTTP server using %s for instrumentation", subject.Name)
 
+	if id.Server.InstrumentationManager != nil {
+		id.Server.InstrumentationManager = instrument.Combine(id.Server.InstrumentationManager, im)
+		return
 	}
 
-	id.Log.LogDebugf("HTTP server using %s for instrumentation", subject.Name)
-
 	id.Server.InstrumentationManager = im
 }
@@ -1,6 +1,13 @@
 package httpserver
 
-import "testing"
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"net/http"
+	"testing"
+)
 
 func TestFacilityNaming(t *testing.T) {
 
@@ -11,3 +18,34 @@ func TestFacilityNaming(t *testing.T) {
 	}
 
 }
+
+func TestInstrumentationManagersCombined(t *testing.T) {
+
+	var begun []string
+
+	id := new(instrumentationDecorator)
+	id.Server = new(HTTPServer)
+	id.Log = new(logging.ConsoleErrorLogger)
+
+	// e.g. an application's own manager followed by the Metrics facility's collector
+	id.DecorateComponent(ioc.NewComponent("appInstrumentation", &countingManager{name: "app", begun: &begun}), nil)
+	id.DecorateComponent(ioc.NewComponent("grncMetricsCollector", &countingManager{name: "metrics", begun: &begun}), nil)
+
+	_, _, end := id.Server.InstrumentationManager.Begin(context.Background(), nil, nil)
+	end()
+
+	if len(begun) != 2 || begun[0] != "app" || begun[1] != "metrics" {
+		t.Errorf("Expected both managers to be used, got %v", begun)
+	}
+}
+
+type countingManager struct {
+	name  string
+	begun *[]string
+}
+
+func (cm *countingManager) Begin(ctx context.Context, res http.ResponseWriter, req *http.Request) (context.Context, instrument.Instrumentor, func()) {
+	*cm.begun = append(*cm.begun, cm.name)
+
+	return new(noopRequestInstrumentationManager).Begin(ctx, res, req)
+}
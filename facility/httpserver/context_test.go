@@ -2,8 +2,11 @@ package httpserver
 
 import (
 	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
 	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -37,3 +40,57 @@ func (mi *mockIrb) WithIdentity(ctx context.Context, req *http.Request) (context
 func (mi *mockIrb) ID(ctx context.Context) string {
 	return "ID"
 }
+
+func TestRequestIDAvailableToLogging(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(mockAsw)
+	s.IDContextBuilder = new(keyedIrb)
+	s.SetProvidersManually(map[string]httpendpoint.Provider{})
+
+	var id string
+	var found bool
+
+	s.AddFilter("capture", filterFunc(func(ctx context.Context) {
+		id, found = s.requestIDFromContext(ctx)
+	}))
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.state = ioc.RunningState
+
+	s.handleAll(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !found || id != "REQ1" {
+		t.Errorf("Expected request ID to be found, got %q %v", id, found)
+	}
+
+	// The builder is not consulted for contexts that were not created while handling a request
+	if _, found := s.requestIDFromContext(context.Background()); found {
+		t.Errorf("Did not expect a request ID outside of a request")
+	}
+}
+
+type irbKey struct{}
+
+// keyedIrb panics if asked for the ID of a context it did not populate
+type keyedIrb struct{}
+
+func (ki *keyedIrb) WithIdentity(ctx context.Context, req *http.Request) (context.Context, error) {
+	return context.WithValue(ctx, irbKey{}, "REQ1"), nil
+}
+
+func (ki *keyedIrb) ID(ctx context.Context) string {
+	return ctx.Value(irbKey{}).(string)
+}
+
+type filterFunc func(ctx context.Context)
+
+func (ff filterFunc) Filter(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, next FilterChain) context.Context {
+	ff(ctx)
+
+	return next(ctx, w, req)
+}
@@ -0,0 +1,141 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"net/http"
+	"sort"
+)
+
+// FilterChain is supplied to a RequestFilter and should be called by the filter to pass the request on to the next
+// filter in the chain or, if there are no more filters, to the httpendpoint.Provider that matches the request.
+type FilterChain func(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context
+
+/*
+RequestFilter is implemented by components that need to intercept every request handled by the HTTPServer facility. Any
+component implementing this interface will be automatically found by the HTTPServer (unless DisableFilterAutoWire is set).
+
+Filters are called after the request has been checked against the server's suspension and concurrency limits, and after
+any IdentifiedRequestContextBuilder has been applied, but before the request is matched to an httpendpoint.Provider. This allows
+filters to modify the request (e.g. normalise headers), decorate the response (e.g. add CORS headers) or write a response
+themselves and short-circuit processing by not calling next (e.g. to answer a CORS pre-flight request or reject a request
+that is too large).
+
+Filters that do not short-circuit must call next and return the context.Context it returns.
+*/
+type RequestFilter interface {
+	// Filter examines or modifies the request and either passes it on by calling next or writes a response itself.
+	Filter(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, next FilterChain) context.Context
+}
+
+// OrderedRequestFilter is implemented by filters that need to run at a specific point in the chain. Filters with a lower
+// FilterOrder are called first. Filters not implementing this interface have an order of zero. Filters with the same order
+// are called in alphabetical order of their component name.
+type OrderedRequestFilter interface {
+	// FilterOrder returns this filter's position relative to other filters.
+	FilterOrder() int
+}
+
+type namedFilter struct {
+	name   string
+	order  int
+	filter RequestFilter
+}
+
+func (nf *namedFilter) wrap(next FilterChain) FilterChain {
+	return func(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+		return nf.filter.Filter(ctx, w, req, next)
+	}
+}
+
+// AddFilter registers a RequestFilter with the server. Filters added after the server has started are ignored.
+func (h *HTTPServer) AddFilter(name string, f RequestFilter) {
+
+	nf := new(namedFilter)
+	nf.name = name
+	nf.filter = f
+
+	if of, found := f.(OrderedRequestFilter); found {
+		nf.order = of.FilterOrder()
+	}
+
+	h.filters = append(h.filters, nf)
+}
+
+func (h *HTTPServer) sortFilters() {
+
+	sort.SliceStable(h.filters, func(i, j int) bool {
+		a, b := h.filters[i], h.filters[j]
+
+		if a.order != b.order {
+			return a.order < b.order
+		}
+
+		return a.name < b.name
+	})
+
+	for _, f := range h.filters {
+		h.FrameworkLogger.LogDebugf("Request filter %s (order %d)", f.name, f.order)
+	}
+}
+
+// buildFilterChain wraps the registered filters (which must already be sorted) around dispatch, so that the same chain
+// can be used for every request.
+func (h *HTTPServer) buildFilterChain() {
+
+	chain := FilterChain(h.dispatchFiltered)
+
+	for i := len(h.filters) - 1; i >= 0; i-- {
+		chain = h.filters[i].wrap(chain)
+	}
+
+	h.chain = chain
+}
+
+// dispatchKey is the context key for the per-request values needed once a request has passed through the filter chain
+type dispatchKey struct{}
+
+type dispatchState struct {
+	instrumentor instrument.Instrumentor
+	suspended    bool
+}
+
+// dispatchFiltered is the end of the filter chain, passing the request on to dispatch
+func (h *HTTPServer) dispatchFiltered(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+
+	ds := ctx.Value(dispatchKey{}).(*dispatchState)
+
+	return h.dispatch(ctx, ds.instrumentor, w, req, ds.suspended)
+}
+
+// Adds any component whose instance is an implementation of RequestFilter to the HTTP server
+type filterDecorator struct {
+	Server *HTTPServer
+	Log    logging.Logger
+}
+
+// OfInterest returns true if the supplied component is an instance of RequestFilter
+func (fd *filterDecorator) OfInterest(subject *ioc.Component) bool {
+	result := false
+
+	switch subject.Instance.(type) {
+	case RequestFilter:
+		result = true
+	}
+
+	return result
+}
+
+// DecorateComponent adds the RequestFilter to the HTTP server
+func (fd *filterDecorator) DecorateComponent(subject *ioc.Component, cc *ioc.ComponentContainer) {
+
+	fd.Log.LogDebugf("HTTP server using %s as a request filter", subject.Name)
+
+	fd.Server.AddFilter(subject.Name, subject.Instance.(RequestFilter))
+}
@@ -0,0 +1,161 @@
+package httpserver
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFiltersCalledInOrder(t *testing.T) {
+
+	var calls []string
+
+	p := new(mockProvider)
+	p.pattern = "^/filtered$"
+	p.serve = func(w http.ResponseWriter) {
+		calls = append(calls, "provider")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(mockAsw)
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"filtered": p})
+
+	s.AddFilter("b", &recordingFilter{name: "b", calls: &calls, proceed: true})
+	s.AddFilter("a", &recordingFilter{name: "a", calls: &calls, proceed: true})
+	s.AddFilter("first", &recordingFilter{name: "first", calls: &calls, proceed: true, order: -10})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.state = ioc.RunningState
+
+	s.handleAll(httptest.NewRecorder(), httptest.NewRequest("GET", "/filtered", nil))
+
+	if c := strings.Join(calls, ","); c != "first,a,b,provider" {
+		t.Fatalf("Unexpected call order %s", c)
+	}
+}
+
+func TestFilterShortCircuit(t *testing.T) {
+
+	var calls []string
+
+	p := new(mockProvider)
+	p.pattern = "^/filtered$"
+	p.serve = func(w http.ResponseWriter) {
+		calls = append(calls, "provider")
+	}
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(mockAsw)
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"filtered": p})
+
+	s.AddFilter("blocker", &recordingFilter{name: "blocker", calls: &calls, proceed: false})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.state = ioc.RunningState
+
+	rec := httptest.NewRecorder()
+
+	s.handleAll(rec, httptest.NewRequest("GET", "/filtered", nil))
+
+	if c := strings.Join(calls, ","); c != "blocker" {
+		t.Fatalf("Unexpected calls %s", c)
+	}
+
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("Expected status from filter, got %d", rec.Code)
+	}
+}
+
+func TestFilterAddedAfterStartIgnored(t *testing.T) {
+
+	var calls []string
+
+	p := new(mockProvider)
+	p.pattern = "^/filtered$"
+	p.serve = func(w http.ResponseWriter) {
+		calls = append(calls, "provider")
+	}
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(mockAsw)
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"filtered": p})
+
+	s.AddFilter("early", &recordingFilter{name: "early", calls: &calls, proceed: true})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.state = ioc.RunningState
+
+	s.AddFilter("late", &recordingFilter{name: "late", calls: &calls, proceed: true})
+
+	s.handleAll(httptest.NewRecorder(), httptest.NewRequest("GET", "/filtered", nil))
+	s.handleAll(httptest.NewRecorder(), httptest.NewRequest("GET", "/filtered", nil))
+
+	if c := strings.Join(calls, ","); c != "early,provider,early,provider" {
+		t.Fatalf("Unexpected calls %s", c)
+	}
+}
+
+func TestFilterDecorator(t *testing.T) {
+
+	fd := new(filterDecorator)
+	fd.Server = new(HTTPServer)
+	fd.Log = new(logging.ConsoleErrorLogger)
+
+	c := ioc.NewComponent("myFilter", new(recordingFilter))
+
+	if !fd.OfInterest(c) {
+		t.Fatalf("Expected filter to be of interest")
+	}
+
+	if fd.OfInterest(ioc.NewComponent("notFilter", new(mockAsw))) {
+		t.Fatalf("Did not expect non-filter to be of interest")
+	}
+
+	fd.DecorateComponent(c, nil)
+
+	if len(fd.Server.filters) != 1 || fd.Server.filters[0].name != "myFilter" {
+		t.Fatalf("Filter not added to server")
+	}
+}
+
+type recordingFilter struct {
+	name    string
+	calls   *[]string
+	proceed bool
+	order   int
+}
+
+func (rf *recordingFilter) Filter(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, next FilterChain) context.Context {
+
+	*rf.calls = append(*rf.calls, rf.name)
+
+	if rf.proceed {
+		return next(ctx, w, req)
+	}
+
+	w.WriteHeader(http.StatusTeapot)
+
+	return ctx
+}
+
+func (rf *recordingFilter) FilterOrder() int {
+	return rf.order
+}
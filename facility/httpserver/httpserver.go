@@ -17,6 +17,7 @@ component definition files. See handler.WsHandler for more details.
 package httpserver
 
 import (
+	"compress/flate"
 	"context"
 	"errors"
 	"fmt"
@@ -28,6 +29,7 @@ import (
 	"net"
 	"net/http"
 	"regexp"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -40,6 +42,7 @@ type registeredProvider struct {
 // HTTPServer is the server that accepts incoming HTTP requests and maps them to handlers to process them.
 type HTTPServer struct {
 	registeredProvidersByMethod map[string][]*registeredProvider
+	templateRouter              *templateRouter
 	unregisteredProviders       map[string]httpendpoint.Provider
 	componentContainer          *ioc.ComponentContainer
 
@@ -52,6 +55,12 @@ type HTTPServer struct {
 	// Whether or not access logging should be enabled.
 	AccessLogging bool
 
+	// Whether or not the server should accept HTTPS (TLS) connections instead of plain HTTP.
+	EnableTLS bool
+
+	// A component able to supply certificates for TLS connections. Automatically added by this facility's builder if TLS is enabled.
+	TLSManager *TLSManager
+
 	// Whether or not instances of httpendpoint.Provider found in the IoC container should be automatically
 	// registered with this server
 	AutoFindHandlers bool
@@ -94,8 +103,70 @@ type HTTPServer struct {
 	// A component able to use data in an HTTP request's headers to populate a context
 	IDContextBuilder IdentifiedRequestContextBuilder
 
+	// Prevents this server from finding and using RequestFilter components
+	DisableFilterAutoWire bool
+
+	// The maximum number of milliseconds allowed to read an entire request, including the body. Zero or less means no limit.
+	ReadTimeoutMS int64
+
+	// The maximum number of milliseconds allowed to read a request's headers. Zero or less means ReadTimeoutMS is used.
+	ReadHeaderTimeoutMS int64
+
+	// The maximum number of milliseconds allowed to write a response, measured from the end of reading the request's headers.
+	// Zero or less means no limit. Note that a non-zero value will end long-lived responses (e.g. streamed responses or
+	// server-sent events) once the timeout is reached.
+	WriteTimeoutMS int64
+
+	// The maximum number of milliseconds to wait for the next request on a keep-alive connection. Zero or less means ReadTimeoutMS is used.
+	IdleTimeoutMS int64
+
+	// The maximum size in bytes of a request's headers. Zero or less means Go's default (1MB).
+	MaxHeaderBytes int
+
+	// The maximum size in bytes of a request body. Requests with a larger Content-Length receive a 413 response without
+	// their bodies being read and reading stops if a body without a Content-Length exceeds the limit. Zero or less means
+	// no limit. Individual handlers may set lower limits (see handler.WsHandler.MaxBodyBytes).
+	MaxRequestBodyBytes int64
+
+	// The default maximum number of milliseconds a request may take to process. The deadline is set on the context passed
+	// to the Provider that handles the request (Providers implementing httpendpoint.DeadlineProvider may override the
+	// default). Zero or less means no deadline. Requests that exceed their deadline are shown as 'timeout' by the %x
+	// access log placeholder ('-' otherwise).
+	RequestTimeoutMS int64
+
+	// Whether or not responses should be compressed if the caller indicates (with an Accept-Encoding header) that it
+	// will accept compressed responses.
+	EnableCompression bool
+
+	// The rules used to decide whether or not a response is compressed. When a response is compressed, the %b and %B
+	// access log placeholders show the compressed size and %{uncompressed}b and %{uncompressed}B show the size before compression.
+	Compression *httpendpoint.ResponseCompression
+
+	// The maximum number of milliseconds the server will wait for in-flight requests to complete once the
+	// server has been asked to stop. Zero or less means the server will wait until the IoC container forces it to stop.
+	DrainTimeoutMS int64
+
+	filters []*namedFilter
+	chain   FilterChain
+
+	longLived []httpendpoint.LongLivedProvider
+
+	exemptFromSuspension bool
+
 	state  ioc.ComponentState
 	server *http.Server
+
+	drainMutex sync.Mutex
+	drained    chan struct{}
+}
+
+func msToDuration(ms int64) time.Duration {
+
+	if ms <= 0 {
+		return 0
+	}
+
+	return time.Duration(ms) * time.Millisecond
 }
 
 // Container allows Granitic to inject a reference to the IOC container
@@ -103,7 +174,19 @@ func (h *HTTPServer) Container(container *ioc.ComponentContainer) {
 	h.componentContainer = container
 }
 
-func (h *HTTPServer) registerProvider(endPointProvider httpendpoint.Provider) {
+func (h *HTTPServer) registerProvider(name string, endPointProvider httpendpoint.Provider) error {
+
+	if ll, found := endPointProvider.(httpendpoint.LongLivedProvider); found {
+		h.longLived = append(h.longLived, ll)
+	}
+
+	if servedWhileSuspended(endPointProvider) {
+		h.exemptFromSuspension = true
+	}
+
+	if tp, found := endPointProvider.(httpendpoint.TemplatedProvider); found && tp.TemplatePattern() != "" {
+		return h.registerTemplatedProvider(name, tp.TemplatePattern(), endPointProvider)
+	}
 
 	for _, method := range endPointProvider.SupportedHTTPMethods() {
 		var compiledRegex *regexp.Regexp
@@ -130,6 +213,27 @@ func (h *HTTPServer) registerProvider(endPointProvider httpendpoint.Provider) {
 		}
 	}
 
+	return nil
+}
+
+func (h *HTTPServer) registerTemplatedProvider(name string, template string, endPointProvider httpendpoint.Provider) error {
+
+	pt, err := httpendpoint.ParsePathTemplate(template)
+
+	if err != nil {
+		return fmt.Errorf("unable to register %s: %s", name, err.Error())
+	}
+
+	for _, method := range endPointProvider.SupportedHTTPMethods() {
+
+		h.FrameworkLogger.LogTracef("Registering template %s %s", template, method)
+
+		if err := h.templateRouter.add(method, name, pt, endPointProvider); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // StartComponent Finds and registers any available components that implement httpendpoint.Provider (normally instances of
@@ -143,6 +247,9 @@ func (h *HTTPServer) StartComponent() error {
 
 	h.state = ioc.StartingState
 	h.registeredProvidersByMethod = make(map[string][]*registeredProvider)
+	h.templateRouter = newTemplateRouter()
+	h.longLived = nil
+	h.exemptFromSuspension = false
 
 	if h.AutoFindHandlers {
 		for _, component := range h.componentContainer.AllComponents() {
@@ -151,14 +258,19 @@ func (h *HTTPServer) StartComponent() error {
 
 			if provider, found := component.Instance.(httpendpoint.Provider); found && provider.AutoWireable() {
 				h.FrameworkLogger.LogDebugf("Found Provider %s", name)
-				h.registerProvider(provider)
+
+				if err := h.registerProvider(name, provider); err != nil {
+					return err
+				}
 			}
 		}
 	} else if h.unregisteredProviders != nil {
 
-		for _, provider := range h.unregisteredProviders {
+		for name, provider := range h.unregisteredProviders {
 
-			h.registerProvider(provider)
+			if err := h.registerProvider(name, provider); err != nil {
+				return err
+			}
 
 		}
 
@@ -171,6 +283,35 @@ func (h *HTTPServer) StartComponent() error {
 		return errors.New("no AbnormalStatusWriter set - make sure you have enabled a web services facility")
 	}
 
+	if h.IDContextBuilder != nil {
+		// Make request IDs available to log line prefixes and JSON log entries
+		logging.RegisterContextValue(logging.RequestIDContextValue, h.requestIDFromContext)
+	}
+
+	h.sortFilters()
+	h.buildFilterChain()
+
+	if h.EnableTLS && h.TLSManager == nil {
+		return errors.New("TLS is enabled, but no TLSManager has been set")
+	}
+
+	if h.EnableCompression {
+
+		if h.Compression == nil {
+			return errors.New("compression is enabled, but no compression rules have been set")
+		}
+
+		for _, e := range h.Compression.Encodings {
+			if e != httpendpoint.GzipEncoding && e != httpendpoint.DeflateEncoding {
+				return fmt.Errorf("%s is not a supported compression encoding (must be %s or %s)", e, httpendpoint.GzipEncoding, httpendpoint.DeflateEncoding)
+			}
+		}
+
+		if l := h.Compression.Level; l < flate.HuffmanOnly || l > flate.BestCompression {
+			return fmt.Errorf("%d is not a valid compression level (must be between %d and %d)", l, flate.HuffmanOnly, flate.BestCompression)
+		}
+	}
+
 	if h.InstrumentationManager == nil {
 		//No RequestInstrumentationManager component injected, use a 'noop' implementation
 		h.FrameworkLogger.LogDebugf("No RequestInstrumentationManager set. Using noop implementation")
@@ -182,7 +323,8 @@ func (h *HTTPServer) StartComponent() error {
 	return nil
 }
 
-// Suspend causes all subsequent new HTTP requests to receive a 'too busy' response until Resume is called.
+// Suspend causes all subsequent new HTTP requests to receive a 'too busy' response until Resume is called. Requests
+// to Providers implementing httpendpoint.SuspensionExemptProvider (such as health checks) are still processed.
 func (h *HTTPServer) Suspend() error {
 
 	if h.state != ioc.RunningState {
@@ -206,7 +348,24 @@ func (h *HTTPServer) Resume() error {
 	return nil
 }
 
-// AllowAccess starts the server listening on the configured address and port. Returns an error if the port is already in use.
+// CheckHealth returns an error if the server is not currently accepting requests (because it is starting, suspended
+// or stopping). Implements ioc.HealthChecker
+func (h *HTTPServer) CheckHealth(ctx context.Context) error {
+
+	switch h.state {
+	case ioc.RunningState:
+		return nil
+	case ioc.SuspendingState, ioc.SuspendedState:
+		return errors.New("server is suspended")
+	case ioc.StoppingState, ioc.StoppedState:
+		return errors.New("server is stopping")
+	default:
+		return errors.New("server is not yet accepting requests")
+	}
+}
+
+// AllowAccess starts the server listening on the configured address and port (using TLS if EnableTLS is true). Returns an
+// error if the port is already in use.
 func (h *HTTPServer) AllowAccess() error {
 
 	if h.state != ioc.AwaitingAccessState {
@@ -218,29 +377,58 @@ func (h *HTTPServer) AllowAccess() error {
 
 	sv := new(http.Server)
 	sv.Handler = sm
+	sv.ReadTimeout = msToDuration(h.ReadTimeoutMS)
+	sv.ReadHeaderTimeout = msToDuration(h.ReadHeaderTimeoutMS)
+	sv.WriteTimeout = msToDuration(h.WriteTimeoutMS)
+	sv.IdleTimeout = msToDuration(h.IdleTimeoutMS)
+
+	if h.MaxHeaderBytes > 0 {
+		sv.MaxHeaderBytes = h.MaxHeaderBytes
+	}
 
 	listenAddress := fmt.Sprintf("%s:%d", h.Address, h.Port)
 
-	//Check if the address is already in use
-	if ln, err := net.Listen("tcp", listenAddress); err == nil {
-		ln.Close()
-	} else {
+	ln, err := net.Listen("tcp", listenAddress)
+
+	if err != nil {
 		return err
 	}
 
 	sv.Addr = listenAddress
 
-	go sv.ListenAndServe()
+	if h.EnableTLS {
+		sv.TLSConfig = h.TLSManager.ServerConfig()
+
+		go sv.ServeTLS(ln, "", "")
+	} else {
+		go sv.Serve(ln)
+	}
 
 	h.server = sv
 
-	h.FrameworkLogger.LogInfof("Listening on %d", h.Port)
+	if h.EnableTLS {
+		h.FrameworkLogger.LogInfof("Listening on %d (TLS)", h.Port)
+	} else {
+		h.FrameworkLogger.LogInfof("Listening on %d", h.Port)
+	}
 
 	h.state = ioc.RunningState
 
 	return nil
 }
 
+// requestIDKey is the key under which the server stores the ID of a request in the request's context
+type requestIDKey struct{}
+
+// requestIDFromContext finds the ID the IDContextBuilder assigned to the request in the supplied context. Contexts that
+// were not created while handling a request are treated as not having an ID.
+func (h *HTTPServer) requestIDFromContext(ctx context.Context) (string, bool) {
+
+	id, found := ctx.Value(requestIDKey{}).(string)
+
+	return id, found && id != ""
+}
+
 // SetProvidersManually manually injects a set of httpendpoint.HTTPEndpointProviders when auto finding is disabled.
 func (h *HTTPServer) SetProvidersManually(p map[string]httpendpoint.Provider) {
 	h.unregisteredProviders = p
@@ -266,14 +454,22 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 	ctx, cancelFunc := context.WithCancel(req.Context())
 	defer cancelFunc()
 
+	wrw := httpendpoint.NewHTTPResponseWriter(res)
+
 	if h.AllowEarlyInstrumentation {
-		ctx, instrumentor, endInstrumentation = h.InstrumentationManager.Begin(ctx, res, req)
+		ctx, instrumentor, endInstrumentation = h.InstrumentationManager.Begin(ctx, wrw, req)
 		defer endInstrumentation()
 	}
 
-	wrw := httpendpoint.NewHTTPResponseWriter(res)
+	if h.EnableCompression {
+		wrw.EnableCompression(h.Compression, req.Header.Get("Accept-Encoding"))
+		defer wrw.Close()
+	}
 
-	if h.state != ioc.RunningState {
+	// Some providers (e.g. health checks) continue to handle requests while the server is suspended
+	suspended := h.state == ioc.SuspendedState && h.exemptFromSuspension
+
+	if h.state != ioc.RunningState && !suspended {
 		// The HTTP server is suspended - reject the request
 		h.writeAbnormal(ctx, h.TooBusyStatus, wrw)
 		return
@@ -288,8 +484,19 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if h.MaxRequestBodyBytes > 0 {
+
+		if req.ContentLength > h.MaxRequestBodyBytes {
+			// No point reading a body that is declared to be too large
+			h.writeAbnormal(ctx, http.StatusRequestEntityTooLarge, wrw)
+			return
+		}
+
+		req.Body = http.MaxBytesReader(res, req.Body, h.MaxRequestBodyBytes)
+	}
+
 	if instrumentor == nil {
-		ctx, instrumentor, endInstrumentation = h.InstrumentationManager.Begin(ctx, res, req)
+		ctx, instrumentor, endInstrumentation = h.InstrumentationManager.Begin(ctx, wrw, req)
 		defer endInstrumentation()
 	}
 
@@ -301,6 +508,7 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 		if idCtx, err := h.IDContextBuilder.WithIdentity(ctx, req); err == nil {
 			ctx = idCtx.(context.Context)
 			requestID = h.IDContextBuilder.ID(idCtx)
+			ctx = context.WithValue(ctx, requestIDKey{}, requestID)
 
 			instrumentor.Amend(instrument.RequestID, requestID)
 
@@ -318,6 +526,34 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	ctx = context.WithValue(ctx, dispatchKey{}, &dispatchState{instrumentor: instrumentor, suspended: suspended})
+	ctx = h.chain(ctx, wrw, req)
+
+	if err := wrw.Close(); err != nil {
+		h.FrameworkLogger.LogErrorfCtx(ctx, "Problem completing a compressed response: %s", err.Error())
+	}
+
+	if h.AccessLogging {
+		finished := time.Now()
+		h.AccessLogWriter.LogRequest(ctx, req, wrw, &received, &finished)
+	}
+
+}
+
+// dispatch finds the Provider(s) that match the request and passes the request to them. Providers registered with a
+// path template are checked first, then Providers registered with a regular expression. If no Provider matches, a
+// 'not found' response is written. If the server is suspended, only Providers that are served while suspended are used.
+func (h *HTTPServer) dispatch(ctx context.Context, instrumentor instrument.Instrumentor, wrw *httpendpoint.HTTPResponseWriter, req *http.Request, suspended bool) context.Context {
+
+	for _, route := range h.templateRouter.match(req.Method, req.URL.Path) {
+
+		if h.versionMatch(instrumentor, req, route.provider) {
+			h.FrameworkLogger.LogTracef("Matches template %s", route.template)
+
+			return h.serve(ctx, route.provider, wrw, req, suspended)
+		}
+	}
+
 	matched := false
 
 	providersByMethod := h.registeredProvidersByMethod[req.Method]
@@ -335,11 +571,13 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 		if pattern.MatchString(path) && h.versionMatch(instrumentor, req, handlerPattern.Provider) {
 			h.FrameworkLogger.LogTracef("Matches %s", pattern.String())
 			matched = true
-			ctx = handlerPattern.Provider.ServeHTTP(ctx, wrw, req)
+			ctx = h.serve(ctx, handlerPattern.Provider, wrw, req, suspended)
 		}
 	}
 
-	if !matched {
+	if !matched && suspended {
+		h.writeAbnormal(ctx, h.TooBusyStatus, wrw)
+	} else if !matched {
 		state := ws.NewAbnormalState(http.StatusNotFound, wrw)
 
 		if err := h.AbnormalStatusWriter.WriteAbnormalStatus(ctx, state); err != nil {
@@ -347,11 +585,57 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	if h.AccessLogging {
-		finished := time.Now()
-		h.AccessLogWriter.LogRequest(ctx, req, wrw, &received, &finished)
+	return ctx
+}
+
+// serve passes the request to the supplied Provider with a context that has the request's deadline (if any) set.
+func (h *HTTPServer) serve(ctx context.Context, p httpendpoint.Provider, wrw *httpendpoint.HTTPResponseWriter, req *http.Request, suspended bool) context.Context {
+
+	if suspended && !servedWhileSuspended(p) {
+		h.writeAbnormal(ctx, h.TooBusyStatus, wrw)
+		return ctx
+	}
+
+	timeout := h.requestTimeout(p)
+
+	if timeout <= 0 {
+		return p.ServeHTTP(ctx, wrw, req)
 	}
 
+	dctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rctx := p.ServeHTTP(dctx, wrw, req)
+
+	if dctx.Err() == context.DeadlineExceeded {
+		wrw.TimedOut = true
+	}
+
+	return rctx
+}
+
+func servedWhileSuspended(p httpendpoint.Provider) bool {
+	sp, found := p.(httpendpoint.SuspensionExemptProvider)
+
+	return found && sp.ServeWhileSuspended()
+}
+
+// requestTimeout returns the deadline that should be applied to requests handled by the supplied Provider.
+func (h *HTTPServer) requestTimeout(p httpendpoint.Provider) time.Duration {
+
+	if dp, found := p.(httpendpoint.DeadlineProvider); found {
+
+		if t := dp.RequestTimeout(); t != 0 {
+			return t
+		}
+	}
+
+	if _, found := p.(httpendpoint.LongLivedProvider); found {
+		// Connections held open indefinitely are not subject to the server's default deadline
+		return 0
+	}
+
+	return msToDuration(h.RequestTimeoutMS)
 }
 
 func (h *HTTPServer) versionMatch(ri instrument.Instrumentor, r *http.Request, p httpendpoint.Provider) bool {
@@ -368,22 +652,77 @@ func (h *HTTPServer) versionMatch(ri instrument.Instrumentor, r *http.Request, p
 
 }
 
-// PrepareToStop sets state to Stopping. Any subsequent requests will receive a 'too busy response'
+// PrepareToStop sets state to Stopping and starts draining the server. The server immediately stops accepting new
+// connections, idle keep-alive connections are closed and any requests that are already being processed are allowed
+// to complete (within DrainTimeoutMS). Any subsequent requests on existing connections will receive a 'too busy' response.
+// Providers implementing httpendpoint.LongLivedProvider are asked to close their open connections.
 func (h *HTTPServer) PrepareToStop() {
 	h.state = ioc.StoppingState
 
-	if h.server != nil {
-		h.server.Shutdown(context.Background())
+	for _, ll := range h.longLived {
+		ll.CloseConnections()
+	}
+
+	if h.server == nil {
+		return
 	}
 
+	h.drainMutex.Lock()
+	defer h.drainMutex.Unlock()
+
+	if h.drained != nil {
+		return
+	}
+
+	h.drained = make(chan struct{})
+
+	go h.drain(h.server, h.drained)
 }
 
-// ReadyToStop returns false is the server is currently handling any requests.
+func (h *HTTPServer) drain(sv *http.Server, done chan struct{}) {
+
+	ctx := context.Background()
+
+	if h.DrainTimeoutMS > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(h.DrainTimeoutMS)*time.Millisecond)
+		defer cancel()
+	}
+
+	err := sv.Shutdown(ctx)
+
+	if err != nil {
+		h.FrameworkLogger.LogWarnf("HTTP server listening on %d did not finish draining within %dms (%d request(s) still active)", h.Port, h.DrainTimeoutMS, atomic.LoadInt64(&h.ActiveRequests))
+	} else {
+		h.FrameworkLogger.LogDebugf("HTTP server listening on %d has finished draining", h.Port)
+	}
+
+	close(done)
+}
+
+// ReadyToStop returns false if the server is still draining connections or is currently handling any requests. If the
+// server was unable to drain within DrainTimeoutMS, ReadyToStop returns true so that the remaining connections can
+// be forcibly closed by Stop.
 func (h *HTTPServer) ReadyToStop() (bool, error) {
-	a := h.ActiveRequests
-	ready := a <= 0
 
-	if ready {
+	a := atomic.LoadInt64(&h.ActiveRequests)
+
+	h.drainMutex.Lock()
+	drained := h.drained
+	h.drainMutex.Unlock()
+
+	if drained != nil {
+
+		select {
+		case <-drained:
+			return true, nil
+		default:
+			return false, fmt.Errorf("HTTP server listening on %d is draining and still serving %d request(s)", h.Port, a)
+		}
+	}
+
+	if a <= 0 {
 		return true, nil
 	}
 
@@ -391,8 +730,8 @@ func (h *HTTPServer) ReadyToStop() (bool, error) {
 
 }
 
-// Stop sets state to Stopped. Any subsequent requests will receive a 'too busy response'. Note that the HTTP
-// server is still listening on its configured port and address.
+// Stop sets state to Stopped and closes any connections that are still open, including those with requests still
+// being processed. Any subsequent requests will receive a 'too busy response'.
 func (h *HTTPServer) Stop() error {
 
 	h.state = ioc.StoppedState
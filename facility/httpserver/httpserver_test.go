@@ -2,10 +2,18 @@ package httpserver
 
 import (
 	"context"
+	"fmt"
 	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/ws"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestServerStart(t *testing.T) {
@@ -37,3 +45,377 @@ type mockAsw struct {
 func (a *mockAsw) WriteAbnormalStatus(ctx context.Context, state *ws.ProcessState) error {
 	return nil
 }
+
+func TestServerDrainsInFlightRequests(t *testing.T) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Unable to find a free port: %s", err.Error())
+	}
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	release := make(chan bool)
+	started := make(chan bool)
+
+	p := new(mockProvider)
+	p.pattern = "^/slow$"
+	p.serve = func(w http.ResponseWriter) {
+		started <- true
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.Address = "127.0.0.1"
+	s.Port = port
+	s.DrainTimeoutMS = 5000
+	s.AbnormalStatusWriter = new(mockAsw)
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"slow": p})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.AllowAccess(); err != nil {
+		t.Fatal(err)
+	}
+
+	status := make(chan int)
+
+	go func() {
+		r, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/slow", port))
+
+		if err != nil {
+			status <- 0
+			return
+		}
+
+		r.Body.Close()
+		status <- r.StatusCode
+	}()
+
+	<-started
+
+	s.PrepareToStop()
+
+	if ready, _ := s.ReadyToStop(); ready {
+		t.Fatalf("Server reported ready to stop while a request was still being processed")
+	}
+
+	if _, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port)); err == nil {
+		t.Fatalf("Server still accepting connections after PrepareToStop")
+	}
+
+	close(release)
+
+	if sc := <-status; sc != http.StatusOK {
+		t.Fatalf("Expected in-flight request to complete with 200, got %d", sc)
+	}
+
+	for i := 0; i < 50; i++ {
+		if ready, _ := s.ReadyToStop(); ready {
+			s.Stop()
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Server did not become ready to stop after in-flight request completed")
+}
+
+type mockProvider struct {
+	pattern  string
+	identify func(req *http.Request)
+	serve    func(w http.ResponseWriter)
+}
+
+func (mp *mockProvider) SupportedHTTPMethods() []string {
+	return []string{"GET"}
+}
+
+func (mp *mockProvider) RegexPattern() string {
+	return mp.pattern
+}
+
+func (mp *mockProvider) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+	if mp.identify != nil {
+		mp.identify(req)
+	}
+
+	mp.serve(w)
+	return ctx
+}
+
+func (mp *mockProvider) VersionAware() bool {
+	return false
+}
+
+func (mp *mockProvider) SupportsVersion(version httpendpoint.RequiredVersion) bool {
+	return true
+}
+
+func (mp *mockProvider) AutoWireable() bool {
+	return false
+}
+
+func TestLongLivedProvidersClosedOnStop(t *testing.T) {
+
+	p := new(longLivedProvider)
+	p.pattern = "^/events$"
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(mockAsw)
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"events": p})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.PrepareToStop()
+
+	if !p.closed {
+		t.Fatalf("Long-lived provider's connections were not closed")
+	}
+}
+
+type longLivedProvider struct {
+	mockProvider
+	closed bool
+}
+
+func (lp *longLivedProvider) CloseConnections() {
+	lp.closed = true
+}
+
+func TestInvalidCompressionLevel(t *testing.T) {
+
+	for level, valid := range map[int]bool{-3: false, -2: true, 0: true, 9: true, 10: false} {
+
+		s := new(HTTPServer)
+		s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+		s.AbnormalStatusWriter = new(mockAsw)
+		s.SetProvidersManually(map[string]httpendpoint.Provider{})
+
+		s.EnableCompression = true
+		s.Compression = &httpendpoint.ResponseCompression{Encodings: []string{httpendpoint.GzipEncoding}, Level: level}
+
+		if err := s.StartComponent(); (err == nil) != valid {
+			t.Errorf("Unexpected outcome starting server with compression level %d: %v", level, err)
+		}
+	}
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+
+	var read []byte
+	var readErr error
+
+	p := new(mockProvider)
+	p.pattern = "^/upload$"
+	p.serve = func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusOK)
+	}
+	p.identify = func(req *http.Request) {
+		read, readErr = ioutil.ReadAll(req.Body)
+	}
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(statusAsw)
+	s.MaxRequestBodyBytes = 8
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"upload": p})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.state = ioc.RunningState
+
+	// Declared length exceeds limit
+	rec := httptest.NewRecorder()
+	s.handleAll(rec, httptest.NewRequest(http.MethodGet, "/upload", strings.NewReader("0123456789")))
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413, got %d", rec.Code)
+	}
+
+	if read != nil {
+		t.Fatalf("Provider was called for a request that exceeded the limit")
+	}
+
+	// Undeclared length exceeds limit
+	req := httptest.NewRequest(http.MethodGet, "/upload", strings.NewReader("0123456789"))
+	req.ContentLength = -1
+
+	s.handleAll(httptest.NewRecorder(), req)
+
+	if readErr == nil || len(read) != 8 {
+		t.Fatalf("Expected reading to stop after 8 bytes with an error, read %d bytes", len(read))
+	}
+}
+
+func TestServerTimeoutsApplied(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.Address = "127.0.0.1"
+	s.ReadHeaderTimeoutMS = 1500
+	s.IdleTimeoutMS = 60000
+	s.WriteTimeoutMS = -1
+	s.MaxHeaderBytes = 4096
+	s.AbnormalStatusWriter = new(mockAsw)
+	s.SetProvidersManually(map[string]httpendpoint.Provider{})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.AllowAccess(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.server.Close()
+
+	sv := s.server
+
+	if sv.ReadHeaderTimeout != 1500*time.Millisecond || sv.IdleTimeout != time.Minute {
+		t.Fatalf("Timeouts not applied to server")
+	}
+
+	if sv.ReadTimeout != 0 || sv.WriteTimeout != 0 || sv.MaxHeaderBytes != 4096 {
+		t.Fatalf("Unexpected server limits")
+	}
+}
+
+type statusAsw struct {
+}
+
+func (a *statusAsw) WriteAbnormalStatus(ctx context.Context, state *ws.ProcessState) error {
+	state.HTTPResponseWriter.WriteHeader(state.Status)
+	return nil
+}
+
+func TestRequestDeadlines(t *testing.T) {
+
+	var deadline time.Time
+	var hasDeadline bool
+
+	d := &deadlineProvider{mockProvider: &mockProvider{pattern: "^/slow$"}}
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.RequestTimeoutMS = 20
+
+	wrw := httpendpoint.NewHTTPResponseWriter(httptest.NewRecorder())
+
+	d.check = func(ctx context.Context) {
+		deadline, hasDeadline = ctx.Deadline()
+		<-ctx.Done()
+	}
+
+	// Server default
+	start := time.Now()
+	s.serve(context.Background(), d, wrw, httptest.NewRequest(http.MethodGet, "/slow", nil), false)
+
+	if !hasDeadline || deadline.Sub(start) > time.Second || !wrw.TimedOut {
+		t.Fatalf("Server default deadline not applied")
+	}
+
+	// Provider override disables deadline
+	d.timeout = -1
+	d.check = func(ctx context.Context) {
+		_, hasDeadline = ctx.Deadline()
+	}
+
+	wrw = httpendpoint.NewHTTPResponseWriter(httptest.NewRecorder())
+	s.serve(context.Background(), d, wrw, httptest.NewRequest(http.MethodGet, "/slow", nil), false)
+
+	if hasDeadline || wrw.TimedOut {
+		t.Fatalf("Provider override not applied")
+	}
+}
+
+type deadlineProvider struct {
+	*mockProvider
+	timeout time.Duration
+	check   func(ctx context.Context)
+}
+
+func (dp *deadlineProvider) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+	dp.check(ctx)
+	return ctx
+}
+
+func (dp *deadlineProvider) RequestTimeout() time.Duration {
+	return dp.timeout
+}
+
+func TestSuspendedServerHealth(t *testing.T) {
+
+	served := false
+
+	p := new(mockProvider)
+	p.pattern = "^/data$"
+	p.serve = func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	hp := &exemptProvider{mockProvider: &mockProvider{pattern: "^/health$"}}
+	hp.serve = func(w http.ResponseWriter) {
+		served = true
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(statusAsw)
+	s.TooBusyStatus = http.StatusServiceUnavailable
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"data": p, "health": hp})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.CheckHealth(context.Background()) == nil {
+		t.Fatalf("Server reported healthy before accepting requests")
+	}
+
+	s.state = ioc.RunningState
+
+	if err := s.CheckHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Suspend()
+
+	if s.CheckHealth(context.Background()) == nil {
+		t.Fatalf("Suspended server reported healthy")
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleAll(rec, httptest.NewRequest(http.MethodGet, "/data", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected suspended server to reject request with 503, got %d", rec.Code)
+	}
+
+	s.handleAll(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if !served {
+		t.Fatalf("Exempt provider not served while server suspended")
+	}
+}
+
+type exemptProvider struct {
+	*mockProvider
+}
+
+func (ep *exemptProvider) ServeWhileSuspended() bool {
+	return true
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+)
+
+// The order in which parameter nodes are tried when more than one type of parameter is declared at the same position.
+var paramTypePriority = []string{httpendpoint.IntParam, httpendpoint.FloatParam, httpendpoint.StringParam}
+
+type templatedRoute struct {
+	name     string
+	template string
+	provider httpendpoint.Provider
+}
+
+// routeNode is a node in a tree of path segments. Static segments are stored in a map, parameters are stored by type.
+type routeNode struct {
+	static map[string]*routeNode
+	params map[string]*routeNode
+	routes []*templatedRoute
+}
+
+func newRouteNode() *routeNode {
+	n := new(routeNode)
+	n.static = make(map[string]*routeNode)
+	n.params = make(map[string]*routeNode)
+
+	return n
+}
+
+// templateRouter matches request paths to Providers registered with a path template using a tree per HTTP method. Static
+// segments are always preferred to parameters and typed parameters are preferred to string parameters. Matching
+// cost depends on the depth of the path, not the number of registered Providers.
+type templateRouter struct {
+	roots map[string]*routeNode
+}
+
+func newTemplateRouter() *templateRouter {
+	tr := new(templateRouter)
+	tr.roots = make(map[string]*routeNode)
+
+	return tr
+}
+
+// add registers a Provider against a template for the supplied method. An error is returned if an equivalent template has
+// already been registered for the method, unless both Providers are version aware (in which case the version
+// of the request will be used to choose between them).
+func (tr *templateRouter) add(method, name string, pt *httpendpoint.PathTemplate, p httpendpoint.Provider) error {
+
+	n := tr.roots[method]
+
+	if n == nil {
+		n = newRouteNode()
+		tr.roots[method] = n
+	}
+
+	for _, s := range pt.Segments {
+
+		var children map[string]*routeNode
+		var key string
+
+		if s.IsParam() {
+			children, key = n.params, s.Type
+		} else {
+			children, key = n.static, s.Static
+		}
+
+		child := children[key]
+
+		if child == nil {
+			child = newRouteNode()
+			children[key] = child
+		}
+
+		n = child
+	}
+
+	for _, existing := range n.routes {
+		if !existing.provider.VersionAware() || !p.VersionAware() {
+			return fmt.Errorf("conflicting routes: %s %s (%s) and %s %s (%s) match the same requests", method, existing.template, existing.name, method, pt.Template, name)
+		}
+	}
+
+	n.routes = append(n.routes, &templatedRoute{name: name, template: pt.Template, provider: p})
+
+	return nil
+}
+
+// match returns the routes registered against the template that best matches the supplied path, or nil if no template matches.
+func (tr *templateRouter) match(method, path string) []*templatedRoute {
+
+	n := tr.roots[method]
+
+	if n == nil {
+		return nil
+	}
+
+	return n.match(httpendpoint.SplitPath(path))
+}
+
+func (n *routeNode) match(segments []string) []*templatedRoute {
+
+	if len(segments) == 0 {
+		return n.routes
+	}
+
+	seg := segments[0]
+	remaining := segments[1:]
+
+	if child := n.static[seg]; child != nil {
+		if r := child.match(remaining); len(r) > 0 {
+			return r
+		}
+	}
+
+	for _, t := range paramTypePriority {
+
+		child := n.params[t]
+
+		if child == nil || !(httpendpoint.TemplateSegment{Param: t, Type: t}).Matches(seg) {
+			continue
+		}
+
+		if r := child.match(remaining); len(r) > 0 {
+			return r
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package httpserver
+
+import (
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"testing"
+)
+
+func addRoute(t *testing.T, tr *templateRouter, name, template string) error {
+
+	pt, err := httpendpoint.ParsePathTemplate(template)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tr.add("GET", name, pt, new(mockProvider))
+}
+
+func TestTemplateRouterMatching(t *testing.T) {
+
+	tr := newTemplateRouter()
+
+	addRoute(t, tr, "byID", "/record/{id:int}")
+	addRoute(t, tr, "bySlug", "/record/{slug}")
+	addRoute(t, tr, "latest", "/record/latest")
+	addRoute(t, tr, "tracks", "/record/{id:int}/tracks")
+
+	expected := map[string]string{
+		"/record/12":        "byID",
+		"/record/12/":       "byID",
+		"/record/blue":      "bySlug",
+		"/record/latest":    "latest",
+		"/record/12/tracks": "tracks",
+	}
+
+	for path, name := range expected {
+		r := tr.match("GET", path)
+
+		if len(r) != 1 || r[0].name != name {
+			t.Errorf("Expected %s to match %s", path, name)
+		}
+	}
+
+	if r := tr.match("GET", "/record/blue/tracks"); r != nil {
+		t.Errorf("Unexpected match for non-integer ID")
+	}
+
+	if r := tr.match("POST", "/record/12"); r != nil {
+		t.Errorf("Unexpected match for unregistered method")
+	}
+}
+
+func TestTemplateRouterConflicts(t *testing.T) {
+
+	tr := newTemplateRouter()
+
+	if err := addRoute(t, tr, "a", "/record/{id:int}"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := addRoute(t, tr, "b", "/record/{recordID:int}"); err == nil {
+		t.Fatalf("Expected conflicting routes to be detected")
+	}
+
+	if err := addRoute(t, tr, "c", "/record/{id:float}"); err != nil {
+		t.Fatalf("Routes with different parameter types should not conflict: %s", err.Error())
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported values for TLSManager.ClientAuth
+const (
+	// ClientAuthNone means client certificates are not requested (standard HTTPS).
+	ClientAuthNone = "NONE"
+	// ClientAuthRequest means a client certificate is requested but not required or verified.
+	ClientAuthRequest = "REQUEST"
+	// ClientAuthVerifyIfGiven means a client certificate is not required, but if one is presented it must be valid.
+	ClientAuthVerifyIfGiven = "VERIFY_IF_GIVEN"
+	// ClientAuthRequire means a valid client certificate must be presented (mutual TLS).
+	ClientAuthRequire = "REQUIRE"
+)
+
+var clientAuthModes = map[string]tls.ClientAuthType{
+	ClientAuthNone:          tls.NoClientCert,
+	ClientAuthRequest:       tls.RequestClientCert,
+	ClientAuthVerifyIfGiven: tls.VerifyClientCertIfGiven,
+	ClientAuthRequire:       tls.RequireAndVerifyClientCert,
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+/*
+TLSManager loads the certificate, private key and (optionally) client CA bundle used by an HTTPServer when TLS is
+enabled and makes them available to the server as a tls.Config.
+
+If ReloadIntervalMS is greater than zero, the modification times of the configured files are checked at that interval
+and the certificates are reloaded if any of the files have changed. New connections will use the reloaded certificates;
+connections that have already been established are unaffected. If a reload fails (for example because the certificate
+and key files are being replaced and are temporarily mismatched) the previously loaded certificates remain in use and
+the reload is retried at the next interval.
+*/
+type TLSManager struct {
+	// Logger used by Granitic framework components. Automatically injected.
+	FrameworkLogger logging.Logger
+
+	// Path to a PEM encoded certificate (or certificate chain) for the server.
+	CertFile string
+
+	// Path to the PEM encoded private key associated with CertFile.
+	KeyFile string
+
+	// Path to a PEM encoded bundle of CA certificates used to verify client certificates. Required if ClientAuth
+	// is VERIFY_IF_GIVEN or REQUIRE.
+	ClientCAFile string
+
+	// Whether client certificates should be requested and verified. One of NONE, REQUEST, VERIFY_IF_GIVEN or REQUIRE.
+	ClientAuth string
+
+	// The minimum version of TLS the server will accept (1.0, 1.1, 1.2 or 1.3).
+	MinVersion string
+
+	// How often (in milliseconds) the certificate, key and CA files are checked for changes. Zero or less disables reloading.
+	ReloadIntervalMS int64
+
+	clientAuth tls.ClientAuthType
+	minVersion uint16
+
+	mutex    sync.RWMutex
+	current  *tls.Config
+	modTimes map[string]time.Time
+
+	stop  chan bool
+	state ioc.ComponentState
+}
+
+// ServerConfig returns a tls.Config suitable for use with an http.Server. The returned config always uses the most
+// recently loaded certificates.
+func (tm *TLSManager) ServerConfig() *tls.Config {
+
+	return &tls.Config{
+		MinVersion:         tm.minVersion,
+		NextProtos:         []string{"h2", "http/1.1"},
+		GetConfigForClient: tm.configForClient,
+	}
+}
+
+func (tm *TLSManager) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+
+	return tm.current, nil
+}
+
+// StartComponent validates the configuration of this component, loads the certificates and key and, if configured,
+// starts checking the files for changes.
+func (tm *TLSManager) StartComponent() error {
+
+	if tm.state != ioc.StoppedState {
+		return nil
+	}
+
+	tm.state = ioc.StartingState
+
+	if err := tm.validateSettings(); err != nil {
+		return err
+	}
+
+	if err := tm.load(); err != nil {
+		return err
+	}
+
+	if tm.ReloadIntervalMS > 0 {
+		tm.stop = make(chan bool)
+		go tm.watch(time.Duration(tm.ReloadIntervalMS) * time.Millisecond)
+	}
+
+	tm.state = ioc.RunningState
+
+	return nil
+}
+
+func (tm *TLSManager) validateSettings() error {
+
+	if strings.TrimSpace(tm.CertFile) == "" || strings.TrimSpace(tm.KeyFile) == "" {
+		return errors.New("TLS is enabled for the HTTP server, but CertFile and/or KeyFile have not been set")
+	}
+
+	mode := strings.ToUpper(tm.ClientAuth)
+
+	if mode == "" {
+		mode = ClientAuthNone
+	}
+
+	ca, found := clientAuthModes[mode]
+
+	if !found {
+		return fmt.Errorf("%s is not a supported ClientAuth mode. Supported modes are %s, %s, %s and %s", tm.ClientAuth,
+			ClientAuthNone, ClientAuthRequest, ClientAuthVerifyIfGiven, ClientAuthRequire)
+	}
+
+	if (ca == tls.VerifyClientCertIfGiven || ca == tls.RequireAndVerifyClientCert) && strings.TrimSpace(tm.ClientCAFile) == "" {
+		return fmt.Errorf("ClientAuth mode %s requires a ClientCAFile to verify client certificates against", mode)
+	}
+
+	tm.clientAuth = ca
+
+	if tm.MinVersion == "" {
+		tm.minVersion = tls.VersionTLS12
+	} else if v, found := tlsVersions[tm.MinVersion]; found {
+		tm.minVersion = v
+	} else {
+		return fmt.Errorf("%s is not a supported TLS MinVersion. Supported versions are 1.0, 1.1, 1.2 and 1.3", tm.MinVersion)
+	}
+
+	return nil
+}
+
+func (tm *TLSManager) files() []string {
+	f := []string{tm.CertFile, tm.KeyFile}
+
+	if tm.ClientCAFile != "" {
+		f = append(f, tm.ClientCAFile)
+	}
+
+	return f
+}
+
+// load reads the certificate, key and CA files and replaces the config returned to new connections.
+func (tm *TLSManager) load() error {
+
+	modTimes := make(map[string]time.Time)
+
+	for _, f := range tm.files() {
+		fi, err := os.Stat(f)
+
+		if err != nil {
+			return fmt.Errorf("unable to access TLS file %s: %s", f, err.Error())
+		}
+
+		modTimes[f] = fi.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(tm.CertFile, tm.KeyFile)
+
+	if err != nil {
+		return fmt.Errorf("unable to load TLS certificate %s and key %s: %s", tm.CertFile, tm.KeyFile, err.Error())
+	}
+
+	c := new(tls.Config)
+	c.MinVersion = tm.minVersion
+	c.NextProtos = []string{"h2", "http/1.1"}
+	c.Certificates = []tls.Certificate{cert}
+	c.ClientAuth = tm.clientAuth
+
+	if tm.ClientCAFile != "" {
+
+		pem, err := ioutil.ReadFile(tm.ClientCAFile)
+
+		if err != nil {
+			return fmt.Errorf("unable to read client CA file %s: %s", tm.ClientCAFile, err.Error())
+		}
+
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM encoded certificates could be found in client CA file %s", tm.ClientCAFile)
+		}
+
+		c.ClientCAs = pool
+	}
+
+	tm.mutex.Lock()
+	tm.current = c
+	tm.modTimes = modTimes
+	tm.mutex.Unlock()
+
+	return nil
+}
+
+// changed returns true if any of the files have a different modification time to when they were last loaded.
+func (tm *TLSManager) changed() bool {
+
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+
+	for _, f := range tm.files() {
+
+		if fi, err := os.Stat(f); err == nil && !fi.ModTime().Equal(tm.modTimes[f]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (tm *TLSManager) watch(interval time.Duration) {
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-tm.stop:
+			return
+		case <-t.C:
+
+			if !tm.changed() {
+				continue
+			}
+
+			if err := tm.load(); err != nil {
+				tm.FrameworkLogger.LogErrorf("Unable to reload TLS certificates (previous certificates still in use): %s", err.Error())
+			} else {
+				tm.FrameworkLogger.LogInfof("Reloaded TLS certificates from %s", tm.CertFile)
+			}
+		}
+	}
+}
+
+// PrepareToStop stops checking the certificate files for changes.
+func (tm *TLSManager) PrepareToStop() {
+
+	if tm.stop != nil && tm.state == ioc.RunningState {
+		close(tm.stop)
+	}
+
+	tm.state = ioc.StoppingState
+}
+
+// ReadyToStop always returns true
+func (tm *TLSManager) ReadyToStop() (bool, error) {
+	return true, nil
+}
+
+// Stop sets the component's state to Stopped
+func (tm *TLSManager) Stop() error {
+	tm.state = ioc.StoppedState
+
+	return nil
+}
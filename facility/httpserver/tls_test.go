@@ -0,0 +1,248 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, cn string, serial int64, parent *testCert, isCA bool) *testCert {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := tmpl, key
+
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := x509.ParseCertificate(der)
+
+	return &testCert{cert: c, key: key, der: der}
+}
+
+func (tc *testCert) write(t *testing.T, dir, name string) (string, string) {
+
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+
+	kb, _ := x509.MarshalECPrivateKey(tc.key)
+
+	if err := ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tc.der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kb}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certPath, keyPath
+}
+
+func TestMutualTLS(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "grnc-tls")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "test-ca", 1, nil, true)
+	server := newTestCert(t, "server", 2, ca, false)
+	client := newTestCert(t, "client-service", 3, ca, false)
+
+	caPath, _ := ca.write(t, dir, "ca")
+	certPath, keyPath := server.write(t, dir, "server")
+
+	tm := new(TLSManager)
+	tm.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	tm.CertFile = certPath
+	tm.KeyFile = keyPath
+	tm.ClientCAFile = caPath
+	tm.ClientAuth = ClientAuthRequire
+
+	if err := tm.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	identities := make(chan string, 1)
+
+	p := new(mockProvider)
+	p.pattern = "^/id$"
+	p.identify = func(req *http.Request) {
+		i, _ := new(ws.ClientCertificateIdentifier).IDentify(context.Background(), req)
+		identities <- i.LoggableUserID()
+	}
+	p.serve = func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.Address = "127.0.0.1"
+	s.Port = port
+	s.EnableTLS = true
+	s.TLSManager = tm
+	s.AbnormalStatusWriter = new(mockAsw)
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"id": p})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.AllowAccess(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	clientPair := tls.Certificate{Certificate: [][]byte{client.der}, PrivateKey: client.key}
+
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{clientPair}}}}
+
+	url := fmt.Sprintf("https://127.0.0.1:%d/id", port)
+
+	r, err := c.Get(url)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Body.Close()
+
+	if id := <-identities; id != "client-service" {
+		t.Fatalf("Expected identity from client certificate, got %s", id)
+	}
+
+	// Without a client certificate, the handshake should fail
+	c = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+
+	if r, err = c.Get(url); err == nil {
+		r.Body.Close()
+		t.Fatalf("Expected request without a client certificate to be rejected")
+	}
+
+}
+
+func TestTLSCertificateReload(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "grnc-tls")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	first := newTestCert(t, "first", 1, nil, false)
+	certPath, keyPath := first.write(t, dir, "server")
+
+	tm := new(TLSManager)
+	tm.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	tm.CertFile = certPath
+	tm.KeyFile = keyPath
+
+	if err := tm.StartComponent(); err != nil {
+		t.Fatal(err)
+	}
+
+	if tm.changed() {
+		t.Fatalf("Files reported as changed immediately after loading")
+	}
+
+	second := newTestCert(t, "second", 2, nil, false)
+	second.write(t, dir, "server")
+
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certPath, later, later)
+	os.Chtimes(keyPath, later, later)
+
+	if !tm.changed() {
+		t.Fatalf("Expected files to be reported as changed")
+	}
+
+	if err := tm.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _ := tm.ServerConfig().GetConfigForClient(nil)
+	leaf, _ := x509.ParseCertificate(cfg.Certificates[0].Certificate[0])
+
+	if leaf.Subject.CommonName != "second" {
+		t.Fatalf("Expected reloaded certificate, got %s", leaf.Subject.CommonName)
+	}
+
+	tm.PrepareToStop()
+	tm.Stop()
+}
+
+func TestTLSManagerRequiresCAForVerification(t *testing.T) {
+
+	tm := new(TLSManager)
+	tm.CertFile = "server.crt"
+	tm.KeyFile = "server.key"
+	tm.ClientAuth = ClientAuthRequire
+
+	if err := tm.StartComponent(); err == nil {
+		t.Fatalf("Expected an error when client verification is required without a CA file")
+	}
+}
@@ -7,14 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/graniticio/granitic/v2/config"
-	"github.com/graniticio/granitic/v2/facility/httpserver"
 	"github.com/graniticio/granitic/v2/facility/logger"
-	"github.com/graniticio/granitic/v2/facility/querymanager"
-	"github.com/graniticio/granitic/v2/facility/rdbms"
-	"github.com/graniticio/granitic/v2/facility/runtimectl"
-	"github.com/graniticio/granitic/v2/facility/serviceerror"
-	"github.com/graniticio/granitic/v2/facility/taskscheduler"
-	"github.com/graniticio/granitic/v2/facility/ws"
 	"github.com/graniticio/granitic/v2/instance"
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
@@ -104,8 +97,9 @@ func (fi *FacilitiesInitialisor) buildEnabledFacilities() error {
 
 }
 
-// Initialise creates a Builder for each of the built-in Granitic facilities and then
-// builds those facilities that have been enabled by the user.
+// Initialise creates a Builder for each of the built-in Granitic facilities, checks the merged configuration against
+// the settings declared by the enabled facilities and components (see CheckConfig) and then builds those facilities that
+// have been enabled by the user.
 func (fi *FacilitiesInitialisor) Initialise(ca *config.Accessor) error {
 	fi.ConfigAccessor = ca
 
@@ -118,18 +112,13 @@ func (fi *FacilitiesInitialisor) Initialise(ca *config.Accessor) error {
 	fi.facilityStatus = fc
 	fi.updateFrameworkLogLevel()
 
-	if fc["ApplicationLogging"].(bool) {
-		fi.addFacility(new(logger.FacilityBuilder))
+	for _, fb := range Builders() {
+		fi.addFacility(fb)
 	}
 
-	fi.addFacility(new(querymanager.FacilityBuilder))
-	fi.addFacility(new(httpserver.FacilityBuilder))
-	fi.addFacility(new(ws.JSONFacilityBuilder))
-	fi.addFacility(new(ws.XMLFacilityBuilder))
-	fi.addFacility(new(serviceerror.FacilityBuilder))
-	fi.addFacility(new(rdbms.FacilityBuilder))
-	fi.addFacility(new(runtimectl.FacilityBuilder))
-	fi.addFacility(new(taskscheduler.FacilityBuilder))
+	if err := fi.checkConfig(); err != nil {
+		return err
+	}
 
 	err = fi.buildEnabledFacilities()
 
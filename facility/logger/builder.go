@@ -65,12 +65,47 @@ func (alfb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager
 
 	cn.WrapAndAddProto(applicationLoggingDecoratorName, ald)
 
-	alfb.addRuntimeCommands(ca, alm, lm, cn)
+	lr, err := alfb.buildRotator(ca, writers)
+
+	if err != nil {
+		return alfb.error(err.Error())
+	}
+
+	cn.WrapAndAddProto(LogRotatorComponentName, lr)
+
+	rl := new(levelReloader)
+	rl.ApplicationManager = alm
+	rl.FrameworkManager = lm
+
+	cn.WrapAndAddProto(LevelReloaderComponentName, rl)
+
+	alfb.addRuntimeCommands(ca, alm, lm, lr, cn)
 
 	return nil
 }
 
-func (alfb *FacilityBuilder) addRuntimeCommands(ca *config.Accessor, alm *logging.ComponentLoggerManager, flm *logging.ComponentLoggerManager, cn *ioc.ComponentContainer) {
+// buildRotator creates a component that can rotate the log file (if file logging is enabled) and any other
+// components that implement logging.Rotatable
+func (alfb *FacilityBuilder) buildRotator(ca *config.Accessor, writers []logging.LogWriter) (*logRotator, error) {
+
+	lr := newLogRotator()
+
+	for _, w := range writers {
+		if r, found := w.(logging.Rotatable); found {
+			lr.add(applicationLoggingManagerName, r)
+		}
+	}
+
+	var err error
+
+	if ca.PathExists("LogWriting.RotateOnSIGHUP") {
+		lr.RotateOnSIGHUP, err = ca.BoolVal("LogWriting.RotateOnSIGHUP")
+	}
+
+	return lr, err
+}
+
+func (alfb *FacilityBuilder) addRuntimeCommands(ca *config.Accessor, alm *logging.ComponentLoggerManager, flm *logging.ComponentLoggerManager, lr *logRotator, cn *ioc.ComponentContainer) {
 
 	if !runtimectl.Enabled(ca) {
 		return
@@ -88,6 +123,17 @@ func (alfb *FacilityBuilder) addRuntimeCommands(ca *config.Accessor, alm *loggin
 
 	cn.WrapAndAddProto(LogLevelComponentName, llc)
 
+	lsl := new(listLevelsCommand)
+	lsl.ApplicationManager = alm
+	lsl.FrameworkManager = flm
+
+	cn.WrapAndAddProto(ListLevelsComponentName, lsl)
+
+	rlc := new(rotateLogsCommand)
+	rlc.Rotator = lr
+
+	cn.WrapAndAddProto(RotateLogsComponentName, rlc)
+
 }
 
 func (alfb *FacilityBuilder) buildFormatter(ca *config.Accessor) (*logging.LogMessageFormatter, error) {
@@ -131,6 +177,38 @@ func (alfb *FacilityBuilder) buildWriters(ca *config.Accessor) ([]logging.LogWri
 		writers = append(writers, fileWriter)
 	}
 
+	if syslog, err := ca.BoolVal("LogWriting.EnableSyslogLogging"); err != nil {
+		return nil, err
+	} else if syslog {
+		syslogWriter := new(logging.SyslogWriter)
+
+		if err = ca.Populate("LogWriting.Syslog", syslogWriter); err != nil {
+			return nil, err
+		}
+
+		if err = syslogWriter.Init(); err != nil {
+			return nil, err
+		}
+
+		writers = append(writers, syslogWriter)
+	}
+
+	if tcp, err := ca.BoolVal("LogWriting.EnableTCPLogging"); err != nil {
+		return nil, err
+	} else if tcp {
+		tcpWriter := new(logging.TCPLineWriter)
+
+		if err = ca.Populate("LogWriting.TCP", tcpWriter); err != nil {
+			return nil, err
+		}
+
+		if err = tcpWriter.Init(); err != nil {
+			return nil, err
+		}
+
+		writers = append(writers, tcpWriter)
+	}
+
 	return writers, nil
 }
 
@@ -140,6 +218,36 @@ func (alfb *FacilityBuilder) error(suffix string) error {
 
 }
 
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (alfb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	lw := config.NewSchema(config.ObjectValue)
+
+	for _, f := range []string{"EnableConsoleLogging", "EnableFileLogging", "EnableSyslogLogging", "EnableTCPLogging", "RotateOnSIGHUP"} {
+		lw.WithField(f, config.NewSchema(config.BoolValue))
+	}
+
+	lw.WithField("File", config.SchemaFromType(logging.AsynchFileWriter{}))
+	lw.WithField("Syslog", config.SchemaFromType(logging.SyslogWriter{}))
+	lw.WithField("TCP", config.SchemaFromType(logging.TCPLineWriter{}))
+	lw.WithField("Format", config.SchemaFromType(logging.LogMessageFormatter{}))
+
+	return map[string]*config.Schema{
+		"LogWriting":        lw,
+		"ApplicationLogger": LevelsSchema(),
+	}
+}
+
+// LevelsSchema returns a Schema for the log level settings in the ApplicationLogger and FrameworkLogger sections of configuration.
+func LevelsSchema() *config.Schema {
+
+	s := config.NewSchema(config.ObjectValue)
+	s.WithField("GlobalLogLevel", config.NewSchema(config.StringValue).Require())
+	s.WithField("ComponentLogLevels", config.NewMapSchema(config.NewSchema(config.StringValue)))
+
+	return s
+}
+
 // FacilityName implements FacilityBuilder.FacilityName
 func (alfb *FacilityBuilder) FacilityName() string {
 	return "ApplicationLogging"
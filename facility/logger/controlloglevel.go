@@ -11,6 +11,8 @@ import (
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/ws"
 	"sort"
+	"strings"
+	"time"
 )
 
 const (
@@ -18,12 +20,18 @@ const (
 	LogLevelComponentName = instance.FrameworkPrefix + "CommandLogLevel"
 	llCommandName         = "log-level"
 	llSummary             = "Views or sets a specific logging threshold for application or framework components."
-	llUsage               = "log-level [component level] [-fw true]"
+	llUsage               = "log-level [component|pattern level] [-ttl duration] [-fw true]"
 	llHelp                = "With no qualifier, this command shows a list of application components that have a specific logging threshold set. When a " +
 		"component and a level are specified as qualifiers, the component's logging threshold is set at the specified level."
 	llHelpTwo   = "Valid values for level are ALL, TRACE, DEBUG, INFO, WARN, ERROR, FATAL (case insensitive)."
 	llHelpThree = "Setting the level to ALL has special behaviour - it efffectively removes the specific logging threshold for the component. The global log threshold will then apply to that component."
 	llHelpFour  = "If the '-fw true' argument is supplied without qualifiers, a list of built-in framework components and their associated log levels will be shown."
+	llHelpFive  = "Instead of a component name, a pattern containing * wildcards can be supplied (e.g. 'grnc*' or '*Handler') to set the threshold of every " +
+		"matching application and framework component, including components whose loggers are created later."
+	llHelpSix = "If the '-ttl' argument is supplied with a duration (e.g. '-ttl 10m'), the change expires after that duration and each affected " +
+		"component returns to the threshold it had before."
+
+	ttlArg = "ttl"
 )
 
 type logLevelCommand struct {
@@ -38,10 +46,10 @@ func (c *logLevelCommand) ExecuteCommand(qualifiers []string, args map[string]st
 		return c.showCurrentLevel(args)
 	}
 
-	return c.setLevel(qualifiers)
+	return c.setLevel(qualifiers, args)
 }
 
-func (c *logLevelCommand) setLevel(qualifiers []string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+func (c *logLevelCommand) setLevel(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
 
 	var err error
 	var ll logging.LogLevel
@@ -57,23 +65,90 @@ func (c *logLevelCommand) setLevel(qualifiers []string) (*ctl.CommandOutput, []*
 		return nil, []*ws.CategorisedError{ctl.NewCommandClientError(err.Error())}
 	}
 
-	logger := c.ApplicationManager.LoggerByName(name)
+	var ttl time.Duration
+
+	if ttl, err = ttlFromArgs(args); err != nil {
+		return nil, []*ws.CategorisedError{ctl.NewCommandClientError(err.Error())}
+	}
+
+	if strings.Contains(name, "*") {
+		return c.setPatternLevel(name, ll, ttl)
+	}
+
+	manager := c.ApplicationManager
 
-	if logger == nil {
-		logger = c.FrameworkManager.LoggerByName(name)
+	if manager.LoggerByName(name) == nil {
+		manager = c.FrameworkManager
 	}
 
-	if logger == nil {
+	if manager.LoggerByName(name) == nil {
 		m := fmt.Sprintf("Component %s does not exist or does not have a logger attached.", name)
 		return nil, []*ws.CategorisedError{ctl.NewCommandClientError(m)}
 	}
 
-	logger.SetLocalThreshold(ll)
+	if ttl > 0 {
+
+		if _, err = manager.SetPatternThreshold(name, ll, ttl); err != nil {
+			return nil, []*ws.CategorisedError{ctl.NewCommandUnexpectedError(err.Error())}
+		}
+
+		return new(ctl.CommandOutput), nil
+	}
+
+	if ll == logging.All {
+		// Overrides would otherwise stop the component picking up changes to its configured level
+		manager.ClearOverrides(name)
+	}
+
+	manager.LoggerByName(name).SetLocalThreshold(ll)
 
 	return new(ctl.CommandOutput), nil
 
 }
 
+// setPatternLevel applies the level to all application and framework components matching the pattern
+func (c *logLevelCommand) setPatternLevel(pattern string, ll logging.LogLevel, ttl time.Duration) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	matched := 0
+
+	for _, manager := range []*logging.ComponentLoggerManager{c.ApplicationManager, c.FrameworkManager} {
+
+		names, err := manager.SetPatternThreshold(pattern, ll, ttl)
+
+		if err != nil {
+			return nil, []*ws.CategorisedError{ctl.NewCommandUnexpectedError(err.Error())}
+		}
+
+		matched += len(names)
+	}
+
+	co := new(ctl.CommandOutput)
+	co.OutputHeader = fmt.Sprintf("Logging threshold set to %s for %d component(s) matching %s", logging.LabelFromLevel(ll), matched, pattern)
+
+	if ttl > 0 {
+		co.OutputHeader += fmt.Sprintf(" for %s", ttl)
+	}
+
+	return co, nil
+}
+
+func ttlFromArgs(args map[string]string) (time.Duration, error) {
+
+	v := args[ttlArg]
+
+	if v == "" {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(v)
+
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("value of %s argument must be a positive duration (e.g. 30s, 10m, 1h)", ttlArg)
+	}
+
+	return ttl, nil
+}
+
 func (c *logLevelCommand) showCurrentLevel(args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
 
 	var comps []*logging.ComponentLevel
@@ -122,5 +197,5 @@ func (c *logLevelCommand) Usage() string {
 }
 
 func (c *logLevelCommand) Help() []string {
-	return []string{llHelp, llHelpTwo, llHelpThree, llHelpFour}
+	return []string{llHelp, llHelpTwo, llHelpThree, llHelpFour, llHelpFive, llHelpSix}
 }
@@ -2,6 +2,8 @@ package logger
 
 import (
 	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"strings"
 	"testing"
 )
 
@@ -29,3 +31,70 @@ func TestControlLogLevelCommand(t *testing.T) {
 	lld.ExecuteCommand([]string{"myComp", "TRACE"}, map[string]string{})
 
 }
+
+func TestPatternAndTTL(t *testing.T) {
+
+	fm := logging.CreateComponentLoggerManager(logging.Info, map[string]interface{}{}, []logging.LogWriter{}, nil)
+	am := logging.CreateComponentLoggerManager(logging.Info, map[string]interface{}{}, []logging.LogWriter{}, nil)
+
+	fw := fm.CreateLogger("grncHTTPServer")
+	app := am.CreateLogger("orderHandler")
+
+	lld := new(logLevelCommand)
+	lld.FrameworkManager = fm
+	lld.ApplicationManager = am
+
+	co, errs := lld.ExecuteCommand([]string{"*r", "TRACE"}, map[string]string{"ttl": "1h"})
+
+	test.ExpectInt(t, len(errs), 0)
+	test.ExpectString(t, co.OutputHeader, "Logging threshold set to TRACE for 2 component(s) matching *r for 1h0m0s")
+	test.ExpectBool(t, fw.IsLevelEnabled(logging.Trace), true)
+	test.ExpectBool(t, app.IsLevelEnabled(logging.Trace), true)
+
+	_, errs = lld.ExecuteCommand([]string{"orderHandler", "DEBUG"}, map[string]string{"ttl": "soon"})
+	test.ExpectInt(t, len(errs), 1)
+
+	_, errs = lld.ExecuteCommand([]string{"orderHandler", "ERROR"}, map[string]string{"ttl": "1h"})
+	test.ExpectInt(t, len(errs), 0)
+	test.ExpectBool(t, app.IsLevelEnabled(logging.Warn), false)
+
+	lsl := new(listLevelsCommand)
+	lsl.FrameworkManager = fm
+	lsl.ApplicationManager = am
+
+	co, _ = lsl.ExecuteCommand([]string{}, map[string]string{})
+	test.ExpectBool(t, strings.HasPrefix(co.OutputBody[0][1], "ERROR (RUNTIME orderHandler until "), true)
+
+	co, _ = lsl.ExecuteCommand([]string{}, map[string]string{"fw": "true"})
+	test.ExpectBool(t, strings.HasPrefix(co.OutputBody[0][1], "TRACE (RUNTIME *r until "), true)
+}
+
+func TestAllRestoresConfiguredLevels(t *testing.T) {
+
+	fm := logging.CreateComponentLoggerManager(logging.Info, map[string]interface{}{}, []logging.LogWriter{}, nil)
+	am := logging.CreateComponentLoggerManager(logging.Info, map[string]interface{}{"orderHandler": "WARN"}, []logging.LogWriter{}, nil)
+
+	app := am.CreateLogger("orderHandler")
+
+	lld := new(logLevelCommand)
+	lld.FrameworkManager = fm
+	lld.ApplicationManager = am
+
+	_, errs := lld.ExecuteCommand([]string{"orderHandler", "TRACE"}, map[string]string{})
+	test.ExpectInt(t, len(errs), 0)
+	test.ExpectBool(t, app.IsLevelEnabled(logging.Trace), true)
+
+	_, errs = lld.ExecuteCommand([]string{"order*", "DEBUG"}, map[string]string{"ttl": "1h"})
+	test.ExpectInt(t, len(errs), 0)
+
+	_, errs = lld.ExecuteCommand([]string{"orderHandler", "ALL"}, map[string]string{})
+	test.ExpectInt(t, len(errs), 0)
+
+	// The global level applies until configuration changes
+	test.ExpectBool(t, app.IsLevelEnabled(logging.Debug), false)
+	test.ExpectBool(t, app.IsLevelEnabled(logging.Info), true)
+
+	test.ExpectNil(t, am.UpdateConfiguredLevels(map[string]interface{}{"orderHandler": "ERROR"}))
+	test.ExpectBool(t, app.IsLevelEnabled(logging.Warn), false)
+	test.ExpectBool(t, app.IsLevelEnabled(logging.Error), true)
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logger
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/facility/runtimectl"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+	"strings"
+)
+
+const (
+	// ListLevelsComponentName is the name of the component able to list the effective log level of every component
+	ListLevelsComponentName = instance.FrameworkPrefix + "CommandListLevels"
+	lslCommandName          = "list-levels"
+	lslSummary              = "Shows the effective logging threshold of every application or framework component and where it was set."
+	lslUsage                = "list-levels [-fw true]"
+	lslHelp                 = "Lists every application component with a logger, the logging threshold it is currently using and whether that threshold " +
+		"comes from configuration (CONFIG), a change made at runtime (RUNTIME) or the global threshold (GLOBAL). Runtime changes made " +
+		"with a pattern show the pattern and, if the change expires, when it expires."
+	lslHelpTwo = "If the '-fw true' argument is supplied, built-in framework components are listed instead."
+)
+
+type listLevelsCommand struct {
+	FrameworkLogger    logging.Logger
+	FrameworkManager   *logging.ComponentLoggerManager
+	ApplicationManager *logging.ComponentLoggerManager
+}
+
+func (c *listLevelsCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	var framework bool
+	var err error
+
+	if framework, err = runtimectl.OperateOnFramework(args); err != nil {
+		return nil, []*ws.CategorisedError{ctl.NewCommandClientError(err.Error())}
+	}
+
+	manager := c.ApplicationManager
+
+	if framework {
+		manager = c.FrameworkManager
+	}
+
+	rows := make([][]string, 0)
+
+	for _, el := range manager.EffectiveLevels() {
+		rows = append(rows, []string{el.Name, describeLevel(el)})
+	}
+
+	co := new(ctl.CommandOutput)
+	co.OutputBody = rows
+	co.RenderHint = ctl.Columns
+
+	return co, nil
+}
+
+// describeLevel summarises the level and its source e.g. TRACE (RUNTIME *Handler until 15:04:05)
+func describeLevel(el *logging.EffectiveLevel) string {
+
+	var details []string
+
+	details = append(details, string(el.Source))
+
+	if el.Pattern != "" {
+		details = append(details, el.Pattern)
+	}
+
+	if !el.Expires.IsZero() {
+		details = append(details, "until "+el.Expires.Format("2006-01-02 15:04:05"))
+	}
+
+	return fmt.Sprintf("%s (%s)", logging.LabelFromLevel(el.Level), strings.Join(details, " "))
+}
+
+// Name returns the command's name
+func (c *listLevelsCommand) Name() string {
+	return lslCommandName
+}
+
+// Summary returns an explanation of what the command does
+func (c *listLevelsCommand) Summmary() string {
+	return lslSummary
+}
+
+// Usage defines how to invoke the command
+func (c *listLevelsCommand) Usage() string {
+	return lslUsage
+}
+
+// Help give detailed information about the command
+func (c *listLevelsCommand) Help() []string {
+	return []string{lslHelp, lslHelpTwo}
+}
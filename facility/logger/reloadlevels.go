@@ -0,0 +1,72 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logger
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/logging"
+)
+
+// LevelReloaderComponentName is the name of the component that applies changes to log levels when configuration is reloaded
+const LevelReloaderComponentName = instance.FrameworkPrefix + "LogLevelReloader"
+
+// levelReloader updates the global and component log levels of the application and framework ComponentLoggerManagers
+// when the GlobalLogLevel or ComponentLogLevels settings in ApplicationLogger or FrameworkLogger change. Implements config.ConfigChangeListener
+type levelReloader struct {
+	FrameworkLogger    logging.Logger
+	FrameworkManager   *logging.ComponentLoggerManager
+	ApplicationManager *logging.ComponentLoggerManager
+}
+
+// ConfigChanged implements config.ConfigChangeListener.ConfigChanged
+func (lr *levelReloader) ConfigChanged(changed []string, ca *config.Accessor) error {
+
+	if err := lr.reload("ApplicationLogger", lr.ApplicationManager, changed, ca); err != nil {
+		return err
+	}
+
+	return lr.reload("FrameworkLogger", lr.FrameworkManager, changed, ca)
+}
+
+func (lr *levelReloader) reload(path string, clm *logging.ComponentLoggerManager, changed []string, ca *config.Accessor) error {
+
+	globalPath := path + ".GlobalLogLevel"
+	componentPath := path + ".ComponentLogLevels"
+
+	if config.PathChanged(changed, globalPath) {
+
+		label, err := ca.StringVal(globalPath)
+
+		if err != nil {
+			return err
+		}
+
+		level, err := logging.LogLevelFromLabel(label)
+
+		if err != nil {
+			return err
+		}
+
+		clm.SetGlobalThreshold(level)
+		lr.FrameworkLogger.LogInfof("%s set to %s", globalPath, label)
+	}
+
+	if config.PathChanged(changed, componentPath) {
+
+		levels, err := ca.ObjectVal(componentPath)
+
+		if err != nil {
+			return err
+		}
+
+		if err := clm.UpdateConfiguredLevels(levels); err != nil {
+			return err
+		}
+
+		lr.FrameworkLogger.LogInfof("%s updated", componentPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func TestLevelReloader(t *testing.T) {
+
+	fm := logging.CreateComponentLoggerManager(logging.Info, map[string]interface{}{}, []logging.LogWriter{}, nil)
+	am := logging.CreateComponentLoggerManager(logging.Info, map[string]interface{}{"myComp": "ERROR"}, []logging.LogWriter{}, nil)
+
+	comp := am.CreateLogger("myComp")
+
+	rl := new(levelReloader)
+	rl.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	rl.FrameworkManager = fm
+	rl.ApplicationManager = am
+
+	ca := &config.Accessor{JSONData: map[string]interface{}{
+		"ApplicationLogger": map[string]interface{}{
+			"GlobalLogLevel":     "WARN",
+			"ComponentLogLevels": map[string]interface{}{"myComp": "DEBUG"},
+		},
+		"FrameworkLogger": map[string]interface{}{
+			"GlobalLogLevel":     "ERROR",
+			"ComponentLogLevels": map[string]interface{}{},
+		},
+	}}
+
+	changed := []string{"ApplicationLogger.ComponentLogLevels.myComp", "ApplicationLogger.GlobalLogLevel"}
+
+	test.ExpectNil(t, rl.ConfigChanged(changed, ca))
+
+	test.ExpectBool(t, am.GlobalLevel() == logging.Warn, true)
+	test.ExpectBool(t, comp.IsLevelEnabled(logging.Debug), true)
+
+	// Framework settings weren't in the list of changed paths
+	test.ExpectBool(t, fm.GlobalLevel() == logging.Info, true)
+
+	ca.JSONData["FrameworkLogger"].(map[string]interface{})["GlobalLogLevel"] = "LOUD"
+
+	test.ExpectNotNil(t, rl.ConfigChanged([]string{"FrameworkLogger"}, ca))
+	test.ExpectBool(t, fm.GlobalLevel() == logging.Info, true)
+}
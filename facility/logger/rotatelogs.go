@@ -0,0 +1,173 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logger
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+const (
+	// LogRotatorComponentName is the name of the component that rotates log files on demand
+	LogRotatorComponentName = instance.FrameworkPrefix + "LogRotator"
+
+	// RotateLogsComponentName is the name of the component able to rotate log files at runtime
+	RotateLogsComponentName = instance.FrameworkPrefix + "CommandRotateLogs"
+	rlCommandName           = "rotate-logs"
+	rlSummary               = "Rotates the application log file and any other rotatable log files (such as the HTTP access log)."
+	rlUsage                 = "rotate-logs"
+	rlHelp                  = "Each log file is renamed with a timestamp suffix and a new file is created at the original path. Messages waiting to be written are written to the new file."
+	rlHelpTwo               = "If a log file has already been moved by another tool, it is re-opened at its original path without being renamed."
+)
+
+// logRotator finds every component that implements logging.Rotatable and rotates them all when RotateAll is called or,
+// if RotateOnSIGHUP is set, when the application receives a SIGHUP signal.
+type logRotator struct {
+	FrameworkLogger logging.Logger
+
+	// Whether or not log files should be rotated when the application receives SIGHUP
+	RotateOnSIGHUP bool
+
+	rotatables map[string]logging.Rotatable
+	signals    chan os.Signal
+	mutex      sync.Mutex
+}
+
+func newLogRotator() *logRotator {
+	lr := new(logRotator)
+	lr.rotatables = make(map[string]logging.Rotatable)
+
+	return lr
+}
+
+func (lr *logRotator) add(name string, r logging.Rotatable) {
+	lr.mutex.Lock()
+	defer lr.mutex.Unlock()
+
+	lr.rotatables[name] = r
+}
+
+// RotateAll rotates every known Rotatable, returning a message for each rotation that failed.
+func (lr *logRotator) RotateAll() []string {
+	lr.mutex.Lock()
+	defer lr.mutex.Unlock()
+
+	var failures []string
+
+	for name, r := range lr.rotatables {
+
+		if err := r.Rotate(); err != nil {
+			m := fmt.Sprintf("Unable to rotate log file for %s: %s", name, err.Error())
+
+			lr.FrameworkLogger.LogErrorf("%s", m)
+			failures = append(failures, m)
+		} else {
+			lr.FrameworkLogger.LogDebugf("Rotated log file for %s", name)
+		}
+	}
+
+	return failures
+}
+
+// OfInterest returns true if the supplied component implements logging.Rotatable
+func (lr *logRotator) OfInterest(subject *ioc.Component) bool {
+	_, found := subject.Instance.(logging.Rotatable)
+
+	return found
+}
+
+// DecorateComponent records the component so it can be rotated on demand
+func (lr *logRotator) DecorateComponent(subject *ioc.Component, cc *ioc.ComponentContainer) {
+	lr.add(subject.Name, subject.Instance.(logging.Rotatable))
+}
+
+// StartComponent starts listening for SIGHUP, if RotateOnSIGHUP is set
+func (lr *logRotator) StartComponent() error {
+
+	if !lr.RotateOnSIGHUP {
+		return nil
+	}
+
+	lr.signals = make(chan os.Signal, 1)
+	signal.Notify(lr.signals, syscall.SIGHUP)
+
+	go func(signals chan os.Signal) {
+		for range signals {
+			lr.FrameworkLogger.LogInfof("SIGHUP received - rotating log files")
+			lr.RotateAll()
+		}
+	}(lr.signals)
+
+	return nil
+}
+
+// PrepareToStop implements ioc.Stoppable
+func (lr *logRotator) PrepareToStop() {
+}
+
+// ReadyToStop always returns true. Implements ioc.Stoppable
+func (lr *logRotator) ReadyToStop() (bool, error) {
+	return true, nil
+}
+
+// Stop stops listening for SIGHUP. Implements ioc.Stoppable
+func (lr *logRotator) Stop() error {
+
+	if lr.signals != nil {
+		signal.Stop(lr.signals)
+		close(lr.signals)
+		lr.signals = nil
+	}
+
+	return nil
+}
+
+type rotateLogsCommand struct {
+	FrameworkLogger logging.Logger
+	Rotator         *logRotator
+}
+
+func (c *rotateLogsCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	if failures := c.Rotator.RotateAll(); len(failures) > 0 {
+
+		errs := make([]*ws.CategorisedError, len(failures))
+
+		for i, f := range failures {
+			errs[i] = ctl.NewCommandUnexpectedError(f)
+		}
+
+		return nil, errs
+	}
+
+	return new(ctl.CommandOutput), nil
+}
+
+// Name returns the command's name
+func (c *rotateLogsCommand) Name() string {
+	return rlCommandName
+}
+
+// Summary returns an explanation of what the command does
+func (c *rotateLogsCommand) Summmary() string {
+	return rlSummary
+}
+
+// Usage defines how to invoke the command
+func (c *rotateLogsCommand) Usage() string {
+	return rlUsage
+}
+
+// Help give detailed information about the command
+func (c *rotateLogsCommand) Help() []string {
+	return []string{rlHelp, rlHelpTwo}
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"errors"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+type mockRotatable struct {
+	rotations int
+	err       error
+}
+
+func (mr *mockRotatable) Rotate() error {
+	mr.rotations++
+	return mr.err
+}
+
+func TestRotateLogsCommand(t *testing.T) {
+
+	lr := newLogRotator()
+	lr.FrameworkLogger = new(logging.ConsoleErrorLogger)
+
+	good := new(mockRotatable)
+	comp := ioc.NewComponent("accessLog", good)
+
+	test.ExpectBool(t, lr.OfInterest(comp), true)
+	test.ExpectBool(t, lr.OfInterest(ioc.NewComponent("other", new(logLevelCommand))), false)
+
+	lr.DecorateComponent(comp, nil)
+
+	rlc := new(rotateLogsCommand)
+	rlc.Rotator = lr
+
+	_, errs := rlc.ExecuteCommand([]string{}, map[string]string{})
+
+	test.ExpectInt(t, len(errs), 0)
+	test.ExpectInt(t, good.rotations, 1)
+
+	bad := &mockRotatable{err: errors.New("disk full")}
+	lr.add("appLog", bad)
+
+	_, errs = rlc.ExecuteCommand([]string{}, map[string]string{})
+
+	test.ExpectInt(t, len(errs), 1)
+	test.ExpectInt(t, good.rotations, 2)
+	test.ExpectInt(t, bad.rotations, 1)
+}
@@ -0,0 +1,203 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package metrics provides the Metrics facility which records metrics about web service requests, scheduled tasks and
+database queries and serves them in the Prometheus text exposition format.
+
+Enabling the facility
+
+	{
+	  "Facilities": {
+		"HTTPServer": true,
+		"Metrics": true
+	  }
+	}
+
+Recorded metrics
+
+The facility creates a component implementing instrument.RequestInstrumentationManager which is automatically used by
+the HTTPServer facility (unless HTTPServer.DisableInstrumentationAutoWire is set). If your application (or another facility)
+also provides an instrument.RequestInstrumentationManager, the server combines them (see instrument.Combine) so existing
+instrumentation continues to work. The following metrics are recorded
+(names are prefixed with the configured Namespace):
+
+	http_requests_total              Counter of completed requests, labelled by handler, method and status.
+	http_request_duration_seconds    Histogram of request latency, labelled by handler, method and status.
+	http_active_requests             The number of requests currently being handled (HTTPServer.ActiveRequests).
+	task_invocations_total           Counter of scheduled task invocations, labelled by task and outcome.
+	task_duration_seconds            Histogram of task invocation duration, labelled by task and outcome.
+	db_query_duration_seconds        Histogram of database statement duration, labelled by qid and outcome.
+
+The handler label is the component name of the ws handler (e.g. handler.WsHandler) that processed the request, or 'none'
+if the request was not handled by a ws handler. Task outcomes are success, failure, retry and panic. Query outcomes are
+success and error; the qid label is the ID of the query template the statement was built from, or empty if the statement
+was not built from a template.
+
+Task and query metrics are recorded automatically if the TaskScheduler and RdbmsAccess facilities are enabled.
+
+Application metrics
+
+The registry holding the facility's metrics is available as a component called grncMetricsRegistry (see
+metrics.Registry). Your components can register their own metrics with it so that they are served alongside the
+facility's metrics.
+
+Serving metrics
+
+By default metrics are served by the HTTPServer facility on the configured Path. If Port is set to a value greater than
+zero, metrics are instead served on that port (and optionally Address) by a separate, minimal HTTP server, which keeps
+them off your application's public interface.
+
+Configuration
+
+	{
+	  "Metrics": {
+		"Path": "/metrics",
+		"Port": 0,
+		"Address": "",
+		"Namespace": "granitic",
+		"LatencyBuckets": [0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10]
+	  }
+	}
+
+LatencyBuckets are the upper bounds (in seconds) of the histogram buckets used for all of the facility's histograms.
+*/
+package metrics
+
+import (
+	"errors"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/metrics"
+	"github.com/graniticio/granitic/v2/rdbms"
+	"github.com/graniticio/granitic/v2/schedule"
+)
+
+const (
+	// RegistryComponentName is the name of the component holding the metrics recorded by this facility
+	RegistryComponentName = instance.FrameworkPrefix + "MetricsRegistry"
+
+	// CollectorComponentName is the name of the component that records request, task and query metrics
+	CollectorComponentName = instance.FrameworkPrefix + "MetricsCollector"
+
+	endpointComponentName  = instance.FrameworkPrefix + "MetricsEndpoint"
+	listenerComponentName  = instance.FrameworkPrefix + "MetricsListener"
+	decoratorComponentName = instance.FrameworkPrefix + "MetricsDecorator"
+)
+
+// FacilityBuilder creates the components that make up the Metrics facility.
+type FacilityBuilder struct {
+}
+
+// BuildAndRegister implements FacilityBuilder.BuildAndRegister
+func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) error {
+
+	r := metrics.NewRegistry()
+	cn.WrapAndAddProto(RegistryComponentName, r)
+
+	c := new(Collector)
+
+	if err := ca.Populate("Metrics", c); err != nil {
+		return err
+	}
+
+	if err := c.register(r); err != nil {
+		return err
+	}
+
+	cn.WrapAndAddProto(CollectorComponentName, c)
+
+	path, _ := ca.StringVal("Metrics.Path")
+	port, _ := ca.IntVal("Metrics.Port")
+	serverEnabled, _ := ca.BoolVal("Facilities.HTTPServer")
+
+	if serverEnabled {
+		cn.AddModifier(CollectorComponentName, "Server", httpserver.HTTPServerComponentName)
+	}
+
+	e := new(endpoint)
+	e.Registry = r
+	e.Path = path
+
+	if port > 0 {
+
+		l := new(listener)
+		l.Endpoint = e
+		l.Port = port
+		l.Address, _ = ca.StringVal("Metrics.Address")
+
+		cn.WrapAndAddProto(listenerComponentName, l)
+
+	} else if serverEnabled {
+		cn.WrapAndAddProto(endpointComponentName, e)
+	} else {
+		return errors.New("the Metrics facility requires either the HTTPServer facility to be enabled or Metrics.Port to be set")
+	}
+
+	d := new(recorderDecorator)
+	d.Collector = c
+	d.Log = lm.CreateLogger(decoratorComponentName)
+
+	cn.WrapAndAddProto(decoratorComponentName, d)
+
+	return nil
+}
+
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (fb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := config.SchemaFromType(new(Collector))
+	s.WithField("Path", config.NewSchema(config.StringValue))
+	s.WithField("Port", config.NewSchema(config.IntValue))
+	s.WithField("Address", config.NewSchema(config.StringValue))
+
+	return map[string]*config.Schema{"Metrics": s}
+}
+
+// FacilityName implements FacilityBuilder.FacilityName
+func (fb *FacilityBuilder) FacilityName() string {
+	return "Metrics"
+}
+
+// DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
+func (fb *FacilityBuilder) DependsOnFacilities() []string {
+	return []string{}
+}
+
+// Injects the Collector into components that can report task outcomes and query timings
+type recorderDecorator struct {
+	Collector *Collector
+	Log       logging.Logger
+}
+
+// OfInterest returns true if the supplied component is a schedule.TaskScheduler or rdbms.GraniticRdbmsClientManager
+func (rd *recorderDecorator) OfInterest(subject *ioc.Component) bool {
+
+	switch subject.Instance.(type) {
+	case *schedule.TaskScheduler, *rdbms.GraniticRdbmsClientManager:
+		return true
+	}
+
+	return false
+}
+
+// DecorateComponent injects the Collector, unless another recorder has already been set
+func (rd *recorderDecorator) DecorateComponent(subject *ioc.Component, cc *ioc.ComponentContainer) {
+
+	switch i := subject.Instance.(type) {
+	case *schedule.TaskScheduler:
+		if i.OutcomeRecorder == nil {
+			rd.Log.LogDebugf("Recording task outcomes from %s", subject.Name)
+			i.OutcomeRecorder = rd.Collector
+		}
+
+	case *rdbms.GraniticRdbmsClientManager:
+		if i.QueryObserver == nil {
+			rd.Log.LogDebugf("Recording query timings from %s", subject.Name)
+			i.QueryObserver = rd.Collector
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/metrics"
+	"github.com/graniticio/granitic/v2/schedule"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// The handler label used for requests that were not processed by a ws handler
+	noHandler = "none"
+
+	querySucceeded = "success"
+	queryFailed    = "error"
+)
+
+// Collector records metrics about web service requests, scheduled task invocations and database queries. Implements
+// instrument.RequestInstrumentationManager, schedule.TaskOutcomeRecorder and rdbms.QueryObserver
+type Collector struct {
+	// A prefix (separated by an underscore) added to the name of every metric recorded by this component. May be empty.
+	Namespace string
+
+	// The upper bounds (in seconds) of the buckets used by the histograms recorded by this component.
+	LatencyBuckets []float64
+
+	// The server whose active requests are reported. Automatically injected if the HTTPServer facility is enabled.
+	Server *httpserver.HTTPServer
+
+	requests       *metrics.Counter
+	requestLatency *metrics.Histogram
+	tasks          *metrics.Counter
+	taskDuration   *metrics.Histogram
+	queryDuration  *metrics.Histogram
+}
+
+// register creates this component's metrics in the supplied Registry
+func (c *Collector) register(r *metrics.Registry) error {
+
+	var err error
+
+	buckets := c.LatencyBuckets
+
+	if len(buckets) == 0 {
+		buckets = metrics.DefaultBuckets
+	}
+
+	if c.requests, err = r.NewCounter(c.name("http_requests_total"), "Number of HTTP requests completed.", "handler", "method", "status"); err != nil {
+		return err
+	}
+
+	if c.requestLatency, err = r.NewHistogram(c.name("http_request_duration_seconds"), "Time taken to complete HTTP requests.", buckets, "handler", "method", "status"); err != nil {
+		return err
+	}
+
+	if err = r.NewGaugeFunc(c.name("http_active_requests"), "Number of HTTP requests currently being handled.", c.activeRequests); err != nil {
+		return err
+	}
+
+	if c.tasks, err = r.NewCounter(c.name("task_invocations_total"), "Number of scheduled task invocations completed.", "task", "outcome"); err != nil {
+		return err
+	}
+
+	if c.taskDuration, err = r.NewHistogram(c.name("task_duration_seconds"), "Time taken by scheduled task invocations.", buckets, "task", "outcome"); err != nil {
+		return err
+	}
+
+	c.queryDuration, err = r.NewHistogram(c.name("db_query_duration_seconds"), "Time taken to execute database statements.", buckets, "qid", "outcome")
+
+	return err
+}
+
+func (c *Collector) name(n string) string {
+
+	if c.Namespace == "" {
+		return n
+	}
+
+	return c.Namespace + "_" + n
+}
+
+func (c *Collector) activeRequests() float64 {
+
+	if c.Server == nil {
+		return 0
+	}
+
+	return float64(atomic.LoadInt64(&c.Server.ActiveRequests))
+}
+
+// Begin starts timing a request. The returned function records the request's latency and outcome and must be called
+// once the response has been written. Implements instrument.RequestInstrumentationManager
+func (c *Collector) Begin(ctx context.Context, res http.ResponseWriter, req *http.Request) (context.Context, instrument.Instrumentor, func()) {
+
+	ri := new(requestInstrumentor)
+	ri.handler = noHandler
+
+	started := time.Now()
+
+	end := func() {
+
+		status := http.StatusOK
+
+		if wrw, found := res.(*httpendpoint.HTTPResponseWriter); found && wrw.Status != 0 {
+			status = wrw.Status
+		}
+
+		code := strconv.Itoa(status)
+
+		c.requests.Inc(ri.handler, req.Method, code)
+		c.requestLatency.Observe(time.Since(started).Seconds(), ri.handler, req.Method, code)
+	}
+
+	return instrument.AddInstrumentorToContext(ctx, ri), ri, end
+}
+
+// RecordOutcome records the duration and outcome of a task invocation. Implements schedule.TaskOutcomeRecorder
+func (c *Collector) RecordOutcome(task *schedule.Task, outcome schedule.TaskOutcome, elapsed time.Duration) {
+
+	o := string(outcome)
+
+	c.tasks.Inc(task.ID, o)
+	c.taskDuration.Observe(elapsed.Seconds(), task.ID, o)
+}
+
+// QueryExecuted records the duration of a database statement. Implements rdbms.QueryObserver
+func (c *Collector) QueryExecuted(qid string, elapsed time.Duration, err error) {
+
+	outcome := querySucceeded
+
+	if err != nil {
+		outcome = queryFailed
+	}
+
+	c.queryDuration.Observe(elapsed.Seconds(), qid, outcome)
+}
+
+// requestInstrumentor captures the name of the handler that processes a request. Sub-events are not recorded.
+type requestInstrumentor struct {
+	handler string
+}
+
+// StartEvent implements instrument.Instrumentor.StartEvent
+func (ri *requestInstrumentor) StartEvent(id string, metadata ...interface{}) instrument.EndEvent {
+	return func() {}
+}
+
+// Fork implements instrument.Instrumentor.Fork
+func (ri *requestInstrumentor) Fork(ctx context.Context) (context.Context, instrument.Instrumentor) {
+	return ctx, ri
+}
+
+// Integrate implements instrument.Instrumentor.Integrate
+func (ri *requestInstrumentor) Integrate(instrumentor instrument.Instrumentor) {
+}
+
+// Amend records the component name of the handler processing the request. Implements instrument.Instrumentor.Amend
+func (ri *requestInstrumentor) Amend(additional instrument.Additional, value interface{}) {
+
+	if additional != instrument.Handler {
+		return
+	}
+
+	if n, found := value.(ioc.ComponentNamer); found && n.ComponentName() != "" {
+		ri.handler = n.ComponentName()
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/metrics"
+	"github.com/graniticio/granitic/v2/schedule"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type namedHandler struct {
+	name string
+}
+
+func (nh *namedHandler) ComponentName() string {
+	return nh.name
+}
+
+func (nh *namedHandler) SetComponentName(name string) {
+	nh.name = name
+}
+
+func newTestCollector(t *testing.T) (*Collector, *metrics.Registry) {
+
+	r := metrics.NewRegistry()
+
+	c := new(Collector)
+	c.Namespace = "test"
+	c.LatencyBuckets = []float64{0.5}
+	c.Server = new(httpserver.HTTPServer)
+
+	test.ExpectNil(t, c.register(r))
+
+	return c, r
+}
+
+func TestRequestMetrics(t *testing.T) {
+
+	c, r := newTestCollector(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	wrw := httpendpoint.NewHTTPResponseWriter(httptest.NewRecorder())
+
+	ctx, ri, end := c.Begin(context.Background(), wrw, req)
+
+	test.ExpectNotNil(t, instrument.InstrumentorFromContext(ctx))
+
+	ri.Amend(instrument.RequestID, "id")
+	ri.Amend(instrument.Handler, &namedHandler{name: "createOrderHandler"})
+
+	wrw.WriteHeader(http.StatusCreated)
+	end()
+
+	// A request that doesn't reach a handler or explicitly set a status
+	_, _, end = c.Begin(context.Background(), httpendpoint.NewHTTPResponseWriter(httptest.NewRecorder()), req)
+	end()
+
+	test.ExpectInt(t, int(c.requests.Value("createOrderHandler", http.MethodPost, "201")), 1)
+	test.ExpectInt(t, int(c.requests.Value(noHandler, http.MethodPost, "200")), 1)
+	test.ExpectInt(t, int(c.requestLatency.Count("createOrderHandler", http.MethodPost, "201")), 1)
+
+	c.Server.ActiveRequests = 3
+
+	var b bytes.Buffer
+	test.ExpectNil(t, r.WriteText(&b))
+
+	out := b.String()
+
+	test.ExpectBool(t, strings.Contains(out, "test_http_active_requests 3\n"), true)
+	test.ExpectBool(t, strings.Contains(out, "test_http_requests_total{handler=\"createOrderHandler\",method=\"POST\",status=\"201\"} 1\n"), true)
+}
+
+func TestTaskAndQueryMetrics(t *testing.T) {
+
+	c, _ := newTestCollector(t)
+
+	tsk := new(schedule.Task)
+	tsk.ID = "cleanUp"
+
+	c.RecordOutcome(tsk, schedule.TaskSucceeded, time.Millisecond)
+	c.RecordOutcome(tsk, schedule.TaskRetrying, time.Second)
+
+	test.ExpectInt(t, int(c.tasks.Value("cleanUp", "success")), 1)
+	test.ExpectInt(t, int(c.taskDuration.Count("cleanUp", "retry")), 1)
+
+	c.QueryExecuted("FIND_ORDER", time.Millisecond, nil)
+	c.QueryExecuted("FIND_ORDER", time.Millisecond, errors.New("timed out"))
+
+	test.ExpectInt(t, int(c.queryDuration.Count("FIND_ORDER", querySucceeded)), 1)
+	test.ExpectInt(t, int(c.queryDuration.Count("FIND_ORDER", queryFailed)), 1)
+}
+
+func TestEndpoint(t *testing.T) {
+
+	_, r := newTestCollector(t)
+
+	e := &endpoint{Registry: r, Path: "/metrics"}
+
+	test.ExpectString(t, e.RegexPattern(), "^/metrics$")
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, rec.Header().Get("Content-Type"), metrics.TextContentType)
+	test.ExpectBool(t, strings.Contains(rec.Body.String(), "# TYPE test_http_requests_total counter"), true)
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/metrics"
+	"net"
+	"net/http"
+	"regexp"
+)
+
+// Serves the contents of a metrics.Registry in the Prometheus text exposition format. Implements httpendpoint.Provider
+type endpoint struct {
+	// Logger used by Granitic framework components. Automatically injected.
+	FrameworkLogger logging.Logger
+	Registry        *metrics.Registry
+	Path            string
+}
+
+// SupportedHTTPMethods implements httpendpoint.Provider.SupportedHTTPMethods
+func (e *endpoint) SupportedHTTPMethods() []string {
+	return []string{http.MethodGet}
+}
+
+// RegexPattern implements httpendpoint.Provider.RegexPattern
+func (e *endpoint) RegexPattern() string {
+	return "^" + regexp.QuoteMeta(e.Path) + "$"
+}
+
+// ServeHTTP implements httpendpoint.Provider.ServeHTTP
+func (e *endpoint) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+	e.write(ctx, w)
+
+	return ctx
+}
+
+func (e *endpoint) write(ctx context.Context, w http.ResponseWriter) {
+
+	w.Header().Set("Content-Type", metrics.TextContentType)
+	w.WriteHeader(http.StatusOK)
+
+	if err := e.Registry.WriteText(w); err != nil {
+		e.FrameworkLogger.LogErrorfCtx(ctx, "Unable to write metrics: %s", err.Error())
+	}
+}
+
+// ServeWhileSuspended returns true so that metrics can be collected from a suspended server. Implements httpendpoint.SuspensionExemptProvider
+func (e *endpoint) ServeWhileSuspended() bool {
+	return true
+}
+
+// VersionAware implements httpendpoint.Provider.VersionAware
+func (e *endpoint) VersionAware() bool {
+	return false
+}
+
+// SupportsVersion implements httpendpoint.Provider.SupportsVersion
+func (e *endpoint) SupportsVersion(version httpendpoint.RequiredVersion) bool {
+	return true
+}
+
+// AutoWireable implements httpendpoint.Provider.AutoWireable
+func (e *endpoint) AutoWireable() bool {
+	return true
+}
+
+// listener serves metrics on a dedicated port, independently of the HTTPServer facility.
+type listener struct {
+	// Logger used by Granitic framework components. Automatically injected.
+	FrameworkLogger logging.Logger
+	Endpoint        *endpoint
+	Port            int
+	Address         string
+	server          *http.Server
+}
+
+// AllowAccess starts listening for requests. Returns an error if the port is already in use. Implements ioc.Accessible
+func (l *listener) AllowAccess() error {
+
+	l.Endpoint.FrameworkLogger = l.FrameworkLogger
+
+	sm := http.NewServeMux()
+	sm.HandleFunc(l.Endpoint.Path, func(w http.ResponseWriter, req *http.Request) {
+
+		if req.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		l.Endpoint.write(req.Context(), w)
+	})
+
+	address := fmt.Sprintf("%s:%d", l.Address, l.Port)
+
+	ln, err := net.Listen("tcp", address)
+
+	if err != nil {
+		return err
+	}
+
+	l.server = &http.Server{Addr: address, Handler: sm}
+
+	go l.server.Serve(ln)
+
+	l.FrameworkLogger.LogInfof("Serving metrics on %d", l.Port)
+
+	return nil
+}
+
+// PrepareToStop implements ioc.Stoppable
+func (l *listener) PrepareToStop() {
+}
+
+// ReadyToStop always returns true. Implements ioc.Stoppable
+func (l *listener) ReadyToStop() (bool, error) {
+	return true, nil
+}
+
+// Stop closes the listener. Implements ioc.Stoppable
+func (l *listener) Stop() error {
+
+	if l.server != nil {
+		return l.server.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package openapi provides the OpenAPI facility which generates an OpenAPI 3 document describing your application's web
+service handlers.
+
+Enabling the facility
+
+	{
+	  "Facilities": {
+		"OpenAPI": true
+	  }
+	}
+
+When the application starts, every handler.WsHandler in the container (apart from Granitic's own handlers and any listed
+in ExcludeHandlers) is examined and an OpenAPI document is generated. See the openapi package for a description of what
+is included in the document.
+
+Handlers that cannot be described (for example a second version-aware handler for the same path and method, or a handler
+using a custom HTTP method) are left out of the document and a warning is logged. If the document cannot be generated at
+all, the error is logged and the application still starts.
+
+Configuration
+
+	{
+	  "OpenAPI": {
+		"Serve": true,
+		"Path": "/openapi.json",
+		"MediaType": "application/json",
+		"Info": {
+		  "Title": "Record catalogue",
+		  "Description": "Manages records and artists",
+		  "Version": "1.2.0"
+		},
+		"Servers": [
+		  {"URL": "https://api.example.com"}
+		],
+		"ExcludeHandlers": ["internalHandler"]
+	  }
+	}
+
+If Serve is true, the document is served as JSON from Path by the HTTPServer facility. MediaType is the media type used
+to describe request bodies and should be changed to application/xml if your application uses the XMLWs facility.
+
+Exporting the document
+
+If the RuntimeCtl facility is enabled, the document can be exported using the grnc-ctl tool:
+
+	grnc-ctl openapi > openapi.json
+*/
+package openapi
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/runtimectl"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/openapi"
+)
+
+const (
+	// DocumentSourceComponentName is the name of the component that generates and stores the OpenAPI document
+	DocumentSourceComponentName = instance.FrameworkPrefix + "OpenAPIDocument"
+	endpointComponentName       = instance.FrameworkPrefix + "OpenAPIEndpoint"
+	commandComponentName        = instance.FrameworkPrefix + "CommandOpenAPI"
+)
+
+// FacilityBuilder creates the components that make up the OpenAPI facility.
+type FacilityBuilder struct {
+}
+
+// BuildAndRegister implements FacilityBuilder.BuildAndRegister
+func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) error {
+
+	ds := new(DocumentSource)
+	ds.Generator = new(openapi.Generator)
+
+	if err := ca.Populate("OpenAPI", ds); err != nil {
+		return err
+	}
+
+	if err := ca.Populate("OpenAPI", ds.Generator); err != nil {
+		return err
+	}
+
+	ds.Generator.Log = lm.CreateLogger(DocumentSourceComponentName)
+
+	cn.WrapAndAddProto(DocumentSourceComponentName, ds)
+
+	if serve, _ := ca.BoolVal("OpenAPI.Serve"); serve {
+
+		ep := new(documentEndpoint)
+		ep.Source = ds
+		ep.Path, _ = ca.StringVal("OpenAPI.Path")
+
+		cn.WrapAndAddProto(endpointComponentName, ep)
+	}
+
+	if runtimectl.Enabled(ca) {
+
+		c := new(openAPICommand)
+		c.Source = ds
+
+		cn.WrapAndAddProto(commandComponentName, c)
+	}
+
+	return nil
+}
+
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (fb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := config.MergeSchemas(config.SchemaFromType(new(DocumentSource)), config.SchemaFromType(new(openapi.Generator)))
+	s.WithField("Serve", config.NewSchema(config.BoolValue))
+	s.WithField("Path", config.NewSchema(config.StringValue))
+
+	return map[string]*config.Schema{"OpenAPI": s}
+}
+
+// FacilityName implements FacilityBuilder.FacilityName
+func (fb *FacilityBuilder) FacilityName() string {
+	return "OpenAPI"
+}
+
+// DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
+func (fb *FacilityBuilder) DependsOnFacilities() []string {
+	return []string{"HTTPServer"}
+}
@@ -0,0 +1,220 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/openapi"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/handler"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DocumentSource generates an OpenAPI document from the handler.WsHandler components in the container once all
+// components have been started, and keeps a copy of the document for serving and exporting.
+type DocumentSource struct {
+	// Logger used by Granitic framework components. Automatically injected.
+	FrameworkLogger logging.Logger
+
+	// Converts handlers into a document.
+	Generator *openapi.Generator
+
+	// The names of handlers that should not be included in the document.
+	ExcludeHandlers []string
+
+	container *ioc.ComponentContainer
+	mutex     sync.Mutex
+	document  *openapi.Document
+	rendered  []byte
+}
+
+// Container implements ioc.ContainerAccessor.Container
+func (ds *DocumentSource) Container(container *ioc.ComponentContainer) {
+	ds.container = container
+}
+
+// AllowAccess generates the document. If the document cannot be generated, the problem is logged rather than stopping
+// the application from starting, and generation is attempted again when the document is next requested. Implements ioc.Accessible
+func (ds *DocumentSource) AllowAccess() error {
+
+	if _, err := ds.Rendered(); err != nil {
+		ds.FrameworkLogger.LogErrorf("Unable to generate OpenAPI document: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Document returns the generated document, generating it if necessary.
+func (ds *DocumentSource) Document() (*openapi.Document, error) {
+
+	if _, err := ds.Rendered(); err != nil {
+		return nil, err
+	}
+
+	return ds.document, nil
+}
+
+// Rendered returns the generated document as indented JSON, generating it if necessary.
+func (ds *DocumentSource) Rendered() ([]byte, error) {
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.rendered != nil {
+		return ds.rendered, nil
+	}
+
+	d, err := ds.Generator.Generate(ds.handlers())
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.MarshalIndent(d, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	ds.FrameworkLogger.LogDebugf("Generated OpenAPI document with %d paths", len(d.Paths))
+
+	ds.document = d
+	ds.rendered = b
+
+	return b, nil
+}
+
+func (ds *DocumentSource) handlers() map[string]*handler.WsHandler {
+
+	excluded := make(map[string]bool)
+
+	for _, n := range ds.ExcludeHandlers {
+		excluded[n] = true
+	}
+
+	handlers := make(map[string]*handler.WsHandler)
+
+	if ds.container == nil {
+		return handlers
+	}
+
+	for _, c := range ds.container.AllComponents() {
+
+		h, found := c.Instance.(*handler.WsHandler)
+
+		if !found || excluded[c.Name] || strings.HasPrefix(c.Name, instance.FrameworkPrefix) {
+			continue
+		}
+
+		handlers[c.Name] = h
+	}
+
+	return handlers
+}
+
+// Serves the generated document as JSON. Implements httpendpoint.Provider
+type documentEndpoint struct {
+	Source *DocumentSource
+	Path   string
+}
+
+// SupportedHTTPMethods implements httpendpoint.Provider.SupportedHTTPMethods
+func (de *documentEndpoint) SupportedHTTPMethods() []string {
+	return []string{http.MethodGet}
+}
+
+// RegexPattern implements httpendpoint.Provider.RegexPattern
+func (de *documentEndpoint) RegexPattern() string {
+	return "^" + regexp.QuoteMeta(de.Path) + "$"
+}
+
+// ServeHTTP implements httpendpoint.Provider.ServeHTTP
+func (de *documentEndpoint) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+
+	b, err := de.Source.Rendered()
+
+	if err != nil {
+		de.Source.FrameworkLogger.LogErrorfCtx(ctx, "Unable to generate OpenAPI document: %s", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return ctx
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+
+	return ctx
+}
+
+// VersionAware implements httpendpoint.Provider.VersionAware
+func (de *documentEndpoint) VersionAware() bool {
+	return false
+}
+
+// SupportsVersion implements httpendpoint.Provider.SupportsVersion
+func (de *documentEndpoint) SupportsVersion(version httpendpoint.RequiredVersion) bool {
+	return true
+}
+
+// AutoWireable implements httpendpoint.Provider.AutoWireable
+func (de *documentEndpoint) AutoWireable() bool {
+	return true
+}
+
+const (
+	openAPICommandName = "openapi"
+	openAPISummary     = "Shows the OpenAPI document describing this application's web services."
+	openAPIUsage       = "openapi"
+	openAPIHelp        = "Outputs the OpenAPI 3 document generated when the application started as JSON. The output is not wrapped or indented by grnc-ctl, so it can be redirected to a file."
+)
+
+// Outputs the generated document. Implements ctl.Command
+type openAPICommand struct {
+	Source *DocumentSource
+}
+
+// ExecuteCommand implements ctl.Command.ExecuteCommand
+func (c *openAPICommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	b, err := c.Source.Rendered()
+
+	if err != nil {
+		return nil, []*ws.CategorisedError{ctl.NewCommandUnexpectedError(err.Error())}
+	}
+
+	co := new(ctl.CommandOutput)
+	co.RenderHint = ctl.Raw
+	co.OutputBody = [][]string{{string(b)}}
+
+	return co, nil
+}
+
+// Name returns the command's name
+func (c *openAPICommand) Name() string {
+	return openAPICommandName
+}
+
+// Summmary returns an explanation of what the command does
+func (c *openAPICommand) Summmary() string {
+	return openAPISummary
+}
+
+// Usage defines how to invoke the command
+func (c *openAPICommand) Usage() string {
+	return openAPIUsage
+}
+
+// Help give detailed information about the command
+func (c *openAPICommand) Help() []string {
+	return []string{openAPIHelp}
+}
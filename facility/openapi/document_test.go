@@ -0,0 +1,90 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/openapi"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/validate"
+	"github.com/graniticio/granitic/v2/ws/handler"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func newTestSource() *DocumentSource {
+	ds := new(DocumentSource)
+	ds.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	ds.Generator = &openapi.Generator{Info: openapi.Info{Title: "Test", Version: "2.0"}}
+
+	return ds
+}
+
+func TestEndpointServesDocument(t *testing.T) {
+
+	ds := newTestSource()
+	test.ExpectNil(t, ds.AllowAccess())
+
+	ep := &documentEndpoint{Source: ds, Path: "/openapi.json"}
+
+	re := regexp.MustCompile(ep.RegexPattern())
+	test.ExpectBool(t, re.MatchString("/openapi.json"), true)
+	test.ExpectBool(t, re.MatchString("/openapiXjson"), false)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+
+	ep.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+
+	d := new(openapi.Document)
+	test.ExpectNil(t, json.Unmarshal(rec.Body.Bytes(), d))
+	test.ExpectString(t, d.OpenAPI, openapi.Version)
+	test.ExpectString(t, d.Info.Version, "2.0")
+}
+
+func TestGenerationErrorDoesNotStopStart(t *testing.T) {
+
+	fm := logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, []logging.LogWriter{}, nil)
+	cc := ioc.NewComponentContainer(fm, new(config.Accessor), new(instance.System))
+
+	// The shared rule referenced by the validator does not exist, so the document cannot be generated
+	v := &validate.RuleValidator{Rules: [][]string{{"Name", "RULE:missing"}}}
+	cc.WrapAndAddProto("badHandler", &handler.WsHandler{HTTPMethod: "GET", PathTemplate: "/a", AutoValidator: v})
+	test.ExpectNil(t, cc.Populate())
+
+	ds := newTestSource()
+	ds.Container(cc)
+
+	test.ExpectNil(t, ds.AllowAccess())
+
+	_, err := ds.Rendered()
+	test.ExpectNotNil(t, err)
+}
+
+func TestExportCommand(t *testing.T) {
+
+	c := &openAPICommand{Source: newTestSource()}
+
+	co, errs := c.ExecuteCommand(nil, nil)
+
+	test.ExpectInt(t, len(errs), 0)
+	test.ExpectString(t, string(co.RenderHint), ctl.Raw)
+	test.ExpectInt(t, len(co.OutputBody), 1)
+	test.ExpectString(t, c.Name(), "openapi")
+}
+
+func TestFacilityNaming(t *testing.T) {
+	test.ExpectString(t, new(FacilityBuilder).FacilityName(), "OpenAPI")
+}
@@ -155,6 +155,21 @@ func (qmfb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager
 	return nil
 }
 
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (qmfb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	vp := config.NewSchema(config.ObjectValue)
+	vp.WithField(confValueProcess, config.SchemaFromType(new(dsquery.ConfigurableProcessor)))
+	vp.WithField(sqlValueProcess, config.SchemaFromType(new(dsquery.SQLProcessor)))
+
+	s := config.SchemaFromType(new(dsquery.TemplatedQueryManager))
+	s.WithField("CreateDefaultValueProcessor", config.NewSchema(config.BoolValue))
+	s.WithField("ProcessorName", config.NewSchema(config.StringValue))
+	s.WithField("ValueProcessors", vp)
+
+	return map[string]*config.Schema{"QueryManager": s}
+}
+
 // FacilityName implements FacilityBuilder.FacilityName
 func (qmfb *FacilityBuilder) FacilityName() string {
 	return QueryManagerFacilityName
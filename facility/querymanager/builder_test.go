@@ -1,6 +1,15 @@
 package querymanager
 
-import "testing"
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/dsquery"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"path/filepath"
+	"testing"
+)
 
 func TestFacilityNaming(t *testing.T) {
 
@@ -11,3 +20,27 @@ func TestFacilityNaming(t *testing.T) {
 	}
 
 }
+
+func TestSQLProcessorBoolDefaults(t *testing.T) {
+
+	jm := config.NewJSONMergerWithDirectLogging(new(logging.ConsoleErrorLogger), new(config.JSONContentParser))
+
+	merged, err := jm.LoadAndMergeConfig([]string{filepath.Join("..", "config", "querymanager.json")})
+	test.ExpectNil(t, err)
+
+	merged["QueryManager"].(map[string]interface{})["ProcessorName"] = "SQL"
+
+	ca := &config.Accessor{JSONData: merged, FrameworkLogger: new(logging.ConsoleErrorLogger)}
+
+	fm := logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, []logging.LogWriter{}, nil)
+	cc := ioc.NewComponentContainer(fm, ca, new(instance.System))
+
+	test.ExpectNil(t, new(FacilityBuilder).BuildAndRegister(fm, ca, cc))
+
+	qm := cc.ProtoComponents()[QueryManagerComponentName].Component.Instance.(*dsquery.TemplatedQueryManager)
+	sp := qm.ValueProcessor.(*dsquery.SQLProcessor)
+
+	// Booleans in generated SQL are rendered as 1 and 0 by default
+	test.ExpectString(t, sp.BoolTrue, "1")
+	test.ExpectString(t, sp.BoolFalse, "0")
+}
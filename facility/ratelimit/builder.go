@@ -0,0 +1,155 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package ratelimit provides the RateLimiting facility which limits the rate at which callers can make requests to your
+application's web service handlers.
+
+Enabling the facility
+
+	{
+	  "Facilities": {
+		"RateLimiting": true
+	  }
+	}
+
+When enabled, every handler.WsHandler that has not had its RateLimiter field set explicitly will be checked against the
+limits defined in configuration. Requests that exceed a limit receive an HTTP 429 response (written by the handler's
+ResponseWriter in the same way as other abnormal statuses) with a Retry-After header.
+
+Configuring limits
+
+	{
+	  "RateLimiting": {
+		"Default": {
+		  "Algorithm": "TOKEN_BUCKET",
+		  "Requests": 100,
+		  "WindowMS": 60000,
+		  "Burst": 20,
+		  "KeyBy": "IP"
+		},
+		"Handlers": {
+		  "createRecordHandler": {
+			"Algorithm": "SLIDING_WINDOW",
+			"Requests": 10,
+			"WindowMS": 1000,
+			"KeyBy": "IDENTITY",
+			"IdentityField": "LoggableUserID"
+		  }
+		},
+		"Server": {
+		  "Requests": 1000,
+		  "WindowMS": 1000
+		}
+	  }
+	}
+
+Default is applied to any handler that does not have an entry in Handlers (no default limit is applied if Default is not set).
+Handler limits are keyed by the handler's component name. See the ratelimit package for a description of each setting.
+
+Server is an optional limit applied to every request received by the HTTPServer facility, before the request is matched
+to a handler. It must use KeyBy IP, as the caller's identity is not known at that point.
+
+If your application is behind a proxy or load-balancer, set TrustForwardedFor to true so the caller's IP address is taken from
+the X-Forwarded-For header.
+
+Reloading limits
+
+If the ConfigReload facility is enabled, changes to Default, Handlers, Server and TrustForwardedFor are applied without
+restarting your application. Adding or removing the Server limit still requires a restart.
+
+Storage
+
+By default, request counts are stored in memory (see ratelimit.MemoryStore). To use a different implementation of
+ratelimit.Store, define it as a component and reference it using the frameworkModifiers mechanism:
+
+	"frameworkModifiers": {
+	  "grncRateLimiter": {
+		"Store": "myDistributedStore"
+	  }
+	}
+*/
+package ratelimit
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ratelimit"
+	"time"
+)
+
+const rateLimiterComponentName = instance.FrameworkPrefix + "RateLimiter"
+const rateLimitStoreComponentName = instance.FrameworkPrefix + "RateLimitStore"
+const rateLimitDecoratorComponentName = instance.FrameworkPrefix + "RateLimitDecorator"
+const rateLimitFilterComponentName = instance.FrameworkPrefix + "RateLimitFilter"
+const rateLimitReloaderComponentName = instance.FrameworkPrefix + "RateLimitReloader"
+
+// FacilityBuilder creates the components that make up the RateLimiting facility.
+type FacilityBuilder struct {
+}
+
+// BuildAndRegister implements FacilityBuilder.BuildAndRegister
+func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) error {
+
+	limiter := new(ratelimit.Limiter)
+	ca.Populate("RateLimiting", limiter)
+
+	cn.WrapAndAddProto(rateLimiterComponentName, limiter)
+
+	if !cn.ModifierExists(rateLimiterComponentName, "Store") {
+
+		sweep, _ := ca.IntVal("RateLimiting.SweepIntervalMS")
+
+		limiter.Store = ratelimit.NewMemoryStore(time.Duration(sweep) * time.Millisecond)
+		cn.WrapAndAddProto(rateLimitStoreComponentName, limiter.Store)
+	}
+
+	d := new(handlerDecorator)
+	d.Limiter = limiter
+	d.Log = lm.CreateLogger(rateLimitDecoratorComponentName)
+
+	cn.WrapAndAddProto(rateLimitDecoratorComponentName, d)
+
+	r := new(limitReloader)
+	r.Limiter = limiter
+
+	cn.WrapAndAddProto(rateLimitReloaderComponentName, r)
+
+	if ca.PathExists("RateLimiting.Server") {
+
+		f := new(serverFilter)
+		r.Filter = f
+		f.Limiter = limiter
+		ca.Populate("RateLimiting.Server", &f.Limit)
+
+		p := ioc.CreateProtoComponent(f, rateLimitFilterComponentName)
+		p.AddDependency("Server", httpserver.HTTPServerComponentName)
+
+		cn.AddProto(p)
+	}
+
+	return nil
+}
+
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (fb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := config.SchemaFromType(new(ratelimit.Limiter))
+	s.WithField("SweepIntervalMS", config.NewSchema(config.IntValue))
+	s.WithField("Server", config.SchemaFromType(new(ratelimit.Limit)))
+
+	return map[string]*config.Schema{"RateLimiting": s}
+}
+
+// FacilityName implements FacilityBuilder.FacilityName
+func (fb *FacilityBuilder) FacilityName() string {
+	return "RateLimiting"
+}
+
+// DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
+func (fb *FacilityBuilder) DependsOnFacilities() []string {
+	return []string{"HTTPServer"}
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ratelimit"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/handler"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const serverScope = "grncServer"
+
+// Injects the RateLimiter into any instance of handler.WsHandler that does not already have one
+type handlerDecorator struct {
+	Limiter *ratelimit.Limiter
+	Log     logging.Logger
+}
+
+// OfInterest returns true if the subject is a WsHandler without a RateLimiter
+func (hd *handlerDecorator) OfInterest(subject *ioc.Component) bool {
+
+	h, found := subject.Instance.(*handler.WsHandler)
+
+	return found && h.RateLimiter == nil
+}
+
+// DecorateComponent sets the handler's RateLimiter
+func (hd *handlerDecorator) DecorateComponent(subject *ioc.Component, cc *ioc.ComponentContainer) {
+
+	hd.Log.LogTracef("Adding rate limiter to %s", subject.Name)
+
+	subject.Instance.(*handler.WsHandler).RateLimiter = hd.Limiter
+}
+
+// serverFilter applies a limit to every request received by the HTTP server. Implements httpserver.RequestFilter
+type serverFilter struct {
+	Limiter *ratelimit.Limiter
+	Limit   ratelimit.Limit
+	Server  *httpserver.HTTPServer
+
+	mutex sync.RWMutex
+}
+
+// Filter rejects the request with a 'too many requests' response if the caller has exceeded the server-wide limit.
+func (sf *serverFilter) Filter(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, next httpserver.FilterChain) context.Context {
+
+	sf.mutex.RLock()
+	limit := sf.Limit
+	sf.mutex.RUnlock()
+
+	allowed, retryAfter := sf.Limiter.Check(ctx, serverScope, &limit, req, nil)
+
+	if allowed {
+		return next(ctx, w, req)
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+	state := ws.NewAbnormalState(http.StatusTooManyRequests, w)
+
+	if err := sf.Server.AbnormalStatusWriter.WriteAbnormalStatus(ctx, state); err != nil {
+		sf.Limiter.FrameworkLogger.LogErrorfCtx(ctx, "Problem writing a 'too many requests' response: %s", err.Error())
+	}
+
+	return ctx
+}
+
+// FilterOrder ensures the server-wide limit is checked before any application filters.
+func (sf *serverFilter) FilterOrder() int {
+	return math.MinInt32
+}
+
+// StartComponent checks the server-wide limit is valid.
+func (sf *serverFilter) StartComponent() error {
+	return validateServerLimit(&sf.Limit)
+}
+
+// setLimit replaces the server-wide limit if the new limit is valid.
+func (sf *serverFilter) setLimit(limit ratelimit.Limit) error {
+
+	if err := validateServerLimit(&limit); err != nil {
+		return err
+	}
+
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	sf.Limit = limit
+
+	return nil
+}
+
+func validateServerLimit(limit *ratelimit.Limit) error {
+
+	if err := limit.Validate(); err != nil {
+		return err
+	}
+
+	if limit.KeyBy != ratelimit.KeyByIP {
+		return errors.New("the server-wide rate limit (RateLimiting.Server) must use KeyBy IP")
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ratelimit
+
+import (
+	"errors"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ratelimit"
+)
+
+// limitReloader replaces the limits used by the rate limiter (and the server-wide filter, if enabled) when the
+// RateLimiting section of configuration changes. Implements config.ConfigChangeListener
+type limitReloader struct {
+	FrameworkLogger logging.Logger
+	Limiter         *ratelimit.Limiter
+	Filter          *serverFilter
+}
+
+// reloadedLimits holds the settings from the RateLimiting section of configuration that can be changed while the
+// application is running.
+type reloadedLimits struct {
+	Default           *ratelimit.Limit
+	Handlers          map[string]*ratelimit.Limit
+	Server            *ratelimit.Limit
+	TrustForwardedFor bool
+}
+
+// ConfigChanged implements config.ConfigChangeListener.ConfigChanged
+func (lr *limitReloader) ConfigChanged(changed []string, ca *config.Accessor) error {
+
+	if !config.PathChanged(changed, "RateLimiting") {
+		return nil
+	}
+
+	rl := new(reloadedLimits)
+
+	if err := ca.Populate("RateLimiting", rl); err != nil {
+		return err
+	}
+
+	if lr.Filter == nil && rl.Server != nil {
+		return errors.New("the application must be restarted to enable the server-wide rate limit (RateLimiting.Server)")
+	}
+
+	if lr.Filter != nil && rl.Server == nil {
+		return errors.New("the application must be restarted to disable the server-wide rate limit (RateLimiting.Server)")
+	}
+
+	if rl.Server != nil {
+		// Check before changing any limits so that an invalid server-wide limit doesn't leave the limits half-applied
+		if err := validateServerLimit(rl.Server); err != nil {
+			return err
+		}
+	}
+
+	if err := lr.Limiter.SetLimits(rl.Default, rl.Handlers, rl.TrustForwardedFor); err != nil {
+		return err
+	}
+
+	if lr.Filter != nil {
+		if err := lr.Filter.setLimit(*rl.Server); err != nil {
+			return err
+		}
+	}
+
+	lr.FrameworkLogger.LogInfof("Rate limits updated")
+
+	return nil
+}
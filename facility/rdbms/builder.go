@@ -175,6 +175,15 @@ func (rafb *FacilityBuilder) findProviders(cn *ioc.ComponentContainer) []string
 
 }
 
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (rafb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := config.NewSchema(config.ObjectValue)
+	s.WithField("Default", config.SchemaFromType(new(rdbms.ClientManagerConfig)))
+
+	return map[string]*config.Schema{"RdbmsAccess": s}
+}
+
 // FacilityName implements FacilityBuilder.FacilityName
 func (rafb *FacilityBuilder) FacilityName() string {
 	return "RdbmsAccess"
@@ -231,6 +231,28 @@ func (fb *FacilityBuilder) addCommand(cc *ioc.ComponentContainer, name string, c
 	cc.WrapAndAddProto(name, c)
 }
 
+// ConfigSchema declares the settings read from the RuntimeCtl and FrameworkServiceErrors configuration paths
+func (fb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	rules := config.NewSchema(config.ArrayValue).WithElements(config.NewSchema(config.ArrayValue).WithElements(config.NewSchema(config.StringValue)))
+
+	s := config.NewSchema(config.ObjectValue)
+	s.WithField("Manager", config.SchemaFromType(new(ctl.CommandManager)))
+	s.WithField("Server", config.SchemaFromType(new(httpserver.HTTPServer)))
+	s.WithField("ResponseWriter", config.SchemaFromType(new(ws.MarshallingResponseWriter)))
+	s.WithField("Marshal", config.SchemaFromType(new(json.MarshalingWriter)))
+	s.WithField("ResponseWrapper", config.SchemaFromType(new(json.GraniticJSONResponseWrapper)))
+	s.WithField("CommandHandler", config.SchemaFromType(new(handler.WsHandler)))
+	s.WithField("SharedRules", config.NewMapSchema(config.NewSchema(config.ArrayValue).WithElements(config.NewSchema(config.StringValue))))
+	s.WithField("CommandValidation", rules)
+	s.WithField("Errors", rules)
+
+	return map[string]*config.Schema{
+		"RuntimeCtl":             s,
+		"FrameworkServiceErrors": config.SchemaFromType(new(ws.FrameworkErrorGenerator)),
+	}
+}
+
 // FacilityName returns the canonical name of this facility (RuntimeCtl)
 func (fb *FacilityBuilder) FacilityName() string {
 	return "RuntimeCtl"
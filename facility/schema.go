@@ -0,0 +1,149 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package facility
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/configreload"
+	"github.com/graniticio/granitic/v2/facility/health"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/facility/logger"
+	"github.com/graniticio/granitic/v2/facility/metrics"
+	"github.com/graniticio/granitic/v2/facility/openapi"
+	"github.com/graniticio/granitic/v2/facility/querymanager"
+	"github.com/graniticio/granitic/v2/facility/ratelimit"
+	"github.com/graniticio/granitic/v2/facility/rdbms"
+	"github.com/graniticio/granitic/v2/facility/runtimectl"
+	"github.com/graniticio/granitic/v2/facility/serviceerror"
+	"github.com/graniticio/granitic/v2/facility/taskscheduler"
+	"github.com/graniticio/granitic/v2/facility/tracing"
+	"github.com/graniticio/granitic/v2/facility/ws"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"sort"
+	"strings"
+)
+
+// Values for System.ConfigValidation
+const (
+	configValidationFail = "FAIL"
+	configValidationWarn = "WARN"
+	configValidationOff  = "OFF"
+)
+
+// Builders returns a Builder for each of Granitic's built-in facilities, in the order in which they are built.
+func Builders() []Builder {
+	return []Builder{
+		new(logger.FacilityBuilder),
+		new(querymanager.FacilityBuilder),
+		new(httpserver.FacilityBuilder),
+		new(ws.JSONFacilityBuilder),
+		new(ws.XMLFacilityBuilder),
+		new(ws.NegotiatedFacilityBuilder),
+		new(serviceerror.FacilityBuilder),
+		new(rdbms.FacilityBuilder),
+		new(runtimectl.FacilityBuilder),
+		new(taskscheduler.FacilityBuilder),
+		new(ratelimit.FacilityBuilder),
+		new(openapi.FacilityBuilder),
+		new(health.FacilityBuilder),
+		new(metrics.FacilityBuilder),
+		new(tracing.FacilityBuilder),
+		new(configreload.FacilityBuilder),
+	}
+}
+
+// CheckConfig compares the merged configuration with the settings declared by Granitic's core components, by each
+// enabled facility whose Builder implements config.SchemaDeclarer and by any of the supplied components that implement
+// config.SchemaDeclarer. Returns a *config.SchemaError listing every problem found, or nil if there are none.
+func CheckConfig(ca *config.Accessor, builders []Builder, protos map[string]*ioc.ProtoComponent) error {
+
+	schemas := make(map[string]*config.Schema)
+
+	addSchemas(schemas, coreSchemas(builders))
+
+	enabled, _ := ca.ObjectVal("Facilities")
+
+	for _, fb := range builders {
+
+		if on, _ := enabled[fb.FacilityName()].(bool); !on {
+			continue
+		}
+
+		if sd, found := fb.(config.SchemaDeclarer); found {
+			addSchemas(schemas, sd.ConfigSchema())
+		}
+	}
+
+	names := make([]string, 0, len(protos))
+
+	for name := range protos {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		if sd, found := protos[name].Component.Instance.(config.SchemaDeclarer); found {
+			addSchemas(schemas, sd.ConfigSchema())
+		}
+	}
+
+	return config.CheckSchemas(ca.JSONData, schemas)
+}
+
+// addSchemas adds the declared schemas to the existing schemas, merging any that are declared for the same path
+func addSchemas(existing map[string]*config.Schema, declared map[string]*config.Schema) {
+
+	for path, s := range declared {
+		existing[path] = config.MergeSchemas(existing[path], s)
+	}
+}
+
+// coreSchemas declares the settings read while the application is starting, before facilities are built
+func coreSchemas(builders []Builder) map[string]*config.Schema {
+
+	facilities := config.NewSchema(config.ObjectValue)
+	facilities.WithField("FrameworkLogging", config.NewSchema(config.BoolValue))
+
+	for _, fb := range builders {
+		facilities.WithField(fb.FacilityName(), config.NewSchema(config.BoolValue))
+	}
+
+	schemas := config.LayersSchema()
+
+	schemas["Facilities"] = facilities
+	schemas["System"] = config.SchemaFromType(new(instance.System))
+	schemas["FrameworkLogger"] = logger.LevelsSchema()
+
+	return schemas
+}
+
+// checkConfig checks the merged configuration and, depending on System.ConfigValidation, returns or logs any problems.
+func (fi *FacilitiesInitialisor) checkConfig() error {
+
+	mode, _ := fi.ConfigAccessor.StringVal("System.ConfigValidation")
+	mode = strings.ToUpper(mode)
+
+	switch mode {
+	case configValidationOff:
+		return nil
+	case "":
+		mode = configValidationWarn
+	case configValidationFail, configValidationWarn:
+	default:
+		return fmt.Errorf("System.ConfigValidation must be %s, %s or %s", configValidationFail, configValidationWarn, configValidationOff)
+	}
+
+	err := CheckConfig(fi.ConfigAccessor, fi.facilities, fi.container.ProtoComponents())
+
+	if err != nil && mode == configValidationWarn {
+		fi.Logger.LogWarnf("%s", err.Error())
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,140 @@
+package facility
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"strings"
+	"testing"
+)
+
+func TestDefaultConfigMatchesSchemas(t *testing.T) {
+
+	ca := loadDefaultConfig(t)
+
+	if err := CheckConfig(ca, Builders(), nil); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+}
+
+func TestProblemsReportedTogether(t *testing.T) {
+
+	ca := loadDefaultConfig(t)
+
+	server := ca.JSONData["HTTPServer"].(map[string]interface{})
+	server["Prot"] = 8081
+	server["MaxConcurrent"] = "ten"
+
+	delete(ca.JSONData["ServiceErrorManager"].(map[string]interface{}), "PanicOnMissing")
+
+	err := CheckConfig(ca, Builders(), nil)
+
+	if !test.ExpectNotNil(t, err) {
+		return
+	}
+
+	se := err.(*config.SchemaError)
+
+	test.ExpectInt(t, len(se.Problems), 3)
+
+	m := err.Error()
+
+	test.ExpectBool(t, strings.Contains(m, "HTTPServer.Prot is not a recognised setting (did you mean Port?)"), true)
+	test.ExpectBool(t, strings.Contains(m, "HTTPServer.MaxConcurrent should be a whole number"), true)
+	test.ExpectBool(t, strings.Contains(m, "ServiceErrorManager.PanicOnMissing is required"), true)
+}
+
+func TestDisabledFacilityNotChecked(t *testing.T) {
+
+	ca := loadDefaultConfig(t)
+
+	ca.JSONData["Facilities"].(map[string]interface{})["HTTPServer"] = false
+	ca.JSONData["Facilities"].(map[string]interface{})["RuntimeCtl"] = false
+	ca.JSONData["HTTPServer"].(map[string]interface{})["Prot"] = 8081
+
+	// Top-level paths may belong to the application, so settings for disabled facilities are not checked
+	test.ExpectNil(t, CheckConfig(ca, Builders(), nil))
+}
+
+func TestComponentSchemasChecked(t *testing.T) {
+
+	ca := loadDefaultConfig(t)
+
+	ca.JSONData["myApp"] = map[string]interface{}{"Greeting": "Hello", "Greting": "Hi"}
+
+	protos := map[string]*ioc.ProtoComponent{
+		"greeter": ioc.CreateProtoComponent(new(greeter), "greeter"),
+	}
+
+	err := CheckConfig(ca, Builders(), protos)
+
+	test.ExpectNotNil(t, err)
+	test.ExpectInt(t, len(err.(*config.SchemaError).Problems), 1)
+	test.ExpectBool(t, strings.Contains(err.Error(), "myApp.Greting is not a recognised setting (did you mean Greeting?)"), true)
+}
+
+func TestProblemsOnlyWarnedByDefault(t *testing.T) {
+
+	ca := loadDefaultConfig(t)
+	ca.JSONData["HTTPServer"].(map[string]interface{})["Prot"] = 8081
+
+	fm := logging.CreateComponentLoggerManager(logging.Fatal, map[string]interface{}{}, []logging.LogWriter{}, nil)
+
+	fi := NewFacilitiesInitialisor(ioc.NewComponentContainer(fm, ca, new(instance.System)), fm)
+	fi.ConfigAccessor = ca
+	fi.facilities = Builders()
+
+	test.ExpectNil(t, fi.checkConfig())
+
+	delete(ca.JSONData["System"].(map[string]interface{}), "ConfigValidation")
+	test.ExpectNil(t, fi.checkConfig())
+
+	ca.JSONData["System"].(map[string]interface{})["ConfigValidation"] = "FAIL"
+	test.ExpectNotNil(t, fi.checkConfig())
+}
+
+func TestConfigLayersDisabledByDefault(t *testing.T) {
+
+	ca := loadDefaultConfig(t)
+
+	layers, err := config.LayersFromConfig(ca.JSONData)
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(layers), 0)
+}
+
+func loadDefaultConfig(t *testing.T) *config.Accessor {
+
+	files, err := config.FindJSONFilesInDir("config")
+
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	jm := config.NewJSONMergerWithDirectLogging(new(logging.ConsoleErrorLogger), new(config.JSONContentParser))
+	jm.MergeArrays = true
+
+	merged, err := jm.LoadAndMergeConfig(files)
+
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	enabled := merged["Facilities"].(map[string]interface{})
+
+	for name := range enabled {
+		enabled[name] = true
+	}
+
+	return &config.Accessor{JSONData: merged, FrameworkLogger: new(logging.ConsoleErrorLogger)}
+}
+
+type greeter struct {
+	Greeting string
+}
+
+func (g *greeter) ConfigSchema() map[string]*config.Schema {
+	return map[string]*config.Schema{"myApp": config.SchemaFromType(new(greeter))}
+}
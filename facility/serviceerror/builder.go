@@ -63,6 +63,16 @@ func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager,
 	return nil
 }
 
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (fb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := config.NewSchema(config.ObjectValue)
+	s.WithField("PanicOnMissing", config.NewSchema(config.BoolValue).Require())
+	s.WithField("ErrorDefinitions", config.NewSchema(config.StringValue).Require())
+
+	return map[string]*config.Schema{"ServiceErrorManager": s}
+}
+
 // FacilityName implements FacilityBuilder.FacilityName
 func (fb *FacilityBuilder) FacilityName() string {
 	return "ServiceErrorManager"
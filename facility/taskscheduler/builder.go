@@ -35,6 +35,11 @@ func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager,
 	return nil
 }
 
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (fb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+	return map[string]*config.Schema{facilityName: config.SchemaFromType(new(schedule.TaskScheduler))}
+}
+
 // FacilityName implements FacilityBuilder.FacilityName
 func (fb *FacilityBuilder) FacilityName() string {
 	return facilityName
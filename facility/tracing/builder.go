@@ -0,0 +1,108 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package tracing provides the Tracing facility which records each web service request as a trace made up of spans and
+propagates W3C trace context (traceparent and tracestate headers).
+
+Enabling the facility
+
+	{
+	  "Facilities": {
+		"HTTPServer": true,
+		"Tracing": true
+	  }
+	}
+
+The facility creates a component implementing instrument.RequestInstrumentationManager which is automatically used by
+the HTTPServer facility (unless HTTPServer.DisableInstrumentationAutoWire is set). Calls to instrument.Event and
+instrument.Method in your code are recorded as spans. See the top-level tracing package for more details.
+
+Exporting spans
+
+By default, the spans for each request are appended to a file as JSON objects, one per line. To send spans elsewhere,
+create a component that implements tracing.SpanExporter and set ExporterComponent to the name of your component.
+
+Configuration
+
+	{
+	  "Tracing": {
+		"ServiceName": "",
+		"ExporterComponent": "",
+		"JSONLines": {
+		  "Path": "spans.jsonl"
+		}
+	  }
+	}
+
+ServiceName is added to the root span of every request. JSONLines.Path is the file spans are written to if
+ExporterComponent is not set.
+*/
+package tracing
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/tracing"
+)
+
+const (
+	// TracerComponentName is the name of the component that records traces
+	TracerComponentName = instance.FrameworkPrefix + "Tracer"
+
+	exporterComponentName = instance.FrameworkPrefix + "SpanExporter"
+)
+
+// FacilityBuilder creates the components that make up the Tracing facility.
+type FacilityBuilder struct {
+}
+
+// BuildAndRegister implements FacilityBuilder.BuildAndRegister
+func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) error {
+
+	t := new(tracing.Tracer)
+	t.ServiceName, _ = ca.StringVal("Tracing.ServiceName")
+
+	cn.WrapAndAddProto(TracerComponentName, t)
+
+	if ec, _ := ca.StringVal("Tracing.ExporterComponent"); ec != "" {
+		cn.AddModifier(TracerComponentName, "Exporter", ec)
+
+		return nil
+	}
+
+	je := new(tracing.JSONLinesExporter)
+
+	if err := ca.Populate("Tracing.JSONLines", je); err != nil {
+		return err
+	}
+
+	t.Exporter = je
+
+	cn.WrapAndAddProto(exporterComponentName, je)
+
+	return nil
+}
+
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (fb *FacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := config.NewSchema(config.ObjectValue)
+	s.WithField("ServiceName", config.NewSchema(config.StringValue))
+	s.WithField("ExporterComponent", config.NewSchema(config.StringValue))
+	s.WithField("JSONLines", config.SchemaFromType(new(tracing.JSONLinesExporter)))
+
+	return map[string]*config.Schema{"Tracing": s}
+}
+
+// FacilityName implements FacilityBuilder.FacilityName
+func (fb *FacilityBuilder) FacilityName() string {
+	return "Tracing"
+}
+
+// DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
+func (fb *FacilityBuilder) DependsOnFacilities() []string {
+	return []string{"HTTPServer"}
+}
@@ -0,0 +1,13 @@
+package tracing
+
+import "testing"
+
+func TestFacilityNaming(t *testing.T) {
+
+	fb := new(FacilityBuilder)
+
+	if fb.FacilityName() != "Tracing" {
+		t.Errorf("Unexpected facility name %s", fb.FacilityName())
+	}
+
+}
@@ -29,6 +29,21 @@ func (fb *JSONFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManag
 
 	wc := buildAndRegisterWsCommon(lm, ca, cn)
 
+	rw, um, err := buildJSONComponents(ca, cn, wc)
+
+	if err != nil {
+		return err
+	}
+
+	buildRegisterWsDecorator(cn, rw, um, wc, lm)
+	offerAbnormalStatusWriter(rw, cn, jsonResponseWriterComponentName)
+
+	return nil
+}
+
+// buildJSONComponents creates and registers the components used to parse and render JSON
+func buildJSONComponents(ca *config.Accessor, cn *ioc.ComponentContainer, wc *wsCommon) (*ws.MarshallingResponseWriter, *json.Unmarshaller, error) {
+
 	um := new(json.Unmarshaller)
 	cn.WrapAndAddProto(jsonUnmarshallerComponentName, um)
 
@@ -39,8 +54,6 @@ func (fb *JSONFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManag
 	rw.StatusDeterminer = wc.StatusDeterminer
 	rw.FrameworkErrors = wc.FrameworkErrors
 
-	buildRegisterWsDecorator(cn, rw, um, wc, lm)
-
 	if !cn.ModifierExists(jsonResponseWriterComponentName, "ErrorFormatter") {
 		rw.ErrorFormatter = new(json.GraniticJSONErrorFormatter)
 	}
@@ -59,13 +72,13 @@ func (fb *JSONFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManag
 			default:
 				m := fmt.Sprintf("JSONWs.WrapMode must be either %s or %s", modeWrap, modeBody)
 
-				return errors.New(m)
+				return nil, nil, errors.New(m)
 			}
 
 			ca.Populate("JSONWs.ResponseWrapper", wrap)
 			rw.ResponseWrapper = wrap
 		} else {
-			return err
+			return nil, nil, err
 		}
 
 	}
@@ -77,9 +90,30 @@ func (fb *JSONFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManag
 		rw.MarshalingWriter = mw
 	}
 
-	offerAbnormalStatusWriter(rw, cn, jsonResponseWriterComponentName)
+	return rw, um, nil
+}
 
-	return nil
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (fb *JSONFacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := commonSchemas()
+	s["JSONWs"] = jsonSchema()
+
+	return s
+}
+
+// jsonSchema declares the settings read by buildJSONComponents
+func jsonSchema() *config.Schema {
+
+	wrappers := config.MergeSchemas(config.SchemaFromType(new(json.BodyOrErrorWrapper)), config.SchemaFromType(new(json.GraniticJSONResponseWrapper)))
+
+	s := config.NewSchema(config.ObjectValue)
+	s.WithField("ResponseWriter", config.SchemaFromType(new(ws.MarshallingResponseWriter)))
+	s.WithField("WrapMode", config.NewSchema(config.StringValue))
+	s.WithField("ResponseWrapper", wrappers)
+	s.WithField("Marshal", config.SchemaFromType(new(json.MarshalingWriter)))
+
+	return s
 }
 
 // FacilityName implements FacilityBuilder.FacilityName
@@ -0,0 +1,111 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/form"
+)
+
+const (
+	negotiatingResponseWriterName = instance.FrameworkPrefix + "NegotiatingResponseWriter"
+	negotiatingUnmarshallerName   = instance.FrameworkPrefix + "NegotiatingUnmarshaller"
+)
+
+// NegotiatedFacilityBuilder creates the components required to support the NegotiatedWs facility, which allows handlers
+// to accept and render both JSON and XML, and adds them the IoC container.
+type NegotiatedFacilityBuilder struct {
+}
+
+type negotiatedConfig struct {
+	DefaultMediaType string
+	JSONMediaTypes   []string
+	XMLMediaTypes    []string
+}
+
+// BuildAndRegister implements FacilityBuilder.BuildAndRegister
+func (fb *NegotiatedFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) error {
+
+	for _, f := range []string{"JSONWs", "XMLWs"} {
+		if enabled, _ := ca.BoolVal("Facilities." + f); enabled {
+			return fmt.Errorf("the NegotiatedWs facility cannot be enabled at the same time as the %s facility", f)
+		}
+	}
+
+	nc := new(negotiatedConfig)
+
+	if err := ca.Populate("NegotiatedWs", nc); err != nil {
+		return err
+	}
+
+	wc := buildAndRegisterWsCommon(lm, ca, cn)
+
+	jrw, jum, err := buildJSONComponents(ca, cn, wc)
+
+	if err != nil {
+		return err
+	}
+
+	xrw, xum, err := buildXMLComponents(ca, cn, wc)
+
+	if err != nil {
+		return err
+	}
+
+	nrw := new(ws.NegotiatingResponseWriter)
+	nrw.DefaultMediaType = nc.DefaultMediaType
+	nrw.Writers = make(map[string]ws.ResponseWriter)
+
+	num := new(ws.NegotiatingUnmarshaller)
+	num.DefaultMediaType = nc.DefaultMediaType
+	num.Unmarshallers = make(map[string]ws.Unmarshaller)
+
+	for _, mt := range nc.JSONMediaTypes {
+		nrw.Writers[mt] = jrw
+		num.Unmarshallers[mt] = jum
+	}
+
+	for _, mt := range nc.XMLMediaTypes {
+		nrw.Writers[mt] = xrw
+		num.Unmarshallers[mt] = xum
+	}
+
+	// Forms can be accepted, but responses are never rendered as forms
+	num.Unmarshallers[form.URLEncodedMediaType] = wc.FormUnmarshaller
+	num.Unmarshallers[form.MultipartMediaType] = wc.FormUnmarshaller
+
+	cn.WrapAndAddProto(negotiatingResponseWriterName, nrw)
+	cn.WrapAndAddProto(negotiatingUnmarshallerName, num)
+
+	buildRegisterWsDecorator(cn, nrw, num, wc, lm)
+	offerAbnormalStatusWriter(nrw, cn, negotiatingResponseWriterName)
+
+	return nil
+}
+
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (fb *NegotiatedFacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := commonSchemas()
+	s["NegotiatedWs"] = config.SchemaFromType(new(negotiatedConfig))
+	s["JSONWs"] = jsonSchema()
+	s["XMLWs"] = xmlSchema()
+
+	return s
+}
+
+// FacilityName implements FacilityBuilder.FacilityName
+func (fb *NegotiatedFacilityBuilder) FacilityName() string {
+	return "NegotiatedWs"
+}
+
+// DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
+func (fb *NegotiatedFacilityBuilder) DependsOnFacilities() []string {
+	return []string{}
+}
@@ -2,7 +2,7 @@
 // Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
 
 /*
-Package ws provides the JSONWs and XMLWs facilities which support JSON and XML web services.
+Package ws provides the JSONWs, XMLWs and NegotiatedWs facilities which support JSON and XML web services.
 
 This facility is documented in detail at http://granitic.io/ref/web-services
 
@@ -54,6 +54,38 @@ Refer to http://granitic.io/ref/xml-web-services for more information.
 
 Many aspects of the parsing and rendering process (including content types and formatting of errors) is configurable.
 Refer to http://granitic.io/ref/xml-web-services for more details.
+
+Content negotiation
+
+If a handler needs to accept and render both JSON and XML, enable the NegotiatedWs facility instead of JSONWs and XMLWs
+(it cannot be enabled at the same time as either of them). The components normally created by the JSONWs and XMLWs
+facilities are created (and configured using the JSONWs and XMLWs configuration) and each request is handled as follows:
+
+The request's Accept header is compared to the media types the facility supports and the most acceptable is used to
+render the response, using the ws.ResponseWrapper and ws.ErrorFormatter associated with that media type. If none of the
+supported media types are acceptable, a 406 response is sent. If the request has no Accept header, DefaultMediaType is used.
+
+If the request has a body, its Content-Type header is used to choose how it is parsed. If the Content-Type is not supported,
+a 415 response is sent. Requests without a Content-Type are assumed to be of type DefaultMediaType.
+
+The supported media types are configured with:
+
+	{
+	  "NegotiatedWs": {
+		"DefaultMediaType": "application/json",
+		"JSONMediaTypes": ["application/json"],
+		"XMLMediaTypes": ["application/xml", "text/xml"]
+	  }
+	}
+
+As XML responses may need to be rendered for any handler, you will normally want to set XMLWs.ResponseMode to MARSHAL.
+
+Forms and file uploads
+
+All of the web service facilities create a form.Unmarshaller (named grncFormUnmarshaller) that can parse URL-encoded and
+multipart form bodies. The NegotiatedWs facility uses it for requests with form Content-Types; with the JSONWs and XMLWs facilities
+it must be set as the Unmarshaller of handlers that accept forms. Limits on the size of forms and uploaded files are set
+in the FormBinding configuration - see the form package documentation for details.
 */
 package ws
 
@@ -64,6 +96,7 @@ import (
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/form"
 	"github.com/graniticio/granitic/v2/ws/handler"
 )
 
@@ -71,6 +104,7 @@ const wsHTTPStatusDeterminerComponentName = instance.FrameworkPrefix + "HTTPStat
 const wsParamBinderComponentName = instance.FrameworkPrefix + "ParamBinder"
 const wsFrameworkErrorGenerator = instance.FrameworkPrefix + "FrameworkErrorGenerator"
 const wsHandlerDecoratorName = instance.FrameworkPrefix + "WsHandlerDecorator"
+const wsFormUnmarshallerName = instance.FrameworkPrefix + "FormUnmarshaller"
 
 func offerAbnormalStatusWriter(arw ws.AbnormalStatusWriter, cc *ioc.ComponentContainer, name string) {
 
@@ -94,7 +128,16 @@ func buildAndRegisterWsCommon(lm *logging.ComponentLoggerManager, ca *config.Acc
 
 	pb.FrameworkErrors = feg
 
-	return newWsCommon(pb, feg, scd)
+	fu := new(form.Unmarshaller)
+	ca.Populate("FormBinding", fu)
+	fu.ParamBinder = pb
+	fu.FrameworkErrors = feg
+	cn.WrapAndAddProto(wsFormUnmarshallerName, fu)
+
+	wc := newWsCommon(pb, feg, scd)
+	wc.FormUnmarshaller = fu
+
+	return wc
 
 }
 
@@ -113,6 +156,7 @@ type wsCommon struct {
 	ParamBinder      *ws.ParamBinder
 	FrameworkErrors  *ws.FrameworkErrorGenerator
 	StatusDeterminer *ws.GraniticHTTPStatusCodeDeterminer
+	FormUnmarshaller *form.Unmarshaller
 }
 
 func buildRegisterWsDecorator(cc *ioc.ComponentContainer, rw ws.ResponseWriter, um ws.Unmarshaller, wc *wsCommon, lm *logging.ComponentLoggerManager) {
@@ -137,10 +181,22 @@ func (jwhd *wsHandlerDecorator) OfInterest(component *ioc.Component) bool {
 		return false
 	case *handler.WsHandler:
 		return h.AutoWireable()
+	case *handler.SSEHandler:
+		return h.AutoWireable()
 	}
 }
 
 func (jwhd *wsHandlerDecorator) DecorateComponent(component *ioc.Component, container *ioc.ComponentContainer) {
+
+	if sh, found := component.Instance.(*handler.SSEHandler); found {
+		// SSE handlers only need to be able to write error responses
+		if sh.ResponseWriter == nil {
+			sh.ResponseWriter = jwhd.ResponseWriter
+		}
+
+		return
+	}
+
 	h := component.Instance.(*handler.WsHandler)
 	l := jwhd.FrameworkLogger
 	l.LogTracef("Decorating component %s", component.Name)
@@ -163,3 +219,11 @@ func (jwhd *wsHandlerDecorator) DecorateComponent(component *ioc.Component, cont
 	}
 
 }
+
+// commonSchemas declares the settings read by buildAndRegisterWsCommon
+func commonSchemas() map[string]*config.Schema {
+	return map[string]*config.Schema{
+		"FrameworkServiceErrors": config.SchemaFromType(new(ws.FrameworkErrorGenerator)),
+		"FormBinding":            config.SchemaFromType(new(form.Unmarshaller)),
+	}
+}
@@ -29,6 +29,21 @@ func (fb *XMLFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManage
 
 	wc := buildAndRegisterWsCommon(lm, ca, cc)
 
+	rw, um, err := buildXMLComponents(ca, cc, wc)
+
+	if err != nil {
+		return err
+	}
+
+	buildRegisterWsDecorator(cc, rw, um, wc, lm)
+	offerAbnormalStatusWriter(rw.(ws.AbnormalStatusWriter), cc, xmlResponseWriterName)
+
+	return nil
+}
+
+// buildXMLComponents creates and registers the components used to parse and render XML
+func buildXMLComponents(ca *config.Accessor, cc *ioc.ComponentContainer, wc *wsCommon) (ws.ResponseWriter, *xml.Unmarshaller, error) {
+
 	um := new(xml.Unmarshaller)
 	cc.WrapAndAddProto(xmlUnmarshallerName, um)
 
@@ -38,20 +53,17 @@ func (fb *XMLFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManage
 
 	switch mode {
 	case templateMode:
-		rw = fb.createTemplateComponents(ca, cc, wc)
+		rw = createTemplateComponents(ca, cc, wc)
 	case marshalMode:
-		rw = fb.createMarshalComponents(ca, cc, wc)
+		rw = createMarshalComponents(ca, cc, wc)
 	default:
-		return errors.New("XMLWs.ResponseMode must be set to either TEMPLATE or MARSHAL")
+		return nil, nil, errors.New("XMLWs.ResponseMode must be set to either TEMPLATE or MARSHAL")
 	}
 
-	buildRegisterWsDecorator(cc, rw, um, wc, lm)
-	offerAbnormalStatusWriter(rw.(ws.AbnormalStatusWriter), cc, xmlResponseWriterName)
-
-	return nil
+	return rw, um, nil
 }
 
-func (fb *XMLFacilityBuilder) createTemplateComponents(ca *config.Accessor, cc *ioc.ComponentContainer, wc *wsCommon) ws.ResponseWriter {
+func createTemplateComponents(ca *config.Accessor, cc *ioc.ComponentContainer, wc *wsCommon) ws.ResponseWriter {
 
 	rw := new(xml.TemplatedXMLResponseWriter)
 	ca.Populate("XMLWs.ResponseWriter", rw)
@@ -64,7 +76,7 @@ func (fb *XMLFacilityBuilder) createTemplateComponents(ca *config.Accessor, cc *
 
 }
 
-func (fb *XMLFacilityBuilder) createMarshalComponents(ca *config.Accessor, cc *ioc.ComponentContainer, wc *wsCommon) ws.ResponseWriter {
+func createMarshalComponents(ca *config.Accessor, cc *ioc.ComponentContainer, wc *wsCommon) ws.ResponseWriter {
 
 	rw := new(ws.MarshallingResponseWriter)
 	ca.Populate("XMLWs.ResponseWriter", rw)
@@ -93,6 +105,32 @@ func (fb *XMLFacilityBuilder) createMarshalComponents(ca *config.Accessor, cc *i
 
 }
 
+// ConfigSchema implements config.SchemaDeclarer.ConfigSchema
+func (fb *XMLFacilityBuilder) ConfigSchema() map[string]*config.Schema {
+
+	s := commonSchemas()
+	s["XMLWs"] = xmlSchema()
+
+	return s
+}
+
+// xmlSchema declares the settings read by buildXMLComponents
+func xmlSchema() *config.Schema {
+
+	writers := config.MergeSchemas(config.SchemaFromType(new(xml.TemplatedXMLResponseWriter)), config.SchemaFromType(new(ws.MarshallingResponseWriter)))
+
+	// Present in the default configuration but not read by the response writers
+	writers.WithField("CacheTemplates", config.NewSchema(config.BoolValue))
+	writers.WithField("PreLoad", config.NewSchema(config.BoolValue))
+
+	s := config.NewSchema(config.ObjectValue)
+	s.WithField("ResponseMode", config.NewSchema(config.StringValue))
+	s.WithField("ResponseWriter", writers)
+	s.WithField("Marshal", config.SchemaFromType(new(xml.MarshalingWriter)))
+
+	return s
+}
+
 // FacilityName implements FacilityBuilder.FacilityName
 func (fb *XMLFacilityBuilder) FacilityName() string {
 	return "XMLWs"
@@ -42,7 +42,43 @@ Folders and files containing configuration are by default expected to be stored
 
 This folder can contain any number of files or sub-directories. This location can be overridden by using the -c argument
 when starting your application from the command line. This argument is expected to be a comma separated list of file paths,
-directories or HTTP URLs to JSON files or any mixture of the above.
+directories or HTTP URLs to JSON files or any mixture of the above. Files with a .yaml, .yml or .toml extension are
+parsed as YAML or TOML and merged with JSON files in the same way.
+
+Environment variables and secrets
+
+Once configuration files have been merged, environment variables can be mapped onto config paths
+(GRNC_RdbmsAccess_Default_Password sets RdbmsAccess.Default.Password) and references in string values like
+${env:DB_USER} or ${file:/run/secrets/db-password} can be replaced with the value of the environment variable or the
+contents of the file. Values set in this way are hidden when the merged configuration is logged. See
+config.EnvironmentLayer and config.ReferenceLayer for details. Both behaviours are disabled by default, so existing
+configuration containing strings like ${env:...} is not changed unexpectedly. They are enabled (and the prefix changed)
+in the ConfigLayers section of your configuration:
+
+	{
+	  "ConfigLayers": {
+	    "Environment": {
+	      "Enabled": true,
+	      "Prefix": "MYAPP_"
+	    },
+	    "References": {
+	      "Enabled": true
+	    }
+	  }
+	}
+
+Reloading configuration
+
+If the ConfigReload facility is enabled, configuration can be reloaded while your application is running (using grnc-ctl
+or by watching your configuration files for changes). Components that implement config.ConfigChangeListener are told which
+configuration paths changed. See the facility/configreload package for details.
+
+Checking configuration
+
+When your application starts, its merged configuration is checked against the settings declared by enabled facilities and
+by components implementing config.SchemaDeclarer. Misspelled settings, values of the wrong type and missing required values
+are reported together and, by default, the application exits. Run the grnc-config tool to perform the same check without
+starting your application. See the facility package for details.
 
 Command line arguments
 
@@ -67,6 +103,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/gob"
+	"encoding/json"
 	"github.com/graniticio/granitic/v2/config"
 	"github.com/graniticio/granitic/v2/facility"
 	"github.com/graniticio/granitic/v2/instance"
@@ -81,11 +118,10 @@ import (
 
 const (
 	//Version is the semantic version number for this version of Granitic
-	Version                            = "2.0.1"
-	initiatorComponentName      string = instance.FrameworkPrefix + "Init"
-	systemPath                         = "System"
-	configAccessorComponentName string = instance.FrameworkPrefix + "Accessor"
-	instanceIDDecoratorName            = instance.FrameworkPrefix + "InstanceIDDecorator"
+	Version                        = "2.0.1"
+	initiatorComponentName  string = instance.FrameworkPrefix + "Init"
+	systemPath                     = "System"
+	instanceIDDecoratorName        = instance.FrameworkPrefix + "InstanceIDDecorator"
 )
 
 // StartGranitic starts the IoC container and populates it with the supplied list of prototype components. Any settings
@@ -148,7 +184,7 @@ func (i *initiator) buildContainer(ac *ioc.ProtoComponents, is *config.InitialSe
 	l.LogInfof("Starting components")
 
 	//Merge all configuration files and create a container
-	ca := i.createConfigAccessor(is, frameworkLoggingManager)
+	ca, loader := i.createConfigAccessor(is, frameworkLoggingManager)
 
 	//Load system settings from config
 	ss := i.loadSystemsSettings(ca)
@@ -156,6 +192,7 @@ func (i *initiator) buildContainer(ac *ioc.ProtoComponents, is *config.InitialSe
 	//Create the IoC container
 	cc := ioc.NewComponentContainer(frameworkLoggingManager, ca, ss)
 	cc.AddProto(logManageProto)
+	cc.WrapAndAddProto(config.LoaderComponentName, loader)
 
 	//Assign an identity to this instance of the application
 	i.createInstanceIdentifier(is, cc)
@@ -231,8 +268,8 @@ func (i *initiator) shutdown(cc *ioc.ComponentContainer) {
 }
 
 // Merge together all of the local and remote JSON configuration files and wrap them in a *config.Accessor
-// which allows programmatic access to the merged config.
-func (i *initiator) createConfigAccessor(is *config.InitialSettings, flm *logging.ComponentLoggerManager) *config.Accessor {
+// which allows programmatic access to the merged config. The Loader is returned so that configuration can be reloaded.
+func (i *initiator) createConfigAccessor(is *config.InitialSettings, flm *logging.ComponentLoggerManager) (*config.Accessor, *config.Loader) {
 
 	builtIn := map[string]interface{}{}
 
@@ -260,24 +297,33 @@ func (i *initiator) createConfigAccessor(is *config.InitialSettings, flm *loggin
 
 	i.logConfigLocations(is.Configuration)
 
-	fl := flm.CreateLogger(configAccessorComponentName)
+	loader := new(config.Loader)
+	loader.BuiltIn = builtIn
+	loader.Sources = is.Configuration
+	loader.Parsers = is.ConfigParsers
+	loader.Layers = is.ConfigLayers
+	loader.FrameworkLoggingManager = flm
 
-	jm := config.NewJSONMergerWithManagedLogging(flm, new(config.JSONContentParser))
-
-	for _, cp := range is.ConfigParsers {
-
-		jm.RegisterContentParser(cp)
-
-	}
-
-	mergedJSON, err := jm.LoadAndMergeConfigWithBase(builtIn, is.Configuration)
+	ca, err := loader.Load()
 
 	if err != nil {
 		i.logger.LogFatalf(err.Error())
 		instance.ExitError()
 	}
 
-	return &config.Accessor{JSONData: mergedJSON, FrameworkLogger: fl}
+	i.logMergedConfig(ca)
+
+	return ca, loader
+}
+
+// Record the merged configuration, with any values set from environment variables or secret files hidden
+func (i *initiator) logMergedConfig(ca *config.Accessor) {
+	if i.logger.IsLevelEnabled(logging.Debug) {
+
+		if b, err := json.MarshalIndent(ca.RedactedJSONData(), "", "  "); err == nil {
+			i.logger.LogDebugf("Merged configuration:\n%s", b)
+		}
+	}
 }
 
 // Record the files and URLs used to create a merged configuration (in the order in which they will be merged)
@@ -0,0 +1,167 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Supported content codings for compressed responses
+const (
+	GzipEncoding    = "gzip"
+	DeflateEncoding = "deflate"
+)
+
+// ResponseCompression holds the rules that determine whether a response is compressed and how. It is shared by all requests
+// handled by a server.
+type ResponseCompression struct {
+	// The content codings the server will use, in order of preference if the caller accepts more than one equally.
+	Encodings []string
+
+	// The compression level (see compress/flate). Zero means the default level.
+	Level int
+
+	// Responses smaller than this will not be compressed.
+	MinSizeBytes int
+
+	// Responses will only be compressed if their Content-Type (ignoring any parameters) starts with one of these values.
+	ContentTypes []string
+
+	gzipPool  sync.Pool
+	flatePool sync.Pool
+}
+
+// Negotiate returns the supported content coding that is most acceptable according to the supplied Accept-Encoding
+// header, or an empty string if none of the supported codings is acceptable (RFC 7231 section 5.3.4).
+func (rc *ResponseCompression) Negotiate(acceptEncoding string) string {
+
+	if strings.TrimSpace(acceptEncoding) == "" {
+		return ""
+	}
+
+	qs := make(map[string]float64)
+
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+
+		parts := strings.Split(entry, ";")
+		coding := strings.ToLower(strings.TrimSpace(parts[0]))
+
+		q := 1.0
+
+		for _, p := range parts[1:] {
+
+			kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+
+			if len(kv) == 2 && strings.ToLower(kv[0]) == "q" {
+				if v, err := strconv.ParseFloat(kv[1], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		qs[coding] = q
+	}
+
+	best := ""
+	bestQ := 0.0
+
+	for _, e := range rc.Encodings {
+
+		q, found := qs[e]
+
+		if !found {
+			q, found = qs["*"]
+		}
+
+		if found && q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+
+	return best
+}
+
+// compressible returns true if responses of the supplied Content-Type may be compressed.
+func (rc *ResponseCompression) compressible(contentType string) bool {
+
+	if contentType == "" {
+		return false
+	}
+
+	mt := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+
+	for _, ct := range rc.ContentTypes {
+		if strings.HasPrefix(mt, ct) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compressor is the interface shared by gzip.Writer and flate.Writer
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+func (rc *ResponseCompression) level() int {
+
+	if rc.Level == 0 {
+		return flate.DefaultCompression
+	}
+
+	return rc.Level
+}
+
+// acquire returns a compressor for the supplied coding, writing to w.
+func (rc *ResponseCompression) acquire(encoding string, w io.Writer) (compressor, error) {
+
+	var pool *sync.Pool
+
+	if encoding == GzipEncoding {
+		pool = &rc.gzipPool
+	} else {
+		pool = &rc.flatePool
+	}
+
+	if c, found := pool.Get().(compressor); found {
+		c.Reset(w)
+		return c, nil
+	}
+
+	if encoding == GzipEncoding {
+		gw, err := gzip.NewWriterLevel(w, rc.level())
+
+		if err != nil {
+			return nil, err
+		}
+
+		return gw, nil
+	}
+
+	fw, err := flate.NewWriter(w, rc.level())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return fw, nil
+}
+
+// release returns a compressor to the pool so it can be reused.
+func (rc *ResponseCompression) release(encoding string, c compressor) {
+
+	if encoding == GzipEncoding {
+		rc.gzipPool.Put(c)
+	} else {
+		rc.flatePool.Put(c)
+	}
+}
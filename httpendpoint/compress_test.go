@@ -0,0 +1,129 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"github.com/graniticio/granitic/v2/test"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func compressionRules() *ResponseCompression {
+
+	rc := new(ResponseCompression)
+	rc.Encodings = []string{GzipEncoding, DeflateEncoding}
+	rc.MinSizeBytes = 100
+	rc.ContentTypes = []string{"text/", "application/json"}
+
+	return rc
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+
+	rc := compressionRules()
+
+	test.ExpectString(t, rc.Negotiate(""), "")
+	test.ExpectString(t, rc.Negotiate("gzip, deflate, br"), GzipEncoding)
+	test.ExpectString(t, rc.Negotiate("deflate"), DeflateEncoding)
+	test.ExpectString(t, rc.Negotiate("gzip;q=0.5, deflate"), DeflateEncoding)
+	test.ExpectString(t, rc.Negotiate("gzip;q=0, *"), DeflateEncoding)
+	test.ExpectString(t, rc.Negotiate("identity"), "")
+}
+
+func TestCompressedResponse(t *testing.T) {
+
+	body := strings.Repeat("{\"field\":\"value\"}", 50)
+
+	rec := httptest.NewRecorder()
+	w := NewHTTPResponseWriter(rec)
+	w.EnableCompression(compressionRules(), "gzip")
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(201)
+	w.Write([]byte(body[:50]))
+	w.Write([]byte(body[50:]))
+
+	test.ExpectNil(t, w.Close())
+
+	test.ExpectInt(t, rec.Code, 201)
+	test.ExpectString(t, rec.Header().Get("Content-Encoding"), GzipEncoding)
+	test.ExpectString(t, rec.Header().Get("Vary"), "Accept-Encoding")
+	test.ExpectString(t, w.ContentEncoding, GzipEncoding)
+
+	test.ExpectInt(t, w.UncompressedBytesServed, len(body))
+	test.ExpectInt(t, w.BytesServed, rec.Body.Len())
+	test.ExpectBool(t, w.BytesServed < w.UncompressedBytesServed, true)
+
+	r, err := gzip.NewReader(rec.Body)
+	test.ExpectNil(t, err)
+
+	b, _ := ioutil.ReadAll(r)
+	test.ExpectString(t, string(b), body)
+}
+
+func TestDeflateFlushedResponse(t *testing.T) {
+
+	rec := httptest.NewRecorder()
+	w := NewHTTPResponseWriter(rec)
+	w.EnableCompression(compressionRules(), "deflate")
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(200)
+	w.Write([]byte("small"))
+
+	// Flushing streams the response, so it is compressed even though it is below the minimum size
+	w.Flush()
+
+	test.ExpectBool(t, rec.Flushed, true)
+	test.ExpectString(t, rec.Header().Get("Content-Encoding"), DeflateEncoding)
+
+	test.ExpectNil(t, w.Close())
+
+	b, _ := ioutil.ReadAll(flate.NewReader(rec.Body))
+	test.ExpectString(t, string(b), "small")
+}
+
+func TestUncompressedResponses(t *testing.T) {
+
+	body := strings.Repeat("a", 200)
+
+	check := func(contentType, contentEncoding, acceptEncoding, written string, vary bool) {
+
+		rec := httptest.NewRecorder()
+		w := NewHTTPResponseWriter(rec)
+		w.EnableCompression(compressionRules(), acceptEncoding)
+
+		w.Header().Set("Content-Type", contentType)
+
+		if contentEncoding != "" {
+			w.Header().Set("Content-Encoding", contentEncoding)
+		}
+
+		w.Write([]byte(written))
+		test.ExpectNil(t, w.Close())
+
+		test.ExpectString(t, rec.Header().Get("Content-Encoding"), contentEncoding)
+		test.ExpectString(t, rec.Body.String(), written)
+		test.ExpectInt(t, w.BytesServed, len(written))
+		test.ExpectInt(t, w.UncompressedBytesServed, len(written))
+		test.ExpectBool(t, rec.Header().Get("Vary") != "", vary)
+		test.ExpectString(t, w.ContentEncoding, "")
+	}
+
+	// Below minimum size
+	check("text/plain", "", "gzip", "small", true)
+
+	// Type not in the allow list
+	check("image/png", "", "gzip", body, false)
+
+	// Already compressed
+	check("text/plain", "br", "gzip", body, false)
+
+	// Caller doesn't accept compression
+	check("text/plain", "", "", body, true)
+}
@@ -17,6 +17,7 @@ package httpendpoint
 import (
 	"context"
 	"net/http"
+	"time"
 )
 
 // HandlerMethods associates HTTP methods (GET, POST etc) and path-matching regular expressions with a handler.
@@ -52,6 +53,28 @@ type Provider interface {
 	AutoWireable() bool
 }
 
+// LongLivedProvider is implemented by Providers that hold connections open indefinitely (for example, streams of
+// server-sent events). An HTTP server that is preparing to stop calls CloseConnections so that these connections can be
+// ended cleanly rather than preventing the server from draining.
+type LongLivedProvider interface {
+	// CloseConnections ends any connections that are currently open and causes subsequent connections to be closed immediately.
+	CloseConnections()
+}
+
+// SuspensionExemptProvider is implemented by Providers that must continue to handle requests while the HTTP server is
+// suspended (for example, health checks that report the suspension to a load balancer).
+type SuspensionExemptProvider interface {
+	// ServeWhileSuspended returns true if this Provider should handle requests while the HTTP server is suspended.
+	ServeWhileSuspended() bool
+}
+
+// DeadlineProvider is implemented by Providers that need a different processing deadline to the HTTP server's default.
+type DeadlineProvider interface {
+	// RequestTimeout returns the maximum amount of time a request may take to process. Zero means that the server's
+	// default applies and a negative value means that requests have no deadline.
+	RequestTimeout() time.Duration
+}
+
 // RequiredVersion is a semi-structured type to allow applications flexibility in defining what a 'version' is.
 type RequiredVersion map[string]interface{}
 
@@ -14,8 +14,26 @@ type HTTPResponseWriter struct {
 	// The HTTP status code sent to the response or zero if no code yet sent.
 	Status int
 
-	// How many bytes have been sent to the response so far (excluding headers).
+	// How many bytes have been sent to the response so far (excluding headers). If the response is compressed, this is
+	// the compressed size.
 	BytesServed int
+
+	// How many bytes have been written to this writer so far (excluding headers), before any compression. If the
+	// response is not compressed, this is the same as BytesServed.
+	UncompressedBytesServed int
+
+	// The content coding (gzip or deflate) applied to the response, or an empty string if the response is not compressed.
+	ContentEncoding string
+
+	// Whether or not the deadline for processing the request passed before the response was complete.
+	TimedOut bool
+
+	compression *ResponseCompression
+	encoding    string
+	pending     bool
+	buffer      []byte
+	compressor  compressor
+	closed      bool
 }
 
 // Header calls through to http.ResponseWriter.Header()
@@ -23,12 +41,36 @@ func (w *HTTPResponseWriter) Header() http.Header {
 	return w.rw.Header()
 }
 
-// Write calls through to http.ResponseWriter.Write while keeping track of the number of bytes sent.
+// Write calls through to http.ResponseWriter.Write while keeping track of the number of bytes sent. If compression
+// is enabled, data is buffered until enough has been written to decide whether or not the response should be compressed.
 func (w *HTTPResponseWriter) Write(b []byte) (int, error) {
 
-	w.BytesServed += len(b)
+	w.UncompressedBytesServed += len(b)
 	w.DataSent = true
 
+	if w.pending {
+
+		if w.Status == 0 {
+			w.Status = http.StatusOK
+		}
+
+		w.buffer = append(w.buffer, b...)
+
+		if len(w.buffer) >= w.compression.MinSizeBytes {
+			if err := w.decide(true); err != nil {
+				return 0, err
+			}
+		}
+
+		return len(b), nil
+	}
+
+	if w.compressor != nil {
+		return w.compressor.Write(b)
+	}
+
+	w.BytesServed += len(b)
+
 	return w.rw.Write(b)
 }
 
@@ -41,8 +83,143 @@ func (w *HTTPResponseWriter) WriteHeader(i int) {
 	}
 
 	w.Status = i
-	w.rw.WriteHeader(i)
 	w.DataSent = true
+
+	if w.pending {
+		// Headers can't be sent until it is known whether the response will be compressed
+		return
+	}
+
+	w.rw.WriteHeader(i)
+}
+
+// Flush sends any buffered data to the client if the underlying http.ResponseWriter implements http.Flusher. If compression
+// is enabled and it has not yet been decided whether or not to compress the response, the response is compressed
+// (regardless of the minimum size) if it is of a compressible type. Implements http.Flusher
+func (w *HTTPResponseWriter) Flush() {
+
+	if w.pending {
+		w.decide(true)
+	}
+
+	if w.compressor != nil {
+		w.compressor.Flush()
+	}
+
+	if f, found := w.rw.(http.Flusher); found {
+		w.DataSent = true
+		f.Flush()
+	}
+}
+
+// EnableCompression allows the response to be compressed using the content coding that best matches the supplied
+// Accept-Encoding header, according to the rules in the supplied ResponseCompression. Must be called before
+// any data is written. If compression is enabled, Close must be called once the response has been written.
+func (w *HTTPResponseWriter) EnableCompression(rc *ResponseCompression, acceptEncoding string) {
+
+	if w.DataSent || rc == nil {
+		return
+	}
+
+	w.compression = rc
+	w.encoding = rc.Negotiate(acceptEncoding)
+	w.pending = true
+}
+
+// Close writes any data that is still buffered or held by a compressor to the underlying http.ResponseWriter. Has no
+// effect if compression has not been enabled.
+func (w *HTTPResponseWriter) Close() error {
+
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	if w.pending {
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+
+	if w.compressor == nil {
+		return nil
+	}
+
+	err := w.compressor.Close()
+
+	w.compression.release(w.encoding, w.compressor)
+	w.compressor = nil
+
+	return err
+}
+
+// decide chooses whether or not to compress the response, sends the headers and writes any buffered data. If ignoreMinimum
+// is false, responses smaller than the minimum size are not compressed.
+func (w *HTTPResponseWriter) decide(ignoreMinimum bool) error {
+
+	w.pending = false
+
+	h := w.rw.Header()
+
+	eligible := h.Get("Content-Encoding") == "" && w.compression.compressible(h.Get("Content-Type")) && bodyAllowed(w.Status)
+
+	if eligible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+
+	if eligible && w.encoding != "" && (ignoreMinimum || len(w.buffer) >= w.compression.MinSizeBytes) {
+
+		c, err := w.compression.acquire(w.encoding, &wireWriter{w})
+
+		if err != nil {
+			return err
+		}
+
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+
+		w.compressor = c
+		w.ContentEncoding = w.encoding
+	}
+
+	if w.Status != 0 {
+		w.rw.WriteHeader(w.Status)
+	}
+
+	b := w.buffer
+	w.buffer = nil
+
+	if len(b) == 0 {
+		return nil
+	}
+
+	if w.compressor != nil {
+		_, err := w.compressor.Write(b)
+		return err
+	}
+
+	w.BytesServed += len(b)
+	_, err := w.rw.Write(b)
+
+	return err
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// wireWriter writes compressed data to the underlying http.ResponseWriter, recording the number of bytes sent.
+type wireWriter struct {
+	w *HTTPResponseWriter
+}
+
+func (ww *wireWriter) Write(b []byte) (int, error) {
+
+	n, err := ww.w.rw.Write(b)
+	ww.w.BytesServed += n
+
+	return n, err
 }
 
 // NewHTTPResponseWriter creates a new HTTPResponseWriter wrapping the supplied http.ResponseWriter
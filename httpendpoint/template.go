@@ -0,0 +1,189 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Types that can be declared for a path template parameter, e.g. /record/{id:int}
+const (
+	// StringParam matches any non-empty path segment. Used if no type is declared.
+	StringParam = "string"
+	// IntParam matches a path segment consisting of an optionally signed integer.
+	IntParam = "int"
+	// FloatParam matches a path segment consisting of an optionally signed decimal number.
+	FloatParam = "float"
+)
+
+var templateParamPatterns = map[string]string{
+	StringParam: "[^/]+",
+	IntParam:    "[-+]?[0-9]+",
+	FloatParam:  "[-+]?(?:[0-9]+\\.?[0-9]*|\\.[0-9]+)",
+}
+
+var templateParamMatchers = map[string]*regexp.Regexp{}
+
+func init() {
+	for t, p := range templateParamPatterns {
+		templateParamMatchers[t] = regexp.MustCompile("^" + p + "$")
+	}
+}
+
+// TemplatedProvider is implemented by Providers that want to be matched to requests using a path template
+// (e.g. /record/{id:int}) rather than the regular expression returned by RegexPattern.
+type TemplatedProvider interface {
+	// TemplatePattern returns a path template or an empty string if the Provider should be matched using its regular expression.
+	TemplatePattern() string
+}
+
+// TemplateSegment is one slash-delimited part of a PathTemplate. A segment is either static text or a named, typed parameter.
+type TemplateSegment struct {
+	// The text of a static segment. Empty if this segment is a parameter.
+	Static string
+
+	// The name of the parameter. Empty if this segment is static.
+	Param string
+
+	// The declared type of the parameter (StringParam, IntParam or FloatParam).
+	Type string
+}
+
+// IsParam returns true if this segment is a parameter rather than static text.
+func (ts TemplateSegment) IsParam() bool {
+	return ts.Param != ""
+}
+
+// Matches returns true if the supplied path segment can be matched by this template segment.
+func (ts TemplateSegment) Matches(segment string) bool {
+
+	if !ts.IsParam() {
+		return ts.Static == segment
+	}
+
+	return templateParamMatchers[ts.Type].MatchString(segment)
+}
+
+// PathTemplate is the parsed form of a path template like /artist/{id:int}/album/{title}. Each parameter must
+// occupy a whole path segment. Request paths matching the template may optionally have a trailing slash.
+type PathTemplate struct {
+	// The unparsed template.
+	Template string
+
+	// The parsed segments of the template.
+	Segments []TemplateSegment
+}
+
+// ParamNames returns the names of the template's parameters in the order they appear in the template.
+func (pt *PathTemplate) ParamNames() []string {
+
+	n := make([]string, 0)
+
+	for _, s := range pt.Segments {
+		if s.IsParam() {
+			n = append(n, s.Param)
+		}
+	}
+
+	return n
+}
+
+// RegexPattern returns a regular expression equivalent to this template, with one capturing group per parameter.
+func (pt *PathTemplate) RegexPattern() string {
+
+	var b bytes.Buffer
+
+	b.WriteString("^")
+
+	for _, s := range pt.Segments {
+		b.WriteString("/")
+
+		if s.IsParam() {
+			b.WriteString("(")
+			b.WriteString(templateParamPatterns[s.Type])
+			b.WriteString(")")
+		} else {
+			b.WriteString(regexp.QuoteMeta(s.Static))
+		}
+	}
+
+	b.WriteString("[/]?$")
+
+	return b.String()
+}
+
+// SplitPath breaks a request path into segments in the same way that templates are broken into segments.
+func SplitPath(path string) []string {
+
+	path = strings.TrimPrefix(path, "/")
+	path = strings.TrimSuffix(path, "/")
+
+	if path == "" {
+		return []string{}
+	}
+
+	return strings.Split(path, "/")
+}
+
+// ParsePathTemplate converts a template like /record/{id:int} into a PathTemplate. Parameters are declared as {name}
+// or {name:type} where type is one of string, int or float. An error is returned if the template is malformed, a
+// parameter does not occupy a whole segment, a type is not supported or a parameter name is used more than once.
+func ParsePathTemplate(template string) (*PathTemplate, error) {
+
+	if !strings.HasPrefix(template, "/") {
+		return nil, fmt.Errorf("path template %s must start with /", template)
+	}
+
+	pt := new(PathTemplate)
+	pt.Template = template
+	pt.Segments = make([]TemplateSegment, 0)
+
+	seen := make(map[string]bool)
+
+	for _, seg := range SplitPath(template) {
+
+		if seg == "" {
+			return nil, fmt.Errorf("path template %s contains an empty segment", template)
+		}
+
+		if !strings.ContainsAny(seg, "{}") {
+			pt.Segments = append(pt.Segments, TemplateSegment{Static: seg})
+			continue
+		}
+
+		if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") || strings.Count(seg, "{") != 1 || strings.Count(seg, "}") != 1 {
+			return nil, fmt.Errorf("path template %s has a parameter (%s) that does not occupy a whole segment", template, seg)
+		}
+
+		decl := seg[1 : len(seg)-1]
+		name := decl
+		paramType := StringParam
+
+		if i := strings.Index(decl, ":"); i >= 0 {
+			name = decl[:i]
+			paramType = decl[i+1:]
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("path template %s has a parameter with no name", template)
+		}
+
+		if _, found := templateParamPatterns[paramType]; !found {
+			return nil, fmt.Errorf("path template %s has a parameter %s with unsupported type %s (supported types are %s, %s and %s)", template, name, paramType, StringParam, IntParam, FloatParam)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("path template %s uses the parameter name %s more than once", template, name)
+		}
+
+		seen[name] = true
+
+		pt.Segments = append(pt.Segments, TemplateSegment{Param: name, Type: paramType})
+	}
+
+	return pt, nil
+}
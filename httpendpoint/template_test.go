@@ -0,0 +1,50 @@
+package httpendpoint
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParsePathTemplate(t *testing.T) {
+
+	pt, err := ParsePathTemplate("/artist/{id:int}/album/{title}")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Join(pt.ParamNames(), ","); n != "id,title" {
+		t.Fatalf("Unexpected param names %s", n)
+	}
+
+	re := regexp.MustCompile(pt.RegexPattern())
+
+	m := re.FindStringSubmatch("/artist/12/album/blue/")
+
+	if len(m) != 3 || m[1] != "12" || m[2] != "blue" {
+		t.Fatalf("Unexpected match %v", m)
+	}
+
+	if re.MatchString("/artist/abc/album/blue") {
+		t.Fatalf("Non-integer matched int parameter")
+	}
+}
+
+func TestInvalidPathTemplates(t *testing.T) {
+
+	invalid := []string{
+		"artist/{id}",
+		"/artist/{id:bool}",
+		"/artist/{id}.json",
+		"/artist/{}",
+		"/artist/{id}/{id}",
+		"/artist//{id}",
+	}
+
+	for _, i := range invalid {
+		if _, err := ParsePathTemplate(i); err == nil {
+			t.Errorf("Expected %s to be rejected", i)
+		}
+	}
+}
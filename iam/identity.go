@@ -19,9 +19,12 @@ See also
 */
 package iam
 
+import "crypto/x509"
+
 const authenticated = "Authenticated"
 const anonymous = "Anonymous"
 const loggableUserID = "LoggableUserID"
+const clientCertificate = "ClientCertificate"
 
 // NewAuthenticatedIdentity creates a new ClientIdentity with the supplied log-friendly version of a user ID. The ClientIdentity will be marked
 // as Authenticated and not anonymous
@@ -92,3 +95,21 @@ func (ci ClientIdentity) LoggableUserID() string {
 
 	return a.(string)
 }
+
+// SetClientCertificate records the verified X.509 certificate that the caller presented when establishing a mutual TLS connection.
+func (ci ClientIdentity) SetClientCertificate(c *x509.Certificate) {
+	ci[clientCertificate] = c
+}
+
+// ClientCertificate returns the verified X.509 certificate that the caller presented when establishing a mutual TLS
+// connection or nil if no certificate was recorded.
+func (ci ClientIdentity) ClientCertificate() *x509.Certificate {
+
+	c := ci[clientCertificate]
+
+	if c == nil {
+		return nil
+	}
+
+	return c.(*x509.Certificate)
+}
@@ -66,6 +66,10 @@ type System struct {
 
 	//How many times a stoppable component can declare it is not ready to stop before a warning message is logged
 	StopTriesBeforeWarn int
+
+	//What happens if configuration does not match the settings declared by facilities and components: FAIL (the application
+	//exits), WARN (the problems are logged - the default) or OFF (configuration is not checked).
+	ConfigValidation string
 }
 
 // IDComponent is the name of the component in the IoC container holding an instance ID.
@@ -0,0 +1,104 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package instrument
+
+import (
+	"context"
+	"net/http"
+)
+
+// Combine returns a RequestInstrumentationManager that begins instrumentation with each of the supplied managers in
+// turn. The Instrumentor stored in the context (and returned by Begin) passes every call on to the Instrumentors
+// created by each of the managers.
+func Combine(managers ...RequestInstrumentationManager) RequestInstrumentationManager {
+	return &combinedManager{managers: managers}
+}
+
+type combinedManager struct {
+	managers []RequestInstrumentationManager
+}
+
+// Begin implements RequestInstrumentationManager.Begin
+func (cm *combinedManager) Begin(ctx context.Context, res http.ResponseWriter, req *http.Request) (context.Context, Instrumentor, func()) {
+
+	ci := new(combinedInstrumentor)
+	ends := make([]func(), len(cm.managers))
+
+	for i, m := range cm.managers {
+
+		var ri Instrumentor
+
+		ctx, ri, ends[i] = m.Begin(ctx, res, req)
+
+		ci.instrumentors = append(ci.instrumentors, ri)
+	}
+
+	end := func() {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i]()
+		}
+	}
+
+	return AddInstrumentorToContext(ctx, ci), ci, end
+}
+
+// combinedInstrumentor passes calls on to a number of other Instrumentors
+type combinedInstrumentor struct {
+	instrumentors []Instrumentor
+}
+
+// StartEvent implements Instrumentor.StartEvent
+func (ci *combinedInstrumentor) StartEvent(id string, metadata ...interface{}) EndEvent {
+
+	ends := make([]EndEvent, len(ci.instrumentors))
+
+	for i, ri := range ci.instrumentors {
+		ends[i] = ri.StartEvent(id, metadata...)
+	}
+
+	return func() {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i]()
+		}
+	}
+}
+
+// Fork implements Instrumentor.Fork
+func (ci *combinedInstrumentor) Fork(ctx context.Context) (context.Context, Instrumentor) {
+
+	forked := new(combinedInstrumentor)
+
+	for _, ri := range ci.instrumentors {
+
+		var fi Instrumentor
+
+		ctx, fi = ri.Fork(ctx)
+
+		forked.instrumentors = append(forked.instrumentors, fi)
+	}
+
+	return AddInstrumentorToContext(ctx, forked), forked
+}
+
+// Integrate implements Instrumentor.Integrate
+func (ci *combinedInstrumentor) Integrate(instrumentor Instrumentor) {
+
+	forked, found := instrumentor.(*combinedInstrumentor)
+
+	if !found || len(forked.instrumentors) != len(ci.instrumentors) {
+		return
+	}
+
+	for i, ri := range ci.instrumentors {
+		ri.Integrate(forked.instrumentors[i])
+	}
+}
+
+// Amend implements Instrumentor.Amend
+func (ci *combinedInstrumentor) Amend(additional Additional, value interface{}) {
+
+	for _, ri := range ci.instrumentors {
+		ri.Amend(additional, value)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package instrument
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recordingManager struct {
+	name   string
+	calls  *[]string
+	amends int
+}
+
+func (rm *recordingManager) Begin(ctx context.Context, res http.ResponseWriter, req *http.Request) (context.Context, Instrumentor, func()) {
+	*rm.calls = append(*rm.calls, "begin "+rm.name)
+
+	return ctx, &recordingInstrumentor{rm}, func() { *rm.calls = append(*rm.calls, "end "+rm.name) }
+}
+
+type recordingInstrumentor struct {
+	rm *recordingManager
+}
+
+func (ri *recordingInstrumentor) StartEvent(id string, metadata ...interface{}) EndEvent {
+	*ri.rm.calls = append(*ri.rm.calls, "start "+ri.rm.name+" "+id)
+
+	return func() {}
+}
+
+func (ri *recordingInstrumentor) Fork(ctx context.Context) (context.Context, Instrumentor) {
+	return ctx, ri
+}
+
+func (ri *recordingInstrumentor) Integrate(instrumentor Instrumentor) {
+}
+
+func (ri *recordingInstrumentor) Amend(additional Additional, value interface{}) {
+	ri.rm.amends++
+}
+
+func TestCombinedManagers(t *testing.T) {
+
+	var calls []string
+
+	a := &recordingManager{name: "a", calls: &calls}
+	b := &recordingManager{name: "b", calls: &calls}
+
+	cm := Combine(a, b)
+
+	ctx, ri, end := cm.Begin(context.Background(), httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	test.ExpectBool(t, InstrumentorFromContext(ctx) == ri, true)
+
+	ri.Amend(RequestID, "id")
+
+	Event(ctx, "ev")()
+
+	fCtx, fi := ri.Fork(ctx)
+	test.ExpectBool(t, InstrumentorFromContext(fCtx) == fi, true)
+	ri.Integrate(fi)
+
+	end()
+
+	test.ExpectInt(t, a.amends, 1)
+	test.ExpectInt(t, b.amends, 1)
+
+	expected := []string{"begin a", "begin b", "start a ev", "start b ev", "end b", "end a"}
+
+	test.ExpectInt(t, len(calls), len(expected))
+
+	for i, c := range expected {
+		test.ExpectString(t, calls[i], c)
+	}
+}
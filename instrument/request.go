@@ -31,5 +31,8 @@ type RequestInstrumentationManager interface {
 	// Begin starts instrumentation and returns a Instrumentor that is able to instrument sub/child events of the request.
 	// It is expected that most implementation will also store the Instrumentor in the context so it can be easily recovered
 	// at any point in the request using the function InstrumentorFromContext.
+	//
+	// When called by Granitic's HTTPServer, res is an *httpendpoint.HTTPResponseWriter, so implementations can find the
+	// status code and size of the response when the returned function is called.
 	Begin(ctx context.Context, res http.ResponseWriter, req *http.Request) (context.Context, Instrumentor, func())
 }
@@ -4,6 +4,7 @@
 package ioc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/graniticio/granitic/v2/instance"
@@ -90,6 +91,18 @@ type AccessibilityBlocker interface {
 	BlockAccess() (bool, error)
 }
 
+/*
+HealthChecker is implemented by components that are able to report whether or not they are currently healthy enough
+for the application to receive requests. Unlike AccessibilityBlocker, which is only consulted while the application
+is starting, CheckHealth may be called at any time while the application is running (for example, each time a load
+balancer checks whether the application is ready).
+*/
+type HealthChecker interface {
+	// CheckHealth returns nil if the component is healthy or an error describing the problem. Implementations should
+	// return promptly and respect the deadline of the supplied context.
+	CheckHealth(ctx context.Context) error
+}
+
 /*
 Accessible is implemented by components that require a final phase of initialisation to make themselves outside of the application.
 Typically implemented by HTTP servers and message queue listeners to start listening on TCP ports.
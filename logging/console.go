@@ -4,6 +4,7 @@
 package logging
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"runtime"
@@ -13,6 +14,7 @@ import (
 // Messages at all other levels are ignored. This implementation is used by Granitic's command line tools and is not
 // recommended for use in user applications but can by useful for unit tests.
 type ConsoleErrorLogger struct {
+	fields []field
 }
 
 // LogTracefCtx is ignored - messages sent to this method are discarded.
@@ -37,7 +39,7 @@ func (l *ConsoleErrorLogger) LogWarnfCtx(ctx context.Context, s string, a ...int
 
 // LogErrorfCtx uses fmt.printf to write the supplied message to the console.
 func (l *ConsoleErrorLogger) LogErrorfCtx(ctx context.Context, format string, a ...interface{}) {
-	fmt.Printf(format+"\n", a...)
+	l.LogErrorf(format, a...)
 }
 
 // LogErrorfCtxWithTrace uses fmt.printf to write the supplied message to the console and appends a stack trace.
@@ -79,7 +81,56 @@ func (l *ConsoleErrorLogger) LogWarnf(format string, a ...interface{}) {
 
 // LogErrorf uses fmt.printf to write the supplied message to the console.
 func (l *ConsoleErrorLogger) LogErrorf(format string, a ...interface{}) {
-	fmt.Printf(format+"\n", a...)
+
+	if len(l.fields) == 0 {
+		fmt.Printf(format+"\n", a...)
+		return
+	}
+
+	l.print(fmt.Sprintf(format, a...), nil)
+}
+
+// print writes the message followed by this Logger's fields and the supplied key/value pairs
+func (l *ConsoleErrorLogger) print(message string, kv []interface{}) {
+
+	var b bytes.Buffer
+
+	b.WriteString(message)
+	writeTextFields(&b, l.fields)
+	writeTextFields(&b, toFields(kv))
+
+	fmt.Println(b.String())
+}
+
+// With returns a ConsoleErrorLogger that adds the supplied key/value pairs to every message it writes.
+func (l *ConsoleErrorLogger) With(kv ...interface{}) Logger {
+	return &ConsoleErrorLogger{fields: append(append([]field{}, l.fields...), toFields(kv)...)}
+}
+
+// LogTraceKV is ignored - messages sent to this method are discarded.
+func (l *ConsoleErrorLogger) LogTraceKV(ctx context.Context, message string, kv ...interface{}) {
+}
+
+// LogDebugKV is ignored - messages sent to this method are discarded.
+func (l *ConsoleErrorLogger) LogDebugKV(ctx context.Context, message string, kv ...interface{}) {
+}
+
+// LogInfoKV is ignored - messages sent to this method are discarded.
+func (l *ConsoleErrorLogger) LogInfoKV(ctx context.Context, message string, kv ...interface{}) {
+}
+
+// LogWarnKV is ignored - messages sent to this method are discarded.
+func (l *ConsoleErrorLogger) LogWarnKV(ctx context.Context, message string, kv ...interface{}) {
+}
+
+// LogErrorKV writes the supplied message and key/value pairs to the console.
+func (l *ConsoleErrorLogger) LogErrorKV(ctx context.Context, message string, kv ...interface{}) {
+	l.print(message, kv)
+}
+
+// LogFatalKV writes the supplied message and key/value pairs to the console.
+func (l *ConsoleErrorLogger) LogFatalKV(ctx context.Context, message string, kv ...interface{}) {
+	l.print(message, kv)
 }
 
 // LogErrorfWithTrace uses fmt.printf to write the supplied message to the console and appends
@@ -0,0 +1,110 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The value used when a key is supplied without a value
+const missingValue = "(MISSING)"
+
+// field is a single key/value pair attached to a log message
+type field struct {
+	key   string
+	value interface{}
+}
+
+// toFields converts a list of alternating keys and values into fields. Keys that are not strings are converted to
+// strings. A key without a value is given the value (MISSING).
+func toFields(kv []interface{}) []field {
+
+	if len(kv) == 0 {
+		return nil
+	}
+
+	fields := make([]field, 0, (len(kv)+1)/2)
+
+	for i := 0; i < len(kv); i += 2 {
+
+		k, found := kv[i].(string)
+
+		if !found {
+			k = fmt.Sprint(kv[i])
+		}
+
+		var v interface{} = missingValue
+
+		if i+1 < len(kv) {
+			v = kv[i+1]
+		}
+
+		fields = append(fields, field{key: k, value: v})
+	}
+
+	return fields
+}
+
+// writeTextFields writes fields in the form  k1=v1 k2="v 2". Values containing spaces, quotes, equals signs or
+// control characters are quoted.
+func writeTextFields(b *bytes.Buffer, fields []field) {
+
+	for _, f := range fields {
+		b.WriteString(" ")
+		b.WriteString(f.key)
+		b.WriteString("=")
+		b.WriteString(quoteIfNeeded(textValue(f.value)))
+	}
+}
+
+func textValue(v interface{}) string {
+
+	switch t := v.(type) {
+	case string:
+		return t
+	case error:
+		return t.Error()
+	case fmt.Stringer:
+		return t.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func quoteIfNeeded(s string) string {
+
+	if s == "" {
+		return `""`
+	}
+
+	if strings.IndexFunc(s, func(r rune) bool { return r <= ' ' || r == '"' || r == '=' || r == 0x7f }) >= 0 {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+// jsonValue converts a field's value to JSON. Errors and values that cannot be marshalled are written as strings.
+func jsonValue(v interface{}) []byte {
+
+	switch t := v.(type) {
+	case error:
+		v = t.Error()
+	case json.Marshaler:
+	case fmt.Stringer:
+		v = t.String()
+	}
+
+	j, err := json.Marshal(v)
+
+	if err != nil {
+		j, _ = json.Marshal(fmt.Sprint(v))
+	}
+
+	return j
+}
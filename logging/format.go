@@ -6,10 +6,14 @@ package logging
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -25,6 +29,19 @@ const percent = "%"
 
 const unsupported = "???"
 
+const (
+	// TextMode is the value of LogMessageFormatter.Mode that causes messages to be written as text with a prefix.
+	TextMode = "TEXT"
+
+	// JSONMode is the value of LogMessageFormatter.Mode that causes each message to be written as a JSON object on a
+	// single line.
+	JSONMode = "JSON"
+)
+
+// RequestIDContextValue is the name under which the ID of the current request can be found in a Context. It is available
+// to log line prefixes as %{requestID}X and is included in JSON log entries.
+const RequestIDContextValue = "requestID"
+
 type prefixFormatPlaceHolder int
 
 const (
@@ -118,10 +135,70 @@ type LogMessageFormatter struct {
 
 	// The symbol to use in place of an unset variable in a log line prefix.
 	Unset string
+
+	// Either TextMode (the default) or JSONMode.
+	Mode string
+
+	// Controls how entries are written when Mode is JSONMode. Default field names are used if nil.
+	JSON *JSONFormat
+
+	jsonMode bool
+}
+
+// JSONFormat controls the names of the fields written when a LogMessageFormatter is in JSONMode. Empty field names
+// are replaced with defaults when the LogMessageFormatter is initialised.
+type JSONFormat struct {
+	// The name of the field holding the time the message was logged (default 'timestamp')
+	TimestampField string
+
+	// The Go time layout used to format the timestamp (default time.RFC3339Nano)
+	TimeFormat string
+
+	// The name of the field holding the level of the message (default 'level')
+	LevelField string
+
+	// The name of the field holding the name of the component that logged the message (default 'logger')
+	LoggerField string
+
+	// The name of the field holding the message (default 'message')
+	MessageField string
+
+	// The name of the field holding the ID of the current request, if present in the Context (default 'requestID')
+	RequestIDField string
+
+	// The name of the field holding the stack trace of messages logged with a trace (default 'stack')
+	StackField string
+
+	// Additional values to include from the Context, as a map of field name to the name of the value in the
+	// Context (as used by the %{name}X prefix placeholder). Values not present in the Context are omitted.
+	ContextFields map[string]string
+}
+
+func (jf *JSONFormat) applyDefaults() {
+
+	def := func(f *string, v string) {
+		if *f == "" {
+			*f = v
+		}
+	}
+
+	def(&jf.TimestampField, "timestamp")
+	def(&jf.TimeFormat, time.RFC3339Nano)
+	def(&jf.LevelField, "level")
+	def(&jf.LoggerField, "logger")
+	def(&jf.MessageField, "message")
+	def(&jf.RequestIDField, "requestID")
+	def(&jf.StackField, "stack")
 }
 
-// Format takes the message and prefixes it according the the rule specified in PrefixFormat or PrefixPreset
+// Format takes the message and prefixes it according the the rule specified in PrefixFormat or PrefixPreset. If
+// Mode is JSONMode, the message is instead formatted as a JSON object.
 func (lmf *LogMessageFormatter) Format(ctx context.Context, levelLabel, loggerName, message string) string {
+	return lmf.format(ctx, levelLabel, loggerName, message, nil, nil)
+}
+
+// format formats a message that may be accompanied by a stack trace and key/value fields
+func (lmf *LogMessageFormatter) format(ctx context.Context, levelLabel, loggerName, message string, trace []byte, fields []field) string {
 	var b bytes.Buffer
 	var t time.Time
 
@@ -135,6 +212,10 @@ func (lmf *LogMessageFormatter) Format(ctx context.Context, levelLabel, loggerNa
 		t = time.Now()
 	}
 
+	if lmf.jsonMode {
+		return lmf.formatJSON(ctx, levelLabel, loggerName, message, trace, fields, &t)
+	}
+
 	for _, e := range lmf.elements {
 
 		switch e.elementType {
@@ -148,11 +229,98 @@ func (lmf *LogMessageFormatter) Format(ctx context.Context, levelLabel, loggerNa
 	}
 
 	b.WriteString(message)
+	writeTextFields(&b, fields)
 	b.WriteString("\n")
 
+	if len(trace) > 0 {
+		b.Write(trace)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// formatJSON writes the message as a JSON object on a single line. Fields are written in a fixed order so that log
+// entries are easy to read. Key/value fields are written as native JSON values after any Context values. A key/value
+// field with the same name as a field already written has its name prefixed with 'fields.'
+func (lmf *LogMessageFormatter) formatJSON(ctx context.Context, levelLabel, loggerName, message string, trace []byte, fields []field, loggedAt *time.Time) string {
+
+	jf := lmf.JSON
+
+	var b bytes.Buffer
+
+	b.WriteString("{")
+
+	written := map[string]bool{jf.TimestampField: true, jf.LevelField: true, jf.LoggerField: true, jf.MessageField: true, jf.StackField: true}
+
+	writeJSONField(&b, jf.TimestampField, loggedAt.Format(jf.TimeFormat), true)
+	writeJSONField(&b, jf.LevelField, levelLabel, false)
+	writeJSONField(&b, jf.LoggerField, loggerName, false)
+	writeJSONField(&b, jf.MessageField, message, false)
+
+	if id, found := contextValue(ctx, RequestIDContextValue); found {
+		writeJSONField(&b, jf.RequestIDField, id, false)
+		written[jf.RequestIDField] = true
+	}
+
+	if len(jf.ContextFields) > 0 {
+
+		names := make([]string, 0, len(jf.ContextFields))
+
+		for n := range jf.ContextFields {
+			names = append(names, n)
+		}
+
+		sort.Strings(names)
+
+		for _, n := range names {
+			if v, found := contextValue(ctx, jf.ContextFields[n]); found {
+				writeJSONField(&b, n, v, false)
+				written[n] = true
+			}
+		}
+	}
+
+	for _, f := range fields {
+
+		k := f.key
+
+		if written[k] {
+			k = "fields." + k
+		}
+
+		b.WriteString(",")
+		writeJSONString(&b, k)
+		b.WriteString(":")
+		b.Write(jsonValue(f.value))
+	}
+
+	if len(trace) > 0 {
+		writeJSONField(&b, jf.StackField, string(trace), false)
+	}
+
+	b.WriteString("}\n")
+
 	return b.String()
 }
 
+func writeJSONField(b *bytes.Buffer, name, value string, first bool) {
+
+	if !first {
+		b.WriteString(",")
+	}
+
+	writeJSONString(b, name)
+	b.WriteString(":")
+	writeJSONString(b, value)
+}
+
+func writeJSONString(b *bytes.Buffer, s string) {
+	// Marshalling a string cannot fail
+	j, _ := json.Marshal(s)
+	b.Write(j)
+}
+
 func (lmf *LogMessageFormatter) findValueWithVar(ctx context.Context, element *prefixElement, levelLabel, loggerName string, loggedAt *time.Time) string {
 	switch element.placeholderType {
 	case logTimePH:
@@ -169,15 +337,49 @@ func (lmf *LogMessageFormatter) findValueWithVar(ctx context.Context, element *p
 
 func (lmf *LogMessageFormatter) ctxValue(ctx context.Context, key string) string {
 
-	var v interface{}
+	if s, found := contextValue(ctx, key); found {
+		return s
+	}
 
-	if v = ctx.Value(key); v != nil {
+	return lmf.Unset
+}
 
-		return fmt.Sprintf("%v", v)
+// contextValue finds a value stored in the Context under a string key or, failing that, a value extracted by a
+// function registered with RegisterContextValue
+func contextValue(ctx context.Context, key string) (string, bool) {
 
+	if v := ctx.Value(key); v != nil {
+		return fmt.Sprintf("%v", v), true
 	}
 
-	return lmf.Unset
+	if f := registeredContextValue(key); f != nil {
+		return f(ctx)
+	}
+
+	return "", false
+}
+
+// ContextValueFunc extracts a value from a Context, returning false if the value is not present in the Context.
+type ContextValueFunc func(ctx context.Context) (string, bool)
+
+var contextValues = make(map[string]ContextValueFunc)
+var contextValuesMutex sync.RWMutex
+
+// RegisterContextValue makes a value that is not stored in a Context under a string key available to log line prefixes
+// using the %{name}X placeholder. Values stored in a Context under a string key matching name take precedence over
+// the registered function. Registering a function with the same name as an existing function replaces it.
+func RegisterContextValue(name string, f ContextValueFunc) {
+	contextValuesMutex.Lock()
+	defer contextValuesMutex.Unlock()
+
+	contextValues[name] = f
+}
+
+func registeredContextValue(name string) ContextValueFunc {
+	contextValuesMutex.RLock()
+	defer contextValuesMutex.RUnlock()
+
+	return contextValues[name]
 }
 
 func (lmf *LogMessageFormatter) findValue(element *prefixElement, levelLabel, loggerName string, loggedAt *time.Time) string {
@@ -204,9 +406,27 @@ func (lmf *LogMessageFormatter) findValue(element *prefixElement, levelLabel, lo
 
 }
 
-// Init checks that a valid format has been provided for the log message prefixes.
+// Init checks that a valid format has been provided for the log message prefixes. If Mode is JSONMode, prefixes are
+// not used and default names are set for any JSON fields without a name.
 func (lmf *LogMessageFormatter) Init() error {
 
+	switch strings.ToUpper(lmf.Mode) {
+	case "", TextMode:
+		lmf.jsonMode = false
+	case JSONMode:
+		lmf.jsonMode = true
+
+		if lmf.JSON == nil {
+			lmf.JSON = new(JSONFormat)
+		}
+
+		lmf.JSON.applyDefaults()
+
+		return nil
+	default:
+		return fmt.Errorf("%s is not a supported log format mode (use %s or %s)", lmf.Mode, TextMode, JSONMode)
+	}
+
 	f := lmf.PrefixFormat
 	pre := lmf.PrefixPreset
 
@@ -5,9 +5,12 @@ package logging
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/graniticio/granitic/v2/test"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNoPlaceholdersFormat(t *testing.T) {
@@ -39,3 +42,62 @@ func TestPlaceHolders(t *testing.T) {
 	fmt.Println(m)
 
 }
+
+type ctxTestKey int
+
+func TestRegisteredContextValue(t *testing.T) {
+
+	RegisterContextValue("testID", func(ctx context.Context) (string, bool) {
+		v, found := ctx.Value(ctxTestKey(0)).(string)
+		return v, found
+	})
+
+	lf := new(LogMessageFormatter)
+	lf.PrefixFormat = "%{testID}X "
+	lf.Unset = "-"
+
+	test.ExpectNil(t, lf.Init())
+
+	test.ExpectString(t, lf.Format(context.Background(), "INFO", "NAME", "MESSAGE"), "- MESSAGE\n")
+
+	ctx := context.WithValue(context.Background(), ctxTestKey(0), "abc")
+	test.ExpectString(t, lf.Format(ctx, "INFO", "NAME", "MESSAGE"), "abc MESSAGE\n")
+}
+
+func TestJSONMode(t *testing.T) {
+
+	lf := new(LogMessageFormatter)
+	lf.Mode = "json"
+	lf.UtcTimes = true
+	lf.JSON = &JSONFormat{MessageField: "msg", ContextFields: map[string]string{"tenant": "tenantID"}}
+
+	test.ExpectNil(t, lf.Init())
+
+	ctx := context.WithValue(context.Background(), RequestIDContextValue, "r1")
+	ctx = context.WithValue(ctx, "tenantID", "t1")
+
+	m := lf.format(ctx, "ERROR", "comp", "line one\n\"quoted\"", []byte("goroutine 1 [running]:\n\tmain.go:10"), nil)
+
+	test.ExpectBool(t, strings.HasSuffix(m, "}\n"), true)
+	test.ExpectInt(t, strings.Count(m, "\n"), 1)
+
+	e := make(map[string]string)
+	test.ExpectNil(t, json.Unmarshal([]byte(m), &e))
+
+	test.ExpectString(t, e["level"], "ERROR")
+	test.ExpectString(t, e["logger"], "comp")
+	test.ExpectString(t, e["msg"], "line one\n\"quoted\"")
+	test.ExpectString(t, e["requestID"], "r1")
+	test.ExpectString(t, e["tenant"], "t1")
+	test.ExpectString(t, e["stack"], "goroutine 1 [running]:\n\tmain.go:10")
+
+	_, err := time.Parse(time.RFC3339Nano, e["timestamp"])
+	test.ExpectNil(t, err)
+
+	// Missing context values are omitted
+	m = lf.Format(context.Background(), "INFO", "comp", "m")
+	test.ExpectBool(t, strings.Contains(m, "requestID"), false)
+
+	lf.Mode = "XML"
+	test.ExpectNotNil(t, lf.Init())
+}
@@ -63,6 +63,23 @@ The Logger interface ( implements below) provides methods to log a message at a
 
 	Log.LogDebugf("A %s message", "DEBUG")
 
+Key/value logging
+
+Rather than embedding values in the text of a message, values can be supplied as alternating keys and values:
+
+	Log.LogInfoKV(ctx, "Order placed", "orderID", o.ID, "items", len(o.Items))
+
+A Logger that adds the same fields to every message can be created with With:
+
+	ol := Log.With("orderID", o.ID)
+
+	ol.LogWarnf("Stock low")
+	ol.LogErrorKV(ctx, "Payment failed", "provider", p.Name, "err", err)
+
+Loggers created with With share the log levels, writers and format of the Logger they were created from, so changing a
+component's log level at runtime also affects them. In text output, fields are appended to the message as k=v (values
+containing spaces or quotes are quoted). In JSON output (see below) each field is written as a native JSON value.
+
 
 Global and component thresholds
 
@@ -128,11 +145,94 @@ look something like:
 
 For more information on these settings, refer to http://granitic.io/ref/logging-format-output
 
+Syslog and network output
+
+Messages can also be sent to a syslog server (in the RFC 5424 format, over UDP, TCP or a Unix domain socket) and/or as
+lines of text to a TCP address:
+
+	{
+	  "LogWriting": {
+		"EnableSyslogLogging": true,
+		"Syslog": {
+		  "Network": "unixgram",
+		  "Address": "/dev/log",
+		  "Facility": "local0"
+		},
+		"EnableTCPLogging": true,
+		"TCP": {
+		  "Address": "logs.example.com:5170"
+		}
+	  }
+	}
+
+The severity of each syslog message is based on the level the message was logged at (see SyslogSeverity). Both writers
+queue messages (up to BufferSize) while the remote address is unavailable and keep trying to re-connect. See SyslogWriter
+and TCPLineWriter for all of the available settings.
+
+JSON log entries
+
+Setting LogWriting.Format.Mode to JSON causes each message to be written as a JSON object on a single line, suitable
+for log pipelines. Prefix settings are ignored in this mode.
+
+	{
+	  "LogWriting": {
+		"Format": {
+		  "Mode": "JSON",
+		  "JSON": {
+			"TimestampField": "ts",
+			"ContextFields": {
+			  "trace": "traceID"
+			}
+		  }
+		}
+	  }
+	}
+
+produces entries like:
+
+	{"ts":"2019-05-01T10:15:02.1234Z","level":"ERROR","logger":"orderLogic","message":"Unable to save order","requestID":"a1b2","trace":"4bf92f35..."}
+
+The request ID is included if the HTTPServer facility has an IdentifiedRequestContextBuilder. ContextFields maps field
+names to values in the Context, using the same names as the %{name}X prefix placeholder. Stack traces from
+LogErrorfWithTrace are written to a separate field (StackField, default 'stack') rather than being appended to the message.
+See JSONFormat for all of the field names that can be changed.
+
+Log file rotation
+
+The log file can be rotated automatically when it reaches a certain size and/or once a day. Rotated files are renamed
+with a timestamp suffix (e.g. granitic.log.20190502-150405) and can optionally be compressed with gzip:
+
+	{
+	  "LogWriting": {
+		"File": {
+		  "Rotation": {
+			"MaxSizeBytes": 10485760,
+			"Daily": true,
+			"MaxBackups": 7,
+			"Compress": true
+		  }
+		}
+	  }
+	}
+
+MaxBackups is the number of rotated files to keep (zero keeps all of them). See RotationPolicy for more details. The
+HTTPServer facility's access log supports the same settings under HTTPServer.AccessLog.Rotation.
+
+The log file and the access log can also be rotated on demand with the grnc-ctl rotate-logs command (if the RuntimeCtl
+facility is enabled) or, if LogWriting.RotateOnSIGHUP is set to true, by sending the application a SIGHUP signal. If the
+file has already been moved by an external tool like logrotate, a rotation just re-opens the file at its original path.
+Messages waiting to be written when a rotation starts are written to the new file.
+
 Runtime control
 
 Global log levels and component log levels can be changed at runtime, if your application has the RuntimeCtl facility
 enabled.  implements http://granitic.io/ref/runtime-control for more information
 
+The level of every component whose name matches a pattern can be changed with ComponentLoggerManager.SetPatternThreshold
+(or grnc-ctl log-level with a pattern like 'grnc*' or '*Handler'). Changes can be given a time-to-live, after which
+each component returns to its previous level - useful for temporarily enabling TRACE logging. grnc-ctl list-levels
+shows the level each component is using and whether it comes from configuration, a runtime change or the global level.
+
 Log message prefixes
 
 Every message written to a log file or console can be given a customisable prefix containing meta-data like time
@@ -146,6 +246,7 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"sync/atomic"
 )
 
 /*
@@ -212,6 +313,28 @@ type Logger interface {
 	//IsLevelEnabled returns true if a message at the supplied level would acutally be logged. Useful to check
 	//if the construction of a message would be expensive or slow.
 	IsLevelEnabled(level LogLevel) bool
+
+	//With returns a Logger that adds the supplied key/value pairs to every message it logs. The returned Logger
+	//shares this Logger's log levels, writers and format.
+	With(kv ...interface{}) Logger
+
+	//LogTraceKV log a message and key/value pairs at TRACE level
+	LogTraceKV(ctx context.Context, message string, kv ...interface{})
+
+	//LogDebugKV log a message and key/value pairs at DEBUG level
+	LogDebugKV(ctx context.Context, message string, kv ...interface{})
+
+	//LogInfoKV log a message and key/value pairs at INFO level
+	LogInfoKV(ctx context.Context, message string, kv ...interface{})
+
+	//LogWarnKV log a message and key/value pairs at WARN level
+	LogWarnKV(ctx context.Context, message string, kv ...interface{})
+
+	//LogErrorKV log a message and key/value pairs at ERROR level
+	LogErrorKV(ctx context.Context, message string, kv ...interface{})
+
+	//LogFatalKV log a message and key/value pairs at FATAL level
+	LogFatalKV(ctx context.Context, message string, kv ...interface{})
 }
 
 // GlobalLevel is implemented by Loggers able to state what the current global log level is
@@ -245,7 +368,7 @@ func NewStdoutLogger(level LogLevel, prefix ...string) Logger {
 	}
 
 	l := new(GraniticLogger)
-	l.localLogThreshhold = level
+	l.localLogThreshhold = uint32(level)
 	l.global = gl{l: level}
 
 	w := new(FixedPrefixConsoleWriter)
@@ -260,10 +383,15 @@ func NewStdoutLogger(level LogLevel, prefix ...string) Logger {
 // GraniticLogger is the standard implementation of Logger which respects both a global log level and a specific level for this Logger.
 type GraniticLogger struct {
 	global             GlobalLevel
-	localLogThreshhold LogLevel
+	localLogThreshhold uint32 // A LogLevel, accessed atomically as it may be changed at runtime while messages are being logged
 	loggerName         string
 	writers            []LogWriter
 	formatter          *LogMessageFormatter
+
+	// Set if this Logger was created by calling With on another Logger. Thresholds, writers and the formatter are
+	// always taken from the parent, so runtime changes to the parent affect this Logger.
+	parent *GraniticLogger
+	fields []field
 }
 
 // UpdateWritersAndFormatter implements RuntimeControllableLog.UpdateWritersAndFormatter
@@ -275,10 +403,14 @@ func (grl *GraniticLogger) UpdateWritersAndFormatter(w []LogWriter, f *LogMessag
 // IsLevelEnabled implements Logger.IsLevelEnabled
 func (grl *GraniticLogger) IsLevelEnabled(level LogLevel) bool {
 
+	if grl.parent != nil {
+		return grl.parent.IsLevelEnabled(level)
+	}
+
 	var el LogLevel
 
 	gl := grl.global.GlobalLevel()
-	ll := grl.localLogThreshhold
+	ll := grl.localThreshold()
 
 	if ll == All {
 		el = gl
@@ -292,9 +424,7 @@ func (grl *GraniticLogger) IsLevelEnabled(level LogLevel) bool {
 func (grl *GraniticLogger) log(ctx context.Context, levelLabel string, level LogLevel, message string) {
 
 	if grl.IsLevelEnabled(level) {
-		m := grl.formatter.Format(ctx, levelLabel, grl.loggerName, message)
-
-		grl.write(m)
+		grl.write(ctx, levelLabel, message, nil, nil)
 	}
 
 }
@@ -302,19 +432,94 @@ func (grl *GraniticLogger) logf(ctx context.Context, levelLabel string, level Lo
 
 	if grl.IsLevelEnabled(level) {
 		message := fmt.Sprintf(format, a...)
-		m := grl.formatter.Format(ctx, levelLabel, grl.loggerName, message)
 
-		grl.write(m)
+		grl.write(ctx, levelLabel, message, nil, nil)
+	}
+
+}
+
+func (grl *GraniticLogger) logKV(ctx context.Context, levelLabel string, level LogLevel, message string, kv []interface{}) {
+
+	if grl.IsLevelEnabled(level) {
+		grl.write(ctx, levelLabel, message, nil, toFields(kv))
 	}
 
 }
 
-func (grl *GraniticLogger) write(m string) {
+// write formats the message, adding any fields attached to this Logger with With, and passes it to the root
+// Logger's writers.
+func (grl *GraniticLogger) write(ctx context.Context, levelLabel string, message string, trace []byte, fields []field) {
+
+	r := grl.root()
 
-	for _, w := range grl.writers {
-		w.WriteMessage(m)
+	if len(grl.fields) > 0 {
+		fields = append(append([]field{}, grl.fields...), fields...)
 	}
 
+	m := r.formatter.format(ctx, levelLabel, r.loggerName, message, trace, fields)
+
+	for _, w := range r.writers {
+
+		if lw, found := w.(LevelAwareLogWriter); found {
+			level, _ := LogLevelFromLabel(levelLabel)
+			lw.WriteMessageAtLevel(m, level)
+		} else {
+			w.WriteMessage(m)
+		}
+	}
+
+}
+
+// root returns the Logger created by a ComponentLoggerManager that this Logger was derived from (or this Logger, if it
+// was not created with With)
+func (grl *GraniticLogger) root() *GraniticLogger {
+
+	if grl.parent != nil {
+		return grl.parent
+	}
+
+	return grl
+}
+
+// With implements Logger.With
+func (grl *GraniticLogger) With(kv ...interface{}) Logger {
+
+	l := new(GraniticLogger)
+	l.parent = grl.root()
+	l.loggerName = grl.loggerName
+	l.fields = append(append([]field{}, grl.fields...), toFields(kv)...)
+
+	return l
+}
+
+// LogTraceKV implements Logger.LogTraceKV
+func (grl *GraniticLogger) LogTraceKV(ctx context.Context, message string, kv ...interface{}) {
+	grl.logKV(ctx, TraceLabel, Trace, message, kv)
+}
+
+// LogDebugKV implements Logger.LogDebugKV
+func (grl *GraniticLogger) LogDebugKV(ctx context.Context, message string, kv ...interface{}) {
+	grl.logKV(ctx, DebugLabel, Debug, message, kv)
+}
+
+// LogInfoKV implements Logger.LogInfoKV
+func (grl *GraniticLogger) LogInfoKV(ctx context.Context, message string, kv ...interface{}) {
+	grl.logKV(ctx, InfoLabel, Info, message, kv)
+}
+
+// LogWarnKV implements Logger.LogWarnKV
+func (grl *GraniticLogger) LogWarnKV(ctx context.Context, message string, kv ...interface{}) {
+	grl.logKV(ctx, WarnLabel, Warn, message, kv)
+}
+
+// LogErrorKV implements Logger.LogErrorKV
+func (grl *GraniticLogger) LogErrorKV(ctx context.Context, message string, kv ...interface{}) {
+	grl.logKV(ctx, ErrorLabel, Error, message, kv)
+}
+
+// LogFatalKV implements Logger.LogFatalKV
+func (grl *GraniticLogger) LogFatalKV(ctx context.Context, message string, kv ...interface{}) {
+	grl.logKV(ctx, FatalLabel, Fatal, message, kv)
 }
 
 func (grl *GraniticLogger) logAtLevelCtx(ctx context.Context, level LogLevel, levelLabel string, message string) {
@@ -353,14 +558,15 @@ func (grl *GraniticLogger) LogErrorfCtx(ctx context.Context, format string, a ..
 
 // LogErrorfCtxWithTrace implements Logger.LogErrorfCtxWithTrace
 func (grl *GraniticLogger) LogErrorfCtxWithTrace(ctx context.Context, format string, a ...interface{}) {
-	trace := make([]byte, 2048)
-	runtime.Stack(trace, false)
 
-	format = format + "\n%s"
-	a = append(a, trace)
+	if !grl.IsLevelEnabled(Error) {
+		return
+	}
 
-	grl.logf(ctx, ErrorLabel, Error, format, a...)
+	trace := make([]byte, 2048)
+	n := runtime.Stack(trace, false)
 
+	grl.write(ctx, ErrorLabel, fmt.Sprintf(format, a...), trace[:n], nil)
 }
 
 // LogFatalfCtx implements Logger.LogFatalfCtx
@@ -409,9 +615,13 @@ func (grl *GraniticLogger) LogFatalf(format string, a ...interface{}) {
 	grl.logf(nil, FatalLabel, Fatal, format, a...)
 }
 
-// SetLocalThreshold sets the log threshold for this Logger
+// SetLocalThreshold sets the log threshold for this Logger (or the Logger it was derived from, if it was created with With)
 func (grl *GraniticLogger) SetLocalThreshold(threshold LogLevel) {
-	grl.localLogThreshhold = threshold
+	atomic.StoreUint32(&grl.root().localLogThreshhold, uint32(threshold))
+}
+
+func (grl *GraniticLogger) localThreshold() LogLevel {
+	return LogLevel(atomic.LoadUint32(&grl.localLogThreshhold))
 }
 
 // CreateAnonymousLogger creates a new Logger without attaching it to a LogManager. Useful for tests.
@@ -422,7 +632,7 @@ func CreateAnonymousLogger(componentID string, threshold LogLevel) Logger {
 	gls.level = threshold
 
 	logger.global = gls
-	logger.localLogThreshhold = threshold
+	logger.localLogThreshhold = uint32(threshold)
 	logger.loggerName = componentID
 
 	return logger
@@ -4,7 +4,10 @@
 package logging
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/graniticio/granitic/v2/test"
+	"strings"
 	"testing"
 )
 
@@ -34,3 +37,96 @@ func TestThresholdDetection(t *testing.T) {
 	test.ExpectBool(t, lal.IsLevelEnabled(Trace), true)
 
 }
+
+type capturingWriter struct {
+	messages []string
+}
+
+func (cw *capturingWriter) WriteMessage(m string) {
+	cw.messages = append(cw.messages, m)
+}
+
+func (cw *capturingWriter) Close() {}
+
+func (cw *capturingWriter) Busy() bool {
+	return false
+}
+
+func TestTraceSeparatedInJSONMode(t *testing.T) {
+
+	w := new(capturingWriter)
+
+	lf := new(LogMessageFormatter)
+	lf.Mode = JSONMode
+	test.ExpectNil(t, lf.Init())
+
+	l := CreateAnonymousLogger("traced", Info).(*GraniticLogger)
+	l.UpdateWritersAndFormatter([]LogWriter{w}, lf)
+
+	l.LogErrorfWithTrace("failed %d", 1)
+
+	test.ExpectInt(t, len(w.messages), 1)
+
+	e := make(map[string]string)
+	test.ExpectNil(t, json.Unmarshal([]byte(w.messages[0]), &e))
+
+	test.ExpectString(t, e["message"], "failed 1")
+	test.ExpectBool(t, strings.Contains(e["stack"], "goroutine"), true)
+	test.ExpectBool(t, strings.ContainsRune(e["stack"], 0), false)
+}
+
+func TestKVLogging(t *testing.T) {
+
+	w := new(capturingWriter)
+
+	lm := CreateComponentLoggerManager(Info, nil, []LogWriter{w}, NewNoPrefixFormatter())
+	l := lm.CreateLogger("kv")
+
+	l.LogInfoKV(nil, "placed", "orderID", 12, "note", "two words", "err", errors.New("a=b"), "dangling")
+
+	test.ExpectInt(t, len(w.messages), 1)
+	test.ExpectString(t, w.messages[0], "placed orderID=12 note=\"two words\" err=\"a=b\" dangling=(MISSING)\n")
+
+	ol := l.With("orderID", 12)
+	ol.LogWarnf("stock %s", "low")
+	ol.LogDebugKV(nil, "suppressed")
+
+	test.ExpectInt(t, len(w.messages), 2)
+	test.ExpectString(t, w.messages[1], "stock low orderID=12\n")
+
+	// Runtime changes to the component's threshold apply to derived Loggers
+	lm.LoggerByName("kv").SetLocalThreshold(Debug)
+	ol.With("step", 2).LogDebugKV(nil, "now visible", "k", "")
+
+	test.ExpectInt(t, len(w.messages), 3)
+	test.ExpectString(t, w.messages[2], "now visible orderID=12 step=2 k=\"\"\n")
+
+	lm.SetGlobalThreshold(Error)
+	lm.LoggerByName("kv").SetLocalThreshold(All)
+	ol.LogWarnKV(nil, "hidden")
+
+	test.ExpectInt(t, len(w.messages), 3)
+}
+
+func TestKVLoggingJSON(t *testing.T) {
+
+	w := new(capturingWriter)
+
+	lf := new(LogMessageFormatter)
+	lf.Mode = JSONMode
+	test.ExpectNil(t, lf.Init())
+
+	lm := CreateComponentLoggerManager(Info, nil, []LogWriter{w}, lf)
+	l := lm.CreateLogger("kv").With("count", 3)
+
+	l.LogErrorKV(nil, "failed", "ok", false, "level", "custom", "err", errors.New("boom"))
+
+	e := make(map[string]interface{})
+	test.ExpectNil(t, json.Unmarshal([]byte(w.messages[0]), &e))
+
+	test.ExpectInt(t, int(e["count"].(float64)), 3)
+	test.ExpectBool(t, e["ok"].(bool), false)
+	test.ExpectString(t, e["level"].(string), "ERROR")
+	test.ExpectString(t, e["fields.level"].(string), "custom")
+	test.ExpectString(t, e["err"].(string), "boom")
+}
@@ -3,6 +3,12 @@
 
 package logging
 
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
 // CreateComponentLoggerManager creates a new ComponentLoggerManager with a global level and default values
 // for named components.
 func CreateComponentLoggerManager(globalThreshold LogLevel, initalComponentLogLevels map[string]interface{},
@@ -10,7 +16,8 @@ func CreateComponentLoggerManager(globalThreshold LogLevel, initalComponentLogLe
 
 	clm := new(ComponentLoggerManager)
 	clm.created = make(map[string]*GraniticLogger)
-	clm.globalThreshold = globalThreshold
+	clm.configured = make(map[string]LogLevel)
+	clm.globalThreshold = uint32(globalThreshold)
 	clm.initialLevels = initalComponentLogLevels
 
 	clm.writers = writers
@@ -23,26 +30,36 @@ func CreateComponentLoggerManager(globalThreshold LogLevel, initalComponentLogLe
 type ComponentLoggerManager struct {
 	created         map[string]*GraniticLogger
 	initialLevels   map[string]interface{}
-	globalThreshold LogLevel
+	globalThreshold uint32
 	writers         []LogWriter
 	formatter       *LogMessageFormatter
+
+	// The level each Logger was given when it was created or by SetInitialLogLevels
+	configured map[string]LogLevel
+	overrides  []*levelOverride
+	mutex      sync.RWMutex
 }
 
 // LoggerByName finds a previously created Logger by the name it was given when it was created. Returns nil if no Logger
 // by that name exists.
 func (clm *ComponentLoggerManager) LoggerByName(name string) *GraniticLogger {
+	clm.mutex.RLock()
+	defer clm.mutex.RUnlock()
+
 	return clm.created[name]
 }
 
 // CurrentLevels returns the current local log level for all Loggers managed by this component.
 func (clm *ComponentLoggerManager) CurrentLevels() []*ComponentLevel {
+	clm.mutex.RLock()
+	defer clm.mutex.RUnlock()
 
 	cls := make([]*ComponentLevel, 0)
 
 	for n, c := range clm.created {
 
 		lev := new(ComponentLevel)
-		lev.Level = c.localLogThreshhold
+		lev.Level = c.localThreshold()
 		lev.Name = n
 
 		cls = append(cls, lev)
@@ -54,11 +71,14 @@ func (clm *ComponentLoggerManager) CurrentLevels() []*ComponentLevel {
 
 // GlobalLevel returns the global log level for the scope (application, framework) that this ComponentLoggerManager is responsible for.
 func (clm *ComponentLoggerManager) GlobalLevel() LogLevel {
-	return clm.globalThreshold
+	return LogLevel(atomic.LoadUint32(&clm.globalThreshold))
 }
 
 // UpdateWritersAndFormatter updates the writers and formatters of all Loggers managed by this ComponentLoggerManager.
 func (clm *ComponentLoggerManager) UpdateWritersAndFormatter(writers []LogWriter, formatter *LogMessageFormatter) {
+	clm.mutex.Lock()
+	defer clm.mutex.Unlock()
+
 	clm.writers = writers
 
 	for _, v := range clm.created {
@@ -69,7 +89,7 @@ func (clm *ComponentLoggerManager) UpdateWritersAndFormatter(writers []LogWriter
 // SetGlobalThreshold sets the global log level for the scope (application, framework) that this ComponentLoggerManager is responsible for.
 func (clm *ComponentLoggerManager) SetGlobalThreshold(globalThreshold LogLevel) {
 
-	clm.globalThreshold = globalThreshold
+	atomic.StoreUint32(&clm.globalThreshold, uint32(globalThreshold))
 }
 
 // SetInitialLogLevels provide a map of component names to log levels. If a Logger is subsequently created for a component named in the map,
@@ -78,6 +98,9 @@ func (clm *ComponentLoggerManager) SetInitialLogLevels(ll map[string]interface{}
 
 	clm.initialLevels = ll
 
+	clm.mutex.Lock()
+	defer clm.mutex.Unlock()
+
 	if len(clm.created) > 0 {
 
 		for k, v := range clm.created {
@@ -86,13 +109,69 @@ func (clm *ComponentLoggerManager) SetInitialLogLevels(ll map[string]interface{}
 
 			if level != nil {
 				t, _ := LogLevelFromLabel(level.(string))
-				v.SetLocalThreshold(t)
+				clm.configured[k] = t
+
+				if clm.overrideFor(k) == nil {
+					v.SetLocalThreshold(t)
+				}
 
 			}
 		}
 	}
 }
 
+// UpdateConfiguredLevels replaces the map of component names to log levels supplied to SetInitialLogLevels while the
+// application is running (e.g. when configuration is reloaded). Each existing Logger whose level in the map has changed
+// is set to its new level, or to the global level if it is no longer in the map, unless a pattern set with
+// SetPatternThreshold applies to it. Returns an error, without changing any levels, if the map contains an invalid level.
+func (clm *ComponentLoggerManager) UpdateConfiguredLevels(ll map[string]interface{}) error {
+
+	levels := make(map[string]LogLevel, len(ll))
+
+	for k, v := range ll {
+
+		label, found := v.(string)
+
+		if !found {
+			return fmt.Errorf("the log level for %s is not a string", k)
+		}
+
+		t, err := LogLevelFromLabel(label)
+
+		if err != nil {
+			return err
+		}
+
+		levels[k] = t
+	}
+
+	clm.mutex.Lock()
+	defer clm.mutex.Unlock()
+
+	clm.initialLevels = ll
+
+	for k, l := range clm.created {
+
+		t, found := levels[k]
+
+		if !found {
+			t = All
+		}
+
+		if clm.configured[k] == t {
+			continue
+		}
+
+		clm.configured[k] = t
+
+		if clm.overrideFor(k) == nil {
+			l.SetLocalThreshold(t)
+		}
+	}
+
+	return nil
+}
+
 // CreateLogger creates a new Logger for the supplied component name
 func (clm *ComponentLoggerManager) CreateLogger(componentID string) Logger {
 
@@ -118,12 +197,26 @@ func (clm *ComponentLoggerManager) CreateLogger(componentID string) Logger {
 }
 
 // CreateLoggerAtLevel creates a new Logger for the supplied component name with the local log threshold set to the supplied level.
+// If a pattern set with SetPatternThreshold matches the component name, the pattern's level is used instead.
 func (clm *ComponentLoggerManager) CreateLoggerAtLevel(componentID string, threshold LogLevel) Logger {
+	clm.mutex.Lock()
+	defer clm.mutex.Unlock()
+
+	if clm.configured == nil {
+		clm.configured = make(map[string]LogLevel)
+	}
+
 	l := new(GraniticLogger)
 	l.global = clm
-	l.localLogThreshhold = threshold
+	l.localLogThreshhold = uint32(threshold)
 	l.loggerName = componentID
 
+	clm.configured[componentID] = threshold
+
+	if o := clm.overrideFor(componentID); o != nil {
+		l.localLogThreshhold = uint32(o.level)
+	}
+
 	clm.created[componentID] = l
 
 	l.writers = clm.writers
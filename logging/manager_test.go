@@ -0,0 +1,39 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logging
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/test"
+	"sync"
+	"testing"
+)
+
+func TestLevelsReadWhileLoggersCreated(t *testing.T) {
+
+	clm := CreateComponentLoggerManager(Fatal, map[string]interface{}{}, []LogWriter{}, NewFrameworkLogMessageFormatter())
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 500; i++ {
+			clm.CreateLogger(fmt.Sprintf("comp%d", i))
+		}
+	}()
+
+	for i := 0; i < 500; i++ {
+		clm.CurrentLevels()
+		clm.LoggerByName("comp1")
+		clm.EffectiveLevels()
+	}
+
+	wg.Wait()
+
+	test.ExpectInt(t, len(clm.CurrentLevels()), 500)
+	test.ExpectNotNil(t, clm.LoggerByName("comp499"))
+}
@@ -0,0 +1,384 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultNetworkBufferSize = 100
+	defaultReconnectDelay    = time.Second
+	networkTimeout           = 5 * time.Second
+)
+
+// networkQueue sends messages to a network address from a single goroutine, re-connecting after a failed connection
+// attempt or write. Messages are queued (up to a fixed number) while the connection is unavailable.
+type networkQueue struct {
+	network        string
+	address        string
+	reconnectDelay time.Duration
+	messages       chan []byte
+	done           chan struct{}
+	closeOnce      sync.Once
+	conn           net.Conn
+	connected      int32
+	dropped        uint64
+}
+
+func newNetworkQueue(network, address string, bufferSize int, reconnectDelayMS int) (*networkQueue, error) {
+
+	if strings.TrimSpace(address) == "" {
+		return nil, errors.New("no address to send log messages to specified")
+	}
+
+	if bufferSize <= 0 {
+		bufferSize = defaultNetworkBufferSize
+	}
+
+	nq := new(networkQueue)
+	nq.network = network
+	nq.address = address
+	nq.messages = make(chan []byte, bufferSize)
+	nq.done = make(chan struct{})
+	nq.reconnectDelay = defaultReconnectDelay
+
+	if reconnectDelayMS > 0 {
+		nq.reconnectDelay = time.Duration(reconnectDelayMS) * time.Millisecond
+	}
+
+	go nq.run()
+
+	return nq, nil
+}
+
+// enqueue queues a message for sending without blocking. If the queue is full, the message is discarded.
+func (nq *networkQueue) enqueue(m []byte) {
+
+	select {
+	case nq.messages <- m:
+	default:
+		atomic.AddUint64(&nq.dropped, 1)
+	}
+}
+
+func (nq *networkQueue) run() {
+
+	for {
+		select {
+		case <-nq.done:
+			nq.disconnect()
+			return
+		case m := <-nq.messages:
+			nq.send(m)
+		}
+	}
+}
+
+// send writes the message, connecting first if required. Returns once the message has been written or the queue has
+// been closed.
+func (nq *networkQueue) send(m []byte) {
+
+	for {
+
+		if nq.conn == nil && !nq.connect() {
+
+			select {
+			case <-nq.done:
+				return
+			case <-time.After(nq.reconnectDelay):
+				continue
+			}
+		}
+
+		nq.conn.SetWriteDeadline(time.Now().Add(networkTimeout))
+
+		if _, err := nq.conn.Write(m); err == nil {
+			return
+		}
+
+		nq.disconnect()
+	}
+}
+
+func (nq *networkQueue) connect() bool {
+
+	c, err := net.DialTimeout(nq.network, nq.address, networkTimeout)
+
+	if err != nil {
+		return false
+	}
+
+	nq.conn = c
+	atomic.StoreInt32(&nq.connected, 1)
+
+	return true
+}
+
+func (nq *networkQueue) disconnect() {
+
+	atomic.StoreInt32(&nq.connected, 0)
+
+	if nq.conn != nil {
+		nq.conn.Close()
+		nq.conn = nil
+	}
+}
+
+// busy returns true if messages are waiting to be sent and there is a connection to send them over. Messages queued
+// while the remote address is unavailable do not count, so that the application is not prevented from stopping.
+func (nq *networkQueue) busy() bool {
+	return len(nq.messages) > 0 && atomic.LoadInt32(&nq.connected) == 1
+}
+
+func (nq *networkQueue) close() {
+	nq.closeOnce.Do(func() { close(nq.done) })
+}
+
+// TCPLineWriter is an implementation of LogWriter that sends each message as a line of text over a TCP connection
+// (suitable for log collectors like Logstash or Fluentd that accept newline-delimited input). Messages are sent
+// asynchronously. If the connection cannot be established or fails, messages are queued (up to BufferSize) and
+// connection attempts are repeated every ReconnectDelayMS milliseconds. Messages that arrive when the queue is full
+// are discarded.
+type TCPLineWriter struct {
+	// The host:port to send messages to.
+	Address string
+
+	// The number of messages that can be queued for sending. Defaults to 100.
+	BufferSize int
+
+	// How long to wait before trying to re-connect after a failed connection or write. Defaults to 1000.
+	ReconnectDelayMS int
+
+	queue *networkQueue
+}
+
+// Init validates the writer's configuration and starts sending queued messages.
+func (tw *TCPLineWriter) Init() error {
+
+	q, err := newNetworkQueue("tcp", tw.Address, tw.BufferSize, tw.ReconnectDelayMS)
+
+	if err != nil {
+		return fmt.Errorf("TCP logging is enabled, but %s", err.Error())
+	}
+
+	tw.queue = q
+
+	return nil
+}
+
+// WriteMessage queues a message for sending, adding a newline if the message does not already end with one.
+func (tw *TCPLineWriter) WriteMessage(m string) {
+
+	if !strings.HasSuffix(m, "\n") {
+		m += "\n"
+	}
+
+	tw.queue.enqueue([]byte(m))
+}
+
+// Close stops sending messages and closes the connection.
+func (tw *TCPLineWriter) Close() {
+	if tw.queue != nil {
+		tw.queue.close()
+	}
+}
+
+// Busy returns true if messages are queued and the writer is connected.
+func (tw *TCPLineWriter) Busy() bool {
+	return tw.queue.busy()
+}
+
+// Dropped returns the number of messages that have been discarded because the queue was full.
+func (tw *TCPLineWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&tw.queue.dropped)
+}
+
+// Syslog severities (RFC 5424 section 6.2.1)
+const (
+	severityCritical = 2
+	severityError    = 3
+	severityWarning  = 4
+	severityInfo     = 6
+	severityDebug    = 7
+)
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7, "uucp": 8, "cron": 9,
+	"authpriv": 10, "ftp": 11, "local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21,
+	"local6": 22, "local7": 23,
+}
+
+// SyslogSeverity maps a LogLevel to a syslog severity: FATAL to critical (2), ERROR to error (3), WARN to warning (4),
+// INFO to informational (6) and DEBUG and TRACE to debug (7).
+func SyslogSeverity(level LogLevel) int {
+
+	switch {
+	case level >= Fatal:
+		return severityCritical
+	case level >= Error:
+		return severityError
+	case level >= Warn:
+		return severityWarning
+	case level >= Info:
+		return severityInfo
+	default:
+		return severityDebug
+	}
+}
+
+// SyslogWriter is an implementation of LogWriter that sends messages to a syslog server in the RFC 5424 format. Messages
+// can be sent over UDP, TCP or a Unix domain socket. Over stream connections (tcp and unix), messages are framed using
+// octet counting (RFC 6587). Each message's severity is derived from the level it was logged at (see SyslogSeverity).
+//
+// Messages are sent asynchronously and are queued and re-sent in the same way as TCPLineWriter.
+type SyslogWriter struct {
+	// The type of connection to use: udp, tcp, unix (stream socket) or unixgram (datagram socket). Defaults to udp.
+	Network string
+
+	// The host:port of the syslog server or the path of a Unix domain socket (e.g. /dev/log).
+	Address string
+
+	// The syslog facility messages are logged under (kern, user, mail, daemon, auth, syslog, lpr, news, uucp, cron, authpriv,
+	// ftp or local0 to local7). Defaults to user.
+	Facility string
+
+	// The APP-NAME field of each message. Defaults to the name of the application's executable.
+	AppName string
+
+	// The HOSTNAME field of each message. Defaults to the host's name as reported by the operating system.
+	Hostname string
+
+	// The number of messages that can be queued for sending. Defaults to 100.
+	BufferSize int
+
+	// How long to wait before trying to re-connect after a failed connection or write. Defaults to 1000.
+	ReconnectDelayMS int
+
+	facility int
+	pid      int
+	stream   bool
+	queue    *networkQueue
+}
+
+// Init validates the writer's configuration and starts sending queued messages.
+func (sw *SyslogWriter) Init() error {
+
+	network := strings.ToLower(sw.Network)
+
+	switch network {
+	case "":
+		network = "udp"
+	case "udp", "unixgram":
+	case "tcp", "unix":
+		sw.stream = true
+	default:
+		return fmt.Errorf("%s is not a supported network for syslog (must be udp, tcp, unix or unixgram)", sw.Network)
+	}
+
+	facility := strings.ToLower(sw.Facility)
+
+	if facility == "" {
+		facility = "user"
+	}
+
+	f, found := syslogFacilities[facility]
+
+	if !found {
+		return fmt.Errorf("%s is not a valid syslog facility", sw.Facility)
+	}
+
+	sw.facility = f
+	sw.pid = os.Getpid()
+
+	if sw.AppName == "" {
+		sw.AppName = filepath.Base(os.Args[0])
+	}
+
+	if sw.Hostname == "" {
+		sw.Hostname, _ = os.Hostname()
+	}
+
+	q, err := newNetworkQueue(network, sw.Address, sw.BufferSize, sw.ReconnectDelayMS)
+
+	if err != nil {
+		return fmt.Errorf("syslog logging is enabled, but %s", err.Error())
+	}
+
+	sw.queue = q
+
+	return nil
+}
+
+// WriteMessage queues a message for sending with the informational severity.
+func (sw *SyslogWriter) WriteMessage(m string) {
+	sw.WriteMessageAtLevel(m, Info)
+}
+
+// WriteMessageAtLevel queues a message for sending with the severity associated with the supplied level. Implements LevelAwareLogWriter
+func (sw *SyslogWriter) WriteMessageAtLevel(m string, level LogLevel) {
+	sw.queue.enqueue(sw.encode(m, level, time.Now()))
+}
+
+// encode creates an RFC 5424 message. The structured data field is always nil.
+func (sw *SyslogWriter) encode(m string, level LogLevel, t time.Time) []byte {
+
+	pri := sw.facility*8 + SyslogSeverity(level)
+
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d - - %s", pri, t.Format("2006-01-02T15:04:05.000000Z07:00"),
+		headerField(sw.Hostname, 255), headerField(sw.AppName, 48), sw.pid, strings.TrimRight(m, "\n"))
+
+	if sw.stream {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	return []byte(msg)
+}
+
+// headerField makes a value safe to use as an RFC 5424 header field, which must be printable ASCII without spaces.
+func headerField(v string, maxLen int) string {
+
+	v = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return -1
+		}
+
+		return r
+	}, v)
+
+	if v == "" {
+		return "-"
+	}
+
+	if len(v) > maxLen {
+		v = v[:maxLen]
+	}
+
+	return v
+}
+
+// Close stops sending messages and closes the connection.
+func (sw *SyslogWriter) Close() {
+	if sw.queue != nil {
+		sw.queue.close()
+	}
+}
+
+// Busy returns true if messages are queued and the writer is connected.
+func (sw *SyslogWriter) Busy() bool {
+	return sw.queue.busy()
+}
+
+// Dropped returns the number of messages that have been discarded because the queue was full.
+func (sw *SyslogWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&sw.queue.dropped)
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logging
+
+import (
+	"bufio"
+	"github.com/graniticio/granitic/v2/test"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSeverity(t *testing.T) {
+
+	test.ExpectInt(t, SyslogSeverity(Fatal), 2)
+	test.ExpectInt(t, SyslogSeverity(Error), 3)
+	test.ExpectInt(t, SyslogSeverity(Warn), 4)
+	test.ExpectInt(t, SyslogSeverity(Info), 6)
+	test.ExpectInt(t, SyslogSeverity(Debug), 7)
+	test.ExpectInt(t, SyslogSeverity(Trace), 7)
+}
+
+func TestSyslogUDP(t *testing.T) {
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Skip("Unable to listen for UDP: " + err.Error())
+	}
+
+	defer pc.Close()
+
+	sw := new(SyslogWriter)
+	sw.Address = pc.LocalAddr().String()
+	sw.Facility = "local0"
+	sw.AppName = "my app"
+	sw.Hostname = "host1"
+
+	test.ExpectNil(t, sw.Init())
+	defer sw.Close()
+
+	sw.WriteMessageAtLevel("Order failed\n", Error)
+
+	b := make([]byte, 1024)
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(b)
+	test.ExpectNil(t, err)
+
+	// local0 (16) * 8 + error (3) = 131
+	exp := regexp.MustCompile(`^<131>1 \S+ host1 myapp \d+ - - Order failed$`)
+
+	if !exp.Match(b[:n]) {
+		t.Errorf("Unexpected syslog message %q", b[:n])
+	}
+}
+
+func TestSyslogTCPFraming(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Skip("Unable to listen for TCP: " + err.Error())
+	}
+
+	defer l.Close()
+
+	sw := new(SyslogWriter)
+	sw.Network = "tcp"
+	sw.Address = l.Addr().String()
+
+	test.ExpectNil(t, sw.Init())
+	defer sw.Close()
+
+	sw.WriteMessage("hello\n")
+
+	c, err := l.Accept()
+	test.ExpectNil(t, err)
+	defer c.Close()
+
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	r := bufio.NewReader(c)
+	length, err := r.ReadString(' ')
+	test.ExpectNil(t, err)
+
+	n, err := strconv.Atoi(strings.TrimSpace(length))
+	test.ExpectNil(t, err)
+
+	msg := make([]byte, n)
+	_, err = io.ReadFull(r, msg)
+	test.ExpectNil(t, err)
+
+	// user (1) * 8 + informational (6) = 14
+	test.ExpectBool(t, strings.HasPrefix(string(msg), "<14>1 "), true)
+	test.ExpectBool(t, strings.HasSuffix(string(msg), " - - hello"), true)
+}
+
+func TestSyslogConfigErrors(t *testing.T) {
+
+	sw := &SyslogWriter{Network: "http", Address: "localhost:514"}
+	test.ExpectNotNil(t, sw.Init())
+
+	sw = &SyslogWriter{Facility: "nope", Address: "localhost:514"}
+	test.ExpectNotNil(t, sw.Init())
+
+	sw = new(SyslogWriter)
+	test.ExpectNotNil(t, sw.Init())
+}
+
+func TestTCPLineWriterReconnects(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Skip("Unable to listen for TCP: " + err.Error())
+	}
+
+	defer l.Close()
+
+	tw := new(TCPLineWriter)
+	tw.Address = l.Addr().String()
+	tw.ReconnectDelayMS = 10
+
+	test.ExpectNil(t, tw.Init())
+	defer tw.Close()
+
+	tw.WriteMessage("first")
+
+	c, err := l.Accept()
+	test.ExpectNil(t, err)
+
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(c).ReadString('\n')
+	test.ExpectNil(t, err)
+	test.ExpectString(t, line, "first\n")
+
+	// Break the connection - messages are re-sent over a new connection
+	c.Close()
+
+	go func() {
+		for i := 0; i < 50; i++ {
+			tw.WriteMessage("again\n")
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	c, err = l.Accept()
+	test.ExpectNil(t, err)
+	defer c.Close()
+
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err = bufio.NewReader(c).ReadString('\n')
+	test.ExpectNil(t, err)
+	test.ExpectString(t, line, "again\n")
+}
+
+func TestLevelPassedToWriter(t *testing.T) {
+
+	lw := new(levelCapturingWriter)
+
+	l := new(GraniticLogger)
+	l.global = gl{l: All}
+	l.writers = []LogWriter{lw}
+	l.formatter = NewNoPrefixFormatter()
+
+	l.LogWarnf("careful")
+
+	test.ExpectInt(t, int(lw.level), Warn)
+	test.ExpectString(t, lw.message, "careful\n")
+}
+
+type levelCapturingWriter struct {
+	message string
+	level   LogLevel
+}
+
+func (lw *levelCapturingWriter) WriteMessage(m string) {
+	lw.WriteMessageAtLevel(m, Info)
+}
+
+func (lw *levelCapturingWriter) WriteMessageAtLevel(m string, level LogLevel) {
+	lw.message = m
+	lw.level = level
+}
+
+func (lw *levelCapturingWriter) Close() {
+}
+
+func (lw *levelCapturingWriter) Busy() bool {
+	return false
+}
@@ -0,0 +1,268 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logging
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LevelSource describes where the log level a Logger is currently using was set.
+type LevelSource string
+
+const (
+	// GlobalLevelSource means the Logger has no level of its own, so the global level applies
+	GlobalLevelSource LevelSource = "GLOBAL"
+
+	// ConfigLevelSource means the level was set in configuration (e.g. ApplicationLogger.ComponentLogLevels)
+	ConfigLevelSource LevelSource = "CONFIG"
+
+	// RuntimeLevelSource means the level has been changed since the application started (e.g. with grnc-ctl)
+	RuntimeLevelSource LevelSource = "RUNTIME"
+)
+
+// EffectiveLevel describes the log level a Logger is currently using and where that level was set.
+type EffectiveLevel struct {
+	// The name of the component the Logger was created for
+	Name string
+
+	// The level messages must be logged at (or above) to be written. If the Logger has no level of its own, this is the global level.
+	Level LogLevel
+
+	// Whether the level comes from the global level, configuration or a runtime change
+	Source LevelSource
+
+	// The pattern passed to SetPatternThreshold, if the level was set by a runtime override.
+	Pattern string
+
+	// When the runtime override that set the level expires. Zero if the level was not set by an override or the override does not expire.
+	Expires time.Time
+}
+
+// levelOverride is a log level applied at runtime to every Logger whose name matches a pattern.
+type levelOverride struct {
+	pattern string
+	level   LogLevel
+	expires time.Time
+	timer   *time.Timer
+}
+
+// SetPatternThreshold sets the local log threshold of every Logger whose component name matches the supplied pattern,
+// including Loggers created after this method is called. An asterisk (*) in the pattern matches any sequence of characters,
+// so grnc* matches all framework components and *Handler matches every component whose name ends with Handler. A pattern
+// without an asterisk matches a single component.
+//
+// If ttl is greater than zero, the change expires once ttl has passed and each affected Logger reverts to the level it
+// would otherwise have (from configuration or an earlier override that is still active). Setting a threshold for a pattern
+// that already has one replaces the earlier override.
+//
+// Returns the names of the existing Loggers that matched the pattern.
+func (clm *ComponentLoggerManager) SetPatternThreshold(pattern string, threshold LogLevel, ttl time.Duration) ([]string, error) {
+
+	if strings.TrimSpace(pattern) == "" {
+		return nil, errors.New("a component name or pattern is required")
+	}
+
+	clm.mutex.Lock()
+	defer clm.mutex.Unlock()
+
+	clm.removeOverride(pattern)
+
+	o := &levelOverride{pattern: pattern, level: threshold}
+
+	if ttl > 0 {
+		o.expires = time.Now().Add(ttl)
+		o.timer = time.AfterFunc(ttl, func() { clm.expire(o) })
+	}
+
+	clm.overrides = append(clm.overrides, o)
+
+	matched := make([]string, 0)
+
+	for name, l := range clm.created {
+		if matchesPattern(pattern, name) {
+			l.SetLocalThreshold(threshold)
+			matched = append(matched, name)
+		}
+	}
+
+	sort.Strings(matched)
+
+	return matched, nil
+}
+
+// ClearOverrides removes every override set with SetPatternThreshold whose pattern matches the supplied component name,
+// including patterns containing an asterisk, and returns each Logger those overrides applied to to the level it would
+// otherwise have.
+func (clm *ComponentLoggerManager) ClearOverrides(name string) {
+
+	clm.mutex.Lock()
+	defer clm.mutex.Unlock()
+
+	remaining := make([]*levelOverride, 0, len(clm.overrides))
+	removed := make([]*levelOverride, 0)
+
+	for _, o := range clm.overrides {
+
+		if !matchesPattern(o.pattern, name) {
+			remaining = append(remaining, o)
+			continue
+		}
+
+		if o.timer != nil {
+			o.timer.Stop()
+		}
+
+		removed = append(removed, o)
+	}
+
+	clm.overrides = remaining
+
+	for n, l := range clm.created {
+		for _, o := range removed {
+			if matchesPattern(o.pattern, n) {
+				l.SetLocalThreshold(clm.levelFor(n))
+				break
+			}
+		}
+	}
+}
+
+// EffectiveLevels returns the level each Logger managed by this ComponentLoggerManager is currently using and where
+// that level was set, sorted by component name.
+func (clm *ComponentLoggerManager) EffectiveLevels() []*EffectiveLevel {
+
+	clm.mutex.RLock()
+	defer clm.mutex.RUnlock()
+
+	levels := make([]*EffectiveLevel, 0, len(clm.created))
+
+	for name, l := range clm.created {
+
+		el := new(EffectiveLevel)
+		el.Name = name
+		el.Level = l.localThreshold()
+
+		configured, found := clm.configured[name]
+
+		if o := clm.overrideFor(name); o != nil {
+			el.Source = RuntimeLevelSource
+			el.Pattern = o.pattern
+			el.Expires = o.expires
+		} else if el.Level == All {
+			el.Source = GlobalLevelSource
+		} else if found && configured == el.Level {
+			el.Source = ConfigLevelSource
+		} else {
+			el.Source = RuntimeLevelSource
+		}
+
+		if el.Level == All {
+			el.Level = clm.GlobalLevel()
+		}
+
+		levels = append(levels, el)
+	}
+
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Name < levels[j].Name })
+
+	return levels
+}
+
+// expire removes an override once its time-to-live has passed and recalculates the level of every Logger it applied to.
+func (clm *ComponentLoggerManager) expire(o *levelOverride) {
+
+	clm.mutex.Lock()
+	defer clm.mutex.Unlock()
+
+	for i, current := range clm.overrides {
+
+		if current == o {
+			clm.overrides = append(clm.overrides[:i], clm.overrides[i+1:]...)
+			break
+		}
+	}
+
+	for name, l := range clm.created {
+		if matchesPattern(o.pattern, name) {
+			l.SetLocalThreshold(clm.levelFor(name))
+		}
+	}
+}
+
+func (clm *ComponentLoggerManager) removeOverride(pattern string) {
+
+	for i, o := range clm.overrides {
+
+		if o.pattern == pattern {
+
+			if o.timer != nil {
+				o.timer.Stop()
+			}
+
+			clm.overrides = append(clm.overrides[:i], clm.overrides[i+1:]...)
+			return
+		}
+	}
+}
+
+// overrideFor returns the most recently set override that matches the supplied component name, or nil if there is none.
+func (clm *ComponentLoggerManager) overrideFor(name string) *levelOverride {
+
+	for i := len(clm.overrides) - 1; i >= 0; i-- {
+
+		if o := clm.overrides[i]; matchesPattern(o.pattern, name) {
+			return o
+		}
+	}
+
+	return nil
+}
+
+// levelFor returns the level a Logger for the named component should use, taking overrides into account.
+func (clm *ComponentLoggerManager) levelFor(name string) LogLevel {
+
+	if o := clm.overrideFor(name); o != nil {
+		return o.level
+	}
+
+	if l, found := clm.configured[name]; found {
+		return l
+	}
+
+	return All
+}
+
+// matchesPattern returns true if the name matches the pattern, where * in the pattern matches any sequence of characters.
+func matchesPattern(pattern, name string) bool {
+
+	parts := strings.Split(pattern, "*")
+
+	if len(parts) == 1 {
+		return pattern == name
+	}
+
+	first, last := parts[0], parts[len(parts)-1]
+
+	if !strings.HasPrefix(name, first) {
+		return false
+	}
+
+	remaining := name[len(first):]
+
+	for _, p := range parts[1 : len(parts)-1] {
+
+		i := strings.Index(remaining, p)
+
+		if i < 0 {
+			return false
+		}
+
+		remaining = remaining[i+len(p):]
+	}
+
+	return strings.HasSuffix(remaining, last)
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logging
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+	"time"
+)
+
+func TestMatchesPattern(t *testing.T) {
+
+	test.ExpectBool(t, matchesPattern("grnc*", "grncHTTPServer"), true)
+	test.ExpectBool(t, matchesPattern("grnc*", "myComp"), false)
+	test.ExpectBool(t, matchesPattern("*Handler", "orderHandler"), true)
+	test.ExpectBool(t, matchesPattern("*Handler", "orderHandlerLogic"), false)
+	test.ExpectBool(t, matchesPattern("*Order*", "createOrderLogic"), true)
+	test.ExpectBool(t, matchesPattern("a*a", "a"), false)
+	test.ExpectBool(t, matchesPattern("a*a", "aa"), true)
+	test.ExpectBool(t, matchesPattern("*", "anything"), true)
+	test.ExpectBool(t, matchesPattern("myComp", "myComp"), true)
+	test.ExpectBool(t, matchesPattern("myComp", "myComp2"), false)
+}
+
+func TestPatternThreshold(t *testing.T) {
+
+	clm := CreateComponentLoggerManager(Info, map[string]interface{}{"orderHandler": "WARN"}, nil, nil)
+
+	order := clm.CreateLogger("orderHandler")
+	user := clm.CreateLogger("userHandler")
+	other := clm.CreateLogger("other")
+
+	matched, err := clm.SetPatternThreshold("*Handler", Trace, 0)
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(matched), 2)
+
+	test.ExpectBool(t, order.IsLevelEnabled(Trace), true)
+	test.ExpectBool(t, user.IsLevelEnabled(Trace), true)
+	test.ExpectBool(t, other.IsLevelEnabled(Trace), false)
+
+	// Loggers created later also pick up the pattern
+	later := clm.CreateLogger("stockHandler")
+	test.ExpectBool(t, later.IsLevelEnabled(Trace), true)
+
+	_, err = clm.SetPatternThreshold(" ", Trace, 0)
+	test.ExpectNotNil(t, err)
+}
+
+func TestPatternThresholdExpires(t *testing.T) {
+
+	clm := CreateComponentLoggerManager(Info, map[string]interface{}{"orderHandler": "WARN"}, nil, nil)
+
+	order := clm.CreateLogger("orderHandler")
+	user := clm.CreateLogger("userHandler")
+
+	clm.SetPatternThreshold("*Handler", Debug, 0)
+	clm.SetPatternThreshold("order*", Trace, 20*time.Millisecond)
+
+	test.ExpectBool(t, order.IsLevelEnabled(Trace), true)
+
+	levels := clm.EffectiveLevels()
+	test.ExpectString(t, levels[0].Name, "orderHandler")
+	test.ExpectString(t, string(levels[0].Source), string(RuntimeLevelSource))
+	test.ExpectString(t, levels[0].Pattern, "order*")
+	test.ExpectBool(t, levels[0].Expires.IsZero(), false)
+
+	for i := 0; i < 100 && order.IsLevelEnabled(Trace); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Reverts to the earlier pattern, which is still active
+	test.ExpectBool(t, order.IsLevelEnabled(Trace), false)
+	test.ExpectBool(t, order.IsLevelEnabled(Debug), true)
+	test.ExpectBool(t, user.IsLevelEnabled(Debug), true)
+}
+
+func TestEffectiveLevelSources(t *testing.T) {
+
+	clm := CreateComponentLoggerManager(Error, map[string]interface{}{"configured": "WARN"}, nil, nil)
+
+	clm.CreateLogger("configured")
+	clm.CreateLogger("global")
+	changed := clm.CreateLogger("changed").(*GraniticLogger)
+
+	changed.SetLocalThreshold(Debug)
+
+	levels := clm.EffectiveLevels()
+
+	test.ExpectInt(t, len(levels), 3)
+
+	test.ExpectString(t, levels[0].Name, "changed")
+	test.ExpectString(t, string(levels[0].Source), string(RuntimeLevelSource))
+	test.ExpectInt(t, int(levels[0].Level), Debug)
+
+	test.ExpectString(t, levels[1].Name, "configured")
+	test.ExpectString(t, string(levels[1].Source), string(ConfigLevelSource))
+	test.ExpectInt(t, int(levels[1].Level), Warn)
+
+	test.ExpectString(t, levels[2].Name, "global")
+	test.ExpectString(t, string(levels[2].Source), string(GlobalLevelSource))
+	test.ExpectInt(t, int(levels[2].Level), Error)
+}
+
+func TestUpdateConfiguredLevels(t *testing.T) {
+
+	clm := CreateComponentLoggerManager(Info, map[string]interface{}{"orderHandler": "WARN", "userHandler": "ERROR"}, nil, nil)
+
+	order := clm.CreateLogger("orderHandler")
+	user := clm.CreateLogger("userHandler")
+	stock := clm.CreateLogger("stockHandler")
+
+	clm.SetPatternThreshold("user*", Trace, 0)
+
+	err := clm.UpdateConfiguredLevels(map[string]interface{}{"userHandler": "FATAL", "stockHandler": "DEBUG"})
+	test.ExpectNil(t, err)
+
+	// No longer configured, so the global level applies
+	test.ExpectBool(t, order.IsLevelEnabled(Info), true)
+	test.ExpectBool(t, stock.IsLevelEnabled(Debug), true)
+
+	// Pattern overrides still take precedence over configuration
+	test.ExpectBool(t, user.IsLevelEnabled(Trace), true)
+
+	// ... but the new configured level is used once the pattern is removed
+	clm.removeOverride("user*")
+	test.ExpectInt(t, int(clm.levelFor("userHandler")), int(Fatal))
+
+	// Loggers created later use the updated levels
+	later := clm.CreateLogger("stockHandler2")
+	test.ExpectBool(t, later.IsLevelEnabled(Info), true)
+
+	err = clm.UpdateConfiguredLevels(map[string]interface{}{"stockHandler": "LOUD"})
+	test.ExpectNotNil(t, err)
+	test.ExpectBool(t, stock.IsLevelEnabled(Debug), true)
+}
@@ -0,0 +1,378 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The layout of the timestamp added to the name of a rotated file
+const rotatedTimeFormat = "20060102-150405"
+
+// The file extension added to rotated files that have been compressed
+const compressedExtension = ".gz"
+
+// Rotatable is implemented by components that write to files that can be rotated on demand (for example in response to
+// a grnc-ctl command or a SIGHUP signal).
+type Rotatable interface {
+	// Rotate closes the current file and starts writing to a new file at the same path.
+	Rotate() error
+}
+
+// RotationPolicy controls when a RotatingFile is automatically rotated and what happens to the files it has rotated.
+type RotationPolicy struct {
+	// Rotate the file before a write would cause it to grow beyond this number of bytes. Zero or less disables rotation by size.
+	MaxSizeBytes int64
+
+	// Rotate the file when the first write after midnight (local time) is made.
+	Daily bool
+
+	// The number of rotated files to keep. The oldest rotated files are deleted after each rotation. Zero or less keeps all rotated files.
+	MaxBackups int
+
+	// Whether or not rotated files should be compressed with gzip.
+	Compress bool
+}
+
+// RotatingFile is a file that is appended to and that can be rotated, either automatically according to a RotationPolicy or
+// on demand by calling Rotate. Rotating the file renames the current file to its path plus a timestamp
+// (e.g. granitic.log.20190502-150405) and creates a new file at the original path.
+//
+// If the file has already been renamed or removed by an external tool (such as logrotate) when Rotate is called, the file
+// is re-opened at its original path without any further renaming. RotatingFile is safe for concurrent use - writes made
+// during a rotation wait for the rotation to complete.
+type RotatingFile struct {
+	path        string
+	policy      RotationPolicy
+	file        *os.File
+	size        int64
+	nextDaily   time.Time
+	mutex       sync.Mutex
+	housekeeper sync.Mutex
+	pending     sync.WaitGroup
+	now         func() time.Time
+}
+
+// OpenRotatingFile opens (creating if necessary) the file at the supplied path for appending. If policy is nil, the
+// file is only rotated when Rotate is called.
+func OpenRotatingFile(path string, policy *RotationPolicy) (*RotatingFile, error) {
+
+	rf := new(RotatingFile)
+	rf.path = path
+	rf.now = time.Now
+
+	if policy != nil {
+		rf.policy = *policy
+	}
+
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	return rf, rf.open()
+}
+
+// WriteString appends the supplied string to the file, rotating the file first if required by the RotationPolicy. If the
+// file could not be opened after a previous rotation, another attempt is made to open it.
+func (rf *RotatingFile) WriteString(s string) (int, error) {
+
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	if rf.file != nil && rf.rotationDue(len(s)) {
+		rf.rotate()
+	}
+
+	if rf.file == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.WriteString(s)
+	rf.size += int64(n)
+
+	return n, err
+}
+
+// Rotate renames the current file and opens a new file at the original path.
+func (rf *RotatingFile) Rotate() error {
+
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	return rf.rotate()
+}
+
+// Close closes the file. Any compression or removal of rotated files that is in progress is allowed to complete.
+func (rf *RotatingFile) Close() error {
+
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	rf.pending.Wait()
+
+	if rf.file == nil {
+		return nil
+	}
+
+	err := rf.file.Close()
+	rf.file = nil
+
+	return err
+}
+
+func (rf *RotatingFile) rotationDue(pendingBytes int) bool {
+
+	p := rf.policy
+
+	if p.MaxSizeBytes > 0 && rf.size > 0 && rf.size+int64(pendingBytes) > p.MaxSizeBytes {
+		return true
+	}
+
+	return p.Daily && !rf.now().Before(rf.nextDaily)
+}
+
+func (rf *RotatingFile) open() error {
+
+	if len(strings.TrimSpace(rf.path)) == 0 {
+		return errors.New("no path to a log file specified")
+	}
+
+	f, err := os.OpenFile(rf.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	var size int64
+
+	if fi, err := f.Stat(); err == nil {
+		size = fi.Size()
+	}
+
+	rf.file = f
+	rf.size = size
+
+	n := rf.now()
+	rf.nextDaily = time.Date(n.Year(), n.Month(), n.Day()+1, 0, 0, 0, 0, n.Location())
+
+	return nil
+}
+
+func (rf *RotatingFile) rotate() error {
+
+	if rf.file == nil {
+		return rf.open()
+	}
+
+	current, err := rf.file.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	if onDisk, err := os.Stat(rf.path); err != nil || !os.SameFile(current, onDisk) {
+		// The file has been moved by something else, so only a re-open is required
+		rf.file.Close()
+		rf.file = nil
+
+		return rf.open()
+	}
+
+	rf.file.Close()
+	rf.file = nil
+
+	rotated := rf.rotatedName()
+
+	if err := os.Rename(rf.path, rotated); err != nil {
+		// Carry on appending to the original file
+		rf.open()
+
+		return fmt.Errorf("unable to rotate %s: %s", rf.path, err.Error())
+	}
+
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	rf.pending.Add(1)
+
+	go rf.housekeep(rotated)
+
+	return nil
+}
+
+// rotatedName finds an unused name for the file being rotated. Names are based on the current time, with a numeric
+// suffix added if the file is rotated more than once a second.
+func (rf *RotatingFile) rotatedName() string {
+
+	base := rf.path + "." + rf.now().Format(rotatedTimeFormat)
+	name := base
+
+	for i := 1; exists(name) || exists(name+compressedExtension); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return name
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+
+	return err == nil
+}
+
+// housekeep compresses a newly rotated file (if required) and removes rotated files in excess of the policy's MaxBackups.
+// Runs outside of the lock held during writes so that large files do not block logging.
+func (rf *RotatingFile) housekeep(rotated string) {
+
+	defer rf.pending.Done()
+
+	rf.housekeeper.Lock()
+	defer rf.housekeeper.Unlock()
+
+	if rf.policy.Compress {
+		if compressFile(rotated) == nil {
+			os.Remove(rotated)
+		}
+	}
+
+	if rf.policy.MaxBackups > 0 {
+		rf.removeOldBackups()
+	}
+}
+
+func (rf *RotatingFile) removeOldBackups() {
+
+	backups := rf.backups()
+
+	for len(backups) > rf.policy.MaxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
+
+// backups returns the paths of the files this RotatingFile has rotated, oldest first. Files rotated by other tools
+// are ignored.
+func (rf *RotatingFile) backups() []string {
+
+	dir := filepath.Dir(rf.path)
+	prefix := filepath.Base(rf.path) + "."
+
+	infos, err := readDir(dir)
+
+	if err != nil {
+		return nil
+	}
+
+	keys := make(map[string]string)
+	var backups []string
+
+	for _, fi := range infos {
+
+		name := fi.Name()
+
+		if fi.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		if key, found := backupKey(strings.TrimSuffix(name[len(prefix):], compressedExtension)); found {
+			path := filepath.Join(dir, name)
+
+			keys[path] = key
+			backups = append(backups, path)
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return keys[backups[i]] < keys[backups[j]]
+	})
+
+	return backups
+}
+
+// backupKey checks that the supplied suffix of a file name is a rotation timestamp with an optional numeric suffix
+// (e.g. 20190502-150405 or 20190502-150405-2) and returns a key that sorts files in the order they were rotated.
+func backupKey(suffix string) (string, bool) {
+
+	if len(suffix) < len(rotatedTimeFormat) {
+		return "", false
+	}
+
+	stamp := suffix[:len(rotatedTimeFormat)]
+
+	if _, err := time.Parse(rotatedTimeFormat, stamp); err != nil {
+		return "", false
+	}
+
+	var n int
+
+	if counter := suffix[len(rotatedTimeFormat):]; counter != "" {
+
+		if counter[0] != '-' {
+			return "", false
+		}
+
+		if _, err := fmt.Sscanf(counter[1:], "%d", &n); err != nil || n < 1 {
+			return "", false
+		}
+	}
+
+	return fmt.Sprintf("%s-%09d", stamp, n), true
+}
+
+func readDir(dir string) ([]os.FileInfo, error) {
+
+	d, err := os.Open(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer d.Close()
+
+	return d.Readdir(-1)
+}
+
+func compressFile(path string) error {
+
+	in, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(path+compressedExtension, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+
+	if _, err = io.Copy(zw, in); err == nil {
+		err = zw.Close()
+	}
+
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(path + compressedExtension)
+	}
+
+	return err
+}
@@ -0,0 +1,193 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/graniticio/granitic/v2/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func rotationDir(t *testing.T) string {
+
+	dir, err := ioutil.TempDir("", "granitic-rotation")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestRotateBySize(t *testing.T) {
+
+	dir := rotationDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+
+	rf, err := OpenRotatingFile(path, &RotationPolicy{MaxSizeBytes: 10})
+	test.ExpectNil(t, err)
+
+	rf.WriteString("12345678\n")
+	rf.WriteString("abc\n")
+	rf.Close()
+
+	current, _ := ioutil.ReadFile(path)
+	test.ExpectString(t, string(current), "abc\n")
+
+	backups := rf.backups()
+	test.ExpectInt(t, len(backups), 1)
+
+	rotated, _ := ioutil.ReadFile(backups[0])
+	test.ExpectString(t, string(rotated), "12345678\n")
+}
+
+func TestRotateDaily(t *testing.T) {
+
+	dir := rotationDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+
+	rf, err := OpenRotatingFile(path, &RotationPolicy{Daily: true})
+	test.ExpectNil(t, err)
+
+	rf.WriteString("today\n")
+
+	rf.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+
+	rf.WriteString("tomorrow\n")
+	rf.Close()
+
+	current, _ := ioutil.ReadFile(path)
+	test.ExpectString(t, string(current), "tomorrow\n")
+	test.ExpectInt(t, len(rf.backups()), 1)
+}
+
+func TestRetentionAndCompression(t *testing.T) {
+
+	dir := rotationDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+
+	rf, err := OpenRotatingFile(path, &RotationPolicy{MaxBackups: 2, Compress: true})
+	test.ExpectNil(t, err)
+
+	for i := 0; i < 4; i++ {
+		rf.WriteString(fmt.Sprintf("line %d\n", i))
+		test.ExpectNil(t, rf.Rotate())
+	}
+
+	rf.Close()
+
+	backups := rf.backups()
+	test.ExpectInt(t, len(backups), 2)
+
+	newest := backups[1]
+	test.ExpectBool(t, strings.HasSuffix(newest, compressedExtension), true)
+
+	f, _ := os.Open(newest)
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	test.ExpectNil(t, err)
+
+	content, _ := ioutil.ReadAll(zr)
+	test.ExpectString(t, string(content), "line 3\n")
+}
+
+func TestRotateAfterExternalMove(t *testing.T) {
+
+	dir := rotationDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+
+	rf, err := OpenRotatingFile(path, nil)
+	test.ExpectNil(t, err)
+
+	rf.WriteString("before\n")
+
+	moved := path + ".1"
+	os.Rename(path, moved)
+
+	test.ExpectNil(t, rf.Rotate())
+
+	rf.WriteString("after\n")
+	rf.Close()
+
+	old, _ := ioutil.ReadFile(moved)
+	test.ExpectString(t, string(old), "before\n")
+
+	current, _ := ioutil.ReadFile(path)
+	test.ExpectString(t, string(current), "after\n")
+
+	test.ExpectInt(t, len(rf.backups()), 0)
+}
+
+func TestBackupKey(t *testing.T) {
+
+	_, found := backupKey("20190502-150405")
+	test.ExpectBool(t, found, true)
+
+	_, found = backupKey("1")
+	test.ExpectBool(t, found, false)
+
+	_, found = backupKey("20190502-150405x")
+	test.ExpectBool(t, found, false)
+
+	first, _ := backupKey("20190502-150405-2")
+	second, _ := backupKey("20190502-150405-10")
+	test.ExpectBool(t, first < second, true)
+}
+
+func TestNoMessagesLostDuringRotation(t *testing.T) {
+
+	dir := rotationDir(t)
+	defer os.RemoveAll(dir)
+
+	afw := new(AsynchFileWriter)
+	afw.LogPath = filepath.Join(dir, "app.log")
+	afw.BufferSize = 10
+
+	test.ExpectNil(t, afw.Init())
+
+	lines := 200
+
+	for i := 0; i < lines; i++ {
+		afw.WriteMessage(fmt.Sprintf("%d\n", i))
+
+		if i%50 == 0 {
+			test.ExpectNil(t, afw.Rotate())
+		}
+	}
+
+	written := func() int {
+		count := 0
+
+		for _, p := range append(afw.logFile.backups(), afw.LogPath) {
+			b, _ := ioutil.ReadFile(p)
+			count += strings.Count(string(b), "\n")
+		}
+
+		return count
+	}
+
+	// The last message may still be being written after the queue is empty
+	for i := 0; i < 100 && (afw.Busy() || written() < lines); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	afw.Close()
+
+	test.ExpectInt(t, written(), lines)
+}
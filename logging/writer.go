@@ -6,7 +6,6 @@ package logging
 import (
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 )
 
@@ -22,6 +21,15 @@ type LogWriter interface {
 	Busy() bool
 }
 
+// LevelAwareLogWriter is implemented by LogWriters that need to know the level a message was logged at (for example to
+// set the severity of a syslog message). Loggers call WriteMessageAtLevel instead of WriteMessage on these writers.
+type LevelAwareLogWriter interface {
+	LogWriter
+
+	// WriteMessageAtLevel requests that the supplied message, logged at the supplied level, be written.
+	WriteMessageAtLevel(string, LogLevel)
+}
+
 // ConsoleWriter is an implementation of LogWriter that sends messages to the console/stdout using the fmt.Print method
 type ConsoleWriter struct {
 }
@@ -60,16 +68,22 @@ func (cw *FixedPrefixConsoleWriter) Busy() bool {
 }
 
 // AsynchFileWriter is an implementation of LogWriter that appends a message to a file. Messages will be written
-// asynchronously as long as the number of messages queued for writing does not exceed the value of BufferSize
+// asynchronously as long as the number of messages queued for writing does not exceed the value of BufferSize.
+//
+// The file can be rotated automatically (see RotationPolicy) or on demand by calling Rotate. Messages queued while a
+// rotation is in progress are written to the new file once the rotation is complete.
 type AsynchFileWriter struct {
 	messages chan string
-	logFile  *os.File
+	logFile  *RotatingFile
 
 	//The number of messages that can be queued for writing before calls to WriteMessage block.
 	BufferSize int
 
 	//The file (absolute path or relative to application's working directory) that log messages should be appended to.
 	LogPath string
+
+	//Controls when the log file is automatically rotated. If nil, the file is only rotated when Rotate is called.
+	Rotation *RotationPolicy
 }
 
 // WriteMessage queues a message for writing and returns immediately, as long as the number of queued messages does not
@@ -111,7 +125,7 @@ func (afw *AsynchFileWriter) openFile() error {
 		return errors.New("File logging is enabled, but no path to a log file specified")
 	}
 
-	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	f, err := OpenRotatingFile(logPath, afw.Rotation)
 
 	if err != nil {
 		return err
@@ -121,9 +135,21 @@ func (afw *AsynchFileWriter) openFile() error {
 	return nil
 }
 
+// Rotate renames the current log file and starts writing to a new file at LogPath. Implements Rotatable
+func (afw *AsynchFileWriter) Rotate() error {
+
+	if afw.logFile == nil {
+		return errors.New("the log file is not open")
+	}
+
+	return afw.logFile.Rotate()
+}
+
 // Close closes the log file
 func (afw *AsynchFileWriter) Close() {
-	afw.logFile.Close()
+	if afw.logFile != nil {
+		afw.logFile.Close()
+	}
 }
 
 // Busy returns true if one or more messages are queued for writing.
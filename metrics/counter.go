@@ -0,0 +1,93 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"sync"
+)
+
+// Counter is a metric whose value only increases. A separate value is maintained for each distinct combination of
+// label values.
+type Counter struct {
+	desc   descriptor
+	mutex  sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	value float64
+}
+
+// Inc adds one to the value of the counter for the supplied label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds the supplied amount to the value of the counter for the supplied label values. Negative amounts are ignored.
+func (c *Counter) Add(v float64, labelValues ...string) {
+
+	if v < 0 {
+		return
+	}
+
+	k := c.desc.key(labelValues)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cv := c.values[k]
+
+	if cv == nil {
+		cv = new(counterValue)
+		c.values[k] = cv
+	}
+
+	cv.value += v
+}
+
+// Value returns the current value of the counter for the supplied label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if cv := c.values[c.desc.key(labelValues)]; cv != nil {
+		return cv.value
+	}
+
+	return 0
+}
+
+func (c *Counter) writeText(w *bufio.Writer) {
+
+	c.desc.writeHeader(w, "counter")
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	keys := make([]string, 0, len(c.values))
+
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+
+	for _, k := range sortedKeys(keys) {
+		fmt.Fprintf(w, "%s%s %s\n", c.desc.name, braced(c.desc.labelPairs(k)), formatFloat(c.values[k].value))
+	}
+}
+
+// A gauge whose value is obtained from a function when the registry is written
+type gaugeFunc struct {
+	desc descriptor
+	f    func() float64
+}
+
+func (g *gaugeFunc) writeText(w *bufio.Writer) {
+
+	g.desc.writeHeader(w, "gauge")
+
+	fmt.Fprintf(w, "%s %s\n", g.desc.name, formatFloat(g.f()))
+}
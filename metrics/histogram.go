@@ -0,0 +1,115 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Histogram counts observations (usually durations in seconds) in configurable buckets and records the sum and count
+// of all observations. A separate set of buckets is maintained for each distinct combination of label values.
+type Histogram struct {
+	desc    descriptor
+	buckets []float64
+	mutex   sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	// The number of observations falling into each bucket (not cumulative). The last element counts observations
+	// greater than the largest bucket's upper bound.
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Observe records a single observation for the supplied label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+
+	k := h.desc.key(labelValues)
+
+	// Index of the first bucket whose upper bound is greater than or equal to v
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	hv := h.values[k]
+
+	if hv == nil {
+		hv = new(histogramValue)
+		hv.counts = make([]uint64, len(h.buckets)+1)
+		h.values[k] = hv
+	}
+
+	hv.counts[i]++
+	hv.sum += v
+	hv.count++
+}
+
+// Count returns the number of observations recorded for the supplied label values.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if hv := h.values[h.desc.key(labelValues)]; hv != nil {
+		return hv.count
+	}
+
+	return 0
+}
+
+func (h *Histogram) writeText(w *bufio.Writer) {
+
+	h.desc.writeHeader(w, "histogram")
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	keys := make([]string, 0, len(h.values))
+
+	for k := range h.values {
+		keys = append(keys, k)
+	}
+
+	name := h.desc.name
+
+	for _, k := range sortedKeys(keys) {
+
+		hv := h.values[k]
+		pairs := h.desc.labelPairs(k)
+
+		prefix := ""
+
+		if pairs != "" {
+			prefix = pairs + ","
+		}
+
+		var cumulative uint64
+
+		for i, c := range hv.counts {
+
+			cumulative += c
+
+			le := math.Inf(1)
+
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+
+			fmt.Fprintf(w, "%s_bucket{%sle=\"%s\"} %d\n", name, prefix, formatFloat(le), cumulative)
+		}
+
+		labels := braced(strings.TrimSuffix(prefix, ","))
+
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatFloat(hv.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, labels, hv.count)
+	}
+}
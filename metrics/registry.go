@@ -0,0 +1,256 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package metrics provides counters, histograms and gauges that can be written in the Prometheus text exposition format.
+
+Most applications will not use this package directly, but will enable the Metrics facility (see facility/metrics) which
+records metrics about web service requests, scheduled tasks and database queries. Application code can record its own
+metrics by obtaining the Registry component created by that facility.
+
+Registering metrics
+
+Each metric is created through a Registry, which is responsible for writing every metric it holds in the text format:
+
+	r := metrics.NewRegistry()
+
+	orders, err := r.NewCounter("orders_total", "Number of orders placed", "region")
+
+	orders.Inc("emea")
+
+	latency, err := r.NewHistogram("lookup_seconds", "Time taken to look up a product", metrics.DefaultBuckets)
+
+	latency.Observe(0.023)
+
+Label values are supplied when a metric is updated, in the same order as the label names supplied when the metric was
+created. Metric and label names must match the patterns required by Prometheus ([a-zA-Z_:][a-zA-Z0-9_:]* and
+[a-zA-Z_][a-zA-Z0-9_]* respectively).
+
+All types in this package are safe for concurrent use.
+*/
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TextContentType is the value of the Content-Type header that should be used when serving the output of Registry.WriteText
+const TextContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds (in seconds) of histogram buckets suitable for measuring the latency of typical
+// web service requests.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var metricNamePattern = regexp.MustCompile("^[a-zA-Z_:][a-zA-Z0-9_:]*$")
+var labelNamePattern = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+// Separates label values when they are combined to form the key of a series
+const labelValueSeparator = "\xff"
+
+// family is implemented by all of the types of metric held by a Registry
+type family interface {
+	writeText(w *bufio.Writer)
+}
+
+// Registry holds a set of uniquely named metrics and writes them in the Prometheus text exposition format.
+type Registry struct {
+	mutex    sync.RWMutex
+	families map[string]family
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	r := new(Registry)
+	r.families = make(map[string]family)
+
+	return r
+}
+
+// NewCounter creates and registers a Counter. Returns an error if the name or any of the label names is invalid or a
+// metric with the same name has already been registered.
+func (r *Registry) NewCounter(name string, help string, labels ...string) (*Counter, error) {
+
+	c := new(Counter)
+	c.desc = descriptor{name: name, help: help, labels: labels}
+	c.values = make(map[string]*counterValue)
+
+	return c, r.register(c.desc, c)
+}
+
+// NewHistogram creates and registers a Histogram with the supplied bucket upper bounds, which must be in increasing
+// order. A bucket with an upper bound of +Inf is always added. Returns an error if the name or any of the label names
+// is invalid, the buckets are not in increasing order or a metric with the same name has already been registered.
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labels ...string) (*Histogram, error) {
+
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return nil, fmt.Errorf("buckets for histogram %s are not in increasing order", name)
+		}
+	}
+
+	for _, l := range labels {
+		if l == "le" {
+			return nil, fmt.Errorf("histogram %s cannot use the reserved label name le", name)
+		}
+	}
+
+	h := new(Histogram)
+	h.desc = descriptor{name: name, help: help, labels: labels}
+	h.buckets = append([]float64{}, buckets...)
+	h.values = make(map[string]*histogramValue)
+
+	return h, r.register(h.desc, h)
+}
+
+// NewGaugeFunc creates and registers a gauge whose value is obtained by calling the supplied function each time the
+// Registry is written. Returns an error if the name is invalid or a metric with the same name has already been registered.
+func (r *Registry) NewGaugeFunc(name string, help string, f func() float64) error {
+
+	g := new(gaugeFunc)
+	g.desc = descriptor{name: name, help: help}
+	g.f = f
+
+	return r.register(g.desc, g)
+}
+
+func (r *Registry) register(d descriptor, f family) error {
+
+	if !metricNamePattern.MatchString(d.name) {
+		return fmt.Errorf("%s is not a valid metric name", d.name)
+	}
+
+	for _, l := range d.labels {
+		if !labelNamePattern.MatchString(l) || strings.HasPrefix(l, "__") {
+			return fmt.Errorf("%s is not a valid label name for metric %s", l, d.name)
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.families[d.name] != nil {
+		return fmt.Errorf("a metric named %s has already been registered", d.name)
+	}
+
+	r.families[d.name] = f
+
+	return nil
+}
+
+// WriteText writes every registered metric to the supplied Writer in the Prometheus text exposition format. Metrics
+// are written in name order.
+func (r *Registry) WriteText(w io.Writer) error {
+
+	r.mutex.RLock()
+
+	names := make([]string, 0, len(r.families))
+
+	for n := range r.families {
+		names = append(names, n)
+	}
+
+	families := make([]family, len(names))
+
+	sort.Strings(names)
+
+	for i, n := range names {
+		families[i] = r.families[n]
+	}
+
+	r.mutex.RUnlock()
+
+	bw := bufio.NewWriter(w)
+
+	for _, f := range families {
+		f.writeText(bw)
+	}
+
+	return bw.Flush()
+}
+
+// descriptor holds the name, help text and label names of a metric
+type descriptor struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d *descriptor) writeHeader(w *bufio.Writer, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, metricType)
+}
+
+// key combines label values into a string that uniquely identifies a series. The supplied values are truncated or
+// padded with empty strings to match the number of labels.
+func (d *descriptor) key(values []string) string {
+
+	if len(values) == len(d.labels) {
+		return strings.Join(values, labelValueSeparator)
+	}
+
+	fixed := make([]string, len(d.labels))
+	copy(fixed, values)
+
+	return strings.Join(fixed, labelValueSeparator)
+}
+
+// labelPairs renders the labels of a series in the form name="value",name="value"
+func (d *descriptor) labelPairs(key string) string {
+
+	if len(d.labels) == 0 {
+		return ""
+	}
+
+	values := strings.Split(key, labelValueSeparator)
+	pairs := make([]string, len(d.labels))
+
+	for i, l := range d.labels {
+		pairs[i] = l + "=\"" + escapeLabelValue(values[i]) + "\""
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func sortedKeys(keys []string) []string {
+	sort.Strings(keys)
+	return keys
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\"", "\\\"").Replace(s)
+}
+
+func formatFloat(f float64) string {
+
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func braced(pairs string) string {
+
+	if pairs == "" {
+		return ""
+	}
+
+	return "{" + pairs + "}"
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package metrics
+
+import (
+	"bytes"
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func TestTextFormat(t *testing.T) {
+
+	r := NewRegistry()
+
+	c, err := r.NewCounter("requests_total", "Requests\nreceived", "path", "status")
+	test.ExpectNil(t, err)
+
+	c.Inc("/b", "200")
+	c.Add(2, "/a", "500")
+	c.Inc("/a\"", "200")
+	c.Add(-1, "/a", "500")
+
+	h, err := r.NewHistogram("latency_seconds", "Request latency", []float64{0.1, 1}, "path")
+	test.ExpectNil(t, err)
+
+	h.Observe(0.05, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(1, "/a")
+	h.Observe(3, "/a")
+
+	test.ExpectNil(t, r.NewGaugeFunc("active", "Active requests", func() float64 { return 4 }))
+
+	var b bytes.Buffer
+	test.ExpectNil(t, r.WriteText(&b))
+
+	expected := `# HELP active Active requests
+# TYPE active gauge
+active 4
+# HELP latency_seconds Request latency
+# TYPE latency_seconds histogram
+latency_seconds_bucket{path="/a",le="0.1"} 1
+latency_seconds_bucket{path="/a",le="1"} 3
+latency_seconds_bucket{path="/a",le="+Inf"} 4
+latency_seconds_sum{path="/a"} 4.55
+latency_seconds_count{path="/a"} 4
+# HELP requests_total Requests\nreceived
+# TYPE requests_total counter
+requests_total{path="/a\"",status="200"} 1
+requests_total{path="/a",status="500"} 2
+requests_total{path="/b",status="200"} 1
+`
+
+	test.ExpectString(t, b.String(), expected)
+
+	test.ExpectInt(t, int(c.Value("/a", "500")), 2)
+	test.ExpectInt(t, int(h.Count("/a")), 4)
+}
+
+func TestInvalidMetrics(t *testing.T) {
+
+	r := NewRegistry()
+
+	_, err := r.NewCounter("valid_total", "")
+	test.ExpectNil(t, err)
+
+	_, err = r.NewCounter("valid_total", "")
+	test.ExpectNotNil(t, err)
+
+	_, err = r.NewCounter("1invalid", "")
+	test.ExpectNotNil(t, err)
+
+	_, err = r.NewCounter("invalid_label", "", "a-b")
+	test.ExpectNotNil(t, err)
+
+	_, err = r.NewHistogram("unordered", "", []float64{1, 0.5})
+	test.ExpectNotNil(t, err)
+
+	_, err = r.NewHistogram("reserved", "", DefaultBuckets, "le")
+	test.ExpectNotNil(t, err)
+}
@@ -0,0 +1,617 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package openapi generates OpenAPI 3 documents describing the web service endpoints defined in a Granitic application.
+
+The types in this package are used by the OpenAPI facility (see facility/openapi), which generates a document at start-up
+and serves it from an HTTP endpoint, but a Generator can be used independently.
+
+What is documented
+
+A document is generated by examining instances of handler.WsHandler. For each handler:
+
+	HTTPMethod and PathTemplate/PathPattern   Define the operation and its path
+	BindPathParams                            Names the path parameters
+	FieldQueryParam/AutoBindQuery             Define the query parameters
+	ProcessPayload/UnmarshallTarget           The target type is used to generate the schema of the request body and
+	                                          the types of parameters
+	AutoValidator                             REQ, LEN, REG, IN and RANGE checks are converted into schema constraints
+	RequireAuthentication, AccessChecker,     Add 401, 403 and 429 responses respectively
+	RateLimiter
+
+Paths derived from PathPattern regular expressions are converted on a best-effort basis, with each capturing group
+replaced by a named parameter. The original expression is recorded in the x-granitic-path-pattern extension of the operation.
+
+The type of response bodies cannot be determined from a handler, so responses are described without a schema.
+*/
+package openapi
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/validate"
+	"github.com/graniticio/granitic/v2/ws/handler"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultMediaType is used for request bodies if Generator.MediaType is not set.
+const DefaultMediaType = "application/json"
+
+// Generator creates an OpenAPI Document from a set of handler.WsHandlers.
+type Generator struct {
+	// Metadata about the API.
+	Info Info
+
+	// The URLs at which the API is available (optional).
+	Servers []*Server
+
+	// The media type of request bodies (defaults to application/json)
+	MediaType string
+
+	// Used to warn about handlers that are not included in the document (optional).
+	Log logging.Logger
+}
+
+// Generate creates a Document describing the supplied handlers, which are keyed by component name. Handlers are processed
+// in alphabetical order of name so that the output is stable. If more than one handler maps to the same path and method
+// (e.g. version-aware handlers), only the first is described. Handlers using an HTTP method that cannot be described in
+// an OpenAPI document are skipped. A warning is logged for each handler that is not described. An error is returned if
+// a handler's path cannot be converted or its rules reference a missing shared rule.
+func (g *Generator) Generate(handlers map[string]*handler.WsHandler) (*Document, error) {
+
+	d := new(Document)
+	d.OpenAPI = Version
+	d.Servers = g.Servers
+	d.Paths = make(map[string]*PathItem)
+
+	info := g.Info
+	d.Info = &info
+
+	names := make([]string, 0, len(handlers))
+
+	for n := range handlers {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+
+		h := handlers[n]
+
+		path, op, err := g.describe(n, h)
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe handler %s: %s", n, err.Error())
+		}
+
+		method := strings.ToUpper(h.HTTPMethod)
+		pi := d.Paths[path]
+
+		if pi == nil {
+			pi = new(PathItem)
+		}
+
+		if existing := pi.operation(method); existing != nil {
+
+			if h.VersionAware() {
+				g.warnf("Handler %s is a version-aware handler for %s %s - only %s is included in the OpenAPI document", n, method, path, existing.OperationID)
+			} else {
+				g.warnf("Handlers %s and %s both handle %s %s - only %s is included in the OpenAPI document", existing.OperationID, n, method, path, existing.OperationID)
+			}
+
+			continue
+		}
+
+		if !pi.SetOperation(method, op) {
+			g.warnf("Handler %s uses HTTP method %s which cannot be described in an OpenAPI document and has been skipped", n, method)
+			continue
+		}
+
+		d.Paths[path] = pi
+	}
+
+	return d, nil
+}
+
+func (g *Generator) warnf(format string, a ...interface{}) {
+	if g.Log != nil {
+		g.Log.LogWarnf(format, a...)
+	}
+}
+
+func (g *Generator) describe(name string, h *handler.WsHandler) (string, *Operation, error) {
+
+	op := new(Operation)
+	op.OperationID = name
+	op.Responses = make(map[string]*Response)
+
+	var target *Schema
+
+	if t := h.RequestTarget(); t != nil {
+		target = SchemaFor(reflect.TypeOf(t))
+	}
+
+	constraints, err := fieldConstraints(h.AutoValidator)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	path, pathParams, err := g.path(h)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	op.PathPattern = h.PathPattern
+
+	bound := make(map[string]bool)
+
+	for i, pp := range pathParams {
+
+		field := pp.Name
+
+		if i < len(h.BindPathParams) {
+			field = h.BindPathParams[i]
+		}
+
+		if ps := target.Property(field); ps != nil && pp.Schema.Type == "string" {
+			pp.Schema = ps.copy()
+		}
+
+		applyConstraints(pp.Schema, constraints[field])
+		pp.Required = true
+		bound[field] = true
+
+		op.Parameters = append(op.Parameters, pp)
+	}
+
+	for _, field := range g.queryFields(h, target, bound) {
+
+		p := new(Parameter)
+		p.In = "query"
+		p.Name = field
+		p.Schema = &Schema{Type: "string"}
+
+		if qp := h.FieldQueryParam[field]; qp != "" {
+			p.Name = qp
+		}
+
+		if ps := target.Property(field); ps != nil {
+			p.Schema = ps.copy()
+		}
+
+		if fc := constraints[field]; fc != nil {
+			applyConstraints(p.Schema, fc)
+			p.Required = fc.Required
+		}
+
+		bound[field] = true
+
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	if target != nil && hasBody(h.HTTPMethod) {
+		op.RequestBody = g.requestBody(target, constraints, bound)
+	}
+
+	g.addResponses(h, op)
+
+	return path, op, nil
+}
+
+// path converts the handler's template or regular expression into an OpenAPI path and a set of parameters.
+func (g *Generator) path(h *handler.WsHandler) (string, []*Parameter, error) {
+
+	params := make([]*Parameter, 0)
+
+	if h.PathTemplate != "" {
+
+		pt, err := httpendpoint.ParsePathTemplate(h.PathTemplate)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		segments := make([]string, 0, len(pt.Segments))
+
+		for _, s := range pt.Segments {
+
+			if !s.IsParam() {
+				segments = append(segments, s.Static)
+				continue
+			}
+
+			name := s.Param
+
+			if i := len(params); i < len(h.BindPathParams) {
+				name = h.BindPathParams[i]
+			}
+
+			segments = append(segments, "{"+name+"}")
+
+			params = append(params, &Parameter{Name: name, In: "path", Schema: templateParamSchema(s.Type)})
+		}
+
+		return "/" + strings.Join(segments, "/"), params, nil
+	}
+
+	path, groups := RegexToPath(h.PathPattern, h.BindPathParams)
+
+	for _, name := range groups {
+		params = append(params, &Parameter{Name: name, In: "path", Schema: &Schema{Type: "string"}})
+	}
+
+	return path, params, nil
+}
+
+// queryFields returns the names of the fields on the target that can be populated from query parameters, in a stable order.
+func (g *Generator) queryFields(h *handler.WsHandler, target *Schema, pathBound map[string]bool) []string {
+
+	fields := make([]string, 0)
+
+	if h.DisableQueryParsing || target == nil {
+		return fields
+	}
+
+	if h.AutoBindQuery {
+
+		if hasBody(h.HTTPMethod) {
+			// Automatically bound fields could come from either the body or the query, so only the body is described
+			return fields
+		}
+
+		for f, p := range target.fieldNames {
+
+			ps := target.Properties[p]
+
+			if !pathBound[f] && ps.Type != "object" && ps.Type != "array" {
+				fields = append(fields, f)
+			}
+		}
+
+	} else {
+
+		for f := range h.FieldQueryParam {
+			fields = append(fields, f)
+		}
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+func (g *Generator) requestBody(target *Schema, constraints map[string]*validate.FieldConstraints, bound map[string]bool) *RequestBody {
+
+	body := deepCopy(target)
+
+	for f := range bound {
+		delete(body.Properties, body.PropertyName(f))
+	}
+
+	if len(body.Properties) == 0 {
+		return nil
+	}
+
+	required := false
+
+	for field, fc := range constraints {
+
+		if bound[field] || strings.ContainsAny(field, "[]") {
+			continue
+		}
+
+		parent, s, name := resolveField(body, field)
+
+		if s == nil {
+			continue
+		}
+
+		applyConstraints(s, fc)
+
+		if fc.Required {
+			parent.Required = append(parent.Required, name)
+			required = true
+		}
+	}
+
+	sortRequired(body)
+
+	mt := g.MediaType
+
+	if mt == "" {
+		mt = DefaultMediaType
+	}
+
+	rb := new(RequestBody)
+	rb.Required = required
+	rb.Content = map[string]*MediaType{mt: {Schema: body}}
+
+	return rb
+}
+
+func (g *Generator) addResponses(h *handler.WsHandler, op *Operation) {
+
+	r := op.Responses
+
+	r["200"] = &Response{Description: http.StatusText(http.StatusOK)}
+	r["400"] = &Response{Description: http.StatusText(http.StatusBadRequest)}
+	r["500"] = &Response{Description: http.StatusText(http.StatusInternalServerError)}
+
+	if h.RequireAuthentication {
+		r["401"] = &Response{Description: http.StatusText(http.StatusUnauthorized)}
+	}
+
+	if h.AccessChecker != nil {
+		r["403"] = &Response{Description: http.StatusText(http.StatusForbidden)}
+	}
+
+	if h.RateLimiter != nil {
+		r["429"] = &Response{Description: http.StatusText(http.StatusTooManyRequests)}
+	}
+}
+
+func (pi *PathItem) operation(method string) *Operation {
+
+	switch method {
+	case "GET":
+		return pi.Get
+	case "PUT":
+		return pi.Put
+	case "POST":
+		return pi.Post
+	case "DELETE":
+		return pi.Delete
+	case "OPTIONS":
+		return pi.Options
+	case "HEAD":
+		return pi.Head
+	case "PATCH":
+		return pi.Patch
+	case "TRACE":
+		return pi.Trace
+	}
+
+	return nil
+}
+
+func fieldConstraints(rv *validate.RuleValidator) (map[string]*validate.FieldConstraints, error) {
+
+	m := make(map[string]*validate.FieldConstraints)
+
+	if rv == nil {
+		return m, nil
+	}
+
+	all, err := rv.Constraints()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fc := range all {
+		m[fc.Field] = fc
+	}
+
+	return m, nil
+}
+
+// applyConstraints converts the checks from a validation rule into the equivalent schema constraints.
+func applyConstraints(s *Schema, fc *validate.FieldConstraints) {
+
+	if fc == nil {
+		return
+	}
+
+	if fc.Type == "SLICE" || s.Type == "array" {
+		s.MinItems, s.MaxItems = fc.MinLength, fc.MaxLength
+	} else {
+		s.MinLength, s.MaxLength = fc.MinLength, fc.MaxLength
+	}
+
+	s.Pattern = fc.Pattern
+	s.Minimum, s.Maximum = fc.Min, fc.Max
+
+	if fc.In != nil {
+
+		s.Enum = make([]interface{}, 0, len(fc.In))
+
+		for _, v := range fc.In {
+			s.Enum = append(s.Enum, enumValue(s.Type, v))
+		}
+	}
+}
+
+func enumValue(schemaType, v string) interface{} {
+
+	switch schemaType {
+	case "integer":
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+
+	return v
+}
+
+// resolveField follows a dotted field path (e.g. Address.Street) through nested object schemas, returning the schema
+// containing the final field, the field's schema and its property name.
+func resolveField(s *Schema, path string) (parent, field *Schema, name string) {
+
+	parts := strings.Split(path, ".")
+
+	for i, p := range parts {
+
+		parent = s
+		name = s.PropertyName(p)
+		s = s.Property(p)
+
+		if s == nil {
+			return nil, nil, ""
+		}
+
+		if i < len(parts)-1 && s.Type != "object" {
+			return nil, nil, ""
+		}
+	}
+
+	return parent, s, name
+}
+
+func sortRequired(s *Schema) {
+
+	if s == nil {
+		return
+	}
+
+	sort.Strings(s.Required)
+
+	for _, p := range s.Properties {
+		sortRequired(p)
+	}
+
+	sortRequired(s.Items)
+}
+
+// deepCopy copies a schema and all of its nested object schemas so constraints can be applied to a single handler's
+// view of a type.
+func deepCopy(s *Schema) *Schema {
+
+	if s == nil {
+		return nil
+	}
+
+	c := s.copy()
+	c.Items = deepCopy(s.Items)
+	c.AdditionalProperties = deepCopy(s.AdditionalProperties)
+
+	if s.Properties != nil {
+
+		c.Properties = make(map[string]*Schema, len(s.Properties))
+
+		for n, p := range s.Properties {
+			c.Properties[n] = deepCopy(p)
+		}
+	}
+
+	if s.Required != nil {
+		c.Required = append([]string{}, s.Required...)
+	}
+
+	return c
+}
+
+func templateParamSchema(paramType string) *Schema {
+
+	switch paramType {
+	case httpendpoint.IntParam:
+		return &Schema{Type: "integer", Format: "int64"}
+	case httpendpoint.FloatParam:
+		return &Schema{Type: "number", Format: "double"}
+	}
+
+	return &Schema{Type: "string"}
+}
+
+func hasBody(method string) bool {
+
+	switch strings.ToUpper(method) {
+	case "GET", "DELETE", "HEAD", "OPTIONS", "TRACE":
+		return false
+	}
+
+	return true
+}
+
+// RegexToPath converts a regular expression used to match request paths (e.g. ^/artist/([\d]+)[/]?$) into an OpenAPI
+// path (e.g. /artist/{id}). Each capturing group is replaced by a parameter named after the corresponding entry in names
+// or, if there are fewer names than groups, param1, param2 etc. Returns the path and the names of the parameters in the order
+// they appear.
+func RegexToPath(pattern string, names []string) (string, []string) {
+
+	p := strings.TrimPrefix(pattern, "^")
+	p = strings.TrimSuffix(p, "$")
+
+	for _, optionalSlash := range []string{"[/]?", "/?", "\\/?"} {
+		p = strings.TrimSuffix(p, optionalSlash)
+	}
+
+	var b strings.Builder
+	params := make([]string, 0)
+
+	for i := 0; i < len(p); i++ {
+
+		c := p[i]
+
+		switch {
+		case c == '\\' && i+1 < len(p):
+			i++
+			b.WriteByte(p[i])
+		case c == '(' && !strings.HasPrefix(p[i:], "(?"):
+
+			end := closingParen(p, i)
+
+			name := fmt.Sprintf("param%d", len(params)+1)
+
+			if len(params) < len(names) {
+				name = names[len(params)]
+			}
+
+			params = append(params, name)
+			b.WriteString("{" + name + "}")
+
+			i = end
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	path := b.String()
+
+	if path == "" {
+		path = "/"
+	}
+
+	return path, params
+}
+
+// closingParen returns the index of the parenthesis closing the group opened at start (or the end of the string if unbalanced).
+func closingParen(p string, start int) int {
+
+	depth := 0
+	inClass := false
+
+	for i := start; i < len(p); i++ {
+
+		switch c := p[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return len(p) - 1
+}
@@ -0,0 +1,183 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package openapi
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/types"
+	"github.com/graniticio/granitic/v2/validate"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/handler"
+	"reflect"
+	"testing"
+)
+
+type address struct {
+	Street   string
+	Postcode string `json:"postcode,omitempty"`
+}
+
+type record struct {
+	ID       int64
+	Title    string `json:"title"`
+	Genre    *types.NilableString
+	Rating   float64
+	Tracks   []string
+	Address  *address
+	Secret   string `json:"-"`
+	internal string
+}
+
+type recordLogic struct{}
+
+func (rl *recordLogic) ProcessPayload(ctx context.Context, req *ws.Request, res *ws.Response, r *record) {
+}
+
+func TestSchemaFor(t *testing.T) {
+
+	s := SchemaFor(reflect.TypeOf(new(record)))
+
+	test.ExpectString(t, s.Type, "object")
+	test.ExpectInt(t, len(s.Properties), 6)
+	test.ExpectString(t, s.Property("ID").Format, "int64")
+	test.ExpectString(t, s.Property("Title").Type, "string")
+	test.ExpectString(t, s.PropertyName("Title"), "title")
+	test.ExpectString(t, s.Property("Genre").Type, "string")
+	test.ExpectString(t, s.Property("Rating").Type, "number")
+	test.ExpectString(t, s.Property("Tracks").Items.Type, "string")
+	test.ExpectString(t, s.Property("Address").Property("Postcode").Type, "string")
+	test.ExpectBool(t, s.Property("Secret") == nil, true)
+}
+
+func TestRegexToPath(t *testing.T) {
+
+	p, params := RegexToPath("^/artist/([\\d]+)/album/(\\w+(?:-\\w+)?)[/]?$", []string{"ArtistID"})
+
+	test.ExpectString(t, p, "/artist/{ArtistID}/album/{param2}")
+	test.ExpectInt(t, len(params), 2)
+
+	p, params = RegexToPath("^/v1\\.0/health$", nil)
+
+	test.ExpectString(t, p, "/v1.0/health")
+	test.ExpectInt(t, len(params), 0)
+}
+
+func TestGenerate(t *testing.T) {
+
+	get := new(handler.WsHandler)
+	get.HTTPMethod = "GET"
+	get.PathTemplate = "/record/{ID:int}"
+	get.Logic = new(recordLogic)
+	get.FieldQueryParam = map[string]string{"Genre": "genre"}
+	get.RequireAuthentication = true
+	get.AutoValidator = &validate.RuleValidator{Rules: [][]string{
+		{"Genre", "STR", "REQ", "IN:rock,jazz"},
+		{"ID", "INT", "RANGE:1|"},
+	}}
+
+	create := new(handler.WsHandler)
+	create.HTTPMethod = "POST"
+	create.PathPattern = "^/record[/]?$"
+	create.Logic = new(recordLogic)
+	create.AutoValidator = &validate.RuleValidator{Rules: [][]string{
+		{"Title", "STR", "REQ", "LEN:1-128"},
+		{"Tracks", "SLICE", "LEN:1-20"},
+		{"Address.Street", "STR", "REQ"},
+	}}
+
+	g := new(Generator)
+	g.Info = Info{Title: "Records", Version: "1.0"}
+
+	d, err := g.Generate(map[string]*handler.WsHandler{"getRecord": get, "createRecord": create})
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, d.OpenAPI, Version)
+	test.ExpectString(t, d.Info.Title, "Records")
+
+	op := d.Paths["/record/{ID}"].Get
+
+	test.ExpectString(t, op.OperationID, "getRecord")
+	test.ExpectInt(t, len(op.Parameters), 2)
+
+	id := op.Parameters[0]
+	test.ExpectString(t, id.In, "path")
+	test.ExpectString(t, id.Schema.Type, "integer")
+	test.ExpectFloat(t, *id.Schema.Minimum, 1)
+	test.ExpectBool(t, id.Required, true)
+
+	genre := op.Parameters[1]
+	test.ExpectString(t, genre.In, "query")
+	test.ExpectString(t, genre.Name, "genre")
+	test.ExpectBool(t, genre.Required, true)
+	test.ExpectInt(t, len(genre.Schema.Enum), 2)
+
+	test.ExpectBool(t, op.RequestBody == nil, true)
+	test.ExpectNotNil(t, op.Responses["401"])
+	test.ExpectBool(t, op.Responses["403"] == nil, true)
+
+	op = d.Paths["/record"].Post
+
+	test.ExpectString(t, op.PathPattern, "^/record[/]?$")
+
+	body := op.RequestBody.Content[DefaultMediaType].Schema
+
+	test.ExpectBool(t, op.RequestBody.Required, true)
+	test.ExpectInt(t, len(body.Required), 1)
+	test.ExpectString(t, body.Required[0], "title")
+	test.ExpectInt(t, *body.Property("Title").MaxLength, 128)
+	test.ExpectInt(t, *body.Property("Tracks").MaxItems, 20)
+	test.ExpectString(t, body.Property("Address").Required[0], "Street")
+
+	// Constraints should not leak between handlers using the same type
+	test.ExpectBool(t, SchemaFor(reflect.TypeOf(new(record))).Property("Title").MaxLength == nil, true)
+}
+
+func TestDuplicateOperationsSkipped(t *testing.T) {
+
+	a := &handler.WsHandler{HTTPMethod: "GET", PathTemplate: "/a/{id}", Logic: new(recordLogic)}
+	b := &handler.WsHandler{HTTPMethod: "GET", PathPattern: "^/a/([^/]+)$", BindPathParams: []string{"id"}, Logic: new(recordLogic)}
+	v2 := &handler.WsHandler{HTTPMethod: "GET", PathTemplate: "/a/{id}", Logic: new(recordLogic), VersionAssessor: new(allVersions)}
+
+	log := new(warningCounter)
+
+	d, err := (&Generator{Log: log}).Generate(map[string]*handler.WsHandler{"a": a, "b": b, "v2": v2})
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(d.Paths), 1)
+	test.ExpectString(t, d.Paths["/a/{id}"].Get.OperationID, "a")
+	test.ExpectInt(t, log.warnings, 2)
+}
+
+func TestCustomMethodSkipped(t *testing.T) {
+
+	a := &handler.WsHandler{HTTPMethod: "GET", PathTemplate: "/a", Logic: new(recordLogic)}
+	purge := &handler.WsHandler{HTTPMethod: "PURGE", PathTemplate: "/cache", Logic: new(recordLogic)}
+
+	log := new(warningCounter)
+
+	d, err := (&Generator{Log: log}).Generate(map[string]*handler.WsHandler{"a": a, "purge": purge})
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(d.Paths), 1)
+	test.ExpectNotNil(t, d.Paths["/a"])
+	test.ExpectInt(t, log.warnings, 1)
+}
+
+type allVersions struct{}
+
+func (av *allVersions) SupportsVersion(handlerName string, version httpendpoint.RequiredVersion) bool {
+	return true
+}
+
+type warningCounter struct {
+	logging.ConsoleErrorLogger
+	warnings int
+}
+
+func (wc *warningCounter) LogWarnf(format string, a ...interface{}) {
+	wc.warnings++
+}
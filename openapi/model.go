@@ -0,0 +1,152 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package openapi
+
+// Version is the version of the OpenAPI specification that generated documents conform to.
+const Version = "3.0.3"
+
+// Document is the root of an OpenAPI 3 document. Only the subset of the specification that can be derived from a
+// Granitic application's components is modelled.
+type Document struct {
+	OpenAPI string               `json:"openapi"`
+	Info    *Info                `json:"info"`
+	Servers []*Server            `json:"servers,omitempty"`
+	Paths   map[string]*PathItem `json:"paths"`
+}
+
+// Info provides metadata about the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a URL at which the API is available.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem describes the operations available on a single path.
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Trace   *Operation `json:"trace,omitempty"`
+}
+
+// SetOperation stores the operation against the field corresponding to the supplied HTTP method. Returns false if the
+// method is not supported by OpenAPI.
+func (pi *PathItem) SetOperation(method string, o *Operation) bool {
+
+	switch method {
+	case "GET":
+		pi.Get = o
+	case "PUT":
+		pi.Put = o
+	case "POST":
+		pi.Post = o
+	case "DELETE":
+		pi.Delete = o
+	case "OPTIONS":
+		pi.Options = o
+	case "HEAD":
+		pi.Head = o
+	case "PATCH":
+		pi.Patch = o
+	case "TRACE":
+		pi.Trace = o
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+
+	// The regular expression used to match requests, if the path was derived from a regular expression rather than a template.
+	PathPattern string `json:"x-granitic-path-pattern,omitempty"`
+}
+
+// Parameter describes a single path or query parameter.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a single response from an operation.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType associates a schema with a media type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema describes a data type.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+
+	// Maps Go field names to property names (which may differ due to struct tags). Not serialised.
+	fieldNames map[string]string
+}
+
+// Property returns the schema of the property corresponding to the named Go field, or nil if there is no such property.
+func (s *Schema) Property(field string) *Schema {
+
+	if s == nil || s.Properties == nil {
+		return nil
+	}
+
+	if n, found := s.fieldNames[field]; found {
+		return s.Properties[n]
+	}
+
+	return s.Properties[field]
+}
+
+// PropertyName returns the name of the property corresponding to the named Go field.
+func (s *Schema) PropertyName(field string) string {
+
+	if n, found := s.fieldNames[field]; found {
+		return n
+	}
+
+	return field
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package openapi
+
+import (
+	"github.com/graniticio/granitic/v2/types"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var nilableSchemas = map[reflect.Type]*Schema{
+	reflect.TypeOf(types.NilableString{}):  {Type: "string"},
+	reflect.TypeOf(types.NilableBool{}):    {Type: "boolean"},
+	reflect.TypeOf(types.NilableInt64{}):   {Type: "integer", Format: "int64"},
+	reflect.TypeOf(types.NilableFloat64{}): {Type: "number", Format: "double"},
+	reflect.TypeOf(time.Time{}):            {Type: "string", Format: "date-time"},
+}
+
+// SchemaFor creates a Schema describing the JSON representation of the supplied type. Struct fields are named according
+// to their json tags. Recursive types are described as untyped objects at the point of recursion.
+func SchemaFor(t reflect.Type) *Schema {
+	return schemaFor(t, make(map[reflect.Type]bool))
+}
+
+func schemaFor(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if s, found := nilableSchemas[t]; found {
+		c := *s
+		return &c
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: schemaFor(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), seen)}
+	case reflect.Struct:
+
+		if seen[t] {
+			return &Schema{Type: "object"}
+		}
+
+		seen[t] = true
+		defer delete(seen, t)
+
+		return structSchema(t, seen)
+	}
+
+	return &Schema{}
+}
+
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+
+	s := &Schema{Type: "object"}
+	s.Properties = make(map[string]*Schema)
+	s.fieldNames = make(map[string]string)
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+
+		if f.PkgPath != "" {
+			// Unexported
+			continue
+		}
+
+		name := f.Name
+
+		if tag, found := f.Tag.Lookup("json"); found {
+
+			tagName := strings.Split(tag, ",")[0]
+
+			if tagName == "-" {
+				continue
+			}
+
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		s.Properties[name] = schemaFor(f.Type, seen)
+		s.fieldNames[f.Name] = name
+	}
+
+	return s
+}
+
+// copy creates a shallow copy of the schema, so that constraints can be applied without affecting the original.
+func (s *Schema) copy() *Schema {
+	c := *s
+	return &c
+}
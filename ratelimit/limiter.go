@@ -0,0 +1,186 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/iam"
+	"github.com/graniticio/granitic/v2/logging"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultIdentityField = "LoggableUserID"
+const forwardedForHeader = "X-Forwarded-For"
+
+// Limiter decides whether requests to web service handlers are permitted, using a Limit configured for the handler
+// or, if no Limit is configured for the handler, a default Limit. Each handler's callers are counted separately.
+// Implements ws.RateLimiter
+type Limiter struct {
+	// Logger used by Granitic framework components. Automatically injected.
+	FrameworkLogger logging.Logger
+
+	// The Limit applied to handlers that do not have their own Limit in Handlers. If nil, handlers without their own
+	// Limit are not rate limited.
+	Default *Limit
+
+	// Limits for specific handlers, keyed by the handler's component name.
+	Handlers map[string]*Limit
+
+	// Where records of requests are kept.
+	Store Store
+
+	// Use the first address in the X-Forwarded-For header (if present) as the caller's IP address. Only enable this
+	// if your application is behind a proxy or load-balancer that sets this header.
+	TrustForwardedFor bool
+
+	mutex sync.RWMutex
+}
+
+// Allow returns true if the request to the named handler is permitted. If not, it also returns how long the caller
+// should wait before retrying.
+func (l *Limiter) Allow(ctx context.Context, handlerName string, req *http.Request, identity iam.ClientIdentity) (bool, time.Duration) {
+
+	l.mutex.RLock()
+
+	limit := l.Handlers[handlerName]
+
+	if limit == nil {
+		limit = l.Default
+	}
+
+	l.mutex.RUnlock()
+
+	if limit == nil {
+		return true, 0
+	}
+
+	return l.Check(ctx, handlerName, limit, req, identity)
+}
+
+// Check returns true if the request is permitted under the supplied Limit. Requests are only counted against other requests
+// with the same scope. If the request is not permitted, Check also returns how long the caller should wait before retrying.
+// If the Store returns an error, the problem is logged and the request is permitted.
+func (l *Limiter) Check(ctx context.Context, scope string, limit *Limit, req *http.Request, identity iam.ClientIdentity) (bool, time.Duration) {
+
+	key, found := l.key(scope, limit, req, identity)
+
+	if !found {
+		// Unable to distinguish the caller - don't penalise them
+		return true, 0
+	}
+
+	allowed, wait, err := l.Store.Take(ctx, key, limit)
+
+	if err != nil {
+		l.FrameworkLogger.LogErrorfCtx(ctx, "Unable to check rate limit for %s (request permitted): %s", scope, err.Error())
+		return true, 0
+	}
+
+	return allowed, wait
+}
+
+func (l *Limiter) key(scope string, limit *Limit, req *http.Request, identity iam.ClientIdentity) (string, bool) {
+
+	var value string
+
+	switch limit.KeyBy {
+	case KeyByHandler:
+		return scope, true
+	case KeyByIdentity:
+
+		field := limit.IdentityField
+
+		if field == "" {
+			field = defaultIdentityField
+		}
+
+		if identity == nil || identity[field] == nil {
+			return "", false
+		}
+
+		value = fmt.Sprintf("%v", identity[field])
+	default:
+		value = l.ClientIP(req)
+	}
+
+	return scope + "|" + value, value != ""
+}
+
+// ClientIP returns the IP address of the caller that made the request.
+func (l *Limiter) ClientIP(req *http.Request) string {
+
+	l.mutex.RLock()
+	trust := l.TrustForwardedFor
+	l.mutex.RUnlock()
+
+	if trust {
+
+		if xff := req.Header.Get(forwardedForHeader); xff != "" {
+			return strings.TrimSpace(strings.Split(xff, ",")[0])
+		}
+	}
+
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+
+	return req.RemoteAddr
+}
+
+// SetLimits replaces the Default and Handlers limits and the TrustForwardedFor setting while the application is running.
+// If any of the limits are invalid, an error is returned and the existing limits are kept. Requests already recorded in the
+// Store continue to count against the new limits.
+func (l *Limiter) SetLimits(def *Limit, handlers map[string]*Limit, trustForwardedFor bool) error {
+
+	if err := validateLimits(def, handlers); err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.Default = def
+	l.Handlers = handlers
+	l.TrustForwardedFor = trustForwardedFor
+
+	return nil
+}
+
+// StartComponent checks that the configured limits are valid and that a Store has been set.
+func (l *Limiter) StartComponent() error {
+
+	if l.Store == nil {
+		return errors.New("no Store has been set for the rate limiter")
+	}
+
+	return validateLimits(l.Default, l.Handlers)
+}
+
+func validateLimits(def *Limit, handlers map[string]*Limit) error {
+
+	if def != nil {
+		if err := def.Validate(); err != nil {
+			return fmt.Errorf("default rate limit is invalid: %s", err.Error())
+		}
+	}
+
+	for name, limit := range handlers {
+
+		if limit == nil {
+			continue
+		}
+
+		if err := limit.Validate(); err != nil {
+			return fmt.Errorf("rate limit for handler %s is invalid: %s", name, err.Error())
+		}
+	}
+
+	return nil
+}
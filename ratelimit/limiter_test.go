@@ -0,0 +1,165 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"github.com/graniticio/granitic/v2/iam"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newTestLimiter() *Limiter {
+	l := new(Limiter)
+	l.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	l.Store = NewMemoryStore(0)
+	l.Handlers = make(map[string]*Limit)
+
+	return l
+}
+
+func request(remote string) *http.Request {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = remote
+
+	return req
+}
+
+func TestLimiterKeyedByIP(t *testing.T) {
+
+	l := newTestLimiter()
+	l.Handlers["h"] = &Limit{Requests: 1, WindowMS: 60000}
+
+	test.ExpectNil(t, l.StartComponent())
+
+	ctx := context.Background()
+
+	allowed, _ := l.Allow(ctx, "h", request("10.0.0.1:1234"), nil)
+	test.ExpectBool(t, allowed, true)
+
+	allowed, wait := l.Allow(ctx, "h", request("10.0.0.1:5678"), nil)
+	test.ExpectBool(t, allowed, false)
+	test.ExpectBool(t, wait > 0, true)
+
+	allowed, _ = l.Allow(ctx, "h", request("10.0.0.2:1234"), nil)
+	test.ExpectBool(t, allowed, true)
+
+	// Handlers without a limit, when there is no default, are not limited
+	allowed, _ = l.Allow(ctx, "unlimited", request("10.0.0.1:1234"), nil)
+	test.ExpectBool(t, allowed, true)
+	allowed, _ = l.Allow(ctx, "unlimited", request("10.0.0.1:1234"), nil)
+	test.ExpectBool(t, allowed, true)
+}
+
+func TestLimiterForwardedFor(t *testing.T) {
+
+	l := newTestLimiter()
+
+	req := request("10.0.0.1:1234")
+	req.Header.Set("X-Forwarded-For", "192.168.0.9, 10.0.0.1")
+
+	test.ExpectString(t, l.ClientIP(req), "10.0.0.1")
+
+	l.TrustForwardedFor = true
+
+	test.ExpectString(t, l.ClientIP(req), "192.168.0.9")
+}
+
+func TestLimiterKeyedByIdentity(t *testing.T) {
+
+	l := newTestLimiter()
+	l.Default = &Limit{Requests: 1, WindowMS: 60000, KeyBy: "identity"}
+
+	test.ExpectNil(t, l.StartComponent())
+
+	ctx := context.Background()
+	req := request("10.0.0.1:1234")
+
+	alice := iam.NewAuthenticatedIdentity("alice")
+	bob := iam.NewAuthenticatedIdentity("bob")
+
+	allowed, _ := l.Allow(ctx, "h", req, alice)
+	test.ExpectBool(t, allowed, true)
+
+	allowed, _ = l.Allow(ctx, "h", req, alice)
+	test.ExpectBool(t, allowed, false)
+
+	allowed, _ = l.Allow(ctx, "h", req, bob)
+	test.ExpectBool(t, allowed, true)
+
+	// Callers without an identity can't be distinguished, so aren't limited
+	allowed, _ = l.Allow(ctx, "h", req, nil)
+	test.ExpectBool(t, allowed, true)
+}
+
+func TestLimiterFailsOpen(t *testing.T) {
+
+	l := newTestLimiter()
+	l.Store = new(failingStore)
+	l.Default = &Limit{Requests: 1, WindowMS: 60000}
+
+	test.ExpectNil(t, l.StartComponent())
+
+	allowed, _ := l.Allow(context.Background(), "h", request("10.0.0.1:1234"), nil)
+	test.ExpectBool(t, allowed, true)
+}
+
+func TestInvalidLimits(t *testing.T) {
+
+	l := newTestLimiter()
+	l.Handlers["h"] = &Limit{Requests: 1, WindowMS: 60000, Algorithm: "LEAKY"}
+
+	test.ExpectNotNil(t, l.StartComponent())
+
+	l = newTestLimiter()
+	l.Default = &Limit{Requests: 0, WindowMS: 60000}
+
+	test.ExpectNotNil(t, l.StartComponent())
+
+	l = newTestLimiter()
+	l.Store = nil
+
+	test.ExpectNotNil(t, l.StartComponent())
+}
+
+func TestSetLimits(t *testing.T) {
+
+	l := newTestLimiter()
+	l.Default = &Limit{Requests: 1, WindowMS: 60000}
+
+	test.ExpectNil(t, l.StartComponent())
+
+	ctx := context.Background()
+
+	allowed, _ := l.Allow(ctx, "h", request("10.0.0.1:1234"), nil)
+	test.ExpectBool(t, allowed, true)
+
+	allowed, _ = l.Allow(ctx, "h", request("10.0.0.1:1234"), nil)
+	test.ExpectBool(t, allowed, false)
+
+	err := l.SetLimits(nil, map[string]*Limit{"h": {Requests: 0, WindowMS: 60000}}, false)
+	test.ExpectNotNil(t, err)
+	test.ExpectNotNil(t, l.Default)
+
+	test.ExpectNil(t, l.SetLimits(nil, map[string]*Limit{"other": {Requests: 1, WindowMS: 60000}}, true))
+
+	// No default any more, so h is no longer limited
+	allowed, _ = l.Allow(ctx, "h", request("10.0.0.1:1234"), nil)
+	test.ExpectBool(t, allowed, true)
+
+	req := request("10.0.0.1:1234")
+	req.Header.Set("X-Forwarded-For", "192.168.0.9")
+
+	test.ExpectString(t, l.ClientIP(req), "192.168.0.9")
+}
+
+type failingStore struct{}
+
+func (fs *failingStore) Take(ctx context.Context, key string, limit *Limit) (bool, time.Duration, error) {
+	return false, 0, errors.New("unavailable")
+}
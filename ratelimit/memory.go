@@ -0,0 +1,152 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const defaultSweepInterval = time.Minute
+
+// usage is the record of requests made against a single key.
+type usage struct {
+	// TOKEN_BUCKET: tokens remaining. SLIDING_WINDOW: requests in the current window.
+	count float64
+	// SLIDING_WINDOW: requests in the previous window.
+	previous float64
+	// TOKEN_BUCKET: time tokens were last refilled. SLIDING_WINDOW: start of the current window.
+	mark time.Time
+	// When this record can be discarded without affecting future decisions.
+	expires time.Time
+}
+
+// NewMemoryStore creates a MemoryStore that discards expired records every sweepInterval (a default of one minute is used if
+// sweepInterval is zero or less).
+func NewMemoryStore(sweepInterval time.Duration) *MemoryStore {
+
+	if sweepInterval <= 0 {
+		sweepInterval = defaultSweepInterval
+	}
+
+	ms := new(MemoryStore)
+	ms.sweepInterval = sweepInterval
+	ms.records = make(map[string]*usage)
+	ms.now = time.Now
+
+	return ms
+}
+
+// MemoryStore is an implementation of Store that keeps records in memory. Records that can no longer affect a decision
+// are periodically discarded.
+type MemoryStore struct {
+	mutex         sync.Mutex
+	records       map[string]*usage
+	sweepInterval time.Duration
+	lastSweep     time.Time
+	now           func() time.Time
+}
+
+// Take implements Store.Take
+func (ms *MemoryStore) Take(ctx context.Context, key string, limit *Limit) (bool, time.Duration, error) {
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	now := ms.now()
+
+	if now.Sub(ms.lastSweep) > ms.sweepInterval {
+		ms.sweep(now)
+	}
+
+	u := ms.records[key]
+
+	if limit.Algorithm == SlidingWindow {
+
+		if u == nil {
+			u = &usage{mark: now}
+			ms.records[key] = u
+		}
+
+		return ms.slidingWindow(u, limit, now)
+	}
+
+	if u == nil {
+		u = &usage{count: float64(limit.Capacity()), mark: now}
+		ms.records[key] = u
+	}
+
+	return ms.tokenBucket(u, limit, now)
+}
+
+func (ms *MemoryStore) tokenBucket(u *usage, limit *Limit, now time.Time) (bool, time.Duration, error) {
+
+	capacity := float64(limit.Capacity())
+	perNano := float64(limit.Requests) / float64(limit.Window())
+
+	u.count = math.Min(capacity, u.count+float64(now.Sub(u.mark))*perNano)
+	u.mark = now
+
+	// The time taken for an empty bucket to refill completely
+	u.expires = now.Add(time.Duration(capacity / perNano))
+
+	if u.count >= 1 {
+		u.count--
+		return true, 0, nil
+	}
+
+	wait := time.Duration(math.Ceil((1 - u.count) / perNano))
+
+	return false, wait, nil
+}
+
+func (ms *MemoryStore) slidingWindow(u *usage, limit *Limit, now time.Time) (bool, time.Duration, error) {
+
+	window := limit.Window()
+	elapsed := now.Sub(u.mark)
+
+	if elapsed >= 2*window {
+		// Neither the current nor previous windows are relevant any more
+		u.previous, u.count = 0, 0
+		u.mark = now
+	} else if elapsed >= window {
+		u.previous, u.count = u.count, 0
+		u.mark = u.mark.Add(window)
+	}
+
+	elapsed = now.Sub(u.mark)
+	u.expires = u.mark.Add(2 * window)
+
+	weight := float64(window-elapsed) / float64(window)
+	estimate := u.previous*weight + u.count
+	max := float64(limit.Requests)
+
+	if estimate+1 <= max {
+		u.count++
+		return true, 0, nil
+	}
+
+	if u.count+1 > max || u.previous == 0 {
+		// No amount of decay in the previous window will help - wait for the next window
+		return false, window - elapsed, nil
+	}
+
+	// Find the point in the current window at which the weighted previous count has decayed enough
+	needed := window - time.Duration(float64(window)*(max-u.count-1)/u.previous)
+
+	return false, needed - elapsed, nil
+}
+
+func (ms *MemoryStore) sweep(now time.Time) {
+
+	for k, u := range ms.records {
+		if now.After(u.expires) {
+			delete(ms.records, k)
+		}
+	}
+
+	ms.lastSweep = now
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ratelimit
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (fc *fakeClock) now() time.Time {
+	return fc.t
+}
+
+func (fc *fakeClock) advance(d time.Duration) {
+	fc.t = fc.t.Add(d)
+}
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	fc := &fakeClock{t: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	ms := NewMemoryStore(time.Minute)
+	ms.now = fc.now
+
+	return ms, fc
+}
+
+func TestTokenBucket(t *testing.T) {
+
+	ms, fc := newTestStore()
+	ctx := context.Background()
+
+	l := &Limit{Requests: 2, WindowMS: 1000, Burst: 3}
+	test.ExpectNil(t, l.Validate())
+
+	for i := 0; i < 3; i++ {
+		allowed, _, _ := ms.Take(ctx, "k", l)
+		test.ExpectBool(t, allowed, true)
+	}
+
+	allowed, wait, _ := ms.Take(ctx, "k", l)
+	test.ExpectBool(t, allowed, false)
+	test.ExpectInt(t, int(wait/time.Millisecond), 500)
+
+	// Other keys are unaffected
+	allowed, _, _ = ms.Take(ctx, "other", l)
+	test.ExpectBool(t, allowed, true)
+
+	fc.advance(500 * time.Millisecond)
+
+	allowed, _, _ = ms.Take(ctx, "k", l)
+	test.ExpectBool(t, allowed, true)
+
+	allowed, _, _ = ms.Take(ctx, "k", l)
+	test.ExpectBool(t, allowed, false)
+}
+
+func TestSlidingWindow(t *testing.T) {
+
+	ms, fc := newTestStore()
+	ctx := context.Background()
+
+	l := &Limit{Algorithm: "sliding_window", Requests: 4, WindowMS: 1000}
+	test.ExpectNil(t, l.Validate())
+
+	for i := 0; i < 4; i++ {
+		allowed, _, _ := ms.Take(ctx, "k", l)
+		test.ExpectBool(t, allowed, true)
+	}
+
+	allowed, wait, _ := ms.Take(ctx, "k", l)
+	test.ExpectBool(t, allowed, false)
+	test.ExpectInt(t, int(wait/time.Millisecond), 1000)
+
+	// Halfway through the next window, half of the previous window's requests still count
+	fc.advance(1500 * time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		allowed, _, _ := ms.Take(ctx, "k", l)
+		test.ExpectBool(t, allowed, true)
+	}
+
+	allowed, wait, _ = ms.Take(ctx, "k", l)
+	test.ExpectBool(t, allowed, false)
+	test.ExpectInt(t, int(wait/time.Millisecond), 250)
+
+	// Once two windows have passed, the caller has a full allowance again
+	fc.advance(2 * time.Second)
+
+	for i := 0; i < 4; i++ {
+		allowed, _, _ := ms.Take(ctx, "k", l)
+		test.ExpectBool(t, allowed, true)
+	}
+}
+
+func TestSweep(t *testing.T) {
+
+	ms, fc := newTestStore()
+	ctx := context.Background()
+
+	l := &Limit{Requests: 1, WindowMS: 1000}
+	test.ExpectNil(t, l.Validate())
+
+	ms.Take(ctx, "a", l)
+	ms.Take(ctx, "b", l)
+
+	test.ExpectInt(t, len(ms.records), 2)
+
+	fc.advance(2 * time.Minute)
+
+	ms.Take(ctx, "c", l)
+
+	test.ExpectInt(t, len(ms.records), 1)
+}
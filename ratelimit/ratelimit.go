@@ -0,0 +1,136 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package ratelimit provides types for limiting the rate at which callers can make requests to web service endpoints.
+
+The types in this package are used by the RateLimiting facility (see facility/ratelimit) but can be used independently.
+
+Limits
+
+A Limit describes how many requests are permitted in a window of time and how callers are told apart. Two algorithms are
+supported:
+
+	TOKEN_BUCKET     Each caller has a bucket of Burst tokens (defaults to Requests) that refills at a rate of
+	                 Requests per WindowMS. Allows short bursts of traffic while enforcing an average rate.
+
+	SLIDING_WINDOW   Each caller is allowed Requests in any period of WindowMS milliseconds. The count is approximated
+	                 by weighting the count from the previous fixed window, so memory use per caller is constant.
+
+Callers are distinguished by the Limit's KeyBy setting:
+
+	IP        The caller's IP address (optionally taken from the X-Forwarded-For header).
+	IDENTITY  A field of the iam.ClientIdentity established for the request (see IdentityField).
+	HANDLER   No distinction is made between callers - the limit applies to all requests to the handler.
+
+Stores
+
+The record of how many requests each caller has made is kept in a Store. MemoryStore keeps these records in memory
+and is suitable for single instance applications or where per-instance limits are acceptable. Applications that need
+limits to be shared between instances can provide their own implementation of Store backed by a shared cache.
+*/
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported values for Limit.Algorithm
+const (
+	// TokenBucket allows bursts of up to Limit.Burst requests, refilling at Limit.Requests per Limit.WindowMS
+	TokenBucket = "TOKEN_BUCKET"
+	// SlidingWindow allows Limit.Requests in any period of Limit.WindowMS
+	SlidingWindow = "SLIDING_WINDOW"
+)
+
+// Supported values for Limit.KeyBy
+const (
+	// KeyByIP means callers are distinguished by their IP address
+	KeyByIP = "IP"
+	// KeyByIdentity means callers are distinguished by a field on their iam.ClientIdentity
+	KeyByIdentity = "IDENTITY"
+	// KeyByHandler means all callers share a single limit for the handler
+	KeyByHandler = "HANDLER"
+)
+
+// Limit defines the rate at which requests are permitted.
+type Limit struct {
+	// Either TOKEN_BUCKET (the default) or SLIDING_WINDOW
+	Algorithm string
+
+	// The number of requests permitted per window.
+	Requests int
+
+	// The length of the window in milliseconds.
+	WindowMS int64
+
+	// The maximum number of requests that can be made in a burst (TOKEN_BUCKET only). Defaults to Requests.
+	Burst int
+
+	// How callers are distinguished: IP (the default), IDENTITY or HANDLER
+	KeyBy string
+
+	// The name of the field on the caller's iam.ClientIdentity used to distinguish callers when KeyBy is IDENTITY.
+	// Defaults to LoggableUserID.
+	IdentityField string
+}
+
+// Window returns the Limit's window as a time.Duration
+func (l *Limit) Window() time.Duration {
+	return time.Duration(l.WindowMS) * time.Millisecond
+}
+
+// Capacity returns the maximum number of requests that can be made without waiting.
+func (l *Limit) Capacity() int {
+
+	if l.Algorithm != SlidingWindow && l.Burst > 0 {
+		return l.Burst
+	}
+
+	return l.Requests
+}
+
+// Validate normalises the Limit's settings, applying defaults, and returns an error if the Limit is not usable.
+func (l *Limit) Validate() error {
+
+	l.Algorithm = strings.ToUpper(l.Algorithm)
+	l.KeyBy = strings.ToUpper(l.KeyBy)
+
+	if l.Algorithm == "" {
+		l.Algorithm = TokenBucket
+	}
+
+	if l.KeyBy == "" {
+		l.KeyBy = KeyByIP
+	}
+
+	if l.Algorithm != TokenBucket && l.Algorithm != SlidingWindow {
+		return fmt.Errorf("%s is not a supported rate limiting algorithm (use %s or %s)", l.Algorithm, TokenBucket, SlidingWindow)
+	}
+
+	if l.KeyBy != KeyByIP && l.KeyBy != KeyByIdentity && l.KeyBy != KeyByHandler {
+		return fmt.Errorf("%s is not a supported KeyBy value (use %s, %s or %s)", l.KeyBy, KeyByIP, KeyByIdentity, KeyByHandler)
+	}
+
+	if l.Requests <= 0 || l.WindowMS <= 0 {
+		return fmt.Errorf("rate limits must have Requests and WindowMS greater than zero")
+	}
+
+	if l.Burst < 0 {
+		return fmt.Errorf("rate limit Burst cannot be negative")
+	}
+
+	return nil
+}
+
+// Store is implemented by components able to record the requests made by callers and decide whether a further
+// request is permitted.
+type Store interface {
+	// Take records a request against the supplied key. It returns true if the request is permitted under the supplied
+	// Limit or false and the duration the caller should wait before retrying if it is not. An error is returned if
+	// the store was unable to make a decision.
+	Take(ctx context.Context, key string, limit *Limit) (allowed bool, retryAfter time.Duration, err error)
+}
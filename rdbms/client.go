@@ -9,6 +9,7 @@ import (
 	"errors"
 	"github.com/graniticio/granitic/v2/dsquery"
 	"github.com/graniticio/granitic/v2/logging"
+	"time"
 )
 
 // Client provides access to methods for executing SQL queries and managing transactions
@@ -41,6 +42,15 @@ type Client interface {
 	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
+// QueryObserver is implemented by components that want to be notified each time a ManagedClient executes a statement
+// (for example, to record query timings).
+type QueryObserver interface {
+	// QueryExecuted is called after a statement has been executed. qid is the ID of the query template the statement was
+	// built from, or an empty string if the statement was passed directly to Exec, Query or QueryRow. Errors that
+	// QueryRow defers until the row is scanned are not reported.
+	QueryExecuted(qid string, elapsed time.Duration, err error)
+}
+
 func newRdbmsClient(database *sql.DB, querymanager dsquery.QueryManager, insertFunc InsertWithReturnedID, logger logging.Logger) *ManagedClient {
 	rc := new(ManagedClient)
 	rc.db = database
@@ -68,6 +78,8 @@ type ManagedClient struct {
 	emptyParams     map[string]interface{}
 	binder          *RowBinder
 	ctx             context.Context
+	observer        QueryObserver
+	qid             string
 	FrameworkLogger logging.Logger
 }
 
@@ -149,6 +161,8 @@ func (rc *ManagedClient) InsertCaptureQIDParams(qid string, target *int64, param
 		return err
 	}
 
+	defer rc.executingQID(qid)()
+
 	return rc.lastID(query, rc, target)
 }
 
@@ -236,6 +250,8 @@ func (rc *ManagedClient) SelectQIDParams(qid string, params ...interface{}) (*sq
 		return nil, err
 	}
 
+	defer rc.executingQID(qid)()
+
 	return rc.Query(query)
 
 }
@@ -266,6 +282,8 @@ func (rc *ManagedClient) execQIDParams(qid string, params ...interface{}) (sql.R
 		return nil, err
 	}
 
+	defer rc.executingQID(qid)()
+
 	return rc.Exec(query)
 }
 
@@ -294,13 +312,18 @@ func (rc *ManagedClient) buildQuery(qid string, p ...interface{}) (string, error
 }
 
 // StartTransaction opens a transaction on the underlying sql.DB object and re-maps all calls to non-transactional
-// methods to their transactional equivalents.
+// methods to their transactional equivalents. If the client was created with a context, the transaction is
+// rolled back if the context is cancelled or its deadline passes before the transaction is committed.
 func (rc *ManagedClient) StartTransaction() error {
 
 	if rc.tx != nil {
 		return errors.New("Transaction already open")
 	}
 
+	if rc.contextAware() {
+		return rc.StartTransactionWithOptions(nil)
+	}
+
 	tx, err := rc.db.Begin()
 
 	if err != nil {
@@ -361,7 +384,11 @@ func (rc *ManagedClient) CommitTransaction() error {
 }
 
 // Exec is a pass-through to its sql.DB equivalent (or sql.Tx equivalent is a transaction is open)
-func (rc *ManagedClient) Exec(query string, args ...interface{}) (sql.Result, error) {
+func (rc *ManagedClient) Exec(query string, args ...interface{}) (r sql.Result, err error) {
+
+	if rc.observer != nil {
+		defer rc.observe(time.Now(), &err)
+	}
 
 	tx := rc.tx
 
@@ -382,7 +409,12 @@ func (rc *ManagedClient) Exec(query string, args ...interface{}) (sql.Result, er
 }
 
 // Query is a pass-through to its sql.DB equivalent (or sql.Tx equivalent is a transaction is open)
-func (rc *ManagedClient) Query(query string, args ...interface{}) (*sql.Rows, error) {
+func (rc *ManagedClient) Query(query string, args ...interface{}) (r *sql.Rows, err error) {
+
+	if rc.observer != nil {
+		defer rc.observe(time.Now(), &err)
+	}
+
 	tx := rc.tx
 
 	if rc.contextAware() {
@@ -403,6 +435,11 @@ func (rc *ManagedClient) Query(query string, args ...interface{}) (*sql.Rows, er
 
 // QueryRow is a pass-through to its sql.DB equivalent (or sql.Tx equivalent is a transaction is open)
 func (rc *ManagedClient) QueryRow(query string, args ...interface{}) *sql.Row {
+
+	if rc.observer != nil {
+		defer rc.observe(time.Now(), new(error))
+	}
+
 	tx := rc.tx
 
 	if rc.contextAware() {
@@ -421,6 +458,20 @@ func (rc *ManagedClient) QueryRow(query string, args ...interface{}) *sql.Row {
 	return rc.db.QueryRow(query, args...)
 }
 
+// executingQID records the ID of the query template that is about to be executed so it can be reported to a QueryObserver.
+// Returns a function that clears the ID.
+func (rc *ManagedClient) executingQID(qid string) func() {
+	rc.qid = qid
+
+	return func() {
+		rc.qid = ""
+	}
+}
+
+func (rc *ManagedClient) observe(started time.Time, err *error) {
+	rc.observer.QueryExecuted(rc.qid, time.Since(started), *err)
+}
+
 func (rc *ManagedClient) contextAware() bool {
 	return rc.ctx != nil
 }
@@ -74,6 +74,31 @@ func TestPassthroughs(t *testing.T) {
 
 }
 
+func TestQueryObserved(t *testing.T) {
+
+	o := new(recordingObserver)
+
+	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
+	c.observer = o
+
+	_, err := c.DeleteQIDParams("DELETE")
+	test.ExpectNil(t, err)
+
+	c.Exec("DIRECT")
+
+	test.ExpectInt(t, len(o.qids), 2)
+	test.ExpectString(t, o.qids[0], "DELETE")
+	test.ExpectString(t, o.qids[1], "")
+}
+
+type recordingObserver struct {
+	qids []string
+}
+
+func (ro *recordingObserver) QueryExecuted(qid string, elapsed time.Duration, err error) {
+	ro.qids = append(ro.qids, qid)
+}
+
 func TestTempQueries(t *testing.T) {
 
 	c := newRdbmsClient(db, qm, DefaultInsertWithReturnedID, logging.CreateAnonymousLogger("testLog", logging.Fatal))
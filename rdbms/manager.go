@@ -219,7 +219,8 @@ type ClientManager interface {
 	Client() (Client, error)
 
 	// ClientFromContext returns an ManagedClient that is ready to use. Providing a context allows the underlying DatabaseProvider
-	// to modify the connection to the RDBMS.
+	// to modify the connection to the RDBMS. Statements executed by the client are abandoned if the context is cancelled
+	// or its deadline (for example the deadline of a web service request) passes.
 	ClientFromContext(ctx context.Context) (Client, error)
 }
 
@@ -267,6 +268,9 @@ type GraniticRdbmsClientManager struct {
 
 	SharedLog logging.Logger
 
+	// If set, notified each time a client created by this manager executes a statement.
+	QueryObserver QueryObserver
+
 	state ioc.ComponentState
 }
 
@@ -294,6 +298,35 @@ func (cm *GraniticRdbmsClientManager) BlockAccess() (bool, error) {
 
 }
 
+// CheckHealth returns an error if a connection to the underlying RDBMS cannot be established. Implements ioc.HealthChecker
+func (cm *GraniticRdbmsClientManager) CheckHealth(ctx context.Context) error {
+
+	if cm.state != ioc.RunningState {
+		return errors.New("ClientManager is not running")
+	}
+
+	var db *sql.DB
+	var err error
+
+	provider := cm.Configuration.Provider
+
+	if cdp, found := provider.(ContextAwareDatabaseProvider); found {
+		db, err = cdp.DatabaseFromContext(ctx)
+	} else {
+		db, err = provider.Database()
+	}
+
+	if err == nil {
+		err = db.PingContext(ctx)
+	}
+
+	if err != nil {
+		return errors.New("Unable to connect to database: " + err.Error())
+	}
+
+	return nil
+}
+
 // Client implements ClientManager.Client
 func (cm *GraniticRdbmsClientManager) Client() (Client, error) {
 
@@ -310,7 +343,10 @@ func (cm *GraniticRdbmsClientManager) Client() (Client, error) {
 		return nil, err
 	}
 
-	return newRdbmsClient(db, cm.QueryManager, cm.chooseInsertFunction(), cm.SharedLog), nil
+	rc := newRdbmsClient(db, cm.QueryManager, cm.chooseInsertFunction(), cm.SharedLog)
+	rc.observer = cm.QueryObserver
+
+	return rc, nil
 }
 
 // ClientFromContext implements ClientManager.ClientFromContext
@@ -320,6 +356,11 @@ func (cm *GraniticRdbmsClientManager) ClientFromContext(ctx context.Context) (Cl
 		return nil, errors.New("No Client will be created because ClientManager is not running. Application shutting down?")
 	}
 
+	if err := ctx.Err(); err != nil {
+		// No point connecting to the database if the request has already been cancelled or timed out
+		return nil, err
+	}
+
 	var db *sql.DB
 	var err error
 
@@ -339,6 +380,7 @@ func (cm *GraniticRdbmsClientManager) ClientFromContext(ctx context.Context) (Cl
 
 	rc := newRdbmsClient(db, cm.QueryManager, cm.chooseInsertFunction(), cm.SharedLog)
 	rc.ctx = ctx
+	rc.observer = cm.QueryObserver
 
 	return rc, nil
 }
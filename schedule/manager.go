@@ -28,6 +28,7 @@ type invocationManager struct {
 	running   *invocationQueue
 	State     ioc.ComponentState
 	Log       logging.Logger
+	Recorder  TaskOutcomeRecorder
 }
 
 func (im *invocationManager) Start() {
@@ -102,6 +103,8 @@ func (im *invocationManager) runTask(i *invocation) {
 		go im.listenForStatusUpdates(i, updates)
 	}
 
+	outcome := TaskPanicked
+
 	defer func() {
 		if r := recover(); r != nil {
 			im.Log.LogErrorfWithTrace("Panic recovered while executing task %s (invocation %d started at %v)\n %v", im.Task.FullName(), i.counter, i.startedAt, r)
@@ -110,17 +113,26 @@ func (im *invocationManager) runTask(i *invocation) {
 		close(updates)
 		im.running.Remove(i.counter)
 
+		if im.Recorder != nil {
+			im.Recorder.RecordOutcome(im.Task, outcome, time.Since(i.startedAt))
+		}
+
 	}()
 
 	err := im.Task.logic.ExecuteTask(updates)
 
+	outcome = TaskSucceeded
+
 	if err != nil {
 
+		outcome = TaskFailed
+
 		m := fmt.Sprintf("Problem executing task %s (invocation %d, attempt %d started at %v): %s", im.Task.FullName(), i.counter, i.attempt, i.startedAt, err.Error())
 
 		if _, ok := err.(*AllowRetryError); ok {
 
 			if okay, when := im.attemptRetry(i); okay {
+				outcome = TaskRetrying
 				im.Log.LogWarnf(m)
 				im.Log.LogWarnf("Will retry at %v", when)
 			} else {
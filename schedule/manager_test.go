@@ -1,6 +1,7 @@
 package schedule
 
 import (
+	"errors"
 	"github.com/graniticio/granitic/v2/logging"
 	"testing"
 	"time"
@@ -34,6 +35,61 @@ func TestInvocationManager(t *testing.T) {
 
 }
 
+func TestOutcomeRecorded(t *testing.T) {
+
+	tsk := new(Task)
+	tsk.ID = "id"
+
+	r := new(recordingOutcomes)
+
+	im := newInvocationManager(tsk)
+	im.Log = new(logging.ConsoleErrorLogger)
+	im.Recorder = r
+
+	tsk.logic = new(nullLogic)
+	im.runTask(&invocation{counter: 1})
+
+	tsk.logic = &failingLogic{err: errors.New("failed")}
+	im.runTask(&invocation{counter: 2})
+
+	tsk.logic = &failingLogic{panic: true}
+	im.runTask(&invocation{counter: 3})
+
+	expected := []TaskOutcome{TaskSucceeded, TaskFailed, TaskPanicked}
+
+	if len(r.outcomes) != len(expected) {
+		t.Fatalf("Expected %d outcomes, got %d", len(expected), len(r.outcomes))
+	}
+
+	for i, o := range expected {
+		if r.outcomes[i] != o {
+			t.Errorf("Expected outcome %s, got %s", o, r.outcomes[i])
+		}
+	}
+}
+
+type recordingOutcomes struct {
+	outcomes []TaskOutcome
+}
+
+func (ro *recordingOutcomes) RecordOutcome(task *Task, outcome TaskOutcome, elapsed time.Duration) {
+	ro.outcomes = append(ro.outcomes, outcome)
+}
+
+type failingLogic struct {
+	err   error
+	panic bool
+}
+
+func (fl *failingLogic) ExecuteTask(c chan TaskStatusUpdate) error {
+
+	if fl.panic {
+		panic("task panicked")
+	}
+
+	return fl.err
+}
+
 func TestErrorCreation(t *testing.T) {
 
 	e := NewAllowRetryErrorf("Error = %s", "A")
@@ -20,6 +20,9 @@ type TaskScheduler struct {
 	// Logger used by Granitic framework components. Automatically injected.
 	FrameworkLogger     logging.Logger
 	FrameworkLogManager *logging.ComponentLoggerManager
+
+	// An optional component to be notified each time an invocation of a task ends.
+	OutcomeRecorder TaskOutcomeRecorder
 }
 
 // Container implements ioc.ContainerAccessor.Container
@@ -115,6 +118,7 @@ func (ts *TaskScheduler) validateAndPrepare(cn ioc.ComponentLookup, task *Task)
 	tm := newInvocationManager(task)
 	ts.managedTasks = append(ts.managedTasks, tm)
 	tm.Log = ts.FrameworkLogManager.CreateLogger(task.Component + "TaskManager")
+	tm.Recorder = ts.OutcomeRecorder
 
 	if interval, err := parseEvery(task.Every); err == nil {
 		tm.Interval = interval
@@ -87,6 +87,27 @@ type TaskStatusUpdateReceiver interface {
 	Receive(summary TaskInvocationSummary, update TaskStatusUpdate)
 }
 
+// TaskOutcome describes how an invocation of a task ended
+type TaskOutcome string
+
+const (
+	// TaskSucceeded indicates that the task's logic returned without an error
+	TaskSucceeded TaskOutcome = "success"
+	// TaskFailed indicates that the task's logic returned an error and the invocation will not be retried
+	TaskFailed TaskOutcome = "failure"
+	// TaskRetrying indicates that the task's logic returned an AllowRetryError and the invocation will be retried
+	TaskRetrying TaskOutcome = "retry"
+	// TaskPanicked indicates that the task's logic panicked
+	TaskPanicked TaskOutcome = "panic"
+)
+
+// TaskOutcomeRecorder is implemented by a component that wants to be notified each time an invocation of any task ends
+// (for example, to record metrics).
+type TaskOutcomeRecorder interface {
+	// RecordOutcome is called once for each attempt to run a task, after the task's logic has returned.
+	RecordOutcome(task *Task, outcome TaskOutcome, elapsed time.Duration)
+}
+
 // TaskInvocationSummary meta-data about a task invocation
 type TaskInvocationSummary struct {
 	TaskName        string
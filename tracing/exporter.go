@@ -0,0 +1,97 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// JSONLinesExporter appends each exported span to a file as a JSON object on its own line. Implements SpanExporter
+type JSONLinesExporter struct {
+	// The path of the file spans are appended to. The file and any missing parent directories are created if necessary.
+	Path string
+
+	file  *os.File
+	mutex sync.Mutex
+}
+
+// StartComponent opens the file spans will be written to. Implements ioc.Startable
+func (je *JSONLinesExporter) StartComponent() error {
+
+	if je.Path == "" {
+		return errors.New("no path set for spans to be written to")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(je.Path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(je.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	je.mutex.Lock()
+	defer je.mutex.Unlock()
+
+	je.file = f
+
+	return nil
+}
+
+// ExportSpans writes the supplied spans to the file. Implements SpanExporter
+func (je *JSONLinesExporter) ExportSpans(spans []*Span) error {
+
+	var b bytes.Buffer
+
+	e := json.NewEncoder(&b)
+
+	for _, s := range spans {
+		if err := e.Encode(s); err != nil {
+			return err
+		}
+	}
+
+	je.mutex.Lock()
+	defer je.mutex.Unlock()
+
+	if je.file == nil {
+		return errors.New("the span file is not open")
+	}
+
+	_, err := je.file.Write(b.Bytes())
+
+	return err
+}
+
+// PrepareToStop implements ioc.Stoppable
+func (je *JSONLinesExporter) PrepareToStop() {
+}
+
+// ReadyToStop always returns true. Implements ioc.Stoppable
+func (je *JSONLinesExporter) ReadyToStop() (bool, error) {
+	return true, nil
+}
+
+// Stop closes the file. Implements ioc.Stoppable
+func (je *JSONLinesExporter) Stop() error {
+
+	je.mutex.Lock()
+	defer je.mutex.Unlock()
+
+	if je.file == nil {
+		return nil
+	}
+
+	err := je.file.Close()
+	je.file = nil
+
+	return err
+}
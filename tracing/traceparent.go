@@ -0,0 +1,193 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package tracing provides an implementation of instrument.RequestInstrumentationManager that records the events in a
+request as a tree of spans and propagates trace context using the W3C Trace Context headers (traceparent and tracestate).
+
+Most applications will enable the Tracing facility (see facility/tracing) rather than use this package directly.
+
+Spans
+
+Each request handled by the HTTPServer facility becomes a trace. A root span is started when the request is received
+and ended once the response has been written. Each call to instrument.Event or instrument.Method made while processing
+the request starts a child of the innermost span that is still open:
+
+	func (l *Logic) Process(ctx context.Context, req *ws.Request, res *ws.Response) {
+		defer instrument.Method(ctx)()
+
+		l.loadOrders(ctx)
+	}
+
+	func (l *Logic) loadOrders(ctx context.Context) {
+		defer instrument.Event(ctx, "loadOrders")()
+		...
+	}
+
+If the request has a valid traceparent header, the request's trace continues the caller's trace (the root span's
+parent is the caller's span). Otherwise a new trace is started.
+
+Goroutines
+
+Spans started in a goroutine should use a forked Instrumentor so that they are attached to the correct parent:
+
+	fCtx, _ := instrument.InstrumentorFromContext(ctx).Fork(ctx)
+
+	go func() {
+		defer instrument.Event(fCtx, "background")()
+		...
+	}()
+
+Propagating trace context
+
+Calls to other services made while processing a request can continue the trace by adding trace context headers to the
+outgoing request:
+
+	tracing.InjectHeaders(ctx, outgoing.Header)
+
+Log line prefixes
+
+Importing this package makes the IDs of the current trace and span available to log line prefixes as %{traceID}X and
+%{spanID}X (see logging.LogMessageFormatter).
+
+Exporting spans
+
+Once a request's root span has ended, all of the request's spans are passed to a SpanExporter. JSONLinesExporter writes
+each span as a JSON object on a separate line of a file.
+*/
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// TraceParentHeader is the name of the HTTP header carrying the caller's trace and span IDs
+	TraceParentHeader = "traceparent"
+
+	// TraceStateHeader is the name of the HTTP header carrying vendor-specific trace data
+	TraceStateHeader = "tracestate"
+
+	traceVersion = "00"
+	sampledFlag  = 0x01
+
+	zeroTraceID = "00000000000000000000000000000000"
+	zeroSpanID  = "0000000000000000"
+)
+
+// TraceParent holds the fields of a W3C traceparent header.
+type TraceParent struct {
+	// 32 lower-case hex characters identifying the trace
+	TraceID string
+
+	// 16 lower-case hex characters identifying the caller's span
+	SpanID string
+
+	// Trace flags. Bit 0 indicates whether the caller has sampled (recorded) the trace.
+	Flags byte
+}
+
+// Sampled returns true if the sampled flag is set.
+func (tp *TraceParent) Sampled() bool {
+	return tp.Flags&sampledFlag != 0
+}
+
+// String formats the TraceParent as a traceparent header value.
+func (tp *TraceParent) String() string {
+	return fmt.Sprintf("%s-%s-%s-%02x", traceVersion, tp.TraceID, tp.SpanID, tp.Flags)
+}
+
+// ParseTraceParent parses the value of a traceparent header. Versions other than 00 are accepted as long as the first
+// four fields are in the 00 format, as required by the specification. Returns an error if the value is invalid.
+func ParseTraceParent(v string) (*TraceParent, error) {
+
+	v = strings.TrimSpace(v)
+	f := strings.Split(v, "-")
+
+	if len(f) < 4 {
+		return nil, fmt.Errorf("%q is not a valid traceparent", v)
+	}
+
+	version := f[0]
+
+	if !isHex(version, 2) || version == "ff" || (version == traceVersion && len(f) != 4) {
+		return nil, fmt.Errorf("%q does not have a supported traceparent version", v)
+	}
+
+	if !isHex(f[1], 32) || f[1] == zeroTraceID {
+		return nil, fmt.Errorf("%q does not contain a valid trace ID", v)
+	}
+
+	if !isHex(f[2], 16) || f[2] == zeroSpanID {
+		return nil, fmt.Errorf("%q does not contain a valid parent span ID", v)
+	}
+
+	if !isHex(f[3], 2) {
+		return nil, fmt.Errorf("%q does not contain valid trace flags", v)
+	}
+
+	flags, _ := hex.DecodeString(f[3])
+
+	return &TraceParent{TraceID: f[1], SpanID: f[2], Flags: flags[0]}, nil
+}
+
+func isHex(s string, length int) bool {
+
+	if len(s) != length {
+		return false
+	}
+
+	for _, c := range s {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// InjectHeaders adds traceparent and tracestate headers to the supplied headers so that a call to another service
+// continues the trace of the request being processed. The current span becomes the parent of the span started by the
+// other service. Has no effect if the context does not contain a trace.
+func InjectHeaders(ctx context.Context, h http.Header) {
+
+	ti := fromContext(ctx)
+
+	if ti == nil {
+		return
+	}
+
+	tp := ti.traceParent()
+
+	h.Set(TraceParentHeader, tp.String())
+
+	if ti.trace.state != "" {
+		h.Set(TraceStateHeader, ti.trace.state)
+	}
+}
+
+func newTraceID() string {
+	return randomHex(16, zeroTraceID)
+}
+
+func newSpanID() string {
+	return randomHex(8, zeroSpanID)
+}
+
+func randomHex(bytes int, invalid string) string {
+
+	b := make([]byte, bytes)
+
+	for {
+		rand.Read(b)
+
+		if id := hex.EncodeToString(b); id != invalid {
+			return id
+		}
+	}
+}
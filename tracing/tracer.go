@@ -0,0 +1,310 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package tracing
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type ctxKey int
+
+const tracingKey ctxKey = 0
+
+func init() {
+	logging.RegisterContextValue("traceID", func(ctx context.Context) (string, bool) {
+		id := TraceIDFromContext(ctx)
+		return id, id != ""
+	})
+
+	logging.RegisterContextValue("spanID", func(ctx context.Context) (string, bool) {
+		id := SpanIDFromContext(ctx)
+		return id, id != ""
+	})
+}
+
+// TraceIDFromContext returns the ID of the trace stored in the supplied context, or an empty string if the context
+// does not contain a trace.
+func TraceIDFromContext(ctx context.Context) string {
+
+	if ti := fromContext(ctx); ti != nil {
+		return ti.trace.id
+	}
+
+	return ""
+}
+
+// SpanIDFromContext returns the ID of the innermost open span of the trace stored in the supplied context, or an
+// empty string if the context does not contain a trace.
+func SpanIDFromContext(ctx context.Context) string {
+
+	if ti := fromContext(ctx); ti != nil {
+		return ti.current().SpanID
+	}
+
+	return ""
+}
+
+func fromContext(ctx context.Context) *spanInstrumentor {
+
+	if ctx == nil {
+		return nil
+	}
+
+	ti, _ := ctx.Value(tracingKey).(*spanInstrumentor)
+
+	return ti
+}
+
+// Span records a single timed operation within a trace.
+type Span struct {
+	// The ID of the trace the span belongs to
+	TraceID string
+
+	// The unique ID of this span
+	SpanID string
+
+	// The ID of the span that was open when this span was started, or the caller's span for the root span of a request.
+	// Empty if this is the first span in the trace.
+	ParentSpanID string `json:",omitempty"`
+
+	// The ID of the event (see instrument.Event) or a description of the request for the root span of a request.
+	Name string
+
+	// When the span was started
+	Start time.Time
+
+	// When the span was ended
+	End time.Time
+
+	// Additional information about the span. Contains any metadata passed to instrument.Event.
+	Attributes map[string]interface{} `json:",omitempty"`
+}
+
+// SpanExporter is implemented by components that can send the spans recorded for a request to a store or tracing system.
+type SpanExporter interface {
+	// ExportSpans is called once the root span of a request has ended, with every span recorded for the request that has ended.
+	ExportSpans(spans []*Span) error
+}
+
+// Tracer creates a trace for each web service request, continuing the caller's trace if the request has a valid
+// traceparent header. Implements instrument.RequestInstrumentationManager
+type Tracer struct {
+	// Logger used by Granitic framework components. Automatically injected.
+	FrameworkLogger logging.Logger
+
+	// The component that spans are sent to once a request is complete. If nil, spans are not exported.
+	Exporter SpanExporter
+
+	// If set, added to the attributes of the root span of each request as service.name
+	ServiceName string
+}
+
+// Begin starts the root span of a request. The returned function ends the root span and exports the request's spans
+// and must be called once the response has been written. Implements instrument.RequestInstrumentationManager
+func (t *Tracer) Begin(ctx context.Context, res http.ResponseWriter, req *http.Request) (context.Context, instrument.Instrumentor, func()) {
+
+	tr := new(trace)
+	parentID := ""
+
+	if h := req.Header.Get(TraceParentHeader); h != "" {
+
+		if tp, err := ParseTraceParent(h); err == nil {
+			tr.id = tp.TraceID
+			tr.flags = tp.Flags
+			tr.state = req.Header.Get(TraceStateHeader)
+			parentID = tp.SpanID
+		} else {
+			t.FrameworkLogger.LogDebugfCtx(ctx, "Ignoring invalid trace context: %s", err.Error())
+		}
+	}
+
+	if tr.id == "" {
+		tr.id = newTraceID()
+		tr.flags = sampledFlag
+	}
+
+	root := tr.startSpan(req.Method+" "+req.URL.Path, parentID)
+	root.Attributes = map[string]interface{}{
+		"http.method": req.Method,
+		"http.path":   req.URL.Path,
+	}
+
+	if t.ServiceName != "" {
+		root.Attributes["service.name"] = t.ServiceName
+	}
+
+	ti := &spanInstrumentor{trace: tr, stack: []*Span{root}}
+
+	end := func() {
+
+		status := http.StatusOK
+
+		if wrw, found := res.(*httpendpoint.HTTPResponseWriter); found && wrw.Status != 0 {
+			status = wrw.Status
+		}
+
+		tr.setAttribute(root, "http.status_code", status)
+		tr.endSpan(root)
+
+		if t.Exporter == nil || tr.flags&sampledFlag == 0 {
+			return
+		}
+
+		if err := t.Exporter.ExportSpans(tr.ended()); err != nil {
+			t.FrameworkLogger.LogErrorfCtx(ctx, "Unable to export spans for trace %s: %s", tr.id, err.Error())
+		}
+	}
+
+	ctx = context.WithValue(ctx, tracingKey, ti)
+
+	return instrument.AddInstrumentorToContext(ctx, ti), ti, end
+}
+
+// trace holds all of the spans recorded for a single request. Spans may be recorded from several goroutines.
+type trace struct {
+	id    string
+	state string
+	flags byte
+	mutex sync.Mutex
+	spans []*Span
+}
+
+func (t *trace) startSpan(name, parentID string) *Span {
+
+	s := new(Span)
+	s.TraceID = t.id
+	s.SpanID = newSpanID()
+	s.ParentSpanID = parentID
+	s.Name = name
+	s.Start = time.Now()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.spans = append(t.spans, s)
+
+	return s
+}
+
+func (t *trace) endSpan(s *Span) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if s.End.IsZero() {
+		s.End = time.Now()
+	}
+}
+
+func (t *trace) setAttribute(s *Span, name string, value interface{}) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]interface{})
+	}
+
+	s.Attributes[name] = value
+}
+
+// ended returns the spans that have ended
+func (t *trace) ended() []*Span {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	spans := make([]*Span, 0, len(t.spans))
+
+	for _, s := range t.spans {
+		if !s.End.IsZero() {
+			spans = append(spans, s)
+		}
+	}
+
+	return spans
+}
+
+// spanInstrumentor starts spans as children of the innermost span that is still open. Implements instrument.Instrumentor
+type spanInstrumentor struct {
+	trace *trace
+
+	// Open spans, outermost first. The first span was started by another Instrumentor (or is the root span) and is
+	// never ended by this Instrumentor.
+	stack []*Span
+}
+
+func (si *spanInstrumentor) current() *Span {
+	return si.stack[len(si.stack)-1]
+}
+
+func (si *spanInstrumentor) traceParent() *TraceParent {
+	return &TraceParent{TraceID: si.trace.id, SpanID: si.current().SpanID, Flags: si.trace.flags}
+}
+
+// StartEvent starts a child of the innermost open span. Any metadata of type map[string]interface{} is added to the
+// span's attributes; other metadata is added as a list under the attribute 'metadata'. Implements instrument.Instrumentor.StartEvent
+func (si *spanInstrumentor) StartEvent(id string, metadata ...interface{}) instrument.EndEvent {
+
+	s := si.trace.startSpan(id, si.current().SpanID)
+
+	for _, m := range metadata {
+
+		if attrs, found := m.(map[string]interface{}); found {
+			for k, v := range attrs {
+				si.trace.setAttribute(s, k, v)
+			}
+		} else {
+			others, _ := s.Attributes["metadata"].([]interface{})
+			si.trace.setAttribute(s, "metadata", append(others, m))
+		}
+	}
+
+	si.stack = append(si.stack, s)
+
+	return func() {
+		si.trace.endSpan(s)
+
+		// Spans that are still open inside this span are no longer suitable parents
+		for i := len(si.stack) - 1; i > 0; i-- {
+			if si.stack[i] == s {
+				si.stack = si.stack[:i]
+				break
+			}
+		}
+	}
+}
+
+// Fork creates an Instrumentor that starts spans as children of the current innermost open span. Implements instrument.Instrumentor.Fork
+func (si *spanInstrumentor) Fork(ctx context.Context) (context.Context, instrument.Instrumentor) {
+
+	fi := &spanInstrumentor{trace: si.trace, stack: []*Span{si.current()}}
+
+	ctx = context.WithValue(ctx, tracingKey, fi)
+
+	return instrument.AddInstrumentorToContext(ctx, fi), fi
+}
+
+// Integrate has no effect as spans recorded by forked Instrumentors are already part of the trace. Implements instrument.Instrumentor.Integrate
+func (si *spanInstrumentor) Integrate(instrumentor instrument.Instrumentor) {
+}
+
+// Amend adds the request ID and the name of the handler processing the request to the root span's attributes. Implements instrument.Instrumentor.Amend
+func (si *spanInstrumentor) Amend(additional instrument.Additional, value interface{}) {
+
+	root := si.stack[0]
+
+	switch additional {
+	case instrument.RequestID:
+		si.trace.setAttribute(root, "request.id", value)
+	case instrument.Handler:
+		if n, found := value.(ioc.ComponentNamer); found {
+			si.trace.setAttribute(root, "handler", n.ComponentName())
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const validParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+type capturingExporter struct {
+	spans []*Span
+}
+
+func (ce *capturingExporter) ExportSpans(spans []*Span) error {
+	ce.spans = append(ce.spans, spans...)
+	return nil
+}
+
+func TestParseTraceParent(t *testing.T) {
+
+	tp, err := ParseTraceParent(validParent)
+	test.ExpectNil(t, err)
+	test.ExpectString(t, tp.TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	test.ExpectString(t, tp.SpanID, "00f067aa0ba902b7")
+	test.ExpectBool(t, tp.Sampled(), true)
+	test.ExpectString(t, tp.String(), validParent)
+
+	// Future versions may add fields
+	_, err = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	test.ExpectNil(t, err)
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1",
+	}
+
+	for _, v := range invalid {
+		_, err = ParseTraceParent(v)
+		test.ExpectNotNil(t, err)
+	}
+}
+
+func TestSpanTree(t *testing.T) {
+
+	ex := new(capturingExporter)
+
+	tr := new(Tracer)
+	tr.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	tr.Exporter = ex
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(TraceParentHeader, validParent)
+	req.Header.Set(TraceStateHeader, "vendor=value")
+
+	wrw := httpendpoint.NewHTTPResponseWriter(httptest.NewRecorder())
+
+	ctx, ri, end := tr.Begin(context.Background(), wrw, req)
+
+	ri.Amend(instrument.RequestID, "req-1")
+
+	test.ExpectString(t, TraceIDFromContext(ctx), "4bf92f3577b34da6a3ce929d0e0e4736")
+
+	endOuter := instrument.Event(ctx, "outer", map[string]interface{}{"key": "value"})
+	instrument.Event(ctx, "inner")()
+
+	h := http.Header{}
+	InjectHeaders(ctx, h)
+
+	fCtx, _ := instrument.InstrumentorFromContext(ctx).Fork(ctx)
+	instrument.Event(fCtx, "forked")()
+
+	endOuter()
+
+	instrument.Event(ctx, "sibling")()
+
+	wrw.WriteHeader(http.StatusCreated)
+	end()
+
+	test.ExpectInt(t, len(ex.spans), 5)
+
+	byName := make(map[string]*Span)
+
+	for _, s := range ex.spans {
+		byName[s.Name] = s
+		test.ExpectString(t, s.TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	}
+
+	root := byName["GET /orders"]
+	test.ExpectNotNil(t, root)
+	test.ExpectString(t, root.ParentSpanID, "00f067aa0ba902b7")
+	test.ExpectInt(t, root.Attributes["http.status_code"].(int), http.StatusCreated)
+	test.ExpectString(t, root.Attributes["request.id"].(string), "req-1")
+
+	test.ExpectString(t, byName["outer"].ParentSpanID, root.SpanID)
+	test.ExpectString(t, byName["outer"].Attributes["key"].(string), "value")
+	test.ExpectString(t, byName["inner"].ParentSpanID, byName["outer"].SpanID)
+	test.ExpectString(t, byName["forked"].ParentSpanID, byName["outer"].SpanID)
+	test.ExpectString(t, byName["sibling"].ParentSpanID, root.SpanID)
+
+	test.ExpectString(t, h.Get(TraceParentHeader), "00-4bf92f3577b34da6a3ce929d0e0e4736-"+byName["outer"].SpanID+"-01")
+	test.ExpectString(t, h.Get(TraceStateHeader), "vendor=value")
+}
+
+func TestNewAndUnsampledTraces(t *testing.T) {
+
+	ex := new(capturingExporter)
+
+	tr := new(Tracer)
+	tr.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	tr.Exporter = ex
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(TraceParentHeader, "invalid")
+
+	ctx, _, end := tr.Begin(context.Background(), httptest.NewRecorder(), req)
+
+	test.ExpectInt(t, len(TraceIDFromContext(ctx)), 32)
+	test.ExpectInt(t, len(SpanIDFromContext(ctx)), 16)
+
+	end()
+
+	test.ExpectInt(t, len(ex.spans), 1)
+	test.ExpectString(t, ex.spans[0].ParentSpanID, "")
+
+	req.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	_, _, end = tr.Begin(context.Background(), httptest.NewRecorder(), req)
+	end()
+
+	// The caller chose not to sample the trace
+	test.ExpectInt(t, len(ex.spans), 1)
+}
+
+func TestLogPlaceholders(t *testing.T) {
+
+	tr := new(Tracer)
+	ctx, _, _ := tr.Begin(context.Background(), httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	lf := new(logging.LogMessageFormatter)
+	lf.PrefixFormat = "%{traceID}X %{spanID}X "
+	lf.Unset = "-"
+	test.ExpectNil(t, lf.Init())
+
+	test.ExpectString(t, lf.Format(ctx, "INFO", "c", "m"), TraceIDFromContext(ctx)+" "+SpanIDFromContext(ctx)+" m\n")
+	test.ExpectString(t, lf.Format(context.Background(), "INFO", "c", "m"), "- - m\n")
+}
+
+func TestJSONLinesExporter(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "grnc-tracing")
+	test.ExpectNil(t, err)
+	defer os.RemoveAll(dir)
+
+	je := new(JSONLinesExporter)
+	je.Path = filepath.Join(dir, "spans", "spans.jsonl")
+
+	test.ExpectNil(t, je.StartComponent())
+	test.ExpectNil(t, je.ExportSpans([]*Span{{TraceID: "t", SpanID: "a", Name: "one"}, {TraceID: "t", SpanID: "b", ParentSpanID: "a", Name: "two"}}))
+	test.ExpectNil(t, je.Stop())
+
+	f, err := os.Open(je.Path)
+	test.ExpectNil(t, err)
+	defer f.Close()
+
+	var names []string
+
+	sc := bufio.NewScanner(f)
+
+	for sc.Scan() {
+		s := new(Span)
+		test.ExpectNil(t, json.Unmarshal(sc.Bytes(), s))
+		names = append(names, s.Name)
+	}
+
+	test.ExpectInt(t, len(names), 2)
+	test.ExpectString(t, names[1], "two")
+}
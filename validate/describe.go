@@ -0,0 +1,142 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package validate
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FieldConstraints is a summary of the checks a rule makes against a field, intended for generating documentation
+// (e.g. OpenAPI schemas). Checks that cannot be described declaratively (EXT, MEX etc) are not included.
+type FieldConstraints struct {
+	// The name (or dotted path) of the field the rule applies to.
+	Field string
+
+	// The type of the rule (STR, INT, FLOAT, BOOL, OBJ or SLICE)
+	Type string
+
+	// Whether the rule includes a REQ check.
+	Required bool
+
+	// The minimum length of a string or slice (from a LEN check). Nil if no minimum is set.
+	MinLength *int
+
+	// The maximum length of a string or slice (from a LEN check). Nil if no maximum is set.
+	MaxLength *int
+
+	// The regular expression a string must match (from a REG check).
+	Pattern string
+
+	// The permitted values of the field (from an IN check).
+	In []string
+
+	// The minimum value of an int or float (from a RANGE check). Nil if no minimum is set.
+	Min *float64
+
+	// The maximum value of an int or float (from a RANGE check). Nil if no maximum is set.
+	Max *float64
+}
+
+// Constraints returns a summary of the checks made by each of this RuleValidator's rules, in the order the rules are defined.
+// References to shared rules are resolved using the RuleManager. An error is returned if a shared rule cannot be found.
+func (ov *RuleValidator) Constraints() ([]*FieldConstraints, error) {
+
+	all := make([]*FieldConstraints, 0)
+
+	for _, rule := range ov.Rules {
+
+		if len(rule) < 2 {
+			continue
+		}
+
+		field := rule[0]
+		ops := rule[1:]
+
+		if ov.isRuleRef(rule[1]) {
+
+			shared, err := ov.findRule(field, rule[1])
+
+			if err != nil {
+				return nil, err
+			}
+
+			ops = shared
+		}
+
+		all = append(all, describeRule(field, ops))
+	}
+
+	return all, nil
+}
+
+func describeRule(field string, ops []string) *FieldConstraints {
+
+	fc := new(FieldConstraints)
+	fc.Field = field
+
+	for _, op := range ops {
+
+		d := decomposeOperation(op)
+
+		switch d[0] {
+		case stringRuleCode, intRuleCode, floatRuleCode, boolRuleCode, objectRuleCode, sliceRuleCode:
+			fc.Type = d[0]
+		case commonOpRequired:
+			fc.Required = true
+		case commonOpLen:
+			if len(d) > 1 {
+				fc.MinLength, fc.MaxLength = describeBounds(d[1], "-")
+			}
+		case stringOpRegCode:
+			if len(d) > 1 {
+				fc.Pattern = d[1]
+			}
+		case commonOpIn:
+			if len(d) > 1 {
+				fc.In = strings.Split(d[1], setMemberSep)
+			}
+		case intOpRangeCode:
+			if len(d) > 1 {
+				fc.Min, fc.Max = describeRange(d[1])
+			}
+		}
+	}
+
+	return fc
+}
+
+func describeBounds(vals, sep string) (min, max *int) {
+
+	b := strings.SplitN(vals, sep, 2)
+
+	if i, err := strconv.Atoi(b[0]); err == nil {
+		min = &i
+	}
+
+	if len(b) > 1 {
+		if i, err := strconv.Atoi(b[1]); err == nil {
+			max = &i
+		}
+	}
+
+	return min, max
+}
+
+func describeRange(vals string) (min, max *float64) {
+
+	b := strings.SplitN(vals, "|", 2)
+
+	if f, err := strconv.ParseFloat(b[0], 64); err == nil {
+		min = &f
+	}
+
+	if len(b) > 1 {
+		if f, err := strconv.ParseFloat(b[1], 64); err == nil {
+			max = &f
+		}
+	}
+
+	return min, max
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package validate
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func TestConstraints(t *testing.T) {
+
+	ov := new(RuleValidator)
+	ov.Rules = [][]string{
+		{"Name", "STR", "REQ", "HARDTRIM", "LEN:1-64", "REG:^[a-z]+$"},
+		{"Ref", "STR", "REG:^[A-Z]{3}::[0-9]+$:BAD_REF"},
+		{"Count", "INT", "RANGE:|10"},
+		{"Genre", "RULE:genre"},
+		{"Tracks", "SLICE", "LEN:1-"},
+	}
+
+	ov.RuleManager = &UnparsedRuleManager{Rules: map[string][]string{"genre": {"STR", "IN:rock,jazz"}}}
+
+	c, err := ov.Constraints()
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(c), 5)
+
+	name := c[0]
+	test.ExpectString(t, name.Type, "STR")
+	test.ExpectBool(t, name.Required, true)
+	test.ExpectInt(t, *name.MinLength, 1)
+	test.ExpectInt(t, *name.MaxLength, 64)
+
+	test.ExpectString(t, c[1].Pattern, "^[A-Z]{3}:[0-9]+$")
+	test.ExpectBool(t, c[1].Required, false)
+
+	test.ExpectBool(t, c[2].Min == nil, true)
+	test.ExpectFloat(t, *c[2].Max, 10)
+
+	test.ExpectString(t, c[3].Type, "STR")
+	test.ExpectInt(t, len(c[3].In), 2)
+
+	test.ExpectString(t, c[4].Type, "SLICE")
+	test.ExpectBool(t, c[4].MaxLength == nil, true)
+
+	ov.Rules = [][]string{{"Missing", "RULE:missing"}}
+
+	_, err = ov.Constraints()
+	test.ExpectNotNil(t, err)
+}
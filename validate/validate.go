@@ -81,6 +81,23 @@ Sometimes it is useful for a rule to be defined once and re-used by multiple Rul
 to use some advanced techniques for deep validation of the elements of a slice. This technique is described in detail at
 http://granitic.io/ref/validation rule manager.
 
+Reloading rules
+
+If the ConfigReload facility is enabled, a RuleValidator can re-parse its rules when configuration changes. Set the
+RuleValidator's RulesPath (and the RuleManager's RulesPath, if shared rules are used) to the configuration paths the rules
+are injected from:
+
+	"createRecordValidator": {
+	  "type": "validate.RuleValidator",
+	  "DefaultErrorCode": "CREATE_RECORD",
+	  "Rules": "conf:createRecordRules",
+	  "RulesPath": "createRecordRules"
+	}
+
+If the new rules cannot be parsed, the problem is logged and the RuleValidator continues to use its previous rules. Error codes
+referenced by reloaded rules are not checked against the ServiceErrorManager's definitions, so make sure any new codes are
+already defined.
+
 Decomposing the application of a rule
 
 The first rule in the example above is:
@@ -126,12 +143,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/graniticio/granitic/v2/config"
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/types"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type validationRuleType uint
@@ -270,18 +289,37 @@ type validatorLink struct {
 type UnparsedRuleManager struct {
 	// A map between a name for a rule and the rule's unparsed definition.
 	Rules map[string][]string
+
+	// The configuration path Rules was injected from. Only required if rules should be updated when configuration is reloaded.
+	RulesPath string
+
+	mutex sync.RWMutex
 }
 
 // Exists returns true if a rule with the supplied name exists.
 func (rm *UnparsedRuleManager) Exists(ref string) bool {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
 	return rm.Rules[ref] != nil
 }
 
 // Rule returns the unparsed representation of the rule with the supplied name.
 func (rm *UnparsedRuleManager) Rule(ref string) []string {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
 	return rm.Rules[ref]
 }
 
+// replaceRules replaces the shared rules after configuration has been reloaded.
+func (rm *UnparsedRuleManager) replaceRules(rules map[string][]string) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	rm.Rules = rules
+}
+
 // FieldErrors is a summary of all the errors found while validating an object
 type FieldErrors struct {
 
@@ -313,6 +351,9 @@ type RuleValidator struct {
 	//The text representation of rules in the order in which they should be applied.
 	Rules [][]string
 
+	// The configuration path Rules was injected from. Only required if rules should be updated when configuration is reloaded.
+	RulesPath string
+
 	jsonConfig             interface{}
 	stringBuilder          *stringValidationRuleBuilder
 	objectValidatorBuilder *objectValidationRuleBuilder
@@ -324,6 +365,7 @@ type RuleValidator struct {
 	componentName          string
 	codesInUse             types.StringSet
 	state                  ioc.ComponentState
+	mutex                  sync.RWMutex
 }
 
 // ValidateMissing returns true if all error codes reference by this RuleValidator must have corresponding definitions
@@ -362,7 +404,11 @@ func (ov *RuleValidator) Validate(ctx context.Context, subject *SubjectContext)
 	unsetFields := types.NewOrderedStringSet([]string{})
 	setFields := types.NewOrderedStringSet([]string{})
 
-	for _, vl := range ov.validatorChain {
+	ov.mutex.RLock()
+	chain := ov.validatorChain
+	ov.mutex.RUnlock()
+
+	for _, vl := range chain {
 		f := vl.field
 		v := vl.validationRule
 		log.LogDebugf("Checking field %s set", f)
@@ -387,7 +433,7 @@ func (ov *RuleValidator) Validate(ctx context.Context, subject *SubjectContext)
 	}
 
 Rules:
-	for _, vl := range ov.validatorChain {
+	for _, vl := range chain {
 
 		f := vl.field
 
@@ -506,6 +552,76 @@ func (ov *RuleValidator) StartComponent() error {
 
 }
 
+// ConfigChanged re-parses the validator's rules if the configuration at RulesPath (or at its RuleManager's RulesPath)
+// has changed. If the new rules cannot be parsed, an error is returned and the existing rules are kept. Implements
+// config.ConfigChangeListener
+func (ov *RuleValidator) ConfigChanged(changed []string, ca *config.Accessor) error {
+
+	ownChanged := ov.RulesPath != "" && config.PathChanged(changed, ov.RulesPath)
+
+	rm := ov.RuleManager
+	sharedChanged := rm != nil && rm.RulesPath != "" && config.PathChanged(changed, rm.RulesPath)
+
+	if !ownChanged && !sharedChanged {
+		return nil
+	}
+
+	nv := new(RuleValidator)
+	nv.ComponentFinder = ov.ComponentFinder
+	nv.DefaultErrorCode = ov.DefaultErrorCode
+	nv.DisableCodeValidation = ov.DisableCodeValidation
+	nv.Log = ov.Log
+	nv.RuleManager = rm
+	nv.Rules = ov.Rules
+	nv.RulesPath = ov.RulesPath
+	nv.componentName = ov.componentName
+
+	if ownChanged {
+		if err := ca.SetField("Rules", ov.RulesPath, nv); err != nil {
+			return err
+		}
+	}
+
+	if sharedChanged {
+
+		nm := new(UnparsedRuleManager)
+		nm.RulesPath = rm.RulesPath
+
+		if err := ca.SetField("Rules", rm.RulesPath, nm); err != nil {
+			return err
+		}
+
+		nv.RuleManager = nm
+	}
+
+	if err := nv.StartComponent(); err != nil {
+		return err
+	}
+
+	if sharedChanged {
+		// Validators sharing the manager each parse their own copy of the new rules, so it is safe to update the shared manager
+		rm.replaceRules(nv.RuleManager.Rules)
+		nv.RuleManager = rm
+	}
+
+	ov.mutex.Lock()
+	defer ov.mutex.Unlock()
+
+	ov.Rules = nv.Rules
+	ov.stringBuilder = nv.stringBuilder
+	ov.objectValidatorBuilder = nv.objectValidatorBuilder
+	ov.boolValidatorBuilder = nv.boolValidatorBuilder
+	ov.intValidatorBuilder = nv.intValidatorBuilder
+	ov.floatValidatorBuilder = nv.floatValidatorBuilder
+	ov.sliceValidatorBuilder = nv.sliceValidatorBuilder
+	ov.validatorChain = nv.validatorChain
+	ov.codesInUse = nv.codesInUse
+
+	ov.Log.LogInfof("Validation rules for %s reloaded", ov.componentName)
+
+	return nil
+}
+
 func (ov *RuleValidator) parseRules() error {
 
 	var err error
@@ -69,6 +69,99 @@ func TestConfigParsing(t *testing.T) {
 
 }
 
+func TestRulesReloaded(t *testing.T) {
+
+	ca := &config.Accessor{FrameworkLogger: new(logging.ConsoleErrorLogger), JSONData: map[string]interface{}{
+		"rules":  []interface{}{[]interface{}{"Password", "STR", "LEN:5-"}},
+		"shared": map[string]interface{}{"passwordRule": []interface{}{"STR", "LEN:5-"}},
+	}}
+
+	rm := new(UnparsedRuleManager)
+	rm.RulesPath = "shared"
+	ca.SetField("Rules", "shared", rm)
+
+	ov := new(RuleValidator)
+	ov.RuleManager = rm
+	ov.DefaultErrorCode = "DEFAULT"
+	ov.Log = new(logging.ConsoleErrorLogger)
+	ov.RulesPath = "rules"
+	ca.SetField("Rules", "rules", ov)
+
+	test.ExpectNil(t, ov.StartComponent())
+
+	validate := func(u *User) int {
+		sc := new(SubjectContext)
+		sc.Subject = u
+
+		fe, err := ov.Validate(context.Background(), sc)
+		test.ExpectNil(t, err)
+
+		return len(fe)
+	}
+
+	test.ExpectInt(t, validate(&User{Password: "abc"}), 1)
+
+	ca.JSONData["rules"] = []interface{}{[]interface{}{"Password", "RULE:passwordRule"}}
+	ca.JSONData["shared"] = map[string]interface{}{"passwordRule": []interface{}{"STR", "LEN:3-"}}
+
+	// Not a path the validator depends on
+	test.ExpectNil(t, ov.ConfigChanged([]string{"other"}, ca))
+	test.ExpectInt(t, validate(&User{Password: "abc"}), 1)
+
+	test.ExpectNil(t, ov.ConfigChanged([]string{"rules", "shared.passwordRule"}, ca))
+	test.ExpectInt(t, validate(&User{Password: "abc"}), 0)
+	test.ExpectInt(t, validate(&User{Password: "ab"}), 1)
+	test.ExpectInt(t, len(rm.Rules["passwordRule"]), 2)
+
+	// Invalid rules are rejected and the previous rules are kept
+	ca.JSONData["rules"] = []interface{}{[]interface{}{"Password", "UNKNOWN"}}
+
+	test.ExpectNotNil(t, ov.ConfigChanged([]string{"rules"}, ca))
+	test.ExpectInt(t, validate(&User{Password: "ab"}), 1)
+}
+
+func TestSharedRulesReadWhileReloaded(t *testing.T) {
+
+	ca := &config.Accessor{FrameworkLogger: new(logging.ConsoleErrorLogger), JSONData: map[string]interface{}{
+		"rules":  []interface{}{[]interface{}{"Password", "RULE:passwordRule"}},
+		"shared": map[string]interface{}{"passwordRule": []interface{}{"STR", "LEN:5-"}},
+	}}
+
+	rm := new(UnparsedRuleManager)
+	rm.RulesPath = "shared"
+	ca.SetField("Rules", "shared", rm)
+
+	ov := new(RuleValidator)
+	ov.RuleManager = rm
+	ov.DefaultErrorCode = "DEFAULT"
+	ov.Log = new(logging.ConsoleErrorLogger)
+	ov.RulesPath = "rules"
+	ca.SetField("Rules", "rules", ov)
+
+	test.ExpectNil(t, ov.StartComponent())
+
+	done := make(chan bool)
+
+	// Another validator sharing the manager reads its rules while this validator applies a reload
+	go func() {
+		for i := 0; i < 100; i++ {
+			if rm.Exists("passwordRule") {
+				rm.Rule("passwordRule")
+			}
+		}
+
+		done <- true
+	}()
+
+	for i := 0; i < 100; i++ {
+		test.ExpectNil(t, ov.ConfigChanged([]string{"shared.passwordRule"}, ca))
+	}
+
+	<-done
+
+	test.ExpectInt(t, len(rm.Rule("passwordRule")), 2)
+}
+
 func validatorAndUser(t *testing.T) (*RuleValidator, *User) {
 	ca := LoadTestConfig()
 
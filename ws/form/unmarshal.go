@@ -0,0 +1,340 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package form defines an Unmarshaller that binds the fields of URL-encoded (application/x-www-form-urlencoded) and
+multipart (multipart/form-data) request bodies to the target of a web service request.
+
+An instance of Unmarshaller is created whenever the JSONWs, XMLWs or NegotiatedWs facility is enabled. The NegotiatedWs
+facility uses it automatically for requests with form Content-Types. When using the JSONWs or XMLWs facility, set
+the Unmarshaller of a handler that accepts forms explicitly:
+
+	{
+	  "uploadHandler": {
+		"type": "handler.WsHandler",
+		"HTTPMethod": "POST",
+		"Logic": "ref:uploadLogic",
+		"PathPattern": "^/document$",
+		"Unmarshaller": "ref:grncFormUnmarshaller"
+	  }
+	}
+
+Field binding
+
+Text fields are bound to the fields on the request's target struct that have exactly the same name as the form field,
+using the same conversion rules as query parameter binding (see ws.ParamBinder). Form fields without a matching struct
+field are ignored.
+
+Files in multipart forms are bound to fields of type *Upload (or []*Upload if the field may contain more than one file)
+with the same name as the form field. Files smaller than MemoryThresholdBytes are held in memory; larger files are
+written to a temporary file which is removed once the request has been processed.
+
+Limits
+
+The size of the request body, the size of each uploaded file and the number of files can be limited with the following
+configuration (the defaults are shown):
+
+	{
+	  "FormBinding": {
+		"MaxBodyBytes": 33554432,
+		"MaxFileBytes": 10485760,
+		"MaxFiles": 10,
+		"MemoryThresholdBytes": 1048576,
+		"TempDir": ""
+	  }
+	}
+
+Zero or less means no limit. A form that breaks one of these limits results in a framework error (FormTooLarge,
+FormFileTooLarge or FormTooManyFiles) and, unless the handler defers framework errors, a 400 response.
+
+These limits are checked after any limit set on the request body as a whole. A body that is larger than the handler's
+MaxBodyBytes (see handler.WsHandler) or the HTTP server's MaxRequestBodyBytes is rejected with a RequestBodyTooLarge
+framework error and a 413 response, whatever its Content-Type, so FormBinding.MaxBodyBytes only has an effect if it is
+lower than those limits.
+*/
+package form
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+const (
+	// URLEncodedMediaType is the Content-Type of URL-encoded forms
+	URLEncodedMediaType = "application/x-www-form-urlencoded"
+
+	// MultipartMediaType is the Content-Type of multipart forms
+	MultipartMediaType = "multipart/form-data"
+
+	tempFilePattern = "grnc-upload-"
+)
+
+var uploadType = reflect.TypeOf(new(Upload))
+var uploadsType = reflect.TypeOf([]*Upload{})
+
+// Unmarshaller parses URL-encoded and multipart form request bodies and binds their fields and files to the request's
+// target. Implements ws.Unmarshaller
+type Unmarshaller struct {
+	// Injected by Granitic
+	FrameworkLogger logging.Logger
+
+	// Source of messages for errors encountered when a limit is exceeded
+	FrameworkErrors *ws.FrameworkErrorGenerator
+
+	// Used to bind text fields to the request target
+	ParamBinder *ws.ParamBinder
+
+	// The maximum size of a request body. Zero or less means no limit.
+	MaxBodyBytes int64
+
+	// The maximum size of an individual uploaded file. Zero or less means no limit.
+	MaxFileBytes int64
+
+	// The maximum number of files in a multipart form. Zero or less means no limit.
+	MaxFiles int
+
+	// Uploaded files larger than this are written to a temporary file rather than being held in memory.
+	MemoryThresholdBytes int64
+
+	// The directory in which temporary files are created. If empty, the system's default temporary directory is used.
+	TempDir string
+}
+
+// Unmarshall parses the form in the request body and binds it to wsReq.RequestBody. Problems caused by the caller
+// (limits being exceeded, values that can't be converted) are recorded as framework errors on the request. Returns a
+// *ws.UnsupportedMediaTypeError if the request does not contain a form.
+func (u *Unmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *ws.Request) error {
+	defer req.Body.Close()
+
+	ct := req.Header.Get("Content-Type")
+
+	mt, params, err := mime.ParseMediaType(ct)
+
+	if err != nil {
+		return &ws.UnsupportedMediaTypeError{MediaType: ct}
+	}
+
+	body := ws.NewLimitedBody(req.Body, u.MaxBodyBytes)
+
+	var values url.Values
+	var uploads map[string][]*Upload
+
+	switch mt {
+	case URLEncodedMediaType:
+		values, err = u.parseURLEncoded(body)
+	case MultipartMediaType:
+		values, uploads, err = u.parseMultipart(body, params["boundary"], wsReq)
+	default:
+		return &ws.UnsupportedMediaTypeError{MediaType: mt}
+	}
+
+	if body.Exceeded() {
+		m, c := u.FrameworkErrors.MessageCode(ws.FormTooLarge, u.MaxBodyBytes)
+		wsReq.AddFrameworkError(ws.NewUnmarshallFrameworkError(m, c))
+
+		return nil
+	}
+
+	if fe, found := err.(*ws.FrameworkError); found {
+		wsReq.AddFrameworkError(fe)
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if wsReq.RequestBody == nil {
+		return nil
+	}
+
+	u.ParamBinder.BindFormValues(wsReq, ws.NewParamsForQuery(values))
+	u.bindUploads(wsReq, uploads)
+
+	return nil
+}
+
+func (u *Unmarshaller) parseURLEncoded(r io.Reader) (url.Values, error) {
+
+	b, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return url.ParseQuery(string(b))
+}
+
+func (u *Unmarshaller) parseMultipart(r io.Reader, boundary string, wsReq *ws.Request) (url.Values, map[string][]*Upload, error) {
+
+	if boundary == "" {
+		return nil, nil, errors.New("multipart request has no boundary")
+	}
+
+	values := make(url.Values)
+	uploads := make(map[string][]*Upload)
+	fileCount := 0
+
+	mr := multipart.NewReader(r, boundary)
+
+	for {
+		part, err := mr.NextPart()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		name := part.FormName()
+
+		if name == "" {
+			continue
+		}
+
+		if part.FileName() == "" {
+
+			b, err := ioutil.ReadAll(part)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			values.Add(name, string(b))
+
+			continue
+		}
+
+		fileCount++
+
+		if u.MaxFiles > 0 && fileCount > u.MaxFiles {
+			m, c := u.FrameworkErrors.MessageCode(ws.FormTooManyFiles, u.MaxFiles)
+			return nil, nil, ws.NewUnmarshallFrameworkError(m, c)
+		}
+
+		up, err := u.readUpload(part, wsReq)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		uploads[name] = append(uploads[name], up)
+	}
+
+	return values, uploads, nil
+}
+
+// readUpload reads the contents of a file part into memory, spilling to a temporary file if the part is larger than
+// MemoryThresholdBytes.
+func (u *Unmarshaller) readUpload(part *multipart.Part, wsReq *ws.Request) (*Upload, error) {
+
+	up := new(Upload)
+	up.FieldName = part.FormName()
+	up.Filename = part.FileName()
+	up.ContentType = part.Header.Get("Content-Type")
+	up.Header = part.Header
+
+	var src io.Reader = part
+
+	if u.MaxFileBytes > 0 {
+		// Read one byte more than the limit so oversized files can be detected
+		src = io.LimitReader(part, u.MaxFileBytes+1)
+	}
+
+	var buf bytes.Buffer
+
+	n, err := io.CopyN(&buf, src, u.MemoryThresholdBytes+1)
+
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if n > u.MemoryThresholdBytes {
+
+		f, err := ioutil.TempFile(u.TempDir, tempFilePattern)
+
+		if err != nil {
+			return nil, err
+		}
+
+		up.path = f.Name()
+
+		wsReq.AddCleanup(func() {
+			if err := up.Remove(); err != nil {
+				u.FrameworkLogger.LogErrorf("Unable to remove temporary upload file %s: %s", up.path, err.Error())
+			}
+		})
+
+		n, err = io.Copy(f, io.MultiReader(&buf, src))
+
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+	} else {
+		up.data = buf.Bytes()
+	}
+
+	if u.MaxFileBytes > 0 && n > u.MaxFileBytes {
+		m, c := u.FrameworkErrors.MessageCode(ws.FormFileTooLarge, up.FieldName, u.MaxFileBytes)
+		return nil, ws.NewFormBindFrameworkError(m, c, up.FieldName, "")
+	}
+
+	up.Size = n
+
+	return up, nil
+}
+
+func (u *Unmarshaller) bindUploads(wsReq *ws.Request, uploads map[string][]*Upload) {
+
+	t := reflect.ValueOf(wsReq.RequestBody).Elem()
+
+	for name, files := range uploads {
+
+		f := t.FieldByName(name)
+
+		if !f.IsValid() || !f.CanSet() {
+			continue
+		}
+
+		switch f.Type() {
+		case uploadType:
+
+			if len(files) > 1 {
+				m, c := u.FrameworkErrors.MessageCode(ws.FormTargetNotArray, name)
+				wsReq.AddFrameworkError(ws.NewFormBindFrameworkError(m, c, name, name))
+
+				continue
+			}
+
+			f.Set(reflect.ValueOf(files[0]))
+
+		case uploadsType:
+			f.Set(reflect.ValueOf(files))
+
+		default:
+			m, c := u.FrameworkErrors.MessageCode(ws.FormFileNotExpected, name)
+			wsReq.AddFrameworkError(ws.NewFormBindFrameworkError(m, c, name, name))
+
+			continue
+		}
+
+		wsReq.RecordFieldAsBound(name)
+	}
+}
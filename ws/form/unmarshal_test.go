@@ -0,0 +1,226 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package form
+
+import (
+	"bytes"
+	"context"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/types"
+	"github.com/graniticio/granitic/v2/ws"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type uploadTarget struct {
+	Name        string
+	Count       int
+	Public      *types.NilableBool
+	Document    *Upload
+	Attachments []*Upload
+}
+
+func newUnmarshaller() *Unmarshaller {
+
+	feg := new(ws.FrameworkErrorGenerator)
+	feg.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	feg.Messages = map[ws.FrameworkErrorEvent][]string{
+		ws.FormWrongType:       {"FORMBIND", "Wrong type %s %s %s"},
+		ws.FormTooLarge:        {"FORMBIND", "Too large %d"},
+		ws.FormFileTooLarge:    {"FORMBIND", "File too large %s %d"},
+		ws.FormTooManyFiles:    {"FORMBIND", "Too many files %d"},
+		ws.FormFileNotExpected: {"FORMBIND", "Not expected %s"},
+	}
+
+	pb := new(ws.ParamBinder)
+	pb.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	pb.FrameworkErrors = feg
+
+	u := new(Unmarshaller)
+	u.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	u.FrameworkErrors = feg
+	u.ParamBinder = pb
+	u.MemoryThresholdBytes = 1024
+
+	return u
+}
+
+func multipartRequest(t *testing.T, fields map[string]string, files map[string]string) *http.Request {
+
+	var b bytes.Buffer
+
+	mw := multipart.NewWriter(&b)
+
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+
+	for k, v := range files {
+		fw, err := mw.CreateFormFile(k, k+".txt")
+
+		test.ExpectNil(t, err)
+
+		fw.Write([]byte(v))
+	}
+
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &b)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return req
+}
+
+func TestURLEncodedForm(t *testing.T) {
+
+	u := newUnmarshaller()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("Name=report&Count=3&Public=true&Unknown=x"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	wsReq := new(ws.Request)
+	target := new(uploadTarget)
+	wsReq.RequestBody = target
+
+	test.ExpectNil(t, u.Unmarshall(context.Background(), req, wsReq))
+
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 0)
+	test.ExpectString(t, target.Name, "report")
+	test.ExpectInt(t, target.Count, 3)
+	test.ExpectBool(t, target.Public.Bool(), true)
+	test.ExpectBool(t, wsReq.WasFieldBound("Count"), true)
+
+	req = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("Count=many"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	wsReq = new(ws.Request)
+	wsReq.RequestBody = new(uploadTarget)
+
+	test.ExpectNil(t, u.Unmarshall(context.Background(), req, wsReq))
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+	test.ExpectBool(t, wsReq.FrameworkErrors[0].Phase == ws.FormBind, true)
+	test.ExpectString(t, wsReq.FrameworkErrors[0].ClientField, "Count")
+}
+
+func TestMultipartForm(t *testing.T) {
+
+	u := newUnmarshaller()
+	u.TempDir = os.TempDir()
+
+	large := strings.Repeat("x", 2048)
+
+	req := multipartRequest(t, map[string]string{"Name": "report"}, map[string]string{"Document": "small", "Attachments": large})
+
+	wsReq := new(ws.Request)
+	target := new(uploadTarget)
+	wsReq.RequestBody = target
+
+	test.ExpectNil(t, u.Unmarshall(context.Background(), req, wsReq))
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 0)
+	test.ExpectString(t, target.Name, "report")
+
+	test.ExpectNotNil(t, target.Document)
+	test.ExpectBool(t, target.Document.InMemory(), true)
+	test.ExpectString(t, target.Document.Filename, "Document.txt")
+	test.ExpectInt(t, int(target.Document.Size), 5)
+
+	test.ExpectInt(t, len(target.Attachments), 1)
+
+	a := target.Attachments[0]
+
+	test.ExpectBool(t, a.InMemory(), false)
+	test.ExpectInt(t, int(a.Size), 2048)
+
+	r, err := a.Open()
+	test.ExpectNil(t, err)
+
+	contents, _ := ioutil.ReadAll(r)
+	r.Close()
+
+	test.ExpectString(t, string(contents), large)
+
+	// Temporary files are removed once the request is complete
+	path := a.TempPath()
+
+	_, err = os.Stat(path)
+	test.ExpectNil(t, err)
+
+	wsReq.Cleanup()
+
+	_, err = os.Stat(path)
+	test.ExpectBool(t, os.IsNotExist(err), true)
+}
+
+func TestFormLimits(t *testing.T) {
+
+	u := newUnmarshaller()
+	u.MaxFileBytes = 10
+
+	req := multipartRequest(t, nil, map[string]string{"Document": strings.Repeat("x", 11)})
+
+	wsReq := new(ws.Request)
+	wsReq.RequestBody = new(uploadTarget)
+
+	test.ExpectNil(t, u.Unmarshall(context.Background(), req, wsReq))
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+	test.ExpectString(t, wsReq.FrameworkErrors[0].Message, "File too large Document 10")
+
+	u.MaxFileBytes = 0
+	u.MaxFiles = 1
+
+	req = multipartRequest(t, nil, map[string]string{"Document": "a", "Attachments": "b"})
+
+	wsReq = new(ws.Request)
+	wsReq.RequestBody = new(uploadTarget)
+
+	test.ExpectNil(t, u.Unmarshall(context.Background(), req, wsReq))
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+	test.ExpectString(t, wsReq.FrameworkErrors[0].Message, "Too many files 1")
+
+	u.MaxFiles = 0
+	u.MaxBodyBytes = 16
+
+	req = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("Name="+strings.Repeat("x", 32)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	wsReq = new(ws.Request)
+	wsReq.RequestBody = new(uploadTarget)
+
+	test.ExpectNil(t, u.Unmarshall(context.Background(), req, wsReq))
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+	test.ExpectString(t, wsReq.FrameworkErrors[0].Message, "Too large 16")
+
+	u.MaxBodyBytes = 0
+
+	req = multipartRequest(t, nil, map[string]string{"Name": "a"})
+
+	wsReq = new(ws.Request)
+	wsReq.RequestBody = new(uploadTarget)
+
+	test.ExpectNil(t, u.Unmarshall(context.Background(), req, wsReq))
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+	test.ExpectString(t, wsReq.FrameworkErrors[0].Message, "Not expected Name")
+}
+
+func TestUnsupportedFormType(t *testing.T) {
+
+	u := newUnmarshaller()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+
+	wsReq := new(ws.Request)
+	wsReq.RequestBody = new(uploadTarget)
+
+	err := u.Unmarshall(context.Background(), req, wsReq)
+
+	_, found := err.(*ws.UnsupportedMediaTypeError)
+	test.ExpectBool(t, found, true)
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package form
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"os"
+)
+
+// Upload is a file uploaded as part of a multipart form. Small files are held in memory, larger files are written to a
+// temporary file that is removed once the request has been processed. Declare fields of type *Upload or []*Upload on
+// your request body to receive uploaded files.
+type Upload struct {
+	// The name of the form field the file was uploaded in.
+	FieldName string
+
+	// The name of the file as supplied by the caller. Should not be trusted as a path on the local filesystem.
+	Filename string
+
+	// The Content-Type of the part (not verified against the actual content of the file).
+	ContentType string
+
+	// All of the MIME headers of the part.
+	Header textproto.MIMEHeader
+
+	// The size of the file in bytes.
+	Size int64
+
+	data []byte
+	path string
+}
+
+// Open returns a reader over the contents of the file. The caller must close the reader.
+func (u *Upload) Open() (io.ReadCloser, error) {
+
+	if u.path != "" {
+		return os.Open(u.path)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(u.data)), nil
+}
+
+// InMemory returns true if the contents of the file are held in memory rather than in a temporary file.
+func (u *Upload) InMemory() bool {
+	return u.path == ""
+}
+
+// TempPath returns the location of the temporary file holding the contents of the upload, or an empty string if
+// the contents are held in memory. The file is removed once the request has been processed, so applications that
+// need to keep the file must copy or move it.
+func (u *Upload) TempPath() string {
+	return u.path
+}
+
+// Remove deletes the temporary file holding the contents of the upload (if there is one).
+func (u *Upload) Remove() error {
+
+	if u.path == "" {
+		return nil
+	}
+
+	err := os.Remove(u.path)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
@@ -21,6 +21,9 @@ const (
 
 	//PathBind indicates an error was encountered while mapping elements of an HTTP request's path to fields on a struct
 	PathBind
+
+	// FormBind indicates an error was encountered while mapping the fields of a URL-encoded or multipart form to fields on a struct
+	FormBind
 )
 
 // FrameworkError an error encountered in early phases of request processing, before application code is invoked.
@@ -93,12 +96,28 @@ func NewPathBindFrameworkError(message, code, target string) *FrameworkError {
 	return f
 }
 
+// NewFormBindFrameworkError creates a FrameworkError with fields set appropriate for an error
+// encountered while mapping the fields of a form in the HTTP request's body to fields on a Request's Body.
+func NewFormBindFrameworkError(message, code, field, target string) *FrameworkError {
+	f := new(FrameworkError)
+	f.Phase = FormBind
+	f.Message = message
+	f.ClientField = field
+	f.TargetField = target
+	f.Code = code
+
+	return f
+}
+
 // FrameworkErrorEvent uniquely identifies a 'handled' failure during the parsing and binding phases
 type FrameworkErrorEvent string
 
 const (
 	// UnableToParseRequest indicates that the HTTP request could not be parsed
 	UnableToParseRequest = "UnableToParseRequest"
+
+	// RequestBodyTooLarge indicates that the body of the HTTP request was larger than the maximum permitted size
+	RequestBodyTooLarge = "RequestBodyTooLarge"
 	// QueryTargetNotArray indicates that a query parameter whose value is a list has been bound to a target field that is not an array
 	QueryTargetNotArray = "QueryTargetNotArray"
 
@@ -110,6 +129,24 @@ const (
 
 	// QueryNoTargetField indicates that no field on the target can be matched to the a named query parameter
 	QueryNoTargetField = "QueryNoTargetField"
+
+	// FormTargetNotArray indicates that a form field with multiple values has been bound to a target field that is not an array
+	FormTargetNotArray = "FormTargetNotArray"
+
+	// FormWrongType indicates that the value of a form field is not compatible with the type of field to which it is bound
+	FormWrongType = "FormWrongType"
+
+	// FormTooLarge indicates that a form in a request body is larger than the maximum permitted size
+	FormTooLarge = "FormTooLarge"
+
+	// FormFileTooLarge indicates that a file uploaded as part of a multipart form is larger than the maximum permitted size
+	FormFileTooLarge = "FormFileTooLarge"
+
+	// FormTooManyFiles indicates that a multipart form contains more files than permitted
+	FormTooManyFiles = "FormTooManyFiles"
+
+	// FormFileNotExpected indicates that a file has been uploaded in a form field that cannot be bound to an upload
+	FormFileNotExpected = "FormFileNotExpected"
 )
 
 // A FrameworkErrorGenerator can create error messages for errors that occur outside of application code and messages
@@ -27,7 +27,15 @@ A handler is declared in your component definition file like:
 
 Each handler must have the following before it is considered a valid web service endpoint.
 
-1. A regular expression that will be matched against the path component of incoming HTTP requests.
+1. A regular expression (PathPattern) or a path template (PathTemplate) that will be matched against the path component of
+incoming HTTP requests. Path templates look like
+
+	/artist/{id:int}/album/{title}
+
+and support parameters of type string (the default), int and float. Handlers with a path template are matched using a tree
+rather than by testing each handler's regular expression in turn, and conflicting templates are reported when the
+application starts. Unless BindPathParams is set, each template parameter is bound to the field on the request body with
+the same name.
 
 2. A single HTTP method that it will be responsible for handling. This is generally GET, POST, PUT or DELETE but any
 standard or custom HTTP method can be used.
@@ -35,6 +43,20 @@ standard or custom HTTP method can be used.
 3. A 'logic' component that implements at least WsRequestProcessor (additional WsXXX interfaces can be implemented
 to support advanced behaviour) OR has a method with the signature ProcessPayload(ctx context.Context, request *ws.Request, response *ws.Response, payload *YourStruct)
 
+Server-sent events
+
+Endpoints that push a stream of events to browsers should be declared as an SSEHandler rather than a WsHandler. SSEHandlers
+identify, authenticate and check the access of callers in the same way as WsHandlers, but the Logic component implements
+SSEEventSource and sends events to a channel for as long as the caller stays connected.
+
+Request deadlines
+
+The HTTPServer facility sets a deadline (HTTPServer.RequestTimeoutMS) on the context passed to handlers, which an
+individual handler can override with its TimeoutMS field. Logic components should pass the context to anything that
+might block (rdbms.ClientManager.ClientFromContext, outbound HTTP requests etc). If the deadline passes before the
+request has been processed, the caller receives a response with the handler's TimeoutStatus (504 by default) instead
+of the result of processing.
+
 */
 package handler
 
@@ -49,9 +71,12 @@ import (
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/validate"
 	"github.com/graniticio/granitic/v2/ws"
+	"math"
 	"net/http"
 	"reflect"
 	"regexp"
+	"strconv"
+	"time"
 )
 
 const processPayloadFunc = "ProcessPayload"
@@ -133,7 +158,8 @@ type WsHandler struct {
 	// A component able to use a set of user-defined rules to validate a request.
 	AutoValidator *validate.RuleValidator
 
-	// A list of field names on the target object into which path parameters (groups in the request regex) should be bound to.
+	// A list of field names on the target object into which path parameters (groups in the request regex or parameters in
+	// the path template) should be bound to.
 	BindPathParams []string
 
 	// Check caller's permissions after request has been parsed (true) or before parsing (false).
@@ -151,6 +177,10 @@ type WsHandler struct {
 	// If true, discard the request's query parameters.
 	DisableQueryParsing bool
 
+	// The maximum size in bytes of the request body this handler will accept. Requests with a larger body receive a 413
+	// response. Zero or less means only the server's limit (if any) applies.
+	MaxBodyBytes int64
+
 	// If true, discard any path parameters found by match the request URI against the PathMatchPattern regex.
 	DisablePathParsing bool
 
@@ -177,9 +207,19 @@ type WsHandler struct {
 	// and Granitic types.
 	ParamBinder *ws.ParamBinder
 
+	// A component able to decide whether the caller has made too many requests to this handler. Automatically injected if
+	// the RateLimiting facility is enabled.
+	RateLimiter ws.RateLimiter
+
 	// A regex that will be matched against inbound request paths to check if this handler should be used to service the request.
+	// Mutually exclusive with PathTemplate.
 	PathPattern string
 
+	// A template (e.g. /record/{id:int}) that will be matched against inbound request paths to check if this handler should be
+	// used to service the request. If BindPathParams is not set, each parameter in the template will be bound to the field
+	// on the request body with the same name as the parameter. Mutually exclusive with PathPattern.
+	PathTemplate string
+
 	// A component that might want to modify a response after it has been processed by the supplied Logic component.
 	PostProcessor WsPostProcessor
 
@@ -195,6 +235,15 @@ type WsHandler struct {
 	// Whether on not the caller needs to be authenticated (using a ws.Identifier) in order to access the logic behind this handler.
 	RequireAuthentication bool
 
+	// The maximum number of milliseconds a request to this handler may take to process. The deadline is set on the context
+	// passed to the Logic component. Zero means the HTTP server's default (HTTPServer.RequestTimeoutMS) applies and a negative
+	// value means requests to this handler have no deadline.
+	TimeoutMS int64
+
+	// The HTTP status (503 or 504) returned to the caller if the deadline passes before the request has been processed.
+	// Defaults to 504.
+	TimeoutStatus int
+
 	// A component injected by the Granitic framework that can extract the body of the incoming HTTP request into a Go struct.
 	Unmarshaller ws.Unmarshaller
 
@@ -208,6 +257,7 @@ type WsHandler struct {
 	httpMethods       []string
 	componentName     string
 	pathRegex         *regexp.Regexp
+	pathTemplate      *httpendpoint.PathTemplate
 	state             ioc.ComponentState
 	validationEnabled bool
 	validator         WsRequestValidator
@@ -242,6 +292,8 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 	wsReq.HTTPMethod = req.Method
 	wsReq.ServingHandler = wh.ComponentName()
 
+	defer wsReq.Cleanup()
+
 	if wh.AllowDirectHTTPAccess {
 		da := new(ws.DirectHTTPAccess)
 		da.Request = req
@@ -250,6 +302,11 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 		wsReq.UnderlyingHTTP = da
 	}
 
+	//Make sure the response can be rendered in a form the caller will accept
+	if !wh.negotiateResponseType(ctx, w, req, wsReq) {
+		return ctx
+	}
+
 	//Try to identify and/or authenticate the caller
 	var okay bool
 
@@ -258,13 +315,21 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 		return ctx
 	}
 
+	//Check the caller hasn't exceeded the rate at which they can make requests
+	if !wh.checkRateLimit(ctx, w, req, wsReq) {
+		return ctx
+	}
+
 	//Check caller has permission to use this resource
 	if !wh.CheckAccessAfterParse && !wh.checkAccess(ctx, w, wsReq) {
 		return ctx
 	}
 
 	//Unmarshall body, query parameters and path parameters
-	wh.unmarshall(ctx, req, wsReq)
+	if !wh.unmarshall(ctx, w, req, wsReq) {
+		return ctx
+	}
+
 	wh.processQueryParams(ctx, req, wsReq)
 	wh.processPathParams(req, wsReq)
 
@@ -290,12 +355,37 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 		return ctx
 	}
 
+	if wh.deadlinePassed(ctx, w, wsReq) {
+		return ctx
+	}
+
 	//Execute logic
 	wh.process(ctx, wsReq, w)
 
 	return ctx
 }
 
+// deadlinePassed checks whether the request's deadline has passed and, if so, writes a timeout response.
+func (wh *WsHandler) deadlinePassed(ctx context.Context, w *httpendpoint.HTTPResponseWriter, wsReq *ws.Request) bool {
+
+	if ctx.Err() != context.DeadlineExceeded {
+		return false
+	}
+
+	wh.Log.LogDebugfCtx(ctx, "Deadline passed while processing request to %s", wh.ComponentName())
+
+	w.TimedOut = true
+
+	state := ws.NewAbnormalState(wh.TimeoutStatus, w)
+	state.WsRequest = wsReq
+
+	if err := wh.ResponseWriter.Write(ctx, state, ws.Abnormal); err != nil {
+		wh.Log.LogErrorfCtx(ctx, "Problem writing timeout response: %s", err.Error())
+	}
+
+	return true
+}
+
 func (wh *WsHandler) validateRequest(ctx context.Context, wsReq *ws.Request, errors *ws.ServiceErrors) {
 	if wh.validationEnabled {
 		proceed := true
@@ -358,7 +448,9 @@ func (wh *WsHandler) validateRequest(ctx context.Context, wsReq *ws.Request, err
 
 }
 
-func (wh *WsHandler) unmarshall(ctx context.Context, req *http.Request, wsReq *ws.Request) {
+// unmarshall parses the request body into a target object. Returns false if the request body's media type is not supported
+// (in which case a response has already been written).
+func (wh *WsHandler) unmarshall(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, wsReq *ws.Request) bool {
 
 	var uf func() interface{}
 
@@ -370,18 +462,73 @@ func (wh *WsHandler) unmarshall(ctx context.Context, req *http.Request, wsReq *w
 		uf = wh.createTarget
 	} else {
 		//No way of creating a target
-		return
+		return true
 	}
 
 	target := uf()
 	wsReq.RequestBody = target
 
 	if req.ContentLength == 0 {
-		return
+		return true
+	}
+
+	var body *ws.LimitedBody
+
+	if wh.MaxBodyBytes > 0 {
+
+		if req.ContentLength > wh.MaxBodyBytes {
+			// Reject the request without reading a body that is declared to be too large
+			wh.Log.LogDebugfCtx(ctx, "Request body for %s %s is %d bytes (limit %d)", req.URL.Path, req.Method, req.ContentLength, wh.MaxBodyBytes)
+
+			state := ws.NewAbnormalState(http.StatusRequestEntityTooLarge, w)
+			state.WsRequest = wsReq
+
+			wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
+			return false
+		}
+
+		body = ws.NewLimitedBody(req.Body, wh.MaxBodyBytes)
+		req.Body = body
 	}
 
 	err := wh.Unmarshaller.Unmarshall(ctx, req, wsReq)
 
+	tooLarge, limit := body != nil && body.Exceeded(), wh.MaxBodyBytes
+
+	if !tooLarge {
+		// The server's limit may have been reached before the handler's
+		tooLarge, limit = ws.ServerLimitExceeded(err)
+	}
+
+	if tooLarge {
+
+		wh.Log.LogDebugfCtx(ctx, "Request body for %s %s exceeds limit of %d bytes", req.URL.Path, req.Method, limit)
+
+		m, c := wh.FrameworkErrors.MessageCode(ws.RequestBodyTooLarge, limit)
+		wsReq.AddFrameworkError(ws.NewUnmarshallFrameworkError(m, c))
+
+		if wh.DeferFrameworkErrors {
+			return true
+		}
+
+		state := ws.NewAbnormalState(http.StatusRequestEntityTooLarge, w)
+		state.WsRequest = wsReq
+
+		wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
+		return false
+	}
+
+	if ue, found := err.(*ws.UnsupportedMediaTypeError); found {
+
+		wh.Log.LogDebugfCtx(ctx, "Unsupported request body for %s %s %s", req.URL.Path, req.Method, ue)
+
+		state := ws.NewAbnormalState(http.StatusUnsupportedMediaType, w)
+		state.WsRequest = wsReq
+
+		wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
+		return false
+	}
+
 	if err != nil {
 
 		wh.Log.LogDebugfCtx(ctx, "Error unmarshalling request body for %s %s %s", req.URL.Path, req.Method, err)
@@ -392,6 +539,30 @@ func (wh *WsHandler) unmarshall(ctx context.Context, req *http.Request, wsReq *w
 		wsReq.AddFrameworkError(f)
 	}
 
+	return true
+}
+
+func (wh *WsHandler) negotiateResponseType(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, wsReq *ws.Request) bool {
+
+	n, found := wh.ResponseWriter.(ws.MediaTypeNegotiator)
+
+	if !found {
+		return true
+	}
+
+	mt, acceptable := n.SelectMediaType(req.Header.Get("Accept"))
+
+	if acceptable {
+		wsReq.ResponseMediaType = mt
+		return true
+	}
+
+	state := ws.NewAbnormalState(http.StatusNotAcceptable, w)
+	state.WsRequest = wsReq
+
+	wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
+
+	return false
 }
 
 func (wh *WsHandler) processPathParams(req *http.Request, wsReq *ws.Request) {
@@ -437,8 +608,12 @@ func (wh *WsHandler) processQueryParams(ctx context.Context, req *http.Request,
 }
 
 func (wh *WsHandler) checkAccess(ctx context.Context, w *httpendpoint.HTTPResponseWriter, wsReq *ws.Request) bool {
+	return checkAccess(ctx, wh.AccessChecker, wh.ResponseWriter, w, wsReq)
+}
 
-	ac := wh.AccessChecker
+// checkAccess uses the supplied AccessChecker (if not nil) to see if the caller is allowed to access the endpoint, writing
+// a 403 response if not. Shared by all types of handler in this package.
+func checkAccess(ctx context.Context, ac ws.AccessChecker, rw ws.ResponseWriter, w *httpendpoint.HTTPResponseWriter, wsReq *ws.Request) bool {
 
 	if ac == nil {
 		return true
@@ -454,27 +629,58 @@ func (wh *WsHandler) checkAccess(ctx context.Context, w *httpendpoint.HTTPRespon
 	state.Identity = wsReq.UserIdentity
 	state.WsRequest = wsReq
 
-	wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
+	rw.Write(ctx, state, ws.Abnormal)
 	return false
 
 }
 
+func (wh *WsHandler) checkRateLimit(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, wsReq *ws.Request) bool {
+
+	rl := wh.RateLimiter
+
+	if rl == nil {
+		return true
+	}
+
+	allowed, retryAfter := rl.Allow(ctx, wh.ComponentName(), req, wsReq.UserIdentity)
+
+	if allowed {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+	state := ws.NewAbnormalState(http.StatusTooManyRequests, w)
+	state.Identity = wsReq.UserIdentity
+	state.WsRequest = wsReq
+
+	wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
+	return false
+}
+
 func (wh *WsHandler) identifyAndAuthenticate(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, wsReq *ws.Request) (bool, context.Context) {
+	return identifyAndAuthenticate(ctx, wh.UserIdentifier, wh.RequireAuthentication, wh.ResponseWriter, w, req, wsReq)
+}
+
+// identifyAndAuthenticate uses the supplied Identifier (if not nil) to determine the identity of the caller, writing a
+// 401 response if authentication is required and the caller is not authenticated. Shared by all types of handler in this package.
+func identifyAndAuthenticate(ctx context.Context, ui ws.Identifier, requireAuthentication bool, rw ws.ResponseWriter,
+	w *httpendpoint.HTTPResponseWriter, req *http.Request, wsReq *ws.Request) (bool, context.Context) {
 
 	var i iam.ClientIdentity
 
-	if wh.UserIdentifier != nil {
+	if ui != nil {
 
-		i, ctx = wh.UserIdentifier.IDentify(ctx, req)
+		i, ctx = ui.IDentify(ctx, req)
 		wsReq.UserIdentity = i
 
-		if wh.RequireAuthentication && !i.Authenticated() {
+		if requireAuthentication && !i.Authenticated() {
 
 			state := ws.NewAbnormalState(http.StatusUnauthorized, w)
 			state.Identity = wsReq.UserIdentity
 			state.WsRequest = wsReq
 
-			wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
+			rw.Write(ctx, state, ws.Abnormal)
 			return false, ctx
 		}
 
@@ -499,11 +705,23 @@ func (wh *WsHandler) SupportedHTTPMethods() []string {
 }
 
 // RegexPattern returns the unparsed regex pattern that should be applicaed to the path of incoming requests to
-// see if this handler should handle the request.
+// see if this handler should handle the request. If the handler has a PathTemplate, the equivalent regex for that
+// template is returned.
 func (wh *WsHandler) RegexPattern() string {
+
+	if wh.pathTemplate != nil {
+		return wh.pathTemplate.RegexPattern()
+	}
+
 	return wh.PathPattern
 }
 
+// TemplatePattern returns the template (e.g. /record/{id:int}) that should be used to match incoming requests to
+// this handler or an empty string if the handler uses a regex pattern. Implements httpendpoint.TemplatedProvider
+func (wh *WsHandler) TemplatePattern() string {
+	return wh.PathTemplate
+}
+
 // VersionAware returns true if this handler can be considered when a user requests a specific version of functionality.
 func (wh *WsHandler) VersionAware() bool {
 	return wh.VersionAssessor != nil
@@ -515,6 +733,17 @@ func (wh *WsHandler) SupportsVersion(version httpendpoint.RequiredVersion) bool
 	return wh.VersionAssessor.SupportsVersion(wh.ComponentName(), version)
 }
 
+// RequestTimeout returns the maximum amount of time a request to this handler may take to process (see TimeoutMS).
+// Implements httpendpoint.DeadlineProvider
+func (wh *WsHandler) RequestTimeout() time.Duration {
+
+	if wh.TimeoutMS < 0 {
+		return -1
+	}
+
+	return time.Duration(wh.TimeoutMS) * time.Millisecond
+}
+
 // AutoWireable returns true if this handler should be automatically registered with any instances of httpserver.HTTPServer
 // that are running in the application.
 func (wh *WsHandler) AutoWireable() bool {
@@ -562,6 +791,11 @@ func (wh *WsHandler) process(ctx context.Context, request *ws.Request, w *httpen
 		wh.PostProcessor.PostProcess(ctx, wh.ComponentName(), request, wsRes)
 	}
 
+	if wh.deadlinePassed(ctx, w, request) {
+		// The caller will receive a timeout response rather than the (possibly incomplete) result of processing
+		return
+	}
+
 	state := new(ws.ProcessState)
 	state.Identity = request.UserIdentity
 	state.HTTPResponseWriter = w
@@ -647,8 +881,33 @@ func (wh *WsHandler) StartComponent() error {
 
 	wh.state = ioc.StartingState
 
-	if wh.PathPattern == "" || wh.HTTPMethod == "" || wh.Logic == nil {
-		return errors.New("handlers must have at least a PathPattern string, HTTPMethod string and Logic component set")
+	if (wh.PathPattern == "" && wh.PathTemplate == "") || wh.HTTPMethod == "" || wh.Logic == nil {
+		return errors.New("handlers must have at least a PathPattern or PathTemplate string, HTTPMethod string and Logic component set")
+	}
+
+	if wh.PathPattern != "" && wh.PathTemplate != "" {
+		return errors.New("handlers must have either a PathPattern or a PathTemplate, not both")
+	}
+
+	if wh.PathTemplate != "" {
+
+		pt, err := httpendpoint.ParsePathTemplate(wh.PathTemplate)
+
+		if err != nil {
+			return err
+		}
+
+		wh.pathTemplate = pt
+
+		if len(wh.BindPathParams) == 0 {
+			wh.BindPathParams = pt.ParamNames()
+		}
+	}
+
+	if wh.TimeoutStatus == 0 {
+		wh.TimeoutStatus = http.StatusGatewayTimeout
+	} else if wh.TimeoutStatus != http.StatusServiceUnavailable && wh.TimeoutStatus != http.StatusGatewayTimeout {
+		return fmt.Errorf("TimeoutStatus must be %d or %d", http.StatusServiceUnavailable, http.StatusGatewayTimeout)
 	}
 
 	if wh.AutoValidator != nil && wh.ErrorFinder == nil {
@@ -673,7 +932,7 @@ func (wh *WsHandler) StartComponent() error {
 
 		wh.bindPathParams = len(wh.BindPathParams) > 0
 
-		r, err := regexp.Compile(wh.PathPattern)
+		r, err := regexp.Compile(wh.RegexPattern())
 
 		if err != nil {
 			return err
@@ -775,6 +1034,22 @@ func (wh *WsHandler) extractFactoryFromLogic() func() interface{} {
 
 }
 
+// RequestTarget returns a new, empty instance of the object into which request data (body, query and path parameters)
+// will be bound, or nil if the handler's Logic does not use a target object. Intended for tools that describe the handler
+// (e.g. documentation generators) rather than for use during request processing.
+func (wh *WsHandler) RequestTarget() interface{} {
+
+	if targetSource, found := wh.Logic.(WsUnmarshallTarget); found {
+		return targetSource.UnmarshallTarget()
+	}
+
+	if f := wh.extractFactoryFromLogic(); f != nil {
+		return f()
+	}
+
+	return nil
+}
+
 // ComponentName implements ComponentNamer.ComponentName
 func (wh *WsHandler) ComponentName() string {
 	return wh.componentName
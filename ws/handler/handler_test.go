@@ -8,12 +8,17 @@ import (
 	"bytes"
 	"context"
 	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/iam"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/test"
 	"github.com/graniticio/granitic/v2/ws"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestMinimal(t *testing.T) {
@@ -286,3 +291,309 @@ type mockLogicInvalid struct {
 func (ml *mockLogicInvalid) ProcessPayload(ctx context.Context, request *ws.Request, response *ws.Response, target mockTarget) {
 
 }
+
+func TestPathTemplateBinding(t *testing.T) {
+
+	l := new(templateLogic)
+
+	h, _ := GetHandler(t)
+	h.PathPattern = ""
+	h.PathTemplate = "/artist/{ArtistID:int}/album/{Title}"
+	h.Logic = l
+	h.ParamBinder = new(ws.ParamBinder)
+
+	test.ExpectNil(t, h.StartComponent())
+
+	test.ExpectString(t, h.TemplatePattern(), "/artist/{ArtistID:int}/album/{Title}")
+
+	req, _ := http.NewRequest("GET", "/artist/42/album/blue", nil)
+
+	w := httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter())
+
+	h.ServeHTTP(context.Background(), w, req)
+
+	test.ExpectInt(t, l.ArtistID, 42)
+	test.ExpectString(t, l.Title, "blue")
+
+	h = new(WsHandler)
+	h.PathPattern = "^/artist$"
+	h.PathTemplate = "/artist"
+	h.HTTPMethod = "GET"
+	h.Logic = l
+
+	test.ExpectNotNil(t, h.StartComponent())
+}
+
+type templateTarget struct {
+	ArtistID int
+	Title    string
+}
+
+type templateLogic struct {
+	ArtistID int
+	Title    string
+}
+
+func (tl *templateLogic) ProcessPayload(ctx context.Context, request *ws.Request, response *ws.Response, target *templateTarget) {
+	tl.ArtistID = target.ArtistID
+	tl.Title = target.Title
+}
+
+func TestRateLimitedRequest(t *testing.T) {
+
+	l := new(ProcessOnlyLogic)
+
+	h, req := GetHandler(t)
+	h.Logic = l
+
+	rw := new(recordingResponseWriter)
+	h.ResponseWriter = rw
+	h.RateLimiter = &mockRateLimiter{retryAfter: 1500 * time.Millisecond}
+
+	test.ExpectNil(t, h.StartComponent())
+
+	w := httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter())
+
+	h.ServeHTTP(context.Background(), w, req)
+
+	test.ExpectBool(t, l.Called, false)
+	test.ExpectInt(t, rw.status, http.StatusTooManyRequests)
+	test.ExpectString(t, w.Header().Get("Retry-After"), "2")
+}
+
+type mockRateLimiter struct {
+	retryAfter time.Duration
+}
+
+func (rl *mockRateLimiter) Allow(ctx context.Context, handlerName string, req *http.Request, identity iam.ClientIdentity) (bool, time.Duration) {
+	return false, rl.retryAfter
+}
+
+type recordingResponseWriter struct {
+	status int
+}
+
+func (rw *recordingResponseWriter) Write(ctx context.Context, state *ws.ProcessState, outcome ws.Outcome) error {
+	rw.status = state.Status
+	return nil
+}
+
+func TestContentNegotiation(t *testing.T) {
+
+	l := new(ProcessOnlyLogic)
+
+	h, req := GetHandler(t)
+	h.Logic = l
+
+	rw := &negotiatingWriter{supported: "application/xml"}
+	h.ResponseWriter = rw
+
+	test.ExpectNil(t, h.StartComponent())
+
+	req.Header.Set("Accept", "application/json")
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter()), req)
+
+	test.ExpectBool(t, l.Called, false)
+	test.ExpectInt(t, rw.status, http.StatusNotAcceptable)
+
+	req.Header.Set("Accept", "application/xml")
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter()), req)
+
+	test.ExpectBool(t, l.Called, true)
+	test.ExpectString(t, rw.mediaType, "application/xml")
+}
+
+func TestUnsupportedRequestMediaType(t *testing.T) {
+
+	l := new(AllPhasesLogic)
+
+	h, _ := GetHandler(t)
+	h.Logic = l
+
+	rw := new(recordingResponseWriter)
+	h.ResponseWriter = rw
+	h.Unmarshaller = new(unsupportedUnmarshaller)
+	h.Log = new(logging.ConsoleErrorLogger)
+
+	test.ExpectNil(t, h.StartComponent())
+
+	req, _ := http.NewRequest("GET", "/test", bytes.NewBufferString("a: b"))
+	req.Header.Set("Content-Type", "application/yaml")
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter()), req)
+
+	test.ExpectBool(t, l.ProcessCalled, false)
+	test.ExpectInt(t, rw.status, http.StatusUnsupportedMediaType)
+}
+
+type negotiatingWriter struct {
+	supported string
+	status    int
+	mediaType string
+}
+
+func (nw *negotiatingWriter) SelectMediaType(accept string) (string, bool) {
+	return ws.SelectMediaType(accept, []string{nw.supported})
+}
+
+func (nw *negotiatingWriter) Write(ctx context.Context, state *ws.ProcessState, outcome ws.Outcome) error {
+	nw.status = state.Status
+
+	if state.WsRequest != nil {
+		nw.mediaType = state.WsRequest.ResponseMediaType
+	}
+
+	return nil
+}
+
+type unsupportedUnmarshaller struct{}
+
+func (uu *unsupportedUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *ws.Request) error {
+	return &ws.UnsupportedMediaTypeError{MediaType: req.Header.Get("Content-Type")}
+}
+
+func TestRequestBodyTooLarge(t *testing.T) {
+
+	l := new(AllPhasesLogic)
+
+	h, _ := GetHandler(t)
+	h.Logic = l
+
+	rw := new(recordingResponseWriter)
+	h.ResponseWriter = rw
+	h.Unmarshaller = new(readingUnmarshaller)
+	h.Log = new(logging.ConsoleErrorLogger)
+	h.MaxBodyBytes = 8
+
+	feg := new(ws.FrameworkErrorGenerator)
+	feg.FrameworkLogger = h.Log
+	feg.Messages = map[ws.FrameworkErrorEvent][]string{
+		ws.RequestBodyTooLarge: {"PARSE", "Too large %d"},
+	}
+	h.FrameworkErrors = feg
+
+	test.ExpectNil(t, h.StartComponent())
+
+	// Declared length is too large
+	req, _ := http.NewRequest("POST", "/test", bytes.NewBufferString("0123456789"))
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter()), req)
+
+	test.ExpectBool(t, l.ProcessCalled, false)
+	test.ExpectInt(t, rw.status, http.StatusRequestEntityTooLarge)
+
+	// Length not declared
+	req, _ = http.NewRequest("POST", "/test", bytes.NewBufferString("0123456789"))
+	req.ContentLength = -1
+
+	rw.status = 0
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter()), req)
+
+	test.ExpectBool(t, l.ProcessCalled, false)
+	test.ExpectInt(t, rw.status, http.StatusRequestEntityTooLarge)
+
+	// Within limit
+	req, _ = http.NewRequest("POST", "/test", bytes.NewBufferString("01234567"))
+	req.ContentLength = -1
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter()), req)
+
+	test.ExpectBool(t, l.ProcessCalled, true)
+}
+
+func TestServerBodyLimitReachedFirst(t *testing.T) {
+
+	for _, handlerLimit := range []int64{0, 64} {
+
+		l := new(AllPhasesLogic)
+
+		h, _ := GetHandler(t)
+		h.Logic = l
+
+		rw := new(recordingResponseWriter)
+		h.ResponseWriter = rw
+		h.Unmarshaller = new(readingUnmarshaller)
+		h.Log = new(logging.ConsoleErrorLogger)
+		h.MaxBodyBytes = handlerLimit
+
+		feg := new(ws.FrameworkErrorGenerator)
+		feg.FrameworkLogger = h.Log
+		feg.Messages = map[ws.FrameworkErrorEvent][]string{
+			ws.RequestBodyTooLarge:  {"PARSE", "Too large %d"},
+			ws.UnableToParseRequest: {"PARSE", "Unparseable"},
+		}
+		h.FrameworkErrors = feg
+
+		test.ExpectNil(t, h.StartComponent())
+
+		// Chunked request body, limited by the server as the HTTPServer facility does
+		res := NewStringBufferResponseWriter()
+		req, _ := http.NewRequest("POST", "/test", bytes.NewBufferString("0123456789"))
+		req.ContentLength = -1
+		req.Body = http.MaxBytesReader(res, req.Body, 8)
+
+		h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(res), req)
+
+		test.ExpectBool(t, l.ProcessCalled, false)
+		test.ExpectInt(t, rw.status, http.StatusRequestEntityTooLarge)
+	}
+}
+
+type readingUnmarshaller struct{}
+
+func (ru *readingUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *ws.Request) error {
+	_, err := ioutil.ReadAll(req.Body)
+	return err
+}
+
+func TestRequestDeadline(t *testing.T) {
+
+	l := new(slowLogic)
+	l.delay = 50 * time.Millisecond
+
+	h, req := GetHandler(t)
+	h.Logic = l
+	h.Log = new(logging.ConsoleErrorLogger)
+	h.TimeoutMS = 10
+
+	rw := new(recordingResponseWriter)
+	h.ResponseWriter = rw
+
+	test.ExpectNil(t, h.StartComponent())
+	test.ExpectInt(t, h.TimeoutStatus, http.StatusGatewayTimeout)
+	test.ExpectBool(t, h.RequestTimeout() == 10*time.Millisecond, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.RequestTimeout())
+	defer cancel()
+
+	w := httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter())
+
+	h.ServeHTTP(ctx, w, req)
+
+	test.ExpectBool(t, l.deadlineSeen, true)
+	test.ExpectInt(t, rw.status, http.StatusGatewayTimeout)
+	test.ExpectBool(t, w.TimedOut, true)
+
+	h.TimeoutStatus = http.StatusOK
+	h.state = ioc.StoppedState
+	test.ExpectNotNil(t, h.StartComponent())
+
+	h.TimeoutMS = -1
+	test.ExpectBool(t, h.RequestTimeout() < 0, true)
+}
+
+type slowLogic struct {
+	delay        time.Duration
+	deadlineSeen bool
+}
+
+func (l *slowLogic) Process(ctx context.Context, request *ws.Request, response *ws.Response) {
+
+	_, l.deadlineSeen = ctx.Deadline()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(l.delay):
+	}
+}
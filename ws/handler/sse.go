@@ -0,0 +1,462 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/instrument"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHeartbeatMS = 15000
+	defaultEventBuffer = 16
+	lastEventIDHeader  = "Last-Event-ID"
+)
+
+// SSEEvent is a single server-sent event (see https://html.spec.whatwg.org/multipage/server-sent-events.html)
+type SSEEvent struct {
+	// An identifier for the event. Browsers send the ID of the last event they received in the Last-Event-ID header when they reconnect.
+	ID string
+
+	// The type of the event. If empty, browsers treat the event as type 'message'.
+	Event string
+
+	// The event's payload. Strings and byte slices are sent as-is, anything else is serialised to JSON.
+	Data interface{}
+
+	// If greater than zero, instructs the browser to wait this long before reconnecting if the connection is lost.
+	Retry time.Duration
+}
+
+// SSEEventSource is implemented by the Logic component of an SSEHandler.
+type SSEEventSource interface {
+	// StreamEvents sends events to the caller by writing them to the supplied channel. Implementations should return when
+	// there are no more events to send or when ctx is done (because the caller has disconnected or the server is stopping),
+	// so every send on the channel should be in a select that also checks ctx.Done(). The channel must not be closed by the implementation.
+	//
+	// lastEventID is the value of the Last-Event-ID header sent by a reconnecting browser (empty on the first connection)
+	// and should be used to resume the stream after the event with that ID.
+	StreamEvents(ctx context.Context, req *ws.Request, lastEventID string, events chan<- *SSEEvent) error
+}
+
+/*
+SSEHandler is an httpendpoint.Provider that streams server-sent events (text/event-stream) to the caller for as long as
+the caller remains connected. It is declared in your component definition file like:
+
+	{
+	  "jobProgressHandler": {
+		"type": "handler.SSEHandler",
+		"Logic": "ref:jobProgressLogic",
+		"PathTemplate": "/job/{id:int}/progress",
+		"RequireAuthentication": true,
+		"UserIdentifier": "ref:userIdentifier"
+	  }
+	}
+
+The caller is identified and authenticated with UserIdentifier and checked with AccessChecker in the same way as a WsHandler
+and any error responses (401, 403 etc) are written by the ResponseWriter injected by the JSONWs, XMLWs or NegotiatedWs facility.
+
+Once the caller has been allowed access, the Logic's StreamEvents method is called in a separate goroutine and each event it
+sends to its channel is written and flushed to the caller. A comment line is written every HeartbeatMS milliseconds to stop
+proxies closing idle connections.
+
+Streams count as active requests on the HTTP server, so are subject to MaxConcurrent and are refused while the server is
+suspended. When the server prepares to stop, all open streams are ended (browsers will automatically reconnect, which
+allows another instance of your application to take over the stream).
+*/
+type SSEHandler struct {
+	// A component able to examine a request and see if the caller is allowed to access this endpoint.
+	AccessChecker ws.AccessChecker
+
+	// The size of the buffer of the channel passed to the Logic. Defaults to 16
+	EventBuffer int
+
+	// How often (in milliseconds) a heartbeat comment is sent to the caller. Defaults to 15000. A negative value disables heartbeats.
+	HeartbeatMS int64
+
+	// The HTTP method this handler supports. Defaults to GET
+	HTTPMethod string
+
+	// A logger injected by the Granitic framework.
+	Log logging.Logger
+
+	// The component that generates events.
+	Logic SSEEventSource
+
+	// A regex that will be matched against inbound request paths. Mutually exclusive with PathTemplate.
+	PathPattern string
+
+	// A template (e.g. /job/{id:int}/progress) that will be matched against inbound request paths. Mutually exclusive with PathPattern.
+	PathTemplate string
+
+	// Stop the framework automatically adding this handler to an HTTP server.
+	PreventAutoWiring bool
+
+	// Whether on not the caller needs to be authenticated (using a ws.Identifier) in order to access this endpoint.
+	RequireAuthentication bool
+
+	// A component injected by the Granitic framework that writes error responses (for example if the caller is not authenticated).
+	ResponseWriter ws.ResponseWriter
+
+	// If greater than zero, sent to the caller when the stream is opened to set how long (in milliseconds) browsers wait before reconnecting.
+	RetryMS int64
+
+	// A component that can examine a request to determine the calling user/service's identity.
+	UserIdentifier ws.Identifier
+
+	componentName string
+	heartbeat     time.Duration
+	pathRegex     *regexp.Regexp
+	pathTemplate  *httpendpoint.PathTemplate
+	state         ioc.ComponentState
+	closing       chan struct{}
+	closeOnce     sync.Once
+}
+
+// ServeHTTP identifies and authenticates the caller then streams events from the Logic component until the caller disconnects,
+// the Logic returns or the handler is asked to close its connections. Implements httpendpoint.Provider
+func (sh *SSEHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+
+	if ri := instrument.InstrumentorFromContext(ctx); ri != nil {
+		ri.Amend(instrument.Handler, sh)
+	}
+
+	wsReq := new(ws.Request)
+	wsReq.HTTPMethod = req.Method
+	wsReq.ServingHandler = sh.ComponentName()
+	wsReq.QueryParams = ws.NewParamsForQuery(req.URL.Query())
+
+	if params := sh.pathRegex.FindStringSubmatch(req.URL.Path); len(params) > 1 {
+		wsReq.PathParams = params[1:]
+	}
+
+	var okay bool
+
+	if okay, ctx = identifyAndAuthenticate(ctx, sh.UserIdentifier, sh.RequireAuthentication, sh.ResponseWriter, w, req, wsReq); !okay {
+		return ctx
+	}
+
+	if !checkAccess(ctx, sh.AccessChecker, sh.ResponseWriter, w, wsReq) {
+		return ctx
+	}
+
+	select {
+	case <-sh.closing:
+		state := ws.NewAbnormalState(http.StatusServiceUnavailable, w)
+		state.WsRequest = wsReq
+
+		sh.ResponseWriter.Write(ctx, state, ws.Abnormal)
+
+		return ctx
+	default:
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+
+	w.WriteHeader(http.StatusOK)
+
+	if sh.RetryMS > 0 {
+		fmt.Fprintf(w, "retry: %d\n\n", sh.RetryMS)
+	}
+
+	w.Flush()
+
+	sh.stream(ctx, wsReq, req.Header.Get(lastEventIDHeader), w)
+
+	return ctx
+}
+
+func (sh *SSEHandler) stream(ctx context.Context, wsReq *ws.Request, lastEventID string, w *httpendpoint.HTTPResponseWriter) {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan *SSEEvent, sh.EventBuffer)
+	finished := make(chan error, 1)
+
+	go func() {
+
+		defer func() {
+			if r := recover(); r != nil {
+				sh.Log.LogErrorfCtxWithTrace(ctx, "Panic recovered while streaming events %s", r)
+				finished <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+
+		finished <- sh.Logic.StreamEvents(ctx, wsReq, lastEventID, events)
+	}()
+
+	var heartbeat <-chan time.Time
+
+	if sh.heartbeat > 0 {
+		t := time.NewTicker(sh.heartbeat)
+		defer t.Stop()
+
+		heartbeat = t.C
+	}
+
+	for {
+		select {
+		case e := <-events:
+
+			if err := sh.writeEvent(w, e); err != nil {
+				sh.Log.LogWarnfCtx(ctx, "Unable to write event to caller: %s", err.Error())
+				sh.abandon(cancel, events, finished)
+				return
+			}
+
+		case <-heartbeat:
+
+			if _, err := w.Write([]byte(": heartbeat\n\n")); err != nil {
+				sh.abandon(cancel, events, finished)
+				return
+			}
+
+			w.Flush()
+
+		case err := <-finished:
+
+			// Write any events still in the buffer
+			for len(events) > 0 {
+				if sh.writeEvent(w, <-events) != nil {
+					return
+				}
+			}
+
+			if err != nil && ctx.Err() == nil {
+				sh.Log.LogErrorfCtx(ctx, "Problem streaming events: %s", err.Error())
+			}
+
+			return
+
+		case <-ctx.Done():
+			sh.abandon(cancel, events, finished)
+			return
+
+		case <-sh.closing:
+			sh.abandon(cancel, events, finished)
+			return
+		}
+	}
+}
+
+// abandon cancels the Logic's context and waits for it to return, discarding any events it sends in the meantime.
+func (sh *SSEHandler) abandon(cancel context.CancelFunc, events <-chan *SSEEvent, finished <-chan error) {
+
+	cancel()
+
+	for {
+		select {
+		case <-events:
+		case <-finished:
+			return
+		}
+	}
+}
+
+func (sh *SSEHandler) writeEvent(w *httpendpoint.HTTPResponseWriter, e *SSEEvent) error {
+
+	if e == nil {
+		return nil
+	}
+
+	b, err := e.encode()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+
+	w.Flush()
+
+	return nil
+}
+
+// encode converts the event into the text/event-stream wire format.
+func (e *SSEEvent) encode() ([]byte, error) {
+
+	var b bytes.Buffer
+
+	if e.ID != "" {
+		b.WriteString("id: " + singleLine(e.ID) + "\n")
+	}
+
+	if e.Event != "" {
+		b.WriteString("event: " + singleLine(e.Event) + "\n")
+	}
+
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(int64(e.Retry/time.Millisecond), 10) + "\n")
+	}
+
+	var data string
+
+	switch d := e.Data.(type) {
+	case nil:
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	default:
+		j, err := json.Marshal(d)
+
+		if err != nil {
+			return nil, err
+		}
+
+		data = string(j)
+	}
+
+	data = strings.Replace(data, "\r\n", "\n", -1)
+
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+
+	b.WriteString("\n")
+
+	return b.Bytes(), nil
+}
+
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// CloseConnections ends all open streams and causes subsequent requests to receive a 503 response. Called by the HTTP
+// server when it is preparing to stop. Implements httpendpoint.LongLivedProvider
+func (sh *SSEHandler) CloseConnections() {
+	sh.closeOnce.Do(func() {
+		close(sh.closing)
+	})
+}
+
+// SupportedHTTPMethods returns the HTTP method that this handler supports
+// (GET if HTTPMethod has not been set). Implements httpendpoint.Provider
+func (sh *SSEHandler) SupportedHTTPMethods() []string {
+
+	if sh.HTTPMethod == "" {
+		return []string{http.MethodGet}
+	}
+
+	return []string{sh.HTTPMethod}
+}
+
+// RegexPattern returns the regex pattern (or the regex equivalent of the PathTemplate) used to match requests to
+// this handler. Implements httpendpoint.Provider
+func (sh *SSEHandler) RegexPattern() string {
+
+	if sh.pathTemplate != nil {
+		return sh.pathTemplate.RegexPattern()
+	}
+
+	return sh.PathPattern
+}
+
+// TemplatePattern returns the handler's PathTemplate. Implements httpendpoint.TemplatedProvider
+func (sh *SSEHandler) TemplatePattern() string {
+	return sh.PathTemplate
+}
+
+// VersionAware returns false - SSEHandlers do not support request versioning. Implements httpendpoint.Provider
+func (sh *SSEHandler) VersionAware() bool {
+	return false
+}
+
+// SupportsVersion always returns true. Implements httpendpoint.Provider
+func (sh *SSEHandler) SupportsVersion(version httpendpoint.RequiredVersion) bool {
+	return true
+}
+
+// AutoWireable returns true if this handler should be automatically registered with any instances of httpserver.HTTPServer
+// that are running in the application.
+func (sh *SSEHandler) AutoWireable() bool {
+	return !sh.PreventAutoWiring
+}
+
+// StartComponent is called by the IoC container. Verifies that the handler's configuration is valid and applies defaults.
+func (sh *SSEHandler) StartComponent() error {
+
+	if sh.state != ioc.StoppedState {
+		return nil
+	}
+
+	sh.state = ioc.StartingState
+
+	if (sh.PathPattern == "" && sh.PathTemplate == "") || sh.Logic == nil {
+		return errors.New("SSE handlers must have at least a PathPattern or PathTemplate string and Logic component set")
+	}
+
+	if sh.PathPattern != "" && sh.PathTemplate != "" {
+		return errors.New("SSE handlers must have either a PathPattern or a PathTemplate, not both")
+	}
+
+	if sh.ResponseWriter == nil {
+		return errors.New("SSE handlers must have a ResponseWriter set. Check that the JSONWs, XMLWs or NegotiatedWs facility is enabled")
+	}
+
+	if sh.PathTemplate != "" {
+
+		pt, err := httpendpoint.ParsePathTemplate(sh.PathTemplate)
+
+		if err != nil {
+			return err
+		}
+
+		sh.pathTemplate = pt
+	}
+
+	r, err := regexp.Compile(sh.RegexPattern())
+
+	if err != nil {
+		return err
+	}
+
+	sh.pathRegex = r
+
+	if sh.EventBuffer <= 0 {
+		sh.EventBuffer = defaultEventBuffer
+	}
+
+	if sh.HeartbeatMS == 0 {
+		sh.HeartbeatMS = defaultHeartbeatMS
+	}
+
+	sh.heartbeat = time.Duration(sh.HeartbeatMS) * time.Millisecond
+
+	sh.closing = make(chan struct{})
+
+	sh.state = ioc.RunningState
+
+	return nil
+}
+
+// ComponentName implements ComponentNamer.ComponentName
+func (sh *SSEHandler) ComponentName() string {
+	return sh.componentName
+}
+
+// SetComponentName implements ComponentNamer.SetComponentName
+func (sh *SSEHandler) SetComponentName(name string) {
+	sh.componentName = name
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/iam"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSSEHandler(t *testing.T, logic SSEEventSource) *SSEHandler {
+
+	sh := new(SSEHandler)
+	sh.Logic = logic
+	sh.PathTemplate = "/job/{id:int}/progress"
+	sh.Log = new(logging.ConsoleErrorLogger)
+	sh.ResponseWriter = new(recordingResponseWriter)
+	sh.HeartbeatMS = -1
+
+	test.ExpectNil(t, sh.StartComponent())
+
+	return sh
+}
+
+func TestSSEStream(t *testing.T) {
+
+	l := &resumingSource{}
+	sh := newSSEHandler(t, l)
+	sh.RetryMS = 3000
+
+	test.ExpectString(t, sh.SupportedHTTPMethods()[0], http.MethodGet)
+
+	req := httptest.NewRequest(http.MethodGet, "/job/12/progress", nil)
+	req.Header.Set("Last-Event-ID", "4")
+
+	rec := httptest.NewRecorder()
+
+	sh.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, rec.Header().Get("Content-Type"), "text/event-stream")
+	test.ExpectString(t, l.lastEventID, "4")
+	test.ExpectString(t, l.pathParams[0], "12")
+	test.ExpectBool(t, rec.Flushed, true)
+
+	expected := "retry: 3000\n\n" +
+		"id: 5\nevent: progress\ndata: {\"Percent\":50}\n\n" +
+		"id: 6\ndata: line one\ndata: line two\n\n"
+
+	test.ExpectString(t, rec.Body.String(), expected)
+}
+
+func TestSSEDefaultMethodBeforeStart(t *testing.T) {
+
+	sh := new(SSEHandler)
+
+	test.ExpectInt(t, len(sh.SupportedHTTPMethods()), 1)
+	test.ExpectString(t, sh.SupportedHTTPMethods()[0], http.MethodGet)
+
+	sh.HTTPMethod = http.MethodPost
+
+	test.ExpectString(t, sh.SupportedHTTPMethods()[0], http.MethodPost)
+}
+
+func TestSSERequiresAuthentication(t *testing.T) {
+
+	l := &resumingSource{}
+	sh := newSSEHandler(t, l)
+	sh.RequireAuthentication = true
+	sh.UserIdentifier = new(anonymousIdentifier)
+
+	req := httptest.NewRequest(http.MethodGet, "/job/12/progress", nil)
+
+	sh.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(httptest.NewRecorder()), req)
+
+	test.ExpectBool(t, l.called, false)
+	test.ExpectInt(t, sh.ResponseWriter.(*recordingResponseWriter).status, http.StatusUnauthorized)
+}
+
+func TestSSECloseConnections(t *testing.T) {
+
+	l := &blockingSource{started: make(chan bool)}
+	sh := newSSEHandler(t, l)
+	sh.heartbeat = 5 * time.Millisecond
+
+	req := httptest.NewRequest(http.MethodGet, "/job/12/progress", nil)
+	rec := httptest.NewRecorder()
+
+	done := make(chan bool)
+
+	go func() {
+		sh.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+		done <- true
+	}()
+
+	<-l.started
+	time.Sleep(20 * time.Millisecond)
+
+	sh.CloseConnections()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stream was not closed")
+	}
+
+	test.ExpectBool(t, l.cancelled, true)
+	test.ExpectBool(t, strings.Contains(rec.Body.String(), ": heartbeat\n\n"), true)
+
+	// New connections are refused once connections have been closed
+	sh.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(httptest.NewRecorder()), req)
+	test.ExpectInt(t, sh.ResponseWriter.(*recordingResponseWriter).status, http.StatusServiceUnavailable)
+}
+
+type resumingSource struct {
+	called      bool
+	lastEventID string
+	pathParams  []string
+}
+
+func (rs *resumingSource) StreamEvents(ctx context.Context, req *ws.Request, lastEventID string, events chan<- *SSEEvent) error {
+
+	rs.called = true
+	rs.lastEventID = lastEventID
+	rs.pathParams = req.PathParams
+
+	events <- &SSEEvent{ID: "5", Event: "progress", Data: struct{ Percent int }{50}}
+	events <- &SSEEvent{ID: "6", Data: "line one\r\nline two"}
+
+	return nil
+}
+
+type blockingSource struct {
+	started   chan bool
+	cancelled bool
+}
+
+func (bs *blockingSource) StreamEvents(ctx context.Context, req *ws.Request, lastEventID string, events chan<- *SSEEvent) error {
+
+	bs.started <- true
+
+	<-ctx.Done()
+	bs.cancelled = true
+
+	return ctx.Err()
+}
+
+type anonymousIdentifier struct{}
+
+func (ai *anonymousIdentifier) IDentify(ctx context.Context, req *http.Request) (iam.ClientIdentity, context.Context) {
+	return iam.NewAnonymousIdentity(), ctx
+}
@@ -5,8 +5,10 @@ package ws
 
 import (
 	"context"
+	"crypto/x509"
 	"github.com/graniticio/granitic/v2/iam"
 	"net/http"
+	"time"
 )
 
 // Identifier is implemented by components that are able to identify a caller based on a raw HTTP request (normally from
@@ -21,3 +23,44 @@ type AccessChecker interface {
 	// Allowed returns true if the caller is allowed to have this request processed, false otherwise.
 	Allowed(ctx context.Context, r *Request) bool
 }
+
+// RateLimiter is implemented by components able to decide whether a caller has exceeded the rate at which they are
+// permitted to make requests to a handler.
+type RateLimiter interface {
+	// Allow returns true if the request to the named handler should be processed. If false, retryAfter is how long
+	// the caller should wait before making another request.
+	Allow(ctx context.Context, handlerName string, req *http.Request, identity iam.ClientIdentity) (allowed bool, retryAfter time.Duration)
+}
+
+// VerifiedClientCertificate returns the certificate presented by the caller if the request was made over a TLS connection
+// and the certificate was verified against the HTTP server's client CA bundle. Returns nil if the request was not made
+// over TLS, no certificate was presented or the certificate was not verified.
+func VerifiedClientCertificate(req *http.Request) *x509.Certificate {
+
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return req.TLS.VerifiedChains[0][0]
+}
+
+// ClientCertificateIdentifier is an Identifier that identifies callers by the verified client certificate they presented
+// when connecting to an HTTP server with mutual TLS enabled. Callers that presented a verified certificate are given an
+// authenticated iam.ClientIdentity with the certificate's subject common name as their loggable user ID and the certificate
+// itself available via ClientIdentity.ClientCertificate. All other callers are anonymous.
+type ClientCertificateIdentifier struct{}
+
+// IDentify implements Identifier.IDentify
+func (cci *ClientCertificateIdentifier) IDentify(ctx context.Context, req *http.Request) (iam.ClientIdentity, context.Context) {
+
+	cert := VerifiedClientCertificate(req)
+
+	if cert == nil {
+		return iam.NewAnonymousIdentity(), ctx
+	}
+
+	i := iam.NewAuthenticatedIdentity(cert.Subject.CommonName)
+	i.SetClientCertificate(cert)
+
+	return i, ctx
+}
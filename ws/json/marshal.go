@@ -38,10 +38,17 @@ The method must be explicitly called in your handler's logic like:
 
 This feature should be considered experimental.
 
+Streamed responses
+
+If a handler's Logic sets the response body to a *ws.StreamedBody, MarshalingWriter writes each item generated by the body's
+producer as soon as it is available, either as an element of a JSON array or as a line of NDJSON. See the ws package
+documentation of StreamedBody for how errors encountered mid-stream are handled.
+
 */
 package json
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/graniticio/granitic/v2/ws"
 	"net/http"
@@ -175,3 +182,94 @@ func (ef *GraniticJSONErrorFormatter) FormatErrors(errors *ws.ServiceErrors) int
 
 	return f
 }
+
+// MarshalAndStream implements ws.StreamingMarshalingWriter. Items are written either as the elements of a JSON array or,
+// if the body's format is ws.NDJSONStream, as one compact JSON document per line.
+func (mw *MarshalingWriter) MarshalAndStream(ctx context.Context, body *ws.StreamedBody, w http.ResponseWriter, formattedErrors func(error) interface{}) error {
+
+	ndjson := body.Format == ws.NDJSONStream
+
+	flusher, _ := w.(http.Flusher)
+
+	flushEvery := body.FlushEvery
+
+	if flushEvery <= 0 {
+		flushEvery = 1
+	}
+
+	count := 0
+
+	if !ndjson {
+		if _, err := w.Write([]byte("[")); err != nil {
+			return err
+		}
+	}
+
+	emit := func(item interface{}) error {
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		b, err := mw.marshalItem(item, ndjson)
+
+		if err != nil {
+			return err
+		}
+
+		if ndjson {
+			b = append(b, '\n')
+		} else if count > 0 {
+			b = append([]byte(","), b...)
+		}
+
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+
+		count++
+
+		if flusher != nil && count%flushEvery == 0 {
+			flusher.Flush()
+		}
+
+		return nil
+	}
+
+	if err := body.Producer(ctx, emit); err != nil {
+
+		if ndjson && ctx.Err() == nil && formattedErrors != nil {
+			// Give the caller a way of telling that the stream was incomplete
+			if b, merr := json.Marshal(formattedErrors(err)); merr == nil {
+				w.Write(append(b, '\n'))
+			}
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		return err
+	}
+
+	if !ndjson {
+		if _, err := w.Write([]byte("]")); err != nil {
+			return err
+		}
+	}
+
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+func (mw *MarshalingWriter) marshalItem(item interface{}, compact bool) ([]byte, error) {
+
+	if mw.PrettyPrint && !compact {
+		return json.MarshalIndent(item, mw.PrefixString, mw.IndentString)
+	}
+
+	return json.Marshal(item)
+}
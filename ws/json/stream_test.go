@@ -0,0 +1,152 @@
+package json
+
+import (
+	"context"
+	"errors"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http/httptest"
+	"testing"
+)
+
+type streamItem struct {
+	ID int
+}
+
+func producerOf(count int, failAt int) ws.StreamProducer {
+
+	return func(ctx context.Context, emit func(interface{}) error) error {
+
+		for i := 1; i <= count; i++ {
+
+			if i == failAt {
+				return errors.New("producer failed")
+			}
+
+			if err := emit(&streamItem{ID: i}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func errorLine(err error) interface{} {
+	return map[string]string{"Error": "stream failed"}
+}
+
+func TestStreamJSONArray(t *testing.T) {
+
+	mw := new(MarshalingWriter)
+	w := httptest.NewRecorder()
+
+	err := mw.MarshalAndStream(context.Background(), ws.NewStreamedBody(producerOf(3, 0)), w, errorLine)
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, w.Body.String(), `[{"ID":1},{"ID":2},{"ID":3}]`)
+	test.ExpectBool(t, w.Flushed, true)
+
+	w = httptest.NewRecorder()
+
+	err = mw.MarshalAndStream(context.Background(), ws.NewStreamedBody(producerOf(0, 0)), w, errorLine)
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, w.Body.String(), `[]`)
+}
+
+func TestStreamNDJSON(t *testing.T) {
+
+	mw := new(MarshalingWriter)
+	mw.PrettyPrint = true
+	mw.IndentString = "  "
+
+	w := httptest.NewRecorder()
+
+	sb := ws.NewStreamedBody(producerOf(2, 0))
+	sb.Format = ws.NDJSONStream
+
+	err := mw.MarshalAndStream(context.Background(), sb, w, errorLine)
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, w.Body.String(), "{\"ID\":1}\n{\"ID\":2}\n")
+}
+
+func TestStreamProducerError(t *testing.T) {
+
+	mw := new(MarshalingWriter)
+
+	w := httptest.NewRecorder()
+
+	err := mw.MarshalAndStream(context.Background(), ws.NewStreamedBody(producerOf(3, 3)), w, errorLine)
+
+	test.ExpectNotNil(t, err)
+	test.ExpectString(t, w.Body.String(), `[{"ID":1},{"ID":2}`)
+
+	w = httptest.NewRecorder()
+
+	sb := ws.NewStreamedBody(producerOf(3, 2))
+	sb.Format = ws.NDJSONStream
+
+	err = mw.MarshalAndStream(context.Background(), sb, w, errorLine)
+
+	test.ExpectNotNil(t, err)
+	test.ExpectString(t, w.Body.String(), "{\"ID\":1}\n{\"Error\":\"stream failed\"}\n")
+}
+
+func TestStreamCancelled(t *testing.T) {
+
+	mw := new(MarshalingWriter)
+
+	w := httptest.NewRecorder()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	items := make(chan interface{})
+	done := make(chan struct{})
+
+	go func() {
+		for i := 1; ; i++ {
+			select {
+			case items <- &streamItem{ID: i}:
+				if i == 2 {
+					cancel()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := mw.MarshalAndStream(ctx, ws.NewChannelStreamedBody(items, nil, done), w, errorLine)
+
+	test.ExpectBool(t, err == context.Canceled, true)
+
+	_, open := <-done
+	test.ExpectBool(t, open, false)
+	test.ExpectString(t, w.Body.String()[:len(`[{"ID":1},{"ID":2}`)], `[{"ID":1},{"ID":2}`)
+}
+
+func TestStreamErrorsChannelClosedEarly(t *testing.T) {
+
+	mw := new(MarshalingWriter)
+	w := httptest.NewRecorder()
+
+	items := make(chan interface{})
+	errs := make(chan error)
+
+	close(errs)
+
+	go func() {
+		for i := 1; i <= 2; i++ {
+			items <- &streamItem{ID: i}
+		}
+
+		close(items)
+	}()
+
+	err := mw.MarshalAndStream(context.Background(), ws.NewChannelStreamedBody(items, errs, nil), w, errorLine)
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, w.Body.String(), `[{"ID":1},{"ID":2}]`)
+}
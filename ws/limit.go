@@ -0,0 +1,74 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrBodyTooLarge is returned when reading from a LimitedBody once more than the permitted number of bytes have been read.
+var ErrBodyTooLarge = errors.New("request body is larger than the maximum permitted size")
+
+// LimitedBody wraps a request body, returning ErrBodyTooLarge once more than the permitted number of bytes have been
+// read. Whether or not the limit was exceeded is recorded, as unmarshallers (and the multipart package) do not always
+// pass the reader's error through intact.
+type LimitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limited   bool
+	exceeded  bool
+}
+
+// NewLimitedBody wraps the supplied body so that no more than max bytes can be read from it. Zero or less means no limit.
+func NewLimitedBody(body io.ReadCloser, max int64) *LimitedBody {
+	return &LimitedBody{ReadCloser: body, remaining: max, limited: max > 0}
+}
+
+// Exceeded returns true if an attempt was made to read more than the permitted number of bytes.
+func (lb *LimitedBody) Exceeded() bool {
+	return lb.exceeded
+}
+
+// Read implements io.Reader.Read
+func (lb *LimitedBody) Read(p []byte) (int, error) {
+
+	if !lb.limited {
+		return lb.ReadCloser.Read(p)
+	}
+
+	if lb.remaining < 0 {
+		lb.exceeded = true
+		return 0, ErrBodyTooLarge
+	}
+
+	if int64(len(p)) > lb.remaining+1 {
+		p = p[:lb.remaining+1]
+	}
+
+	n, err := lb.ReadCloser.Read(p)
+	lb.remaining -= int64(n)
+
+	if lb.remaining < 0 {
+		// Only the byte beyond the limit is discarded
+		lb.exceeded = true
+		return n + int(lb.remaining), ErrBodyTooLarge
+	}
+
+	return n, err
+}
+
+// ServerLimitExceeded returns true if the error was caused by a request body exceeding the limit set on the HTTP server
+// (see http.MaxBytesReader), in which case the limit is also returned.
+func ServerLimitExceeded(err error) (bool, int64) {
+
+	var mbe *http.MaxBytesError
+
+	if errors.As(err, &mbe) {
+		return true, mbe.Limit
+	}
+
+	return false, 0
+}
@@ -0,0 +1,53 @@
+package ws
+
+import (
+	"errors"
+	"github.com/graniticio/granitic/v2/test"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitedBody(t *testing.T) {
+
+	lb := NewLimitedBody(ioutil.NopCloser(strings.NewReader("0123456789")), 8)
+
+	b, err := ioutil.ReadAll(lb)
+
+	test.ExpectBool(t, err == ErrBodyTooLarge, true)
+	test.ExpectInt(t, len(b), 8)
+	test.ExpectBool(t, lb.Exceeded(), true)
+
+	lb = NewLimitedBody(ioutil.NopCloser(strings.NewReader("0123456789")), 10)
+
+	b, err = ioutil.ReadAll(lb)
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(b), 10)
+	test.ExpectBool(t, lb.Exceeded(), false)
+
+	lb = NewLimitedBody(ioutil.NopCloser(strings.NewReader("0123456789")), 0)
+
+	b, _ = ioutil.ReadAll(lb)
+
+	test.ExpectInt(t, len(b), 10)
+	test.ExpectBool(t, lb.Exceeded(), false)
+}
+
+func TestServerLimitExceeded(t *testing.T) {
+
+	body := http.MaxBytesReader(httptest.NewRecorder(), ioutil.NopCloser(strings.NewReader("0123456789")), 8)
+
+	_, err := ioutil.ReadAll(body)
+
+	exceeded, limit := ServerLimitExceeded(err)
+
+	test.ExpectBool(t, exceeded, true)
+	test.ExpectInt(t, int(limit), 8)
+
+	exceeded, _ = ServerLimitExceeded(errors.New("other"))
+
+	test.ExpectBool(t, exceeded, false)
+}
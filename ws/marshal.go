@@ -78,14 +78,18 @@ func (rw *MarshallingResponseWriter) write(ctx context.Context, res *Response, w
 		return nil
 	}
 
+	e := res.Errors
+
+	if sb, streamed := res.Body.(*StreamedBody); streamed && !e.HasErrors() {
+		return rw.stream(ctx, res, sb, w, ch)
+	}
+
 	headers := MergeHeaders(res, ch, rw.DefaultHeaders)
 	WriteHeaders(w, headers)
 
 	s := rw.StatusDeterminer.DetermineCode(res)
 	w.WriteHeader(s)
 
-	e := res.Errors
-
 	if res.Body == nil && !e.HasErrors() {
 		return nil
 	}
@@ -99,6 +103,44 @@ func (rw *MarshallingResponseWriter) write(ctx context.Context, res *Response, w
 	return rw.MarshalingWriter.MarshalAndWrite(wrapper, w)
 }
 
+func (rw *MarshallingResponseWriter) stream(ctx context.Context, res *Response, sb *StreamedBody, w *httpendpoint.HTTPResponseWriter, ch map[string]string) error {
+
+	smw, supported := rw.MarshalingWriter.(StreamingMarshalingWriter)
+
+	if !supported {
+		if err := rw.writeAbnormalStatus(ctx, http.StatusInternalServerError, w, ch); err != nil {
+			return err
+		}
+
+		return ErrStreamingUnsupported
+	}
+
+	headers := MergeHeaders(res, ch, rw.DefaultHeaders)
+
+	if sb.Format == NDJSONStream {
+		headers["Content-Type"] = NDJSONContentType
+	}
+
+	WriteHeaders(w, headers)
+
+	w.WriteHeader(rw.StatusDeterminer.DetermineCode(res))
+
+	formatter := func(err error) interface{} {
+		var se ServiceErrors
+		se.AddError(rw.FrameworkErrors.HTTPError(http.StatusInternalServerError))
+
+		fe := rw.ErrorFormatter.FormatErrors(&se)
+
+		if rw.ResponseWrapper != nil {
+			return rw.ResponseWrapper.WrapResponse(nil, fe)
+		}
+
+		return fe
+	}
+
+	return smw.MarshalAndStream(ctx, sb, w, formatter)
+}
+
 // WriteAbnormalStatus implements AbnormalStatusWriter.WriteAbnormalStatus
 func (rw *MarshallingResponseWriter) WriteAbnormalStatus(ctx context.Context, state *ProcessState) error {
 	return rw.Write(ctx, state, Abnormal)
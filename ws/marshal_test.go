@@ -3,9 +3,12 @@ package ws
 import (
 	"bytes"
 	"context"
+	"errors"
 	"github.com/graniticio/granitic/v2/httpendpoint"
 	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -69,3 +72,62 @@ type mockWriter struct{}
 func (mw *mockWriter) MarshalAndWrite(data interface{}, w http.ResponseWriter) error {
 	return nil
 }
+
+func TestMarshalStreamedBody(t *testing.T) {
+
+	mrw := new(MarshallingResponseWriter)
+	mrw.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	mrw.StatusDeterminer = new(GraniticHTTPStatusCodeDeterminer)
+	mrw.ErrorFormatter = new(mockErrorFormatter)
+	mrw.ResponseWrapper = new(mockResponseWrapper)
+
+	feg := new(FrameworkErrorGenerator)
+	feg.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	mrw.FrameworkErrors = feg
+
+	sw := new(mockStreamingWriter)
+	mrw.MarshalingWriter = sw
+
+	rec := httptest.NewRecorder()
+
+	ps := new(ProcessState)
+	ps.HTTPResponseWriter = httpendpoint.NewHTTPResponseWriter(rec)
+	ps.WsResponse = new(Response)
+	ps.WsResponse.Errors = new(ServiceErrors)
+
+	sb := NewStreamedBody(nil)
+	sb.Format = NDJSONStream
+	ps.WsResponse.Body = sb
+
+	err := mrw.Write(context.Background(), ps, Normal)
+
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, sw.streamed == sb, true)
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, rec.Header().Get("Content-Type"), NDJSONContentType)
+	test.ExpectString(t, sw.formatted.(string), "WRAPPED")
+
+	// Writers without streaming support result in a 500
+	mrw.MarshalingWriter = new(mockWriter)
+
+	rec = httptest.NewRecorder()
+	ps.HTTPResponseWriter = httpendpoint.NewHTTPResponseWriter(rec)
+
+	err = mrw.Write(context.Background(), ps, Normal)
+
+	test.ExpectBool(t, err == ErrStreamingUnsupported, true)
+	test.ExpectInt(t, rec.Code, http.StatusInternalServerError)
+}
+
+type mockStreamingWriter struct {
+	mockWriter
+	streamed  *StreamedBody
+	formatted interface{}
+}
+
+func (mw *mockStreamingWriter) MarshalAndStream(ctx context.Context, body *StreamedBody, w http.ResponseWriter, formattedErrors func(error) interface{}) error {
+	mw.streamed = body
+	mw.formatted = formattedErrors(errors.New("failed"))
+
+	return nil
+}
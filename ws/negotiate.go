@@ -0,0 +1,266 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MediaTypeNegotiator is implemented by ResponseWriters that are able to render responses in more than one media type.
+type MediaTypeNegotiator interface {
+	// SelectMediaType returns the supported media type that best matches the supplied Accept header, or false if none
+	// of the supported media types are acceptable to the caller.
+	SelectMediaType(accept string) (string, bool)
+}
+
+// UnsupportedMediaTypeError is returned by an Unmarshaller when it is unable to parse request bodies of the request's Content-Type.
+type UnsupportedMediaTypeError struct {
+	// The media type of the request.
+	MediaType string
+}
+
+// Error implements error.Error
+func (e *UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("request bodies of type %s are not supported", e.MediaType)
+}
+
+// NegotiatingResponseWriter chooses between a number of ResponseWriters according to the media type selected for the
+// response when the request was received (see Request.ResponseMediaType). Implements ResponseWriter, AbnormalStatusWriter
+// and MediaTypeNegotiator.
+type NegotiatingResponseWriter struct {
+	// ResponseWriters keyed by the media type (e.g. application/json) they render.
+	Writers map[string]ResponseWriter
+
+	// The media type used if the caller does not express a preference or if a response is written before the media
+	// type has been negotiated. Must be a key in Writers.
+	DefaultMediaType string
+
+	supported []string
+}
+
+// SelectMediaType implements MediaTypeNegotiator.SelectMediaType
+func (nw *NegotiatingResponseWriter) SelectMediaType(accept string) (string, bool) {
+	return SelectMediaType(accept, nw.supported)
+}
+
+// Write finds the ResponseWriter for the state's negotiated media type and uses it to write the response. Implements ResponseWriter
+func (nw *NegotiatingResponseWriter) Write(ctx context.Context, state *ProcessState, outcome Outcome) error {
+
+	if state.HTTPResponseWriter != nil {
+		state.HTTPResponseWriter.Header().Add("Vary", "Accept")
+	}
+
+	return nw.writerFor(state).Write(ctx, state, outcome)
+}
+
+// WriteAbnormalStatus finds the ResponseWriter for the state's negotiated media type and uses it to write the response.
+// Implements AbnormalStatusWriter
+func (nw *NegotiatingResponseWriter) WriteAbnormalStatus(ctx context.Context, state *ProcessState) error {
+
+	rw := nw.writerFor(state)
+
+	if asw, found := rw.(AbnormalStatusWriter); found {
+		return asw.WriteAbnormalStatus(ctx, state)
+	}
+
+	return rw.Write(ctx, state, Abnormal)
+}
+
+func (nw *NegotiatingResponseWriter) writerFor(state *ProcessState) ResponseWriter {
+
+	if state.WsRequest != nil {
+		if rw := nw.Writers[state.WsRequest.ResponseMediaType]; rw != nil {
+			return rw
+		}
+	}
+
+	return nw.Writers[nw.DefaultMediaType]
+}
+
+// StartComponent checks that the default media type has a ResponseWriter.
+func (nw *NegotiatingResponseWriter) StartComponent() error {
+
+	if nw.Writers[nw.DefaultMediaType] == nil {
+		return fmt.Errorf("no ResponseWriter is available for the default media type %s", nw.DefaultMediaType)
+	}
+
+	// The default is listed first and the others in alphabetical order, so that ties during negotiation are resolved predictably
+	others := make([]string, 0, len(nw.Writers))
+
+	for mt := range nw.Writers {
+		if mt != nw.DefaultMediaType {
+			others = append(others, mt)
+		}
+	}
+
+	sort.Strings(others)
+
+	nw.supported = append([]string{nw.DefaultMediaType}, others...)
+
+	return nil
+}
+
+// NegotiatingUnmarshaller chooses between a number of Unmarshallers according to the Content-Type of the request.
+// Implements Unmarshaller
+type NegotiatingUnmarshaller struct {
+	// Unmarshallers keyed by the media type (e.g. application/json) they parse.
+	Unmarshallers map[string]Unmarshaller
+
+	// The media type assumed if the request does not have a Content-Type header. Must be a key in Unmarshallers.
+	DefaultMediaType string
+}
+
+// Unmarshall uses the Unmarshaller for the request's Content-Type to parse the request body. If there is no Unmarshaller
+// for the Content-Type, an *UnsupportedMediaTypeError is returned.
+func (nu *NegotiatingUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *Request) error {
+
+	mt := nu.DefaultMediaType
+
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+
+		parsed, _, err := mime.ParseMediaType(ct)
+
+		if err != nil {
+			return &UnsupportedMediaTypeError{MediaType: ct}
+		}
+
+		mt = parsed
+	}
+
+	um := nu.Unmarshallers[mt]
+
+	if um == nil {
+		return &UnsupportedMediaTypeError{MediaType: mt}
+	}
+
+	return um.Unmarshall(ctx, req, wsReq)
+}
+
+// StartComponent checks that the default media type has an Unmarshaller.
+func (nu *NegotiatingUnmarshaller) StartComponent() error {
+
+	if nu.Unmarshallers[nu.DefaultMediaType] == nil {
+		return errors.New("no Unmarshaller is available for the default media type " + nu.DefaultMediaType)
+	}
+
+	return nil
+}
+
+type mediaRange struct {
+	mediaType string
+	subType   string
+	q         float64
+}
+
+// specificity returns how closely the range matches the supplied type (-1 if it doesn't match).
+func (mr *mediaRange) specificity(mediaType, subType string) int {
+
+	switch {
+	case mr.mediaType == "*" && mr.subType == "*":
+		return 0
+	case mr.mediaType == mediaType && mr.subType == "*":
+		return 1
+	case mr.mediaType == mediaType && mr.subType == subType:
+		return 2
+	}
+
+	return -1
+}
+
+// SelectMediaType chooses the media type from supported that is most acceptable according to the supplied Accept header
+// (RFC 7231 section 5.3.2). The first supported type is preferred if the header is empty or if several types are equally
+// acceptable. Returns false if none of the supported types are acceptable.
+func SelectMediaType(accept string, supported []string) (string, bool) {
+
+	if len(supported) == 0 {
+		return "", false
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return supported[0], true
+	}
+
+	ranges := parseAccept(accept)
+
+	best := ""
+	bestQ := 0.0
+
+	for _, s := range supported {
+
+		mediaType, subType := splitMediaType(s)
+
+		q := 0.0
+		specificity := -1
+
+		for _, r := range ranges {
+			if sp := r.specificity(mediaType, subType); sp > specificity {
+				specificity, q = sp, r.q
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = s, q
+		}
+	}
+
+	return best, best != ""
+}
+
+func parseAccept(accept string) []*mediaRange {
+
+	ranges := make([]*mediaRange, 0)
+
+	for _, entry := range strings.Split(accept, ",") {
+
+		parts := strings.Split(entry, ";")
+
+		mediaType, subType := splitMediaType(parts[0])
+
+		if mediaType == "" {
+			continue
+		}
+
+		mr := &mediaRange{mediaType: mediaType, subType: subType, q: 1}
+
+		for _, p := range parts[1:] {
+
+			kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+
+			if len(kv) == 2 && strings.ToLower(kv[0]) == "q" {
+				if q, err := strconv.ParseFloat(kv[1], 64); err == nil {
+					mr.q = q
+				}
+			}
+		}
+
+		ranges = append(ranges, mr)
+	}
+
+	return ranges
+}
+
+func splitMediaType(mt string) (string, string) {
+
+	mt = strings.ToLower(strings.TrimSpace(mt))
+
+	i := strings.Index(mt, "/")
+
+	if i < 0 {
+
+		if mt == "*" {
+			return "*", "*"
+		}
+
+		return mt, ""
+	}
+
+	return mt[:i], mt[i+1:]
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"bytes"
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSelectMediaType(t *testing.T) {
+
+	supported := []string{"application/json", "application/xml", "text/xml"}
+
+	check := func(accept, expected string, acceptable bool) {
+		mt, ok := SelectMediaType(accept, supported)
+		test.ExpectBool(t, ok, acceptable)
+		test.ExpectString(t, mt, expected)
+	}
+
+	check("", "application/json", true)
+	check("*/*", "application/json", true)
+	check("application/xml", "application/xml", true)
+	check("text/*", "text/xml", true)
+	check("application/json;q=0.5, application/xml", "application/xml", true)
+	check("application/*;q=0.2, application/json;q=0", "application/xml", true)
+	check("text/html, application/xhtml+xml", "", false)
+	check("*/*;q=0", "", false)
+}
+
+type recordingWriter struct {
+	name    string
+	written *string
+}
+
+func (rw *recordingWriter) Write(ctx context.Context, state *ProcessState, outcome Outcome) error {
+	*rw.written = rw.name
+	return nil
+}
+
+func TestNegotiatingResponseWriter(t *testing.T) {
+
+	var written string
+
+	nw := new(NegotiatingResponseWriter)
+	nw.DefaultMediaType = "application/json"
+	nw.Writers = map[string]ResponseWriter{
+		"application/json": &recordingWriter{"json", &written},
+		"application/xml":  &recordingWriter{"xml", &written},
+	}
+
+	test.ExpectNil(t, nw.StartComponent())
+
+	mt, _ := nw.SelectMediaType("application/xml")
+
+	w := httpendpoint.NewHTTPResponseWriter(httptest.NewRecorder())
+
+	state := NewAbnormalState(http.StatusNotFound, w)
+	state.WsRequest = &Request{ResponseMediaType: mt}
+
+	nw.Write(context.Background(), state, Normal)
+	test.ExpectString(t, written, "xml")
+	test.ExpectString(t, w.Header().Get("Vary"), "Accept")
+
+	// Responses written without a negotiated type use the default
+	nw.WriteAbnormalStatus(context.Background(), NewAbnormalState(http.StatusServiceUnavailable, w))
+	test.ExpectString(t, written, "json")
+
+	nw.DefaultMediaType = "text/plain"
+	test.ExpectNotNil(t, nw.StartComponent())
+}
+
+type recordingUnmarshaller struct {
+	called bool
+}
+
+func (ru *recordingUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *Request) error {
+	ru.called = true
+	return nil
+}
+
+func TestNegotiatingUnmarshaller(t *testing.T) {
+
+	json := new(recordingUnmarshaller)
+
+	nu := new(NegotiatingUnmarshaller)
+	nu.DefaultMediaType = "application/json"
+	nu.Unmarshallers = map[string]Unmarshaller{"application/json": json}
+
+	test.ExpectNil(t, nu.StartComponent())
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewBufferString("{}"))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	test.ExpectNil(t, nu.Unmarshall(context.Background(), req, new(Request)))
+	test.ExpectBool(t, json.called, true)
+
+	req.Header.Set("Content-Type", "application/yaml")
+
+	err := nu.Unmarshall(context.Background(), req, new(Request))
+
+	ue, found := err.(*UnsupportedMediaTypeError)
+
+	test.ExpectBool(t, found, true)
+	test.ExpectString(t, ue.MediaType, "application/yaml")
+}
@@ -125,6 +125,45 @@ func (pb *ParamBinder) AutoBindQueryParameters(wsReq *Request) {
 	pb.initialiseUnsetNilables(t)
 }
 
+// BindFormValues takes the text fields of a URL-encoded or multipart form and injects them into fields on the
+// Request.RequestBody that have exactly the same name as the form fields, using the same conversion rules as for query
+// parameters. Form fields without a corresponding field on the RequestBody are ignored. Any errors encountered are recorded
+// as framework errors in the Request.
+func (pb *ParamBinder) BindFormValues(wsReq *Request, p *types.Params) {
+
+	t := wsReq.RequestBody
+
+	for _, fieldName := range p.ParamNames() {
+
+		if !rt.HasFieldOfName(t, fieldName) {
+			continue
+		}
+
+		if !rt.TargetFieldIsArray(t, fieldName) && p.MultipleValues(fieldName) {
+			m, c := pb.FrameworkErrors.MessageCode(FormTargetNotArray, fieldName)
+			wsReq.AddFrameworkError(NewFormBindFrameworkError(m, c, fieldName, fieldName))
+
+			continue
+		}
+
+		pi := new(types.ParamValueInjector)
+
+		if err := pi.BindValueToField(fieldName, fieldName, p, t, pb.formValueError); err != nil {
+
+			if fe, okay := err.(*FrameworkError); okay {
+				wsReq.AddFrameworkError(fe)
+			} else {
+				pb.FrameworkLogger.LogErrorf("Unexpected error of type %t (was expecting *FrameworkError). Message was: %s", err, err.Error())
+			}
+
+		} else {
+			wsReq.RecordFieldAsBound(fieldName)
+		}
+	}
+
+	pb.initialiseUnsetNilables(t)
+}
+
 func (pb *ParamBinder) bindValueToField(paramName string, fieldName string, p *types.Params, t interface{}, errorFn types.GenerateMappingError) error {
 
 	if !rt.TargetFieldIsArray(t, fieldName) && p.MultipleValues(paramName) {
@@ -189,6 +228,19 @@ func (pb *ParamBinder) queryParamError(paramName string, fieldName string, typeN
 
 }
 
+func (pb *ParamBinder) formValueError(paramName string, fieldName string, typeName string, p *types.Params) error {
+
+	var v = ""
+
+	if p.Exists(paramName) {
+		v, _ = p.StringValue(paramName)
+	}
+
+	m, c := pb.FrameworkErrors.MessageCode(FormWrongType, paramName, typeName, v)
+	return NewFormBindFrameworkError(m, c, paramName, fieldName)
+
+}
+
 func (pb *ParamBinder) pathParamError(paramName string, fieldName string, typeName string, p *types.Params) error {
 
 	var v = ""
@@ -38,6 +38,29 @@ type Request struct {
 
 	//The component name of the handler that generated this Request.
 	ServingHandler string
+
+	// The media type (e.g. application/json) chosen for the response, if the handler's ResponseWriter is able to render
+	// more than one media type (see MediaTypeNegotiator).
+	ResponseMediaType string
+
+	cleanups []func()
+}
+
+// AddCleanup registers a function to be called once processing of this request is complete (for example, to remove
+// temporary files created while the request body was parsed).
+func (wsr *Request) AddCleanup(f func()) {
+	wsr.cleanups = append(wsr.cleanups, f)
+}
+
+// Cleanup calls the functions registered with AddCleanup in the reverse order to which they were added. Called by the
+// handler that created the Request once the response has been written.
+func (wsr *Request) Cleanup() {
+
+	for i := len(wsr.cleanups) - 1; i >= 0; i-- {
+		wsr.cleanups[i]()
+	}
+
+	wsr.cleanups = nil
 }
 
 // HasFrameworkErrors returns true if one or more framework errors have been recorded.
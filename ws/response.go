@@ -38,6 +38,12 @@ Response writing
 The serialisation of the data in a Response to an HTTP response is handled by a component implementing ResponseWriter.
 A component of this type will be automatically created for you when you enable the JSONWs or XMLWs facility.
 
+Streamed responses
+
+Logic that returns a large number of items can set the Response's Body to a StreamedBody. Rather than being marshalled
+in one go, items are written to the caller (as a JSON array or as NDJSON) as they are generated and the producer is stopped
+if the request's context is cancelled. Streaming is currently supported by the JSONWs facility only - see StreamedBody for details.
+
 Parameter binding
 
 Parameter binding refers to the process of automatically capturing request query parameters and injecting them into fields
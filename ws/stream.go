@@ -0,0 +1,140 @@
+// Copyright 2019 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Supported values for StreamedBody.Format
+const (
+	// JSONArrayStream renders each item as an element of a single JSON array.
+	JSONArrayStream = "JSON_ARRAY"
+
+	// NDJSONStream renders each item as a JSON object on its own line (http://ndjson.org)
+	NDJSONStream = "NDJSON"
+)
+
+// NDJSONContentType is the Content-Type of responses streamed in the NDJSONStream format.
+const NDJSONContentType = "application/x-ndjson"
+
+// ErrStreamingUnsupported is returned by a ResponseWriter that is asked to write a StreamedBody but cannot.
+var ErrStreamingUnsupported = errors.New("the response writer does not support streamed responses")
+
+// StreamProducer is implemented by application code that generates the items in a streamed response. The producer
+// must call emit once for each item, in order, and return when there are no more items. If emit returns an error (because
+// the request's context has been cancelled or the caller has disconnected) the producer must stop and return.
+//
+// If the producer returns an error, the response is terminated as described in the documentation for StreamedBody.
+type StreamProducer func(ctx context.Context, emit func(item interface{}) error) error
+
+/*
+StreamedBody can be set as the Body of a Response by Logic that needs to return a large number of items without
+building the whole response in memory. For example:
+
+	func (l *ExportLogic) Process(ctx context.Context, req *ws.Request, res *ws.Response) {
+
+		res.Body = ws.NewStreamedBody(func(ctx context.Context, emit func(interface{}) error) error {
+
+			for rows.Next() {
+				r := new(Record)
+
+				if err := rows.Scan(&r.ID, &r.Name); err != nil {
+					return err
+				}
+
+				if err := emit(r); err != nil {
+					return err
+				}
+			}
+
+			return rows.Err()
+		})
+	}
+
+The producer is called by the ResponseWriter after the HTTP status and headers have been sent, so it is not possible to
+change the status of the response once the producer has been called. Streamed responses are not wrapped by
+the ResponseWriter's ResponseWrapper. If the Response contains errors, the errors are written as a normal response and the
+producer is not called.
+
+The response is flushed to the caller every FlushEvery items (every item if FlushEvery is zero or less).
+
+Errors during streaming
+
+If the producer returns an error after streaming has started, the error is logged and the response is terminated.
+In NDJSON format, a final line is written containing a generic HTTP 500 error (formatted and wrapped by the ResponseWriter's
+ErrorFormatter and ResponseWrapper in the same way as any other error response), so callers
+can distinguish a failed export from a complete one. In JSON array format, the closing bracket of the array is not written, so callers
+receive an invalid JSON document rather than a truncated but valid array.
+
+If the request's context is cancelled, emit returns the context's error and no further output is written.
+*/
+type StreamedBody struct {
+	// Generates the items in the response.
+	Producer StreamProducer
+
+	// JSON_ARRAY (the default) or NDJSON
+	Format string
+
+	// How many items are written between each flush of the response.
+	FlushEvery int
+}
+
+// NewStreamedBody creates a StreamedBody that renders the items generated by the supplied producer as a JSON array.
+func NewStreamedBody(p StreamProducer) *StreamedBody {
+	return &StreamedBody{Producer: p, Format: JSONArrayStream}
+}
+
+// NewChannelStreamedBody creates a StreamedBody that renders items received from the supplied channel until the channel is closed.
+// If the request's context is cancelled, the stream stops and done is closed, to signal to the goroutine sending the items
+// that it should stop. If errs is not nil and an error is received on it, the stream is terminated with that error.
+func NewChannelStreamedBody(items <-chan interface{}, errs <-chan error, done chan<- struct{}) *StreamedBody {
+
+	p := func(ctx context.Context, emit func(interface{}) error) error {
+
+		if done != nil {
+			defer close(done)
+		}
+
+		for {
+			select {
+			case item, open := <-items:
+
+				if !open {
+					return nil
+				}
+
+				if err := emit(item); err != nil {
+					return err
+				}
+
+			case err, open := <-errs:
+
+				if !open {
+					// A nil channel is never selected, so stop waiting for errors
+					errs = nil
+					continue
+				}
+
+				if err != nil {
+					return err
+				}
+
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	return NewStreamedBody(p)
+}
+
+// StreamingMarshalingWriter is implemented by MarshalingWriters that are able to write a StreamedBody.
+type StreamingMarshalingWriter interface {
+	// MarshalAndStream calls the body's producer, writing each item it emits to the HTTP output stream. formattedErrors is
+	// called to obtain a serialisable representation of an error returned by the producer.
+	MarshalAndStream(ctx context.Context, body *StreamedBody, w http.ResponseWriter, formattedErrors func(error) interface{}) error
+}